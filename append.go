@@ -0,0 +1,72 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// AppendToList returns the encoding of the list encoded as encoded, with elem appended
+// to it. The element is the only value being encoded: fixed-size elements are appended
+// as is, while the offsets of variable-size elements are shifted to make room for the
+// offset of the new element, without decoding the existing ones. The list limit is not
+// known from the encoding alone, and is left to the caller to check.
+//
+//  encoded, err := ssz.AppendToList(encodedAttestations, att)
+//  if err != nil {
+//      return fmt.Errorf("failed to append attestation: %v", err)
+//  }
+func AppendToList(encoded []byte, elem interface{}) ([]byte, error) {
+	if elem == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	elemType := reflect.TypeOf(elem)
+	encodedElem, err := Marshal(elem)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal element: %w", err)
+	}
+	if !isVariableSizeType(elemType) {
+		size := staticFixedSize(elemType)
+		if size == 0 || uint64(len(encoded))%size != 0 {
+			return nil, errorf(ErrSize, "%d bytes cannot encode a list of %v", len(encoded), elemType)
+		}
+		result := make([]byte, 0, len(encoded)+len(encodedElem))
+		result = append(result, encoded...)
+		return append(result, encodedElem...), nil
+	}
+
+	count := uint64(0)
+	if len(encoded) > 0 {
+		firstOffset, err := readOffset(encoded, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		if firstOffset == 0 || firstOffset%BytesPerLengthOffset != 0 {
+			return nil, errorf(ErrOffset, "first offset %d is not a multiple of %d", firstOffset, BytesPerLengthOffset)
+		}
+		count = firstOffset / BytesPerLengthOffset
+	}
+	offsetsSize := count * BytesPerLengthOffset
+	size := uint64(len(encoded)) + BytesPerLengthOffset + uint64(len(encodedElem))
+	if size > 1<<32 {
+		return nil, errorf(ErrOffset, "list of %d bytes cannot be addressed by offsets", size)
+	}
+	result := make([]byte, size)
+	previous := offsetsSize
+	for i := uint64(0); i < count; i++ {
+		offset, err := readOffset(encoded, i*BytesPerLengthOffset, 0)
+		if err != nil {
+			return nil, err
+		}
+		if offset < previous {
+			return nil, errorf(ErrOffset, "offset %d of element %d precedes offset %d", offset, i, previous)
+		}
+		previous = offset
+		binary.LittleEndian.PutUint32(result[i*BytesPerLengthOffset:], uint32(offset+BytesPerLengthOffset))
+	}
+	binary.LittleEndian.PutUint32(result[offsetsSize:], uint32(uint64(len(encoded))+BytesPerLengthOffset))
+	copy(result[offsetsSize+BytesPerLengthOffset:], encoded[offsetsSize:])
+	copy(result[uint64(len(encoded))+BytesPerLengthOffset:], encodedElem)
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAppendToList(t *testing.T) {
+	tests := []struct {
+		name  string
+		items interface{}
+	}{
+		{name: "fixed-size elements", items: []fork{{Epoch: 1}, {Epoch: 2}, {Epoch: 3}}},
+		{name: "basic elements", items: []uint64{5, 6, 7}},
+		{name: "variable-size elements", items: []iteratedItem{{Slot: 1, Data: []byte{1, 2}}, {Slot: 2, Data: []byte{}}, {Slot: 3, Data: []byte{3}}}},
+		{name: "pointer elements", items: []*iteratedItem{{Slot: 1, Data: []byte{1}}, {Slot: 2, Data: []byte{2, 3}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := reflect.ValueOf(tt.items)
+			encoded, err := Marshal(items.Slice(0, 0).Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < items.Len(); i++ {
+				if encoded, err = AppendToList(encoded, items.Index(i).Interface()); err != nil {
+					t.Fatal(err)
+				}
+				want, err := Marshal(items.Slice(0, i+1).Interface())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(encoded, want) {
+					t.Errorf("Expected %#x after appending element %d, received %#x", want, i, encoded)
+				}
+			}
+		})
+	}
+}
+
+func TestAppendToList_Errors(t *testing.T) {
+	if _, err := AppendToList(make([]byte, 10), uint64(1)); err == nil {
+		t.Error("Expected error for data which is not a multiple of the element size")
+	}
+	if _, err := AppendToList([]byte{3, 0, 0, 0}, iteratedItem{}); err == nil {
+		t.Error("Expected error for a first offset which is not a multiple of the offset size")
+	}
+	if _, err := AppendToList([]byte{8, 0, 0, 0, 4, 0, 0, 0}, iteratedItem{}); err == nil {
+		t.Error("Expected error for decreasing offsets")
+	}
+	if _, err := AppendToList(nil, nil); err == nil {
+		t.Error("Expected error for untyped nil")
+	}
+}
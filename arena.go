@@ -0,0 +1,128 @@
+package ssz
+
+import (
+	"reflect"
+)
+
+// arenaSlabSize is the size in bytes of the regions an Arena allocates values from.
+// Slices larger than a region are allocated from the heap.
+const arenaSlabSize = 64 << 10
+
+var byteSliceType = reflect.TypeOf([]byte{})
+
+// Arena is a bump allocator for UnmarshalWithArena: the slices and pointers set by
+// decoding are carved out of a few large regions instead of being allocated one by one,
+// which spares the garbage collector from tracking millions of small objects. Regions
+// are released together, either by dropping the arena and everything decoded with it,
+// or by calling Reset to reuse them. Arenas are not safe for concurrent use.
+type Arena struct {
+	slabs map[reflect.Type]*arenaSlab
+	// limits are the DecodeLimits of the input decoded with the arena, nil if there
+	// are none, and depth the nesting of the value being decoded, see withLimits.
+	limits *DecodeLimits
+	depth  int
+}
+
+// arenaSlab holds the regions of an arena for elements of a single type.
+type arenaSlab struct {
+	regions []reflect.Value
+	// current is the index of the region being allocated from, and used the
+	// number of its elements already handed out.
+	current int
+	used    int
+}
+
+// NewArena returns an empty arena.
+func NewArena() *Arena {
+	return &Arena{slabs: make(map[reflect.Type]*arenaSlab)}
+}
+
+// Reset makes the whole arena available for new allocations. Every value decoded
+// with the arena shares its memory, which gets zeroed and reused: none of them may
+// be used after calling Reset.
+func (a *Arena) Reset() {
+	for typ, slab := range a.slabs {
+		zero := reflect.Zero(typ)
+		for i := 0; i <= slab.current && i < len(slab.regions); i++ {
+			region := slab.regions[i]
+			n := region.Len()
+			if i == slab.current {
+				n = slab.used
+			}
+			if typ.Kind() == reflect.Uint8 {
+				b := region.Bytes()[:n]
+				for j := range b {
+					b[j] = 0
+				}
+				continue
+			}
+			for j := 0; j < n; j++ {
+				region.Index(j).Set(zero)
+			}
+		}
+		slab.current = 0
+		slab.used = 0
+	}
+}
+
+// fromHeap reports whether the arena allocates from the heap, as nil arenas and the
+// arenas holding only limits do.
+func (a *Arena) fromHeap() bool {
+	return a == nil || a.slabs == nil
+}
+
+// makeSlice returns a slice of type typ, allocated from the arena unless the arena
+// allocates from the heap or the slice does not fit in a region.
+func (a *Arena) makeSlice(typ reflect.Type, length int, capacity int) reflect.Value {
+	if a.fromHeap() || capacity == 0 {
+		return reflect.MakeSlice(typ, length, capacity)
+	}
+	elem := typ.Elem()
+	elemSize := int(elem.Size())
+	if elemSize == 0 {
+		elemSize = 1
+	}
+	regionLen := arenaSlabSize / elemSize
+	if capacity > regionLen {
+		return reflect.MakeSlice(typ, length, capacity)
+	}
+	slab, ok := a.slabs[elem]
+	if !ok {
+		slab = &arenaSlab{}
+		a.slabs[elem] = slab
+	}
+	if len(slab.regions) == 0 {
+		slab.regions = append(slab.regions, reflect.MakeSlice(reflect.SliceOf(elem), regionLen, regionLen))
+	}
+	if slab.used+capacity > slab.regions[slab.current].Len() {
+		slab.current++
+		slab.used = 0
+		// Regions kept by Reset are already zeroed.
+		if slab.current == len(slab.regions) {
+			slab.regions = append(slab.regions, reflect.MakeSlice(reflect.SliceOf(elem), regionLen, regionLen))
+		}
+	}
+	s := slab.regions[slab.current].Slice3(slab.used, slab.used+length, slab.used+capacity)
+	slab.used += capacity
+	return s.Convert(typ)
+}
+
+// new returns a pointer to a new zero value of type typ, allocated from the arena
+// unless the arena allocates from the heap.
+func (a *Arena) new(typ reflect.Type) reflect.Value {
+	if a.fromHeap() {
+		return reflect.New(typ)
+	}
+	return a.makeSlice(reflect.SliceOf(typ), 1, 1).Index(0).Addr()
+}
+
+// copyBytes returns a copy of b, allocated from the arena unless the arena allocates
+// from the heap.
+func (a *Arena) copyBytes(b []byte) []byte {
+	if a.fromHeap() {
+		return append(make([]byte, 0, len(b)), b...)
+	}
+	c := a.makeSlice(byteSliceType, len(b), len(b)).Bytes()
+	copy(c, b)
+	return c
+}
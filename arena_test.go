@@ -0,0 +1,106 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+type arenaContainer struct {
+	Slot     uint64
+	Forks    []fork `ssz-max:"16"`
+	Pointer  *fork
+	Data     []byte   `ssz-max:"64"`
+	Balances []uint64 `ssz-max:"64"`
+}
+
+func newArenaContainer(i uint64) arenaContainer {
+	return arenaContainer{
+		Slot:     i,
+		Forks:    []fork{{Epoch: i}, {Epoch: i + 1}},
+		Pointer:  &fork{Epoch: i + 2},
+		Data:     []byte{byte(i), 1, 2},
+		Balances: []uint64{i, i * 2},
+	}
+}
+
+func TestUnmarshalWithArena(t *testing.T) {
+	arena := NewArena()
+	decoded := make([]arenaContainer, 100)
+	for i := range decoded {
+		item := newArenaContainer(uint64(i))
+		encoded, err := Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := UnmarshalWithArena(encoded, &decoded[i], arena); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Values decoded with an arena must not overlap.
+	for i := range decoded {
+		if want := newArenaContainer(uint64(i)); !reflect.DeepEqual(decoded[i], want) {
+			t.Errorf("Expected %v, received %v", want, decoded[i])
+		}
+	}
+
+	arena.Reset()
+	if decoded[0].Pointer.Epoch != 0 || decoded[0].Forks[0].Epoch != 0 && decoded[1].Forks[0].Epoch != 0 {
+		t.Error("Expected Reset to zero the memory of decoded values")
+	}
+	item := newArenaContainer(7)
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reused arenaContainer
+	if err := UnmarshalWithArena(encoded, &reused, arena); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reused, item) {
+		t.Errorf("Expected %v, received %v", item, reused)
+	}
+}
+
+func TestArena_LargeSlices(t *testing.T) {
+	arena := NewArena()
+	large := arena.makeSlice(reflect.TypeOf([]uint64{}), 10, arenaSlabSize)
+	if large.Len() != 10 || large.Cap() != arenaSlabSize {
+		t.Errorf("Expected slice of length 10 and capacity %d, received %d and %d", arenaSlabSize, large.Len(), large.Cap())
+	}
+	if len(arena.slabs) != 0 {
+		t.Error("Expected slices larger than a region to be allocated from the heap")
+	}
+	named := arena.makeSlice(reflect.TypeOf(opaqueWords{}), 1, 1)
+	if named.Type() != reflect.TypeOf(opaqueWords{}) {
+		t.Errorf("Expected slice of type %v, received %v", reflect.TypeOf(opaqueWords{}), named.Type())
+	}
+}
+
+func BenchmarkUnmarshalWithArena(b *testing.B) {
+	encoded, err := Marshal(newArenaContainer(1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded arenaContainer
+			if err := Unmarshal(encoded, &decoded); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("arena", func(b *testing.B) {
+		arena := NewArena()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded arenaContainer
+			if err := UnmarshalWithArena(encoded, &decoded, arena); err != nil {
+				b.Fatal(err)
+			}
+			if i%1000 == 999 {
+				arena.Reset()
+			}
+		}
+	})
+}
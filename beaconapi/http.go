@@ -0,0 +1,247 @@
+// Package beaconapi serves and consumes the SSZ encoding of values over the eth2
+// Beacon API, negotiating it against the JSON encoding of the jsonx package. Servers
+// respond in the encoding the Accept header of a request prefers, and clients decode
+// responses by their Content-Type:
+//
+//  func handler(w http.ResponseWriter, r *http.Request) {
+//      if err := beaconapi.WriteResponse(w, r, state); err != nil {
+//          log.Print(err)
+//      }
+//  }
+package beaconapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/jsonx"
+)
+
+// Content types of the Beacon API.
+const (
+	ContentTypeSSZ  = "application/octet-stream"
+	ContentTypeJSON = "application/json"
+)
+
+// AcceptSSZ is an Accept header preferring SSZ responses, falling back to JSON for
+// the endpoints without SSZ support.
+const AcceptSSZ = "application/octet-stream;q=1.0,application/json;q=0.9"
+
+// StatusError is the error of a response whose status is not successful, with the
+// message of its JSON body when it has one.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("beacon API responded with status %d", e.Code)
+	}
+	return fmt.Sprintf("beacon API responded with status %d: %s", e.Code, e.Message)
+}
+
+// Negotiate returns the content type of the response to r, ContentTypeJSON unless
+// the Accept header prefers ContentTypeSSZ. It returns an empty string when the
+// Accept header rules out both.
+func Negotiate(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return ContentTypeJSON
+	}
+	sszQuality, jsonQuality := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		// Exact media types take precedence over wildcards, whatever their order.
+		switch mediaType {
+		case ContentTypeSSZ:
+			sszQuality = q
+		case ContentTypeJSON:
+			jsonQuality = q
+		case "*/*", "application/*":
+			if sszQuality < 0 {
+				sszQuality = q
+			}
+			if jsonQuality < 0 {
+				jsonQuality = q
+			}
+		}
+	}
+	switch {
+	case sszQuality <= 0 && jsonQuality <= 0:
+		return ""
+	case sszQuality > jsonQuality:
+		return ContentTypeSSZ
+	default:
+		return ContentTypeJSON
+	}
+}
+
+// WriteResponse writes val to w in the encoding negotiated for r: its SSZ encoding,
+// or its JSON encoding wrapped in the data field of the Beacon API. It responds with
+// http.StatusNotAcceptable when the Accept header rules out both encodings.
+func WriteResponse(w http.ResponseWriter, r *http.Request, val interface{}) error {
+	switch Negotiate(r) {
+	case ContentTypeSSZ:
+		return WriteSSZ(w, val)
+	case ContentTypeJSON:
+		return WriteJSON(w, val)
+	default:
+		http.Error(w, "supported content types are "+ContentTypeJSON+" and "+ContentTypeSSZ, http.StatusNotAcceptable)
+		return nil
+	}
+}
+
+// WriteSSZ writes the SSZ encoding of val to w.
+func WriteSSZ(w http.ResponseWriter, val interface{}) error {
+	encoded, err := ssz.Marshal(val)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeSSZ)
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	_, err = w.Write(encoded)
+	return err
+}
+
+// WriteJSON writes the JSON encoding of val to w, wrapped in the data field of the
+// Beacon API.
+func WriteJSON(w http.ResponseWriter, val interface{}) error {
+	data, err := jsonx.Marshal(val)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(struct {
+		Data json.RawMessage `json:"data"`
+	}{data})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	_, err = w.Write(body)
+	return err
+}
+
+// ReadRequest decodes the body of r into the value pointed to by val, by the
+// Content-Type of r. JSON bodies are not wrapped, following the Beacon API.
+func ReadRequest(r *http.Request, val interface{}) error {
+	return decode(r.Header.Get("Content-Type"), r.Body, val, false)
+}
+
+// NewRequest returns a request whose body is the SSZ encoding of val.
+func NewRequest(method string, url string, val interface{}) (*http.Request, error) {
+	encoded, err := ssz.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentTypeSSZ)
+	req.Header.Set("Accept", AcceptSSZ)
+	return req, nil
+}
+
+// ReadResponse decodes the body of resp into the value pointed to by val, by the
+// Content-Type of resp, and closes it. JSON bodies are unwrapped from their data
+// field. Unsuccessful responses return a *StatusError.
+func ReadResponse(resp *http.Response, val interface{}) error {
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	return decode(resp.Header.Get("Content-Type"), resp.Body, val, true)
+}
+
+// checkStatus returns a *StatusError when the status of resp is not successful.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	statusErr := &StatusError{Code: resp.StatusCode}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err == nil {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &msg) == nil {
+			statusErr.Message = msg.Message
+		}
+	}
+	return statusErr
+}
+
+// decode decodes body by its content type into the value pointed to by val. SSZ
+// bodies are read up to the maximum SSZ size of the type of val, if any.
+func decode(contentType string, body io.Reader, val interface{}, wrapped bool) error {
+	if val == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	mediaType := ContentTypeJSON
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("invalid content type %q: %w", contentType, err)
+		}
+	}
+	switch mediaType {
+	case ContentTypeSSZ:
+		desc, err := ssz.Describe(reflect.TypeOf(val))
+		if err != nil {
+			return err
+		}
+		bounded := desc.MaxSize != 0 && desc.MaxSize < math.MaxInt64
+		if bounded {
+			body = io.LimitReader(body, int64(desc.MaxSize)+1)
+		}
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if bounded && uint64(len(data)) > desc.MaxSize {
+			return fmt.Errorf("body exceeds the maximum SSZ size %d of %v", desc.MaxSize, reflect.TypeOf(val))
+		}
+		return ssz.Unmarshal(data, val)
+	case ContentTypeJSON:
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if !wrapped {
+			return jsonx.Unmarshal(data, val)
+		}
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return err
+		}
+		if envelope.Data == nil {
+			return errors.New("missing data field in the JSON response")
+		}
+		return jsonx.Unmarshal(envelope.Data, val)
+	default:
+		return fmt.Errorf("unsupported content type %q", mediaType)
+	}
+}
@@ -0,0 +1,136 @@
+package beaconapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                                  ContentTypeJSON,
+		"*/*":                               ContentTypeJSON,
+		"application/json":                  ContentTypeJSON,
+		"application/octet-stream":          ContentTypeSSZ,
+		AcceptSSZ:                           ContentTypeSSZ,
+		"application/json;q=0.5, */*":       ContentTypeSSZ,
+		"*/*;q=0.1, application/json;q=0.5": ContentTypeJSON,
+		"application/octet-stream;q=0":      "",
+		"text/html":                         "",
+		"application/json;q=0":              "",
+	}
+	for accept, want := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", accept)
+		if got := Negotiate(r); got != want {
+			t.Errorf("Accept %q: expected %q, received %q", accept, want, got)
+		}
+	}
+}
+
+func TestResponse_RoundTrip(t *testing.T) {
+	want := benchmarks.Checkpoint{Epoch: 7, Root: bytes.Repeat([]byte{0xab}, 32)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := WriteResponse(w, r, want); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	for _, accept := range []string{AcceptSSZ, "application/json"} {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got benchmarks.Checkpoint
+		if err := ReadResponse(resp, &got); err != nil {
+			t.Fatalf("Accept %q: %v", accept, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Accept %q: expected %+v, received %+v", accept, want, got)
+		}
+	}
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ReadResponse(resp, &benchmarks.Checkpoint{})
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected a status error, received %v", err)
+	}
+}
+
+func TestWriteJSON_WrapsData(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteJSON(rec, benchmarks.Checkpoint{Epoch: 1, Root: make([]byte, 32)}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"epoch":"1","root":"0x` + strings.Repeat("00", 32) + `"}}`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("Expected %s, received %s", want, got)
+	}
+}
+
+func TestReadRequest(t *testing.T) {
+	want := benchmarks.Checkpoint{Epoch: 2, Root: bytes.Repeat([]byte{1}, 32)}
+	req, err := NewRequest("POST", "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got benchmarks.Checkpoint
+	if err := ReadRequest(req, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, received %+v", want, got)
+	}
+
+	json := `{"epoch":"2","root":"0x` + strings.Repeat("01", 32) + `"}`
+	req = httptest.NewRequest("POST", "/", strings.NewReader(json))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	got = benchmarks.Checkpoint{}
+	if err := ReadRequest(req, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, received %+v", want, got)
+	}
+
+	encoded, err := ssz.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		contentType string
+		body        []byte
+	}{
+		"oversized body":     {ContentTypeSSZ, append(encoded, 0)},
+		"unsupported type":   {"text/plain", encoded},
+		"invalid JSON":       {ContentTypeJSON, encoded},
+		"invalid media type": {"application/", encoded},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest("POST", "/", bytes.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		if err := ReadRequest(req, &got); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package beaconapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/jsonx"
+)
+
+// ConsensusVersionHeader is the header of the Beacon API naming the fork of the value
+// of a response, whose type changes across forks.
+const ConsensusVersionHeader = "Eth-Consensus-Version"
+
+// MaxVersionlessJSONSize bounds the JSON bodies ReadVersionedResponse reads to find
+// their version field, when responses have no Eth-Consensus-Version header, as their
+// type is only known once read.
+var MaxVersionlessJSONSize int64 = 1 << 30
+
+// Versions maps the names of forks, as found in Eth-Consensus-Version headers, to the
+// types of the value of an endpoint as of those forks:
+//
+//  var blockVersions = beaconapi.Versions{
+//      "phase0": reflect.TypeOf(phase0.SignedBeaconBlock{}),
+//      "altair": reflect.TypeOf(altair.SignedBeaconBlock{}),
+//  }
+//
+//  block, err := beaconapi.ReadVersionedResponse(resp, blockVersions)
+//  if err != nil {
+//      return err
+//  }
+//  switch b := block.Value.(type) {
+//  case *phase0.SignedBeaconBlock:
+//  case *altair.SignedBeaconBlock:
+//  }
+//
+// Fork names are matched regardless of case, and pointer types are treated as the
+// types they point to.
+type Versions map[string]reflect.Type
+
+// Versioned is a value decoded by the fork it belongs to.
+type Versioned struct {
+	// Version is the name of the fork of the value, in lower case.
+	Version string
+	// Value is a pointer to the value, whose type is the one of Version.
+	Value interface{}
+}
+
+// New returns a pointer to a new zero value of the type of version.
+func (v Versions) New(version string) (interface{}, error) {
+	typ, ok := v[strings.ToLower(version)]
+	if !ok {
+		for name, t := range v {
+			if strings.EqualFold(name, version) {
+				typ, ok = t, true
+				break
+			}
+		}
+	}
+	if !ok || typ == nil {
+		return nil, fmt.Errorf("unsupported consensus version %q", version)
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return reflect.New(typ).Interface(), nil
+}
+
+// DecodeVersioned decodes data, the SSZ encoding of a value as of the fork version,
+// into a new value of the type versions hold for it.
+func DecodeVersioned(data []byte, version string, versions Versions) (*Versioned, error) {
+	val, err := versions.New(version)
+	if err != nil {
+		return nil, err
+	}
+	if err := ssz.Unmarshal(data, val); err != nil {
+		return nil, fmt.Errorf("could not decode %s value: %w", version, err)
+	}
+	return &Versioned{Version: strings.ToLower(version), Value: val}, nil
+}
+
+// ReadVersionedResponse decodes the body of resp, by its Content-Type, into a new value
+// of the type versions hold for the fork named by the Eth-Consensus-Version header of
+// resp, and closes it. JSON bodies without the header may name the fork in their
+// version field instead. Unsuccessful responses return a *StatusError.
+func ReadVersionedResponse(resp *http.Response, versions Versions) (*Versioned, error) {
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	version := resp.Header.Get(ConsensusVersionHeader)
+	var body io.Reader = resp.Body
+	if version == "" && isJSON(contentType) {
+		data, err := ioutil.ReadAll(io.LimitReader(body, MaxVersionlessJSONSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > MaxVersionlessJSONSize {
+			return nil, fmt.Errorf("JSON body without %s header exceeds %d bytes", ConsensusVersionHeader, MaxVersionlessJSONSize)
+		}
+		var envelope struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, err
+		}
+		version, body = envelope.Version, bytes.NewReader(data)
+	}
+	if version == "" {
+		return nil, fmt.Errorf("response has no %s header", ConsensusVersionHeader)
+	}
+	val, err := versions.New(version)
+	if err != nil {
+		return nil, err
+	}
+	if err := decode(contentType, body, val, true); err != nil {
+		return nil, fmt.Errorf("could not decode %s value: %w", version, err)
+	}
+	return &Versioned{Version: strings.ToLower(version), Value: val}, nil
+}
+
+// WriteVersionedResponse writes val, a value as of the fork version, to w like
+// WriteResponse does, naming the fork in the Eth-Consensus-Version header and in the
+// version field of JSON bodies.
+func WriteVersionedResponse(w http.ResponseWriter, r *http.Request, version string, val interface{}) error {
+	switch Negotiate(r) {
+	case ContentTypeSSZ:
+		w.Header().Set(ConsensusVersionHeader, version)
+		return WriteSSZ(w, val)
+	case ContentTypeJSON:
+		data, err := jsonx.Marshal(val)
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(struct {
+			Version string          `json:"version"`
+			Data    json.RawMessage `json:"data"`
+		}{version, data})
+		if err != nil {
+			return err
+		}
+		w.Header().Set(ConsensusVersionHeader, version)
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_, err = w.Write(body)
+		return err
+	default:
+		return WriteResponse(w, r, val)
+	}
+}
+
+// isJSON reports whether contentType is the JSON content type, which responses
+// without one default to.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == ContentTypeJSON
+}
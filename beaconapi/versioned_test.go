@@ -0,0 +1,121 @@
+package beaconapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+var testVersions = Versions{
+	"phase0": reflect.TypeOf(benchmarks.Checkpoint{}),
+	"altair": reflect.TypeOf(&benchmarks.Fork{}),
+}
+
+func TestVersionedResponse_RoundTrip(t *testing.T) {
+	values := map[string]interface{}{
+		"phase0": &benchmarks.Checkpoint{Epoch: 7, Root: bytes.Repeat([]byte{0xab}, 32)},
+		"altair": &benchmarks.Fork{PreviousVersion: []byte{0, 0, 0, 0}, CurrentVersion: []byte{1, 0, 0, 0}, Epoch: 74240},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := r.URL.Query().Get("version")
+		if err := WriteVersionedResponse(w, r, version, values[version]); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	for version, want := range values {
+		for _, accept := range []string{AcceptSSZ, "application/json"} {
+			req, err := http.NewRequest("GET", server.URL+"?version="+version, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", accept)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadVersionedResponse(resp, testVersions)
+			if err != nil {
+				t.Fatalf("%s, Accept %q: %v", version, accept, err)
+			}
+			if got.Version != version || !reflect.DeepEqual(got.Value, want) {
+				t.Errorf("%s, Accept %q: expected %+v, received %s %+v", version, accept, want, got.Version, got.Value)
+			}
+		}
+	}
+}
+
+func TestReadVersionedResponse_JSONVersionField(t *testing.T) {
+	body := `{"version":"PHASE0","data":{"epoch":"1","root":"0x` + strings.Repeat("00", 32) + `"}}`
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{ContentTypeJSON}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+	got, err := ReadVersionedResponse(resp, testVersions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &benchmarks.Checkpoint{Epoch: 1, Root: make([]byte, 32)}
+	if got.Version != "phase0" || !reflect.DeepEqual(got.Value, want) {
+		t.Errorf("Expected phase0 %+v, received %s %+v", want, got.Version, got.Value)
+	}
+
+	defer func(size int64) { MaxVersionlessJSONSize = size }(MaxVersionlessJSONSize)
+	MaxVersionlessJSONSize = int64(len(body)) - 1
+	resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	if _, err := ReadVersionedResponse(resp, testVersions); err == nil {
+		t.Error("Expected JSON bodies exceeding MaxVersionlessJSONSize to fail")
+	}
+}
+
+func TestReadVersionedResponse_Invalid(t *testing.T) {
+	encoded, err := ssz.Marshal(benchmarks.Checkpoint{Root: make([]byte, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"missing header":  "",
+		"unknown version": "bellatrix",
+		"mismatched type": "altair",
+	}
+	for name, version := range tests {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{ContentTypeSSZ}},
+			Body:       ioutil.NopCloser(bytes.NewReader(encoded)),
+		}
+		if version != "" {
+			resp.Header.Set(ConsensusVersionHeader, version)
+		}
+		if _, err := ReadVersionedResponse(resp, testVersions); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDecodeVersioned(t *testing.T) {
+	want := &benchmarks.Checkpoint{Epoch: 3, Root: bytes.Repeat([]byte{2}, 32)}
+	encoded, err := ssz.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeVersioned(encoded, "Phase0", testVersions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "phase0" || !reflect.DeepEqual(got.Value, want) {
+		t.Errorf("Expected phase0 %+v, received %s %+v", want, got.Version, got.Value)
+	}
+	if _, err := DecodeVersioned(encoded, "deneb", testVersions); err == nil {
+		t.Error("Expected unknown versions to fail")
+	}
+}
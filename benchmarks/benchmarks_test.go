@@ -0,0 +1,159 @@
+package benchmarks
+
+import (
+	"flag"
+	"sync"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+var validatorCount = flag.Int("validators", DefaultValidatorCount, "number of validators of the beacon state fixture")
+
+var (
+	stateOnce    sync.Once
+	state        *BeaconState
+	encodedState []byte
+)
+
+// stateFixture returns the beacon state fixture along with its encoding, which are
+// only built once as building them dominates the benchmarks otherwise.
+func stateFixture(b *testing.B) (*BeaconState, []byte) {
+	stateOnce.Do(func() {
+		state = NewBeaconState(*validatorCount)
+		var err error
+		if encodedState, err = ssz.Marshal(state); err != nil {
+			b.Fatal(err)
+		}
+	})
+	if encodedState == nil {
+		b.Fatal("Could not build the beacon state fixture")
+	}
+	return state, encodedState
+}
+
+func TestFixtures_RoundTrip(t *testing.T) {
+	fixtures := []struct {
+		name  string
+		value interface{}
+		empty interface{}
+	}{
+		{name: "state", value: NewBeaconState(64), empty: &BeaconState{}},
+		{name: "block", value: NewSignedBeaconBlock(), empty: &SignedBeaconBlock{}},
+	}
+	for _, tt := range fixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := ssz.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ssz.Unmarshal(encoded, tt.empty); err != nil {
+				t.Fatal(err)
+			}
+			if !ssz.DeepEqual(tt.empty, tt.value) {
+				t.Error("Expected the fixture to survive a round trip")
+			}
+			want, err := ssz.HashTreeRoot(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := ssz.HashTreeRoot(tt.empty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root != want {
+				t.Errorf("Expected root %#x, received %#x", want, root)
+			}
+		})
+	}
+}
+
+func BenchmarkBeaconState_Marshal(b *testing.B) {
+	state, encoded := stateFixture(b)
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ssz.Marshal(state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBeaconState_Unmarshal(b *testing.B) {
+	_, encoded := stateFixture(b)
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var decoded BeaconState
+		if err := ssz.Unmarshal(encoded, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBeaconState_HashTreeRoot(b *testing.B) {
+	state, _ := stateFixture(b)
+	benchmarkHashTreeRoot(b, state)
+}
+
+func BenchmarkSignedBeaconBlock_Marshal(b *testing.B) {
+	block := NewSignedBeaconBlock()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ssz.Marshal(block); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignedBeaconBlock_Unmarshal(b *testing.B) {
+	encoded, err := ssz.Marshal(NewSignedBeaconBlock())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var decoded SignedBeaconBlock
+		if err := ssz.Unmarshal(encoded, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignedBeaconBlock_HashTreeRoot(b *testing.B) {
+	benchmarkHashTreeRoot(b, NewSignedBeaconBlock())
+}
+
+// benchmarkHashTreeRoot hashes val with the root cache disabled, and enabled once
+// warmed up, which is the best case of hashing the same values repeatedly.
+func benchmarkHashTreeRoot(b *testing.B, val interface{}) {
+	b.Run("NoCache", func(b *testing.B) {
+		ssz.ToggleCache(false)
+		defer ssz.ToggleCache(true)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := ssz.HashTreeRoot(val); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Cache", func(b *testing.B) {
+		ssz.ToggleCache(true)
+		if _, err := ssz.HashTreeRoot(val); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := ssz.HashTreeRoot(val); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,374 @@
+// Package benchmarks holds realistic fixtures, shaped after the phase 0 beacon chain
+// containers at mainnet scale, along with benchmarks encoding, decoding and hashing
+// them, so that performance changes can be evaluated against representative workloads:
+//
+//  go test ./benchmarks -run XX -bench . -benchmem
+//  go test ./benchmarks -run XX -bench State -validators 1000000
+package benchmarks
+
+import (
+	"encoding/binary"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// Mainnet sizes of the fixtures.
+const (
+	SlotsPerHistoricalRoot      = 8192
+	EpochsPerHistoricalVector   = 65536
+	EpochsPerSlashingsVector    = 8192
+	MaxValidatorsPerCommittee   = 2048
+	MaxProposerSlashings        = 16
+	MaxAttesterSlashings        = 2
+	MaxAttestations             = 128
+	MaxDeposits                 = 16
+	MaxVoluntaryExits           = 16
+	MaxPendingAttestations      = 4096
+	DepositContractTreeDepth    = 32
+	ValidatorRegistryLimit      = 1099511627776
+	DefaultValidatorCount       = 250000
+	pendingAttestationsPerEpoch = MaxAttestations * 32
+)
+
+type Fork struct {
+	PreviousVersion []byte `ssz-size:"4"`
+	CurrentVersion  []byte `ssz-size:"4"`
+	Epoch           uint64
+}
+
+type Checkpoint struct {
+	Epoch uint64
+	Root  []byte `ssz-size:"32"`
+}
+
+type Validator struct {
+	Pubkey                     []byte `ssz-size:"48"`
+	WithdrawalCredentials      []byte `ssz-size:"32"`
+	EffectiveBalance           uint64
+	Slashed                    bool
+	ActivationEligibilityEpoch uint64
+	ActivationEpoch            uint64
+	ExitEpoch                  uint64
+	WithdrawableEpoch          uint64
+}
+
+type AttestationData struct {
+	Slot            uint64
+	Index           uint64
+	BeaconBlockRoot []byte `ssz-size:"32"`
+	Source          Checkpoint
+	Target          Checkpoint
+}
+
+type IndexedAttestation struct {
+	AttestingIndices []uint64 `ssz-max:"2048"`
+	Data             AttestationData
+	Signature        []byte `ssz-size:"96"`
+}
+
+type PendingAttestation struct {
+	AggregationBits bitfield.Bitlist `ssz-max:"2048"`
+	Data            AttestationData
+	InclusionDelay  uint64
+	ProposerIndex   uint64
+}
+
+type Eth1Data struct {
+	DepositRoot  []byte `ssz-size:"32"`
+	DepositCount uint64
+	BlockHash    []byte `ssz-size:"32"`
+}
+
+type DepositData struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+	Signature             []byte `ssz-size:"96"`
+}
+
+type BeaconBlockHeader struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    []byte `ssz-size:"32"`
+	StateRoot     []byte `ssz-size:"32"`
+	BodyRoot      []byte `ssz-size:"32"`
+}
+
+type SignedBeaconBlockHeader struct {
+	Message   BeaconBlockHeader
+	Signature []byte `ssz-size:"96"`
+}
+
+type ProposerSlashing struct {
+	SignedHeader1 SignedBeaconBlockHeader
+	SignedHeader2 SignedBeaconBlockHeader
+}
+
+type AttesterSlashing struct {
+	Attestation1 IndexedAttestation
+	Attestation2 IndexedAttestation
+}
+
+type Attestation struct {
+	AggregationBits bitfield.Bitlist `ssz-max:"2048"`
+	Data            AttestationData
+	Signature       []byte `ssz-size:"96"`
+}
+
+type Deposit struct {
+	Proof [][]byte `ssz-size:"33,32"`
+	Data  DepositData
+}
+
+type VoluntaryExit struct {
+	Epoch          uint64
+	ValidatorIndex uint64
+}
+
+type SignedVoluntaryExit struct {
+	Message   VoluntaryExit
+	Signature []byte `ssz-size:"96"`
+}
+
+type BeaconBlockBody struct {
+	RandaoReveal      []byte `ssz-size:"96"`
+	Eth1Data          Eth1Data
+	Graffiti          []byte                `ssz-size:"32"`
+	ProposerSlashings []ProposerSlashing    `ssz-max:"16"`
+	AttesterSlashings []AttesterSlashing    `ssz-max:"2"`
+	Attestations      []Attestation         `ssz-max:"128"`
+	Deposits          []Deposit             `ssz-max:"16"`
+	VoluntaryExits    []SignedVoluntaryExit `ssz-max:"16"`
+}
+
+type BeaconBlock struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    []byte `ssz-size:"32"`
+	StateRoot     []byte `ssz-size:"32"`
+	Body          BeaconBlockBody
+}
+
+type SignedBeaconBlock struct {
+	Message   BeaconBlock
+	Signature []byte `ssz-size:"96"`
+}
+
+type BeaconState struct {
+	GenesisTime                 uint64
+	GenesisValidatorsRoot       []byte `ssz-size:"32"`
+	Slot                        uint64
+	Fork                        Fork
+	LatestBlockHeader           BeaconBlockHeader
+	BlockRoots                  [][]byte `ssz-size:"8192,32"`
+	StateRoots                  [][]byte `ssz-size:"8192,32"`
+	HistoricalRoots             [][]byte `ssz-size:"?,32" ssz-max:"16777216"`
+	Eth1Data                    Eth1Data
+	Eth1DataVotes               []Eth1Data `ssz-max:"2048"`
+	Eth1DepositIndex            uint64
+	Validators                  []Validator          `ssz-max:"1099511627776"`
+	Balances                    []uint64             `ssz-max:"1099511627776"`
+	RandaoMixes                 [][]byte             `ssz-size:"65536,32"`
+	Slashings                   []uint64             `ssz-size:"8192"`
+	PreviousEpochAttestations   []PendingAttestation `ssz-max:"4096"`
+	CurrentEpochAttestations    []PendingAttestation `ssz-max:"4096"`
+	JustificationBits           bitfield.Bitvector4  `ssz-size:"1"`
+	PreviousJustifiedCheckpoint Checkpoint
+	CurrentJustifiedCheckpoint  Checkpoint
+	FinalizedCheckpoint         Checkpoint
+}
+
+// filler deterministically fills fixtures with distinct, pseudo-random bytes, so
+// that values neither hash nor compress like zeroes.
+type filler struct {
+	state uint64
+}
+
+func (f *filler) uint64() uint64 {
+	// xorshift64*
+	f.state ^= f.state >> 12
+	f.state ^= f.state << 25
+	f.state ^= f.state >> 27
+	return f.state * 2685821657736338717
+}
+
+func (f *filler) bytes(n int) []byte {
+	b := make([]byte, n+8)
+	for i := 0; i < n; i += 8 {
+		binary.LittleEndian.PutUint64(b[i:], f.uint64())
+	}
+	return b[:n:n]
+}
+
+func (f *filler) roots(n int) [][]byte {
+	roots := make([][]byte, n)
+	for i := range roots {
+		roots[i] = f.bytes(32)
+	}
+	return roots
+}
+
+func (f *filler) bits(n uint64) bitfield.Bitlist {
+	bits := bitfield.NewBitlist(n)
+	for i := uint64(0); i < n; i++ {
+		bits.SetBitAt(i, f.uint64()%2 == 0)
+	}
+	return bits
+}
+
+func (f *filler) checkpoint() Checkpoint {
+	return Checkpoint{Epoch: f.uint64() % 100000, Root: f.bytes(32)}
+}
+
+func (f *filler) attestationData() AttestationData {
+	return AttestationData{
+		Slot:            f.uint64() % 3200000,
+		Index:           f.uint64() % 64,
+		BeaconBlockRoot: f.bytes(32),
+		Source:          f.checkpoint(),
+		Target:          f.checkpoint(),
+	}
+}
+
+func (f *filler) eth1Data() Eth1Data {
+	return Eth1Data{DepositRoot: f.bytes(32), DepositCount: f.uint64() % 1000000, BlockHash: f.bytes(32)}
+}
+
+func (f *filler) header() SignedBeaconBlockHeader {
+	return SignedBeaconBlockHeader{
+		Message: BeaconBlockHeader{
+			Slot:          f.uint64() % 3200000,
+			ProposerIndex: f.uint64() % 1000000,
+			ParentRoot:    f.bytes(32),
+			StateRoot:     f.bytes(32),
+			BodyRoot:      f.bytes(32),
+		},
+		Signature: f.bytes(96),
+	}
+}
+
+func (f *filler) indexedAttestation() IndexedAttestation {
+	indices := make([]uint64, MaxValidatorsPerCommittee)
+	for i := range indices {
+		indices[i] = f.uint64() % 1000000
+	}
+	return IndexedAttestation{AttestingIndices: indices, Data: f.attestationData(), Signature: f.bytes(96)}
+}
+
+// NewBeaconState returns a beacon state holding the given number of validators,
+// with every list and vector filled as on mainnet.
+func NewBeaconState(validators int) *BeaconState {
+	f := &filler{state: 1}
+	state := &BeaconState{
+		GenesisTime:           1606824023,
+		GenesisValidatorsRoot: f.bytes(32),
+		Slot:                  f.uint64() % 3200000,
+		Fork: Fork{
+			PreviousVersion: f.bytes(4),
+			CurrentVersion:  f.bytes(4),
+			Epoch:           f.uint64() % 100000,
+		},
+		LatestBlockHeader:           f.header().Message,
+		BlockRoots:                  f.roots(SlotsPerHistoricalRoot),
+		StateRoots:                  f.roots(SlotsPerHistoricalRoot),
+		HistoricalRoots:             f.roots(12000),
+		Eth1Data:                    f.eth1Data(),
+		Eth1DepositIndex:            f.uint64() % 1000000,
+		Validators:                  make([]Validator, validators),
+		Balances:                    make([]uint64, validators),
+		RandaoMixes:                 f.roots(EpochsPerHistoricalVector),
+		Slashings:                   make([]uint64, EpochsPerSlashingsVector),
+		PreviousEpochAttestations:   make([]PendingAttestation, pendingAttestationsPerEpoch),
+		CurrentEpochAttestations:    make([]PendingAttestation, pendingAttestationsPerEpoch),
+		JustificationBits:           bitfield.Bitvector4{0x0f},
+		PreviousJustifiedCheckpoint: f.checkpoint(),
+		CurrentJustifiedCheckpoint:  f.checkpoint(),
+		FinalizedCheckpoint:         f.checkpoint(),
+	}
+	for i := 0; i < 1024; i++ {
+		state.Eth1DataVotes = append(state.Eth1DataVotes, f.eth1Data())
+	}
+	for i := range state.Validators {
+		state.Validators[i] = Validator{
+			Pubkey:                     f.bytes(48),
+			WithdrawalCredentials:      f.bytes(32),
+			EffectiveBalance:           32000000000,
+			ActivationEligibilityEpoch: f.uint64() % 100000,
+			ActivationEpoch:            f.uint64() % 100000,
+			ExitEpoch:                  ^uint64(0),
+			WithdrawableEpoch:          ^uint64(0),
+		}
+		state.Balances[i] = 32000000000 + f.uint64()%1000000000
+	}
+	for i := range state.Slashings {
+		state.Slashings[i] = f.uint64() % 1000000000
+	}
+	for _, attestations := range [][]PendingAttestation{state.PreviousEpochAttestations, state.CurrentEpochAttestations} {
+		for i := range attestations {
+			attestations[i] = PendingAttestation{
+				AggregationBits: f.bits(128),
+				Data:            f.attestationData(),
+				InclusionDelay:  1 + f.uint64()%32,
+				ProposerIndex:   f.uint64() % uint64(validators+1),
+			}
+		}
+	}
+	return state
+}
+
+// NewSignedBeaconBlock returns a signed beacon block whose body is filled with
+// the maximum number of operations of every kind.
+func NewSignedBeaconBlock() *SignedBeaconBlock {
+	f := &filler{state: 2}
+	body := BeaconBlockBody{
+		RandaoReveal: f.bytes(96),
+		Eth1Data:     f.eth1Data(),
+		Graffiti:     f.bytes(32),
+	}
+	for i := 0; i < MaxProposerSlashings; i++ {
+		body.ProposerSlashings = append(body.ProposerSlashings, ProposerSlashing{
+			SignedHeader1: f.header(),
+			SignedHeader2: f.header(),
+		})
+	}
+	for i := 0; i < MaxAttesterSlashings; i++ {
+		body.AttesterSlashings = append(body.AttesterSlashings, AttesterSlashing{
+			Attestation1: f.indexedAttestation(),
+			Attestation2: f.indexedAttestation(),
+		})
+	}
+	for i := 0; i < MaxAttestations; i++ {
+		body.Attestations = append(body.Attestations, Attestation{
+			AggregationBits: f.bits(MaxValidatorsPerCommittee),
+			Data:            f.attestationData(),
+			Signature:       f.bytes(96),
+		})
+	}
+	for i := 0; i < MaxDeposits; i++ {
+		body.Deposits = append(body.Deposits, Deposit{
+			Proof: f.roots(DepositContractTreeDepth + 1),
+			Data: DepositData{
+				Pubkey:                f.bytes(48),
+				WithdrawalCredentials: f.bytes(32),
+				Amount:                32000000000,
+				Signature:             f.bytes(96),
+			},
+		})
+	}
+	for i := 0; i < MaxVoluntaryExits; i++ {
+		body.VoluntaryExits = append(body.VoluntaryExits, SignedVoluntaryExit{
+			Message:   VoluntaryExit{Epoch: f.uint64() % 100000, ValidatorIndex: f.uint64() % 1000000},
+			Signature: f.bytes(96),
+		})
+	}
+	return &SignedBeaconBlock{
+		Message: BeaconBlock{
+			Slot:          f.uint64() % 3200000,
+			ProposerIndex: f.uint64() % 1000000,
+			ParentRoot:    f.bytes(32),
+			StateRoot:     f.bytes(32),
+			Body:          body,
+		},
+		Signature: f.bytes(96),
+	}
+}
@@ -0,0 +1,241 @@
+package ssz
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// Bitfield is implemented by the bitlists and bitvectors the package serializes and
+// hashes, such as those of go-bitfield, and lets other bitfield implementations be
+// used without depending on go-bitfield. Bytes returns the bits of the bitfield,
+// bit i being stored in byte i/8 at position i%8 from the least significant bit,
+// without the delimiter bit of bitlists, and Len returns its number of bits.
+//
+// Bitfields are byte slice types holding the SSZ encoding of their value. Those
+// whose nil value has a Len of 0 are bitlists, whose encoding ends with a delimiter
+// bit, and the others are bitvectors of the Len of their nil value:
+//
+//  type SyncCommitteeBits []byte
+//
+//  func (b SyncCommitteeBits) Len() uint64         { return 512 }
+//  func (b SyncCommitteeBits) Bytes() []byte       { return b }
+//  func (b SyncCommitteeBits) BitAt(i uint64) bool { return i < 512 && b[i/8]&(1<<(i%8)) != 0 }
+type Bitfield interface {
+	Bytes() []byte
+	Len() uint64
+	BitAt(idx uint64) bool
+}
+
+var bitfieldType = reflect.TypeOf((*Bitfield)(nil)).Elem()
+
+// bitfieldLengths caches the Len of the nil value of bitfield types, see bitfieldLength.
+var bitfieldLengths sync.Map
+
+// bitfieldLength reports whether typ is a bitfield type, along with the Len of its nil
+// value, which is 0 for bitlists and the number of bits of bitvectors.
+func bitfieldLength(typ reflect.Type) (uint64, bool) {
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() != reflect.Uint8 {
+		return 0, false
+	}
+	if length, ok := bitfieldLengths.Load(typ); ok {
+		return length.(uint64), length.(uint64) != noBitfield
+	}
+	length := noBitfield
+	if typ.Implements(bitfieldType) {
+		length = reflect.Zero(typ).Interface().(Bitfield).Len()
+	}
+	bitfieldLengths.Store(typ, length)
+	return length, length != noBitfield
+}
+
+// noBitfield is cached by bitfieldLength for byte slice types which are not bitfields.
+const noBitfield = ^uint64(0)
+
+// isBitlist reports whether typ is a bitlist type such as bitfield.Bitlist: a bitfield
+// type whose length depends on its value, and whose encoding ends with a delimiter bit.
+func isBitlist(typ reflect.Type) bool {
+	length, ok := bitfieldLength(typ)
+	return ok && length == 0
+}
+
+// bitvectorLength returns the number of bits of typ if it is a bitvector type, such as
+// bitfield.Bitvector4: a bitfield type whose Len does not depend on the value.
+// Bitvectors are fixed-size, serialized to their bytes without a delimiter bit, and
+// their length is not mixed into their root.
+func bitvectorLength(typ reflect.Type) (uint64, bool) {
+	length, ok := bitfieldLength(typ)
+	if !ok || length == 0 {
+		return 0, false
+	}
+	return length, true
+}
+
+// isBitvector reports whether typ is a bitvector type, see bitvectorLength.
+func isBitvector(typ reflect.Type) bool {
+	_, ok := bitvectorLength(typ)
+	return ok
+}
+
+// bitvectorSize returns the serialized size of the bitvector type typ.
+func bitvectorSize(typ reflect.Type) uint64 {
+	length, _ := bitvectorLength(typ)
+	return (length + 7) / 8
+}
+
+// bitvectorChunks returns the serialized bitvector val padded to whole chunks.
+func bitvectorChunks(val reflect.Value) ([]byte, error) {
+	utils, err := cachedSSZUtils(val.Type())
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, paddedSize(bitvectorSize(val.Type())))
+	if _, err := utils.marshaler(val, buf, 0); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// bitsFieldType returns the type struct fields of type typ tagged with `ssz:"bits"` are
+// treated as: bitfield.Bitlist for lists of booleans, and the vector of the bytes their
+// bits are packed into for vectors of booleans, which bitvectors are serialized and
+// hashed as.
+func bitsFieldType(typ reflect.Type) (reflect.Type, error) {
+	switch {
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Bool:
+		return reflect.TypeOf(bitfield.Bitlist{}), nil
+	case typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Bool && typ.Len() > 0:
+		return reflect.ArrayOf((typ.Len()+7)/8, reflect.TypeOf(byte(0))), nil
+	default:
+		return nil, errorf(ErrInvalidTag, "bits require a list or non-empty vector of booleans, received %v", typ)
+	}
+}
+
+// packBits returns the booleans of val packed into a value of typ, as returned by
+// bitsFieldType.
+func packBits(val reflect.Value, typ reflect.Type) reflect.Value {
+	length := val.Len()
+	packed := reflect.New(typ).Elem()
+	var data []byte
+	if typ.Kind() == reflect.Array {
+		data = packed.Slice(0, packed.Len()).Bytes()
+	} else {
+		// One extra bit is needed for the delimiter.
+		data = make([]byte, length/8+1)
+		data[length/8] = 1 << uint(length%8)
+		packed.SetBytes(data)
+	}
+	for i := 0; i < length; i++ {
+		if val.Index(i).Bool() {
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return packed
+}
+
+// unpackBits sets the booleans of val to the bits packed into packed, a value of the
+// type returned by bitsFieldType for the type of val, which must hold a valid bitlist.
+func unpackBits(a *Arena, packed reflect.Value, val reflect.Value) {
+	var data []byte
+	length := val.Len()
+	if packed.Kind() == reflect.Array {
+		data = packed.Slice(0, packed.Len()).Bytes()
+	} else {
+		data = packed.Bytes()
+		length = int(bitfield.Bitlist(data).Len())
+		if val.Cap() >= length && !val.IsNil() {
+			val.SetLen(length)
+		} else {
+			val.Set(a.makeSlice(val.Type(), length, length))
+		}
+	}
+	for i := 0; i < length; i++ {
+		val.Index(i).SetBool(data[i/8]&(1<<uint(i%8)) != 0)
+	}
+}
+
+// makeBitsUtils returns the ssz utils of a struct field of type goType tagged with
+// `ssz:"bits"`, which pack its booleans into a value of typ, as returned by
+// bitsFieldType, and use the utils of typ.
+func makeBitsUtils(goType reflect.Type, typ reflect.Type) (*sszUtils, error) {
+	utils, err := cachedSSZUtilsNoAcquireLock(typ)
+	if err != nil {
+		return nil, err
+	}
+	hasher := utils.hasher
+	if isBitlist(typ) {
+		hasher = bitlistHasher
+	}
+	return &sszUtils{
+		marshaler: func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+			return utils.marshaler(packBits(val, typ), buf, startOffset)
+		},
+		unmarshaler: func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+			packed := reflect.New(typ).Elem()
+			end, err := utils.unmarshaler(a, input, packed, startOffset)
+			if err != nil {
+				return 0, err
+			}
+			if goType.Kind() == reflect.Array {
+				if err := validateBitvector(packed.Slice(0, packed.Len()).Bytes(), uint64(goType.Len())); err != nil {
+					return 0, err
+				}
+			}
+			unpackBits(a, packed, val)
+			return end, nil
+		},
+		hasher: func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			return hasher(h, packBits(val, typ), maxCapacity)
+		},
+	}, nil
+}
+
+// BitlistFromBools builds a bitlist out of a list of booleans following the
+// SSZ bit ordering: bit i is stored in byte i/8 at position i%8, counting from
+// the least significant bit, and a single delimiter bit is set right after the
+// last bit of the list. An error is returned if the list holds more than max bits.
+//
+//  bits := []bool{true, false, true}
+//  aggregationBits, err := BitlistFromBools(bits, 2048) // 0b00001101
+//  if err != nil {
+//      return fmt.Errorf("failed to build bitlist: %v", err)
+//  }
+func BitlistFromBools(bits []bool, max uint64) (bitfield.Bitlist, error) {
+	if uint64(len(bits)) > max {
+		return nil, errorf(ErrMaxLength, "bitlist length %d exceeds max %d", len(bits), max)
+	}
+	// One extra bit is needed for the delimiter.
+	b := make([]byte, len(bits)/8+1)
+	for i, set := range bits {
+		if set {
+			b[i/8] |= 1 << uint(i%8)
+		}
+	}
+	b[len(bits)/8] |= 1 << uint(len(bits)%8)
+	return bitfield.Bitlist(b), nil
+}
+
+// BoolsFromBitlist is the inverse of BitlistFromBools: it returns the bits of a
+// bitlist, excluding its delimiter bit, as a list of booleans. An error is returned
+// if the bitlist is empty or its last byte does not contain the delimiter bit.
+func BoolsFromBitlist(b bitfield.Bitlist) ([]bool, error) {
+	if len(b) == 0 {
+		return nil, errorf(ErrInvalidValue, "bitlist is empty and is missing its delimiter bit")
+	}
+	last := b[len(b)-1]
+	if last == 0 {
+		return nil, errorf(ErrInvalidValue, "last byte of bitlist does not contain a delimiter bit")
+	}
+	// The delimiter is the most significant set bit of the last byte.
+	msb := 7
+	for last&(1<<uint(msb)) == 0 {
+		msb--
+	}
+	length := (len(b)-1)*8 + msb
+	bits := make([]bool, length)
+	for i := 0; i < length; i++ {
+		bits[i] = b[i/8]&(1<<uint(i%8)) != 0
+	}
+	return bits, nil
+}
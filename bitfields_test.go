@@ -0,0 +1,416 @@
+package ssz
+
+import (
+	"bytes"
+	"math/bits"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+func TestBitlistFromBools(t *testing.T) {
+	tests := []struct {
+		name string
+		bits []bool
+		want []byte
+	}{
+		{
+			name: "empty list only contains the delimiter",
+			bits: []bool{},
+			want: []byte{0x01},
+		},
+		{
+			name: "bits are ordered from the least significant bit",
+			bits: []bool{true, false, true},
+			want: []byte{0x0d},
+		},
+		{
+			name: "a full byte pushes the delimiter into a new byte",
+			bits: []bool{true, true, true, true, true, true, true, true},
+			want: []byte{0xff, 0x01},
+		},
+		{
+			name: "bits spanning several bytes",
+			bits: []bool{false, false, false, false, false, false, false, false, true, false},
+			want: []byte{0x00, 0x05},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BitlistFromBools(tt.bits, 2048)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("BitlistFromBools() = %#x, want %#x", []byte(got), tt.want)
+			}
+			if got.Len() != uint64(len(tt.bits)) {
+				t.Errorf("Len() = %d, want %d", got.Len(), len(tt.bits))
+			}
+			for i, set := range tt.bits {
+				if got.BitAt(uint64(i)) != set {
+					t.Errorf("BitAt(%d) = %v, want %v", i, got.BitAt(uint64(i)), set)
+				}
+			}
+			bools, err := BoolsFromBitlist(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bools, tt.bits) {
+				t.Errorf("BoolsFromBitlist() = %v, want %v", bools, tt.bits)
+			}
+		})
+	}
+}
+
+func TestBitlistFromBools_ExceedsMax(t *testing.T) {
+	if _, err := BitlistFromBools(make([]bool, 9), 8); err == nil {
+		t.Error("Expected bitlist longer than max to fail")
+	}
+}
+
+func TestBoolsFromBitlist_MissingDelimiter(t *testing.T) {
+	for _, b := range []bitfield.Bitlist{{}, {0x01, 0x00}} {
+		if _, err := BoolsFromBitlist(b); err == nil {
+			t.Errorf("Expected bitlist %#x without delimiter to fail", []byte(b))
+		}
+	}
+}
+
+type bitvectorContainer struct {
+	Slot  uint8
+	Bits  bitfield.Bitvector4
+	Index uint16
+}
+
+// bitvectorVectorContainer is bitvectorContainer with its bitvector as a vector of
+// bytes, which bitvectors of at most 8 bits are serialized and hashed as.
+type bitvectorVectorContainer struct {
+	Slot  uint8
+	Bits  [1]byte
+	Index uint16
+}
+
+func TestBitvectorFields(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	// Bits past the length of the bitvector are masked when marshaling.
+	val := bitvectorContainer{Slot: 1, Bits: bitfield.Bitvector4{0xf5}, Index: 2}
+	encoded, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x01, 0x05, 0x02, 0x00}; !bytes.Equal(encoded, want) {
+		t.Errorf("Marshal() = %#x, want %#x", encoded, want)
+	}
+	var decoded bitvectorContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if want := (bitvectorContainer{Slot: 1, Bits: bitfield.Bitvector4{0x05}, Index: 2}); !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", decoded, want)
+	}
+
+	root, err := HashTreeRoot(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := HashTreeRoot(bitvectorVectorContainer{Slot: 1, Bits: [1]byte{0x05}, Index: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("HashTreeRoot() = %#x, want %#x", root, want)
+	}
+	tree, err := NewTree(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != want {
+		t.Errorf("NewTree().Root() = %#x, want %#x", tree.Root(), want)
+	}
+	if root, err = HashTreeRoot(bitfield.Bitvector4{0x0a}); err != nil {
+		t.Fatal(err)
+	}
+	if want := [32]byte{0x0a}; root != want {
+		t.Errorf("HashTreeRoot(bitvector) = %#x, want %#x", root, want)
+	}
+
+	// Nil bitvectors are encoded as unset bits.
+	if encoded, err = Marshal(bitvectorContainer{Slot: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x01, 0x00, 0x00, 0x00}; !bytes.Equal(encoded, want) {
+		t.Errorf("Marshal(nil bitvector) = %#x, want %#x", encoded, want)
+	}
+}
+
+func TestBitvectorFields_RejectsBitsPastLength(t *testing.T) {
+	encoded := []byte{0x01, 0x15, 0x02, 0x00}
+	var decoded bitvectorContainer
+	if err := Unmarshal(encoded, &decoded); err == nil {
+		t.Error("Expected bitvector with bits set past its length to fail")
+	}
+	if err := Validate(encoded, reflect.TypeOf(bitvectorContainer{})); err == nil {
+		t.Error("Expected validation of bitvector with bits set past its length to fail")
+	}
+}
+
+func TestBitvectorFields_Layout(t *testing.T) {
+	desc, err := Describe(reflect.TypeOf(bitvectorContainer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Variable || desc.Size != 4 {
+		t.Errorf("Describe() variable = %v, size = %d, want fixed size of 4", desc.Variable, desc.Size)
+	}
+	bits := desc.Fields[1]
+	if bits.Offset != 1 || bits.Type.Kind != KindBitvector || bits.Type.Length != 4 || bits.Type.Size != 1 {
+		t.Errorf("Describe() of bitvector field = %+v, type %+v", bits, bits.Type)
+	}
+	gindex, err := GeneralizedIndex(reflect.TypeOf(bitvectorContainer{}), "Bits", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gindex != bits.GeneralizedIndex {
+		t.Errorf("GeneralizedIndex(Bits, 3) = %d, want %d", gindex, bits.GeneralizedIndex)
+	}
+	if _, err := GeneralizedIndex(reflect.TypeOf(bitvectorContainer{}), "Bits", 4); err == nil {
+		t.Error("Expected bit past the length of the bitvector to fail")
+	}
+	if _, err := GeneralizedIndex(reflect.TypeOf(bitvectorContainer{}), "Bits", LengthPathElement); err == nil {
+		t.Error("Expected length of bitvector to fail")
+	}
+}
+
+// customBitlist is a bitlist implementation which does not depend on go-bitfield.
+type customBitlist []byte
+
+func (b customBitlist) Len() uint64 {
+	if len(b) == 0 {
+		return 0
+	}
+	return uint64(8*(len(b)-1) + bits.Len8(b[len(b)-1]) - 1)
+}
+
+func (b customBitlist) Bytes() []byte {
+	out := make([]byte, (b.Len()+7)/8)
+	copy(out, b)
+	if b.Len()%8 != 0 {
+		out[len(out)-1] &^= 0xff << (b.Len() % 8)
+	}
+	return out
+}
+
+func (b customBitlist) BitAt(idx uint64) bool {
+	return idx < b.Len() && b[idx/8]&(1<<(idx%8)) != 0
+}
+
+// customBitvector is a bitvector of 12 bits which does not depend on go-bitfield.
+type customBitvector []byte
+
+func (b customBitvector) Len() uint64 { return 12 }
+
+func (b customBitvector) Bytes() []byte {
+	out := make([]byte, 2)
+	copy(out, b)
+	out[1] &= 0x0f
+	return out
+}
+
+func (b customBitvector) BitAt(idx uint64) bool {
+	return idx < 12 && idx/8 < uint64(len(b)) && b[idx/8]&(1<<(idx%8)) != 0
+}
+
+type customBitfieldContainer struct {
+	List   customBitlist `ssz-max:"16"`
+	Vector customBitvector
+}
+
+// goBitfieldContainer is customBitfieldContainer with the bitlist of go-bitfield,
+// and its bitvector as the vector of bytes it is serialized and hashed as.
+type goBitfieldContainer struct {
+	List   bitfield.Bitlist `ssz-max:"16"`
+	Vector [2]byte
+}
+
+func TestCustomBitfields(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	if !isBitlist(reflect.TypeOf(customBitlist{})) || isBitlist(reflect.TypeOf(customBitvector{})) {
+		t.Fatal("Expected customBitlist only to be a bitlist")
+	}
+	val := customBitfieldContainer{List: customBitlist{0xa5, 0x06}, Vector: customBitvector{0x0f, 0xf3}}
+	encoded, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(goBitfieldContainer{List: bitfield.Bitlist{0xa5, 0x06}, Vector: [2]byte{0x0f, 0x03}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Marshal() = %#x, want %#x", encoded, want)
+	}
+
+	root, err := HashTreeRoot(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(goBitfieldContainer{List: bitfield.Bitlist{0xa5, 0x06}, Vector: [2]byte{0x0f, 0x03}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("HashTreeRoot() = %#x, want %#x", root, wantRoot)
+	}
+
+	var decoded customBitfieldContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.List.Len() != 10 || !decoded.List.BitAt(0) || decoded.List.BitAt(1) || !bytes.Equal(decoded.Vector, []byte{0x0f, 0x03}) {
+		t.Errorf("Unmarshal() = %+v, want the bits of %+v", decoded, val)
+	}
+	// The bitlist exceeds its ssz-max of 16 bits.
+	if _, err := HashTreeRoot(customBitfieldContainer{List: customBitlist{0xff, 0xff, 0x02}}); err == nil {
+		t.Error("Expected bitlist exceeding its ssz-max to fail")
+	}
+}
+
+type boolBitsContainer struct {
+	Aggregation []bool   `ssz:"bits,max=16"`
+	Committee   [12]bool `ssz:"bits"`
+	Slot        uint64
+}
+
+// packedBitsContainer is boolBitsContainer with its booleans packed by hand.
+type packedBitsContainer struct {
+	Aggregation bitfield.Bitlist `ssz-max:"16"`
+	Committee   [2]byte
+	Slot        uint64
+}
+
+func TestBitsTag(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	val := boolBitsContainer{
+		Aggregation: []bool{true, false, true, true, false, false, false, false, false, true},
+		Committee:   [12]bool{0: true, 9: true, 11: true},
+		Slot:        5,
+	}
+	packed := packedBitsContainer{
+		Aggregation: bitfield.Bitlist{0x0d, 0x06},
+		Committee:   [2]byte{0x01, 0x0a},
+		Slot:        5,
+	}
+	encoded, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Marshal() = %#x, want %#x", encoded, want)
+	}
+	var decoded boolBitsContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, val) {
+		t.Errorf("Unmarshal() = %+v, want %+v", decoded, val)
+	}
+
+	root, err := HashTreeRoot(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("HashTreeRoot() = %#x, want %#x", root, wantRoot)
+	}
+	tree, err := NewTree(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != wantRoot {
+		t.Errorf("NewTree().Root() = %#x, want %#x", tree.Root(), wantRoot)
+	}
+	fieldRoot, err := FieldRoot(val, "Aggregation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantField, err := FieldRoot(packed, "Aggregation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fieldRoot != wantField {
+		t.Errorf("FieldRoot(Aggregation) = %#x, want %#x", fieldRoot, wantField)
+	}
+
+	var bit bool
+	if err := UnmarshalPath(encoded, reflect.TypeOf(val), &bit, "Committee", 11); err != nil {
+		t.Fatal(err)
+	}
+	if !bit {
+		t.Error("UnmarshalPath(Committee, 11) = false, want true")
+	}
+	var committee [12]bool
+	if err := UnmarshalPath(encoded, reflect.TypeOf(val), &committee, "Committee"); err != nil {
+		t.Fatal(err)
+	}
+	if committee != val.Committee {
+		t.Errorf("UnmarshalPath(Committee) = %v, want %v", committee, val.Committee)
+	}
+	desc, err := Describe(reflect.TypeOf(val))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := desc.Fields[0].Type.Kind; kind != KindBitlist {
+		t.Errorf("Describe() kind of Aggregation = %s, want %s", kind, KindBitlist)
+	}
+	if c := desc.Fields[1].Type; c.Kind != KindBitvector || c.Length != 12 || c.Size != 2 {
+		t.Errorf("Describe() of Committee = %+v, want a bitvector of 12 bits", c)
+	}
+}
+
+func TestBitsTag_Errors(t *testing.T) {
+	encoded := []byte{0x0e, 0, 0, 0, 0x00, 0x08, 0x05, 0, 0, 0, 0, 0, 0, 0, 0x01}
+	var decoded boolBitsContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// Bits past the 12 bits of Committee are set.
+	encoded[5] = 0x10
+	if err := Unmarshal(encoded, &decoded); err == nil {
+		t.Error("Expected bitvector with bits set past its length to fail")
+	}
+	if err := Validate(encoded, reflect.TypeOf(decoded)); err == nil {
+		t.Error("Expected validation of bitvector with bits set past its length to fail")
+	}
+	if _, err := HashTreeRoot(boolBitsContainer{Aggregation: make([]bool, 17)}); err == nil {
+		t.Error("Expected bits exceeding their ssz-max to fail")
+	}
+	invalid := []interface{}{
+		struct {
+			Bytes []byte `ssz:"bits,max=16"`
+		}{},
+		struct {
+			Unbounded []bool `ssz:"bits"`
+		}{},
+		struct {
+			Sized []bool `ssz:"bits" ssz-size:"8"`
+		}{},
+	}
+	for _, val := range invalid {
+		if _, err := Marshal(val); err == nil {
+			t.Errorf("Expected bits field of %T to be invalid", val)
+		}
+	}
+}
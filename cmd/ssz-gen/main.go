@@ -0,0 +1,51 @@
+// Command ssz-gen generates tagged Go structs from container definitions written in
+// the Python syntax of the consensus specs, as read by the schema package:
+//
+//  go run ./cmd/ssz-gen -in phase0.py -package eth -out phase0.ssz.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/prysmaticlabs/go-ssz/schema"
+)
+
+func main() {
+	in := flag.String("in", "", "path of the schema to read")
+	out := flag.String("out", "-", "path of the Go file to write, - for the standard output")
+	pkg := flag.String("package", "types", "name of the generated package")
+	flag.Parse()
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "no schema given, see ssz-gen -h")
+		os.Exit(2)
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open schema: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	s, err := schema.Parse(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+	var src bytes.Buffer
+	if err := s.Generate(&src, *pkg, filepath.Base(*in)); err != nil {
+		fmt.Fprintf(os.Stderr, "could not generate structs: %v\n", err)
+		os.Exit(1)
+	}
+	if *out == "-" {
+		os.Stdout.Write(src.Bytes())
+		return
+	}
+	if err := ioutil.WriteFile(*out, src.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write structs: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,28 @@
+// Command ssz-golden regenerates the golden vectors of the golden package, after a
+// change intentionally altering encodings or roots:
+//
+//  go run ./cmd/ssz-golden -out golden/testdata/vectors.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func main() {
+	out := flag.String("out", "golden/testdata/vectors.json", "path of the vectors file to write")
+	flag.Parse()
+	entries, err := golden.Generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not generate vectors: %v\n", err)
+		os.Exit(1)
+	}
+	if err := golden.Save(*out, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write vectors: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d vectors to %s\n", len(entries), *out)
+}
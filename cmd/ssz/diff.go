@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// chunk is a leaf of the backing tree of a value, reported by ssz.Walk.
+type chunk struct {
+	path string
+	leaf [32]byte
+}
+
+// chunks returns the leaves of the backing tree of val by generalized index,
+// along with the generalized indices in merkleization order.
+func chunks(val interface{}) (map[uint64]chunk, []uint64, error) {
+	leaves := make(map[uint64]chunk)
+	var order []uint64
+	err := ssz.Walk(val, func(path []string, gindex uint64, leaf []byte) error {
+		c := chunk{path: strings.Join(path, "/")}
+		copy(c.leaf[:], leaf)
+		leaves[gindex] = c
+		order = append(order, gindex)
+		return nil
+	})
+	return leaves, order, err
+}
+
+// differ prints the differences between two values of the same type: the
+// leaves of their backing trees which differ, each preceded by the roots of
+// the subtrees holding it.
+type differ struct {
+	w       io.Writer
+	a, b    interface{}
+	printed map[string]bool
+}
+
+// diff prints the differences between a and b, returning the number of
+// differing leaves.
+func diff(w io.Writer, name string, a interface{}, b interface{}) (int, error) {
+	leavesA, orderA, err := chunks(a)
+	if err != nil {
+		return 0, err
+	}
+	leavesB, orderB, err := chunks(b)
+	if err != nil {
+		return 0, err
+	}
+	d := &differ{w: w, a: a, b: b, printed: make(map[string]bool)}
+	count := 0
+	for _, gindex := range mergeOrders(orderA, orderB, leavesA) {
+		ca, inA := leavesA[gindex]
+		cb, inB := leavesB[gindex]
+		switch {
+		case !inB:
+			d.subtrees(name, ca.path)
+			d.printf(ca.path, "%s: %#x, absent from the second value", leafName(name, ca.path), ca.leaf)
+		case !inA:
+			d.subtrees(name, cb.path)
+			d.printf(cb.path, "%s: absent from the first value, %#x", leafName(name, cb.path), cb.leaf)
+		case ca.leaf != cb.leaf:
+			d.subtrees(name, ca.path)
+			d.printf(ca.path, "%s: %#x != %#x", leafName(name, ca.path), ca.leaf, cb.leaf)
+		default:
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// mergeOrders merges the merkleization orders of the leaves of two values,
+// whose common leaves come in the same order in both.
+func mergeOrders(orderA []uint64, orderB []uint64, leavesA map[uint64]chunk) []uint64 {
+	merged := make([]uint64, 0, len(orderA))
+	next := 0
+	for _, gindex := range orderB {
+		if _, ok := leavesA[gindex]; !ok {
+			merged = append(merged, gindex)
+			continue
+		}
+		for next < len(orderA) {
+			merged = append(merged, orderA[next])
+			next++
+			if orderA[next-1] == gindex {
+				break
+			}
+		}
+	}
+	return append(merged, orderA[next:]...)
+}
+
+// subtrees prints the roots of the subtrees holding the leaf at path which
+// were not printed yet.
+func (d *differ) subtrees(name string, path string) {
+	var prefix []string
+	elems := strings.Split(path, "/")
+	if path == "" {
+		elems = nil
+	}
+	for i := 0; i < len(elems); i++ {
+		p := strings.Join(prefix, "/")
+		prefix = append(prefix, elems[i])
+		if d.printed[p] {
+			continue
+		}
+		d.printed[p] = true
+		d.printf(p, "%s: root %s != %s", leafName(name, p), d.root(d.a, p), d.root(d.b, p))
+	}
+}
+
+// root returns the root of the node at path within val, in hex.
+func (d *differ) root(val interface{}, path string) string {
+	var elems []interface{}
+	if path != "" {
+		var err error
+		if elems, err = parsePath(path); err != nil {
+			return "absent"
+		}
+	}
+	root, err := ssz.FieldRoot(val, elems...)
+	if err != nil {
+		return "absent"
+	}
+	return fmt.Sprintf("%#x", root)
+}
+
+func (d *differ) printf(path string, format string, args ...interface{}) {
+	depth := 0
+	if path != "" {
+		depth = strings.Count(path, "/") + 1
+	}
+	fmt.Fprintf(d.w, "%s%s\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+func leafName(name string, path string) string {
+	if path == "" {
+		return name
+	}
+	return path
+}
+
+func runDiff(args []string) error {
+	var f ioFlags
+	fs := newFlagSet("diff", &f)
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 2 {
+		return fmt.Errorf("expected two input files, received %d", len(files))
+	}
+	typ, err := lookupType(f.typeName)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(files))
+	for i, file := range files {
+		input, err := readSSZ(file, f.hex)
+		if err != nil {
+			return err
+		}
+		val := reflect.New(typ)
+		if err := ssz.Unmarshal(input, val.Interface()); err != nil {
+			return fmt.Errorf("could not decode %s: %v", file, err)
+		}
+		values[i] = val.Elem().Interface()
+	}
+	out := io.Writer(os.Stdout)
+	if f.out != "-" {
+		file, err := os.Create(f.out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	count, err := diff(out, f.typeName, values[0], values[1])
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return fmt.Errorf("found %d differing chunks", count)
+	}
+	return nil
+}
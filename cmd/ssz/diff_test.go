@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+func TestDiff(t *testing.T) {
+	a := benchmarks.IndexedAttestation{
+		AttestingIndices: []uint64{1, 2, 3},
+		Data: benchmarks.AttestationData{
+			Slot:            5,
+			BeaconBlockRoot: make([]byte, 32),
+			Source:          benchmarks.Checkpoint{Epoch: 1, Root: make([]byte, 32)},
+			Target:          benchmarks.Checkpoint{Epoch: 2, Root: make([]byte, 32)},
+		},
+		Signature: make([]byte, 96),
+	}
+	var out bytes.Buffer
+	count, err := diff(&out, "IndexedAttestation", a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 || out.Len() != 0 {
+		t.Errorf("Expected no differences, received %d:\n%s", count, out.String())
+	}
+
+	b := a
+	b.AttestingIndices = []uint64{1, 2, 3, 4, 5}
+	b.Data.Target.Epoch = 3
+	out.Reset()
+	if count, err = diff(&out, "IndexedAttestation", a, b); err != nil {
+		t.Fatal(err)
+	}
+	// The first chunk of the indices, the second one only held by b and the
+	// length of the list differ, along with the epoch of the target.
+	if count != 4 {
+		t.Errorf("Expected 4 differing chunks, received %d:\n%s", count, out.String())
+	}
+	for _, want := range []string{
+		"IndexedAttestation: root",
+		"  AttestingIndices: root",
+		"    AttestingIndices/0: ",
+		"    AttestingIndices/4: absent from the first value",
+		"    AttestingIndices/__len__: ",
+		"  Data: root",
+		"    Data/Target: root",
+		"      Data/Target/Epoch: 0x02",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the output to contain %q, received:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Data/Source") {
+		t.Errorf("Expected the equal source to be omitted, received:\n%s", out.String())
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// inspector prints the layout of serialized values, flagging the offsets and sizes
+// which do not match their type instead of stopping at the first of them.
+type inspector struct {
+	w io.Writer
+	// elements is the number of elements of each list or vector which are
+	// inspected, the others only being counted.
+	elements uint64
+	problems int
+}
+
+func (in *inspector) printf(depth int, format string, args ...interface{}) {
+	fmt.Fprintf(in.w, "%s%s\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+func (in *inspector) problem(depth int, format string, args ...interface{}) {
+	in.problems++
+	in.printf(depth, "!! "+format, args...)
+}
+
+// inspect prints the layout of data, located at start within the inspected input,
+// as a value described by desc.
+func (in *inspector) inspect(data []byte, desc *ssz.TypeDescriptor, name string, start uint64, depth int) {
+	end := start + uint64(len(data))
+	in.printf(depth, "[%d, %d) %s: %s %s, %d bytes", start, end, name, desc.Name, desc.Kind, len(data))
+	if !desc.Variable {
+		if uint64(len(data)) != desc.Size {
+			in.problem(depth+1, "expected %d bytes", desc.Size)
+			return
+		}
+		if desc.Kind != ssz.KindContainer && desc.Kind != ssz.KindVector {
+			return
+		}
+	}
+	if desc.MaxSize != 0 && uint64(len(data)) > desc.MaxSize {
+		in.problem(depth+1, "exceeds the maximum size of %d bytes", desc.MaxSize)
+	}
+	switch desc.Kind {
+	case ssz.KindBitlist:
+		in.bitlist(data, desc, depth+1)
+	case ssz.KindContainer:
+		in.container(data, desc, start, depth+1)
+	case ssz.KindVector, ssz.KindList:
+		in.elems(data, desc, start, depth+1)
+	}
+}
+
+func (in *inspector) bitlist(data []byte, desc *ssz.TypeDescriptor, depth int) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		in.problem(depth, "missing the delimiting bit")
+		return
+	}
+	last := data[len(data)-1]
+	bits := uint64(len(data)-1) * 8
+	for last > 1 {
+		last >>= 1
+		bits++
+	}
+	in.printf(depth, "%d bits", bits)
+	if desc.Limit != 0 && bits > desc.Limit {
+		in.problem(depth, "exceeds the limit of %d bits", desc.Limit)
+	}
+}
+
+func (in *inspector) container(data []byte, desc *ssz.TypeDescriptor, start uint64, depth int) {
+	var fixedSize uint64
+	for _, f := range desc.Fields {
+		fixedSize += f.Size
+	}
+	if uint64(len(data)) < fixedSize {
+		in.problem(depth, "shorter than the fixed part of %d bytes", fixedSize)
+		return
+	}
+	in.printf(depth, "fixed part: [%d, %d)", start, start+fixedSize)
+	var variable []*ssz.FieldDescriptor
+	var offsets []uint64
+	for _, f := range desc.Fields {
+		if !f.Type.Variable {
+			continue
+		}
+		offset := uint64(binary.LittleEndian.Uint32(data[f.Offset:]))
+		in.printf(depth+1, "%s: offset %d at [%d, %d)", f.Name, offset, start+f.Offset, start+f.Offset+ssz.BytesPerLengthOffset)
+		variable = append(variable, f)
+		offsets = append(offsets, offset)
+	}
+	if !in.checkOffsets(offsets, fixedSize, uint64(len(data)), depth+1) {
+		return
+	}
+	var next int
+	for _, f := range desc.Fields {
+		if !f.Type.Variable {
+			in.inspect(data[f.Offset:f.Offset+f.Size], f.Type, f.Name, start+f.Offset, depth)
+			continue
+		}
+		from, to := offsets[next], uint64(len(data))
+		if next+1 < len(offsets) {
+			to = offsets[next+1]
+		}
+		in.inspect(data[from:to], variable[next].Type, f.Name, start+from, depth)
+		next++
+	}
+}
+
+func (in *inspector) elems(data []byte, desc *ssz.TypeDescriptor, start uint64, depth int) {
+	elem := desc.Elem
+	size := uint64(len(data))
+	if !elem.Variable {
+		if elem.Size == 0 || size%elem.Size != 0 {
+			in.problem(depth, "not a multiple of the element size of %d bytes", elem.Size)
+			return
+		}
+		count := size / elem.Size
+		in.printf(depth, "%d elements of %d bytes", count, elem.Size)
+		in.checkCount(count, desc, depth)
+		if elem.Kind == ssz.KindBoolean || elem.Kind == ssz.KindUint {
+			return
+		}
+		for i := uint64(0); i < count && i < in.elements; i++ {
+			in.inspect(data[i*elem.Size:(i+1)*elem.Size], elem, fmt.Sprintf("[%d]", i), start+i*elem.Size, depth)
+		}
+		return
+	}
+	if size == 0 {
+		in.printf(depth, "0 elements")
+		in.checkCount(0, desc, depth)
+		return
+	}
+	if size < ssz.BytesPerLengthOffset {
+		in.problem(depth, "too short to hold an offset")
+		return
+	}
+	first := uint64(binary.LittleEndian.Uint32(data))
+	if first == 0 || first%ssz.BytesPerLengthOffset != 0 || first > size {
+		in.problem(depth, "first offset %d does not locate an offset table", first)
+		return
+	}
+	count := first / ssz.BytesPerLengthOffset
+	in.printf(depth, "%d elements, offset table [%d, %d)", count, start, start+first)
+	in.checkCount(count, desc, depth)
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = uint64(binary.LittleEndian.Uint32(data[uint64(i)*ssz.BytesPerLengthOffset:]))
+		if uint64(i) < in.elements {
+			in.printf(depth+1, "[%d]: offset %d", i, offsets[i])
+		}
+	}
+	if !in.checkOffsets(offsets, first, size, depth+1) {
+		return
+	}
+	for i := uint64(0); i < count && i < in.elements; i++ {
+		to := size
+		if i+1 < count {
+			to = offsets[i+1]
+		}
+		in.inspect(data[offsets[i]:to], elem, fmt.Sprintf("[%d]", i), start+offsets[i], depth)
+	}
+}
+
+func (in *inspector) checkCount(count uint64, desc *ssz.TypeDescriptor, depth int) {
+	if desc.Kind == ssz.KindVector && count != desc.Length {
+		in.problem(depth, "expected %d elements", desc.Length)
+	}
+	if desc.Kind == ssz.KindList && desc.Limit != 0 && count > desc.Limit {
+		in.problem(depth, "exceeds the limit of %d elements", desc.Limit)
+	}
+}
+
+// checkOffsets reports whether offsets start right after the fixed part and
+// increase up to the end of the data, flagging those which do not.
+func (in *inspector) checkOffsets(offsets []uint64, fixedSize uint64, size uint64, depth int) bool {
+	valid := true
+	for i, offset := range offsets {
+		switch {
+		case i == 0 && offset != fixedSize:
+			in.problem(depth, "offset %d: first offset %d does not match the fixed part size of %d", i, offset, fixedSize)
+			valid = false
+		case offset > size:
+			in.problem(depth, "offset %d: %d is past the end of the data at %d", i, offset, size)
+			valid = false
+		case i > 0 && offset < offsets[i-1]:
+			in.problem(depth, "offset %d: %d is lower than the previous offset %d", i, offset, offsets[i-1])
+			valid = false
+		}
+	}
+	return valid
+}
+
+func runInspect(args []string) error {
+	var f ioFlags
+	fs := newFlagSet("inspect", &f)
+	elements := fs.Uint64("elements", 4, "number of elements of each list and vector to inspect")
+	if err := parseArgs(fs, args, &f); err != nil {
+		return err
+	}
+	typ, err := lookupType(f.typeName)
+	if err != nil {
+		return err
+	}
+	desc, err := ssz.Describe(typ)
+	if err != nil {
+		return err
+	}
+	input, err := readSSZ(f.in, f.hex)
+	if err != nil {
+		return err
+	}
+	out := io.Writer(os.Stdout)
+	if f.out != "-" {
+		file, err := os.Create(f.out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	in := &inspector{w: out, elements: *elements}
+	in.inspect(input, desc, f.typeName, 0, 0)
+	if in.problems != 0 {
+		return fmt.Errorf("found %d problems", in.problems)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestInspect_Vectors(t *testing.T) {
+	for _, v := range golden.Vectors() {
+		encoded, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc, err := ssz.Describe(reflect.TypeOf(v.Value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		in := &inspector{w: &out, elements: 4}
+		in.inspect(encoded, desc, v.Name, 0, 0)
+		if in.problems != 0 {
+			t.Errorf("%s: expected no problems, received:\n%s", v.Name, out.String())
+		}
+	}
+}
+
+func TestInspect_MalformedOffsets(t *testing.T) {
+	var block interface{}
+	for _, v := range golden.Vectors() {
+		if v.Name == "SignedBeaconBlock" {
+			block = v.Value
+		}
+	}
+	encoded, err := ssz.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := ssz.Describe(reflect.TypeOf(block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		corrupt func(b []byte)
+		want    string
+	}{
+		{
+			name:    "first offset",
+			corrupt: func(b []byte) { binary.LittleEndian.PutUint32(b, 200) },
+			want:    "does not match the fixed part size",
+		},
+		{
+			name:    "past the end",
+			corrupt: func(b []byte) {
+				// The offset of the attester slashings of the body, whose own
+				// offset is at the end of the fixed part of the block.
+				body := 100 + binary.LittleEndian.Uint32(b[180:])
+				binary.LittleEndian.PutUint32(b[body+204:], uint32(len(b)))
+			},
+			want:    "past the end",
+		},
+		{
+			name:    "truncated",
+			corrupt: nil,
+			want:    "!!",
+		},
+	}
+	for _, tt := range tests {
+		data := append([]byte{}, encoded...)
+		if tt.corrupt != nil {
+			tt.corrupt(data)
+		} else {
+			data = data[:len(data)-10]
+		}
+		var out bytes.Buffer
+		in := &inspector{w: &out, elements: 4}
+		in.inspect(data, desc, "SignedBeaconBlock", 0, 0)
+		if in.problems == 0 || !strings.Contains(out.String(), tt.want) {
+			t.Errorf("%s: expected a problem mentioning %q, received:\n%s", tt.name, tt.want, out.String())
+		}
+	}
+}
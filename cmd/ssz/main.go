@@ -0,0 +1,273 @@
+// Command ssz encodes and decodes SSZ values of known types, to debug fixtures and
+// network captures without writing a Go program:
+//
+//  ssz encode -type Checkpoint -in checkpoint.yaml -out checkpoint.ssz
+//  ssz decode -type SignedBeaconBlock -in block.ssz
+//  ssz decode -type Attestation -hex -in capture.txt -format yaml
+//  ssz inspect -type BeaconState -in state.ssz
+//  ssz prove state.ssz -type BeaconState -path Validators/1234/EffectiveBalance -out proof.json
+//  ssz verify proof.json -root 0x...
+//  ssz diff a.ssz b.ssz -type BeaconState
+//  ssz types
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/jsonx"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"decode":  {summary: "decode an SSZ value to JSON or YAML", run: runDecode},
+	"diff":    {summary: "print the fields and subtree roots differing between two SSZ values", run: runDiff},
+	"encode":  {summary: "encode a JSON or YAML value to SSZ", run: runEncode},
+	"inspect": {summary: "print the layout of an SSZ value, flagging malformed offsets", run: runInspect},
+	"prove":   {summary: "print the Merkle proof of a node of an SSZ value", run: runProve},
+	"types":   {summary: "list the known types", run: runTypes},
+	"verify":  {summary: "check a Merkle proof against a root", run: runVerify},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ssz <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nrun ssz <command> -h for the flags of a command")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ssz %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// ioFlags are the flags shared by the commands reading and writing values.
+type ioFlags struct {
+	typeName string
+	in       string
+	out      string
+	format   string
+	hex      bool
+}
+
+func newFlagSet(name string, f *ioFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("ssz "+name, flag.ContinueOnError)
+	fs.StringVar(&f.typeName, "type", "", "type of the value, see ssz types")
+	fs.StringVar(&f.in, "in", "-", "input file, - for the standard input")
+	fs.StringVar(&f.out, "out", "-", "output file, - for the standard output")
+	fs.StringVar(&f.format, "format", "", "json or yaml, guessed from the file extension by default")
+	fs.BoolVar(&f.hex, "hex", false, "read or write SSZ as hex instead of raw bytes")
+	return fs
+}
+
+// parseArgs parses the flags of a command, which may come before or after the
+// input file, given in place of the -in flag.
+func parseArgs(fs *flag.FlagSet, args []string, f *ioFlags) error {
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+	switch len(files) {
+	case 0:
+	case 1:
+		f.in = files[0]
+	default:
+		return fmt.Errorf("expected a single input file, received %d", len(files))
+	}
+	return nil
+}
+
+// parseFiles parses the flags of a command, returning the files given before,
+// between and after them.
+func parseFiles(fs *flag.FlagSet, args []string) ([]string, error) {
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return files, nil
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func writeOutput(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// textFormat returns the format of a JSON or YAML file, from the -format flag or
+// from the extension of its path.
+func textFormat(format string, path string) (string, error) {
+	if format == "" {
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			format = "yaml"
+		default:
+			format = "json"
+		}
+	}
+	if format != "json" && format != "yaml" {
+		return "", fmt.Errorf("unknown format %s", format)
+	}
+	return format, nil
+}
+
+// readSSZ reads SSZ bytes, given either raw or as hex.
+func readSSZ(path string, isHex bool) ([]byte, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isHex {
+		return data, nil
+	}
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+}
+
+func writeSSZ(path string, data []byte, isHex bool) error {
+	if isHex {
+		data = []byte("0x" + hex.EncodeToString(data) + "\n")
+	}
+	return writeOutput(path, data)
+}
+
+func runEncode(args []string) error {
+	var f ioFlags
+	if err := parseArgs(newFlagSet("encode", &f), args, &f); err != nil {
+		return err
+	}
+	typ, err := lookupType(f.typeName)
+	if err != nil {
+		return err
+	}
+	format, err := textFormat(f.format, f.in)
+	if err != nil {
+		return err
+	}
+	input, err := readInput(f.in)
+	if err != nil {
+		return err
+	}
+	val, err := parseValue(input, format, typ)
+	if err != nil {
+		return err
+	}
+	encoded, err := ssz.Marshal(val.Interface())
+	if err != nil {
+		return err
+	}
+	return writeSSZ(f.out, encoded, f.hex)
+}
+
+func runDecode(args []string) error {
+	var f ioFlags
+	if err := parseArgs(newFlagSet("decode", &f), args, &f); err != nil {
+		return err
+	}
+	typ, err := lookupType(f.typeName)
+	if err != nil {
+		return err
+	}
+	format, err := textFormat(f.format, f.out)
+	if err != nil {
+		return err
+	}
+	input, err := readSSZ(f.in, f.hex)
+	if err != nil {
+		return err
+	}
+	val := reflect.New(typ)
+	if err := ssz.Unmarshal(input, val.Interface()); err != nil {
+		return err
+	}
+	output, err := formatValue(val.Elem(), format)
+	if err != nil {
+		return err
+	}
+	return writeOutput(f.out, output)
+}
+
+func runTypes(args []string) error {
+	if err := flag.NewFlagSet("ssz types", flag.ContinueOnError).Parse(args); err != nil {
+		return err
+	}
+	for _, name := range typeNames() {
+		fmt.Printf("%-24s %v\n", name, knownTypes[name])
+	}
+	return nil
+}
+
+// valueJSON is the JSON representation of values, naming fields after their Go
+// names so that they match the type definitions printed by ssz types.
+var valueJSON = jsonx.Options{Naming: jsonx.GoNames, Numbers: true}
+
+// parseValue parses a JSON or YAML representation of a value of type typ.
+func parseValue(input []byte, format string, typ reflect.Type) (reflect.Value, error) {
+	if format == "yaml" {
+		var err error
+		if input, err = yaml.YAMLToJSON(input); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	val := reflect.New(typ)
+	if err := valueJSON.Unmarshal(input, val.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return val.Elem(), nil
+}
+
+// formatValue returns the JSON or YAML representation of val.
+func formatValue(val reflect.Value, format string) ([]byte, error) {
+	output, err := valueJSON.MarshalIndent(val.Interface(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format == "yaml" {
+		return yaml.JSONToYAML(output)
+	}
+	return append(output, '\n'), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestFormatParseValue_RoundTrip(t *testing.T) {
+	for _, v := range golden.Vectors() {
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range []string{"json", "yaml"} {
+			output, err := formatValue(reflect.ValueOf(v.Value), format)
+			if err != nil {
+				t.Fatalf("%s: failed to format %s: %v", v.Name, format, err)
+			}
+			val, err := parseValue(output, format, reflect.TypeOf(v.Value))
+			if err != nil {
+				t.Fatalf("%s: failed to parse %s: %v\n%s", v.Name, format, err, output)
+			}
+			got, err := ssz.Marshal(val.Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: %s round trip changed the value to %+v", v.Name, format, val.Interface())
+			}
+		}
+	}
+}
+
+func TestParseValue_Errors(t *testing.T) {
+	typ := knownTypes["Checkpoint"]
+	tests := map[string]string{
+		"missing field":   `{"Epoch": 1}`,
+		"invalid hex":     `{"Epoch": 1, "Root": "0xzz"}`,
+		"negative number": `{"Epoch": -1, "Root": "0x00"}`,
+		"overflow":        `{"Epoch": 18446744073709551616, "Root": "0x00"}`,
+		"not an object":   `[1, 2]`,
+	}
+	for name, input := range tests {
+		if _, err := parseValue([]byte(input), "json", typ); err == nil {
+			t.Errorf("%s: expected an error parsing %s", name, input)
+		}
+	}
+}
+
+func TestLookupType(t *testing.T) {
+	if _, err := lookupType("BeaconBlock"); err != nil {
+		t.Error(err)
+	}
+	if _, err := lookupType("Unknown"); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
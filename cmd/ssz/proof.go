@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// parsePath splits a path such as Validators/1234/EffectiveBalance into the field
+// names and element indices taken by ssz.Prove.
+func parsePath(s string) ([]interface{}, error) {
+	if s == "" {
+		return nil, fmt.Errorf("no path given")
+	}
+	var path []interface{}
+	for _, elem := range strings.Split(strings.Trim(s, "/"), "/") {
+		if elem == "" {
+			return nil, fmt.Errorf("empty element in path %s", s)
+		}
+		if index, err := strconv.ParseUint(elem, 10, 64); err == nil {
+			path = append(path, index)
+			continue
+		}
+		path = append(path, elem)
+	}
+	return path, nil
+}
+
+// proofFormat returns the format of a proof file, from the -format flag or from the
+// extension of its path. Proofs are JSON or YAML in the format of the consensus spec
+// tests, or SSZ encodings of ssz.Proof.
+func proofFormat(format string, path string) (string, error) {
+	if format == "" && strings.HasSuffix(path, ".ssz") {
+		return "ssz", nil
+	}
+	if format == "ssz" {
+		return format, nil
+	}
+	return textFormat(format, path)
+}
+
+func encodeProof(proof *ssz.Proof, format string, isHex bool) ([]byte, error) {
+	switch format {
+	case "ssz":
+		encoded, err := ssz.Marshal(*proof)
+		if err != nil {
+			return nil, err
+		}
+		if isHex {
+			encoded = []byte("0x" + hex.EncodeToString(encoded) + "\n")
+		}
+		return encoded, nil
+	case "yaml":
+		return yaml.Marshal(proof)
+	default:
+		encoded, err := json.MarshalIndent(proof, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(encoded, '\n'), nil
+	}
+}
+
+func decodeProof(data []byte, format string, isHex bool) (*ssz.Proof, error) {
+	proof := &ssz.Proof{}
+	switch format {
+	case "ssz":
+		if isHex {
+			var err error
+			if data, err = hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")); err != nil {
+				return nil, err
+			}
+		}
+		if err := ssz.Unmarshal(data, proof); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, proof); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(data, proof); err != nil {
+			return nil, err
+		}
+	}
+	return proof, nil
+}
+
+func runProve(args []string) error {
+	var f ioFlags
+	fs := newFlagSet("prove", &f)
+	pathFlag := fs.String("path", "", "path of the proven node, such as Validators/1234/EffectiveBalance")
+	if err := parseArgs(fs, args, &f); err != nil {
+		return err
+	}
+	typ, err := lookupType(f.typeName)
+	if err != nil {
+		return err
+	}
+	path, err := parsePath(*pathFlag)
+	if err != nil {
+		return err
+	}
+	format, err := proofFormat(f.format, f.out)
+	if err != nil {
+		return err
+	}
+	input, err := readSSZ(f.in, f.hex)
+	if err != nil {
+		return err
+	}
+	val := reflect.New(typ)
+	if err := ssz.Unmarshal(input, val.Interface()); err != nil {
+		return err
+	}
+	proof, err := ssz.Prove(val.Elem().Interface(), path...)
+	if err != nil {
+		return err
+	}
+	root, err := ssz.HashTreeRoot(val.Elem().Interface())
+	if err != nil {
+		return err
+	}
+	// The root goes to the standard error, so that the standard output only
+	// holds the proof.
+	fmt.Fprintf(os.Stderr, "root: %#x\n", root)
+	output, err := encodeProof(proof, format, f.hex)
+	if err != nil {
+		return err
+	}
+	return writeOutput(f.out, output)
+}
+
+func runVerify(args []string) error {
+	var f ioFlags
+	fs := newFlagSet("verify", &f)
+	rootFlag := fs.String("root", "", "hex root the proof is checked against")
+	if err := parseArgs(fs, args, &f); err != nil {
+		return err
+	}
+	rootBytes, err := hex.DecodeString(strings.TrimPrefix(*rootFlag, "0x"))
+	if err != nil || len(rootBytes) != 32 {
+		return fmt.Errorf("invalid root %q", *rootFlag)
+	}
+	var root [32]byte
+	copy(root[:], rootBytes)
+	format, err := proofFormat(f.format, f.in)
+	if err != nil {
+		return err
+	}
+	input, err := readInput(f.in)
+	if err != nil {
+		return err
+	}
+	proof, err := decodeProof(input, format, f.hex)
+	if err != nil {
+		return fmt.Errorf("could not read proof: %v", err)
+	}
+	ok, err := ssz.VerifyProof(root, proof)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("proof of generalized index %d does not match root %#x", proof.Index, root)
+	}
+	fmt.Printf("valid proof of generalized index %d\n", proof.Index)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestParsePath(t *testing.T) {
+	path, err := parsePath("Validators/1234/EffectiveBalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"Validators", uint64(1234), "EffectiveBalance"}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("Expected %v, received %v", want, path)
+	}
+	for _, invalid := range []string{"", "Validators//EffectiveBalance"} {
+		if _, err := parsePath(invalid); err == nil {
+			t.Errorf("Expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestProof_Formats(t *testing.T) {
+	var block interface{}
+	for _, v := range golden.Vectors() {
+		if v.Name == "SignedBeaconBlock" {
+			block = v.Value
+		}
+	}
+	path, err := parsePath("Message/Body/Attestations/1/Data/Target/Root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := ssz.Prove(block, path...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ssz.HashTreeRoot(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"json", "yaml", "ssz"} {
+		for _, isHex := range []bool{false, true} {
+			encoded, err := encodeProof(proof, format, isHex)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			decoded, err := decodeProof(encoded, format, isHex)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if ok, err := ssz.VerifyProof(root, decoded); err != nil || !ok {
+				t.Errorf("%s: expected the decoded proof to verify, received %v, %v", format, ok, err)
+			}
+		}
+	}
+}
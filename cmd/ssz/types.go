@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+// knownTypes are the types which can be named with the -type flag.
+var knownTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"bytes4":  reflect.TypeOf([4]byte{}),
+	"bytes32": reflect.TypeOf([32]byte{}),
+	"bytes48": reflect.TypeOf([48]byte{}),
+	"bytes96": reflect.TypeOf([96]byte{}),
+
+	"Attestation":             reflect.TypeOf(benchmarks.Attestation{}),
+	"AttestationData":         reflect.TypeOf(benchmarks.AttestationData{}),
+	"AttesterSlashing":        reflect.TypeOf(benchmarks.AttesterSlashing{}),
+	"BeaconBlock":             reflect.TypeOf(benchmarks.BeaconBlock{}),
+	"BeaconBlockBody":         reflect.TypeOf(benchmarks.BeaconBlockBody{}),
+	"BeaconBlockHeader":       reflect.TypeOf(benchmarks.BeaconBlockHeader{}),
+	"BeaconState":             reflect.TypeOf(benchmarks.BeaconState{}),
+	"Checkpoint":              reflect.TypeOf(benchmarks.Checkpoint{}),
+	"Deposit":                 reflect.TypeOf(benchmarks.Deposit{}),
+	"DepositData":             reflect.TypeOf(benchmarks.DepositData{}),
+	"Eth1Data":                reflect.TypeOf(benchmarks.Eth1Data{}),
+	"Fork":                    reflect.TypeOf(benchmarks.Fork{}),
+	"IndexedAttestation":      reflect.TypeOf(benchmarks.IndexedAttestation{}),
+	"PendingAttestation":      reflect.TypeOf(benchmarks.PendingAttestation{}),
+	"ProposerSlashing":        reflect.TypeOf(benchmarks.ProposerSlashing{}),
+	"SignedBeaconBlock":       reflect.TypeOf(benchmarks.SignedBeaconBlock{}),
+	"SignedBeaconBlockHeader": reflect.TypeOf(benchmarks.SignedBeaconBlockHeader{}),
+	"SignedVoluntaryExit":     reflect.TypeOf(benchmarks.SignedVoluntaryExit{}),
+	"Validator":               reflect.TypeOf(benchmarks.Validator{}),
+	"VoluntaryExit":           reflect.TypeOf(benchmarks.VoluntaryExit{}),
+}
+
+func lookupType(name string) (reflect.Type, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no type given, see ssz types for the known types")
+	}
+	typ, ok := knownTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s, see ssz types for the known types", name)
+	}
+	return typ, nil
+}
+
+func typeNames() []string {
+	names := make([]string, 0, len(knownTypes))
+	for name := range knownTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,144 @@
+package ssz
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// MarshalFunc returns the SSZ encoding of val, a value of the type it is registered for.
+type MarshalFunc func(val interface{}) ([]byte, error)
+
+// UnmarshalFunc decodes data, the SSZ encoding of a value, into the value pointed to
+// by val, a pointer to the type it is registered for.
+type UnmarshalFunc func(data []byte, val interface{}) error
+
+// HashFunc returns the hash tree root of val, a value of the type it is registered for.
+type HashFunc func(val interface{}) ([32]byte, error)
+
+// customCodec holds the functions registered for a type with RegisterCodec.
+type customCodec struct {
+	marshal   MarshalFunc
+	unmarshal UnmarshalFunc
+	hash      HashFunc
+	// size is the SSZ size of the values of fixed-size types, 0 for variable-size ones.
+	size uint64
+}
+
+var (
+	customCodecsLock sync.RWMutex
+	customCodecs     = make(map[reflect.Type]*customCodec)
+)
+
+// RegisterCodec registers the functions encoding, decoding and hashing the values of
+// typ, which the package then uses wherever typ appears, at the top level or within
+// containers, vectors and lists. It lets applications support types the package cannot
+// inspect, such as C-backed structs or interned values, once for all call sites:
+//
+//  ssz.RegisterCodec(reflect.TypeOf(&blst.P1Affine{}), marshalPubkey, unmarshalPubkey, hashPubkey)
+//
+// The values of typ are variable-size, as byte lists are; see RegisterFixedSizeCodec for
+// fixed-size ones. Proofs and trees treat them as leaves holding their root. Codecs must
+// be registered before the first use of typ, and cannot be registered for basic types
+// and vectors of basic types, which are packed.
+func RegisterCodec(typ reflect.Type, m MarshalFunc, u UnmarshalFunc, h HashFunc) {
+	registerCodec(typ, &customCodec{marshal: m, unmarshal: u, hash: h})
+}
+
+// RegisterFixedSizeCodec registers the functions encoding, decoding and hashing the values
+// of typ like RegisterCodec, for a type whose values all encode to size bytes.
+func RegisterFixedSizeCodec(typ reflect.Type, size uint64, m MarshalFunc, u UnmarshalFunc, h HashFunc) {
+	if size == 0 {
+		panic(fmt.Sprintf("ssz: fixed size of the codec of %v must be positive", typ))
+	}
+	registerCodec(typ, &customCodec{marshal: m, unmarshal: u, hash: h, size: size})
+}
+
+func registerCodec(typ reflect.Type, codec *customCodec) {
+	if typ == nil || codec.marshal == nil || codec.unmarshal == nil || codec.hash == nil {
+		panic("ssz: codecs require a type and its marshal, unmarshal and hash functions")
+	}
+	if isBasicType(typ.Kind()) || isBasicTypeArray(typ, typ.Kind()) {
+		panic(fmt.Sprintf("ssz: cannot register a codec for the basic type %v", typ))
+	}
+	customCodecsLock.Lock()
+	defer customCodecsLock.Unlock()
+	customCodecs[typ] = codec
+}
+
+// lookupCodec returns the codec registered for typ, if any.
+func lookupCodec(typ reflect.Type) *customCodec {
+	customCodecsLock.RLock()
+	defer customCodecsLock.RUnlock()
+	return customCodecs[typ]
+}
+
+// encode returns the encoding of val, checking its size for fixed-size types.
+func (c *customCodec) encode(val reflect.Value) ([]byte, error) {
+	encoded, err := c.marshal(val.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %v: %w", val.Type(), err)
+	}
+	if c.size != 0 && uint64(len(encoded)) != c.size {
+		return nil, errorf(ErrSize, "codec of %v returned %d bytes, expected %d", val.Type(), len(encoded), c.size)
+	}
+	return encoded, nil
+}
+
+// encodedSize returns the size of the encoding of val, or 0 if it cannot be encoded,
+// leaving the error to its marshaler.
+func (c *customCodec) encodedSize(val reflect.Value) uint64 {
+	if c.size != 0 {
+		return c.size
+	}
+	encoded, err := c.marshal(val.Interface())
+	if err != nil {
+		return 0
+	}
+	return uint64(len(encoded))
+}
+
+// utils returns the ssz utils calling the functions of the codec.
+func (c *customCodec) utils(typ reflect.Type) *sszUtils {
+	return &sszUtils{
+		marshaler: func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+			encoded, err := c.encode(val)
+			if err != nil {
+				return 0, err
+			}
+			if uint64(len(buf))-startOffset < uint64(len(encoded)) {
+				return 0, errorf(ErrSize, "codec of %v returned more bytes than sized", typ)
+			}
+			return startOffset + uint64(copy(buf[startOffset:], encoded)), nil
+		},
+		unmarshaler: func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+			end := uint64(len(input))
+			if c.size != 0 {
+				end = startOffset + c.size
+			}
+			data, err := segment(input, startOffset, end)
+			if err != nil {
+				return 0, fmt.Errorf("failed to unmarshal %v: %w", typ, err)
+			}
+			// The codec decodes into a fresh value when val cannot be addressed.
+			target := val
+			if !val.CanAddr() {
+				target = reflect.New(typ).Elem()
+			}
+			if err := c.unmarshal(data, target.Addr().Interface()); err != nil {
+				return 0, fmt.Errorf("failed to unmarshal %v: %w", typ, err)
+			}
+			if !val.CanAddr() {
+				val.Set(target)
+			}
+			return end, nil
+		},
+		hasher: func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			root, err := c.hash(val.Interface())
+			if err != nil {
+				return [32]byte{}, fmt.Errorf("failed to hash %v: %w", typ, err)
+			}
+			return root, nil
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pubkeyHandle stands for a C-backed key, whose bytes the package cannot reach.
+type pubkeyHandle struct {
+	key *[48]byte
+}
+
+// internedName stands for an interned value, encoded as the string it refers to.
+type internedName struct {
+	id int
+}
+
+var internedNames = []string{"", "alice", "bob"}
+
+func init() {
+	RegisterFixedSizeCodec(reflect.TypeOf(pubkeyHandle{}), 48,
+		func(val interface{}) ([]byte, error) {
+			h := val.(pubkeyHandle)
+			if h.key == nil {
+				return make([]byte, 48), nil
+			}
+			return h.key[:], nil
+		},
+		func(data []byte, val interface{}) error {
+			key := new([48]byte)
+			copy(key[:], data)
+			*val.(*pubkeyHandle) = pubkeyHandle{key: key}
+			return nil
+		},
+		func(val interface{}) ([32]byte, error) {
+			var key [48]byte
+			if h := val.(pubkeyHandle); h.key != nil {
+				key = *h.key
+			}
+			return HashTreeRoot(key)
+		},
+	)
+	RegisterCodec(reflect.TypeOf(&internedName{}),
+		func(val interface{}) ([]byte, error) {
+			return []byte(internedNames[val.(*internedName).id]), nil
+		},
+		func(data []byte, val interface{}) error {
+			for id, name := range internedNames {
+				if name == string(data) {
+					*val.(**internedName) = &internedName{id: id}
+					return nil
+				}
+			}
+			return errors.New("unknown name")
+		},
+		func(val interface{}) ([32]byte, error) {
+			return HashTreeRootWithCapacity([]byte(internedNames[val.(*internedName).id]), 32)
+		},
+	)
+}
+
+type registeredContainer struct {
+	Index uint64
+	Key   pubkeyHandle
+	Name  *internedName
+	Keys  []pubkeyHandle `ssz-max:"4"`
+}
+
+// registeredEquivalent is the container SSZ sees through the registered codecs.
+type registeredEquivalent struct {
+	Index uint64
+	Key   [48]byte
+	Name  []byte     `ssz-max:"32"`
+	Keys  [][48]byte `ssz-max:"4"`
+}
+
+func TestRegisterCodec_Container(t *testing.T) {
+	key1, key2 := &[48]byte{1}, &[48]byte{2}
+	item := registeredContainer{
+		Index: 3,
+		Key:   pubkeyHandle{key1},
+		Name:  &internedName{id: 1},
+		Keys:  []pubkeyHandle{{key2}, {key1}},
+	}
+	equivalent := registeredEquivalent{
+		Index: 3,
+		Key:   *key1,
+		Name:  []byte("alice"),
+		Keys:  [][48]byte{*key2, *key1},
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	var decoded registeredContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, item) {
+		t.Errorf("Expected %+v, received %+v", item, decoded)
+	}
+	if err := Validate(encoded, reflect.TypeOf(item)); err != nil {
+		t.Errorf("Expected a valid encoding, received %v", err)
+	}
+
+	wantRoot, err := HashTreeRoot(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cache := range []bool{true, false} {
+		useCache = cache
+		root, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != wantRoot {
+			t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+		}
+	}
+	useCache = true
+	// The cache must see through the pointers held by registered values.
+	key1[0] = 9
+	equivalent.Key[0], equivalent.Keys[1][0] = 9, 9
+	if wantRoot, err = HashTreeRoot(equivalent); err != nil {
+		t.Fatal(err)
+	}
+	if root, err := HashTreeRoot(item); err != nil || root != wantRoot {
+		t.Errorf("Expected root %#x after mutating the key, received %#x (%v)", wantRoot, root, err)
+	}
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != wantRoot {
+		t.Errorf("Expected tree root %#x, received %#x", wantRoot, tree.Root())
+	}
+}
+
+func TestRegisterCodec_Describe(t *testing.T) {
+	desc, err := Describe(reflect.TypeOf(registeredContainer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Describe(reflect.TypeOf(registeredEquivalent{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.MinSize != want.MinSize || desc.MaxSize != 0 || !desc.Variable {
+		t.Errorf("Unexpected sizes of %+v", desc)
+	}
+	for _, i := range []int{1, 2} {
+		if kind := desc.Fields[i].Type.Kind; kind != KindCustom {
+			t.Errorf("Expected field %s of kind %s, received %s", desc.Fields[i].Name, KindCustom, kind)
+		}
+	}
+}
+
+func TestRegisterCodec_Errors(t *testing.T) {
+	encoded, err := Marshal(registeredEquivalent{Name: []byte("carol")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded registeredContainer
+	if err := Unmarshal(encoded, &decoded); err == nil || !strings.Contains(err.Error(), "unknown name") {
+		t.Errorf("Expected the codec error, received %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a codec for a basic type to panic")
+		}
+	}()
+	RegisterCodec(reflect.TypeOf(uint64(0)), nil, nil, nil)
+}
@@ -0,0 +1,74 @@
+package ssz
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+var (
+	constantsLock sync.RWMutex
+	constants     = make(map[string]uint64)
+	// resolvedConstants holds the constants tags were resolved with, whose values
+	// are then kept along with the sizes and limits cached for their types.
+	resolvedConstants = make(map[string]bool)
+)
+
+// RegisterConstants registers named constants which ssz-size and ssz-max tags can refer
+// to in place of numbers, so that limits such as those of the consensus specs presets
+// are defined once:
+//
+//  type BeaconState struct {
+//      BlockRoots [][]byte     `ssz-size:"SLOTS_PER_HISTORICAL_ROOT,32"`
+//      Validators []*Validator `ssz-max:"VALIDATOR_REGISTRY_LIMIT"`
+//  }
+//
+//  ssz.RegisterConstants(map[string]uint64{
+//      "SLOTS_PER_HISTORICAL_ROOT": 8192,
+//      "VALIDATOR_REGISTRY_LIMIT":  1 << 40,
+//  })
+//
+// Registering a constant again replaces its value, so that presets can be switched until
+// the first use of the types referring to them. As the tags of a type are only resolved
+// once, changing the value of a constant a tag was resolved with fails instead, leaving
+// all constants unchanged:
+//
+//  if err := ssz.RegisterConstants(minimalPreset); err != nil {
+//      return fmt.Errorf("failed to select the minimal preset: %v", err)
+//  }
+func RegisterConstants(values map[string]uint64) error {
+	constantsLock.Lock()
+	defer constantsLock.Unlock()
+	for name, val := range values {
+		if old, ok := constants[name]; ok && old != val && resolvedConstants[name] {
+			return fmt.Errorf("constant %q was already resolved with value %d, it cannot be changed to %d", name, old, val)
+		}
+	}
+	for name, val := range values {
+		constants[name] = val
+	}
+	return nil
+}
+
+// tagValue parses a size of an ssz-size or ssz-max tag, either a decimal number or the
+// name of a registered constant.
+func tagValue(s string) (uint64, error) {
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		return strconv.ParseUint(s, 10, 64)
+	}
+	constantsLock.RLock()
+	val, resolved := constants[s], resolvedConstants[s]
+	constantsLock.RUnlock()
+	if resolved {
+		return val, nil
+	}
+	// The constant is looked up again, as it may have changed before the lock is held.
+	constantsLock.Lock()
+	defer constantsLock.Unlock()
+	val, ok := constants[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown constant %q", s)
+	}
+	resolvedConstants[s] = true
+	return val, nil
+}
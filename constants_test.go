@@ -0,0 +1,114 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type namedLimits struct {
+	Roots   [][]byte `ssz-size:"TEST_ROOTS_LENGTH,32"`
+	Indices []uint64 `ssz-max:"TEST_INDICES_LIMIT"`
+}
+
+type numericLimits struct {
+	Roots   [][]byte `ssz-size:"2,32"`
+	Indices []uint64 `ssz-max:"64"`
+}
+
+func init() {
+	if err := RegisterConstants(map[string]uint64{
+		"TEST_ROOTS_LENGTH":  2,
+		"TEST_INDICES_LIMIT": 64,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterConstants_ResolvesTags(t *testing.T) {
+	roots := [][]byte{make([]byte, 32), append([]byte{1}, make([]byte, 31)...)}
+	named := namedLimits{Roots: roots, Indices: []uint64{1, 2, 3}}
+	numeric := numericLimits{Roots: roots, Indices: []uint64{1, 2, 3}}
+	encoded, err := Marshal(named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(numeric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	root, err := HashTreeRoot(named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(numeric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+	var decoded namedLimits
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, named) {
+		t.Errorf("Expected %v, received %v", named, decoded)
+	}
+}
+
+func TestRegisterConstants_UnknownConstant(t *testing.T) {
+	type unknownLimit struct {
+		Indices []uint64 `ssz-max:"TEST_UNKNOWN_LIMIT"`
+	}
+	if _, err := HashTreeRoot(unknownLimit{}); err == nil || !strings.Contains(err.Error(), "unknown constant") {
+		t.Errorf("Expected an unknown constant error, received %v", err)
+	}
+	type unknownSize struct {
+		Roots [][]byte `ssz-size:"TEST_UNKNOWN_LENGTH,32"`
+	}
+	if _, err := Marshal(unknownSize{}); err == nil {
+		t.Error("Expected an error for an unknown constant")
+	}
+}
+
+type presetLimits struct {
+	Indices []uint64 `ssz-max:"TEST_PRESET_LIMIT"`
+}
+
+func TestRegisterConstants_SwitchPresets(t *testing.T) {
+	minimal := map[string]uint64{"TEST_PRESET_LIMIT": 2}
+	mainnet := map[string]uint64{"TEST_PRESET_LIMIT": 4}
+	if err := RegisterConstants(minimal); err != nil {
+		t.Fatal(err)
+	}
+	// Presets can be switched until the types referring to them are used.
+	if err := RegisterConstants(mainnet); err != nil {
+		t.Fatal(err)
+	}
+	item := presetLimits{Indices: []uint64{1, 2, 3}}
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := HashTreeRootWithCapacity(item.Indices, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x with the mainnet limit, received %#x", want, root)
+	}
+	// The limit the type was resolved with cannot change anymore.
+	if err := RegisterConstants(minimal); err == nil {
+		t.Error("Expected switching presets after their first use to fail")
+	}
+	if err := RegisterConstants(mainnet); err != nil {
+		t.Errorf("Expected registering the same values again to succeed, received %v", err)
+	}
+	if _, err := Marshal(item); err != nil {
+		t.Errorf("Expected the mainnet limit to be kept, received %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package ssz
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// acyclicTypes caches the types found not to hold themselves, by acyclicKey.
+var acyclicTypes sync.Map
+
+// acyclicKey identifies a type found not to hold itself, either other than within
+// lists or at all when withinLists is set.
+type acyclicKey struct {
+	typ         reflect.Type
+	withinLists bool
+}
+
+// checkAcyclic fails for types holding themselves other than within lists, such as
+//
+//  type Node struct {
+//      Value uint64
+//      Next  *Node
+//  }
+//
+// whose values SSZ would encode as holding a Node, which holds a Node, and so on:
+// pointers are encoded as the values they point to, so that the type is infinitely
+// large. Types holding themselves within lists, such as those of trees, are valid,
+// as lists can be empty.
+func checkAcyclic(typ reflect.Type) error {
+	return findCycle(typ, false, nil, nil)
+}
+
+// checkNotRecursive fails for types holding themselves at all, even within lists,
+// which the functions describing types rather than values cannot walk.
+func checkNotRecursive(typ reflect.Type) error {
+	return findCycle(typ, true, nil, nil)
+}
+
+// findCycle walks the types held by typ, which is held by the types of path through
+// the steps of the same index, such as ".Next" for fields or "[i]" for vectors, and
+// fails if typ is one of them. The elements of lists are only walked if withinLists
+// is set.
+func findCycle(typ reflect.Type, withinLists bool, path []reflect.Type, steps []string) error {
+	// Pointers are encoded as the values they point to.
+	for typ.Kind() == reflect.Ptr && lookupCodec(typ) == nil {
+		typ = typ.Elem()
+	}
+	key := acyclicKey{typ: typ, withinLists: withinLists}
+	if _, ok := acyclicTypes.Load(key); ok {
+		return nil
+	}
+	for i := range path {
+		if path[i] != typ {
+			continue
+		}
+		via := fmt.Sprint(typ)
+		for _, step := range steps[i:] {
+			via += step
+		}
+		if withinLists {
+			return errorf(ErrUnsupportedType, "type %v holds itself at %s", typ, via)
+		}
+		return errorf(ErrUnsupportedType, "type %v holds itself at %s other than within a list, which would make it infinitely large", typ, via)
+	}
+	if lookupCodec(typ) != nil {
+		return nil
+	}
+	path = append(path, typ)
+	switch typ.Kind() {
+	case reflect.Array:
+		if err := findCycle(typ.Elem(), withinLists, path, append(steps, "[i]")); err != nil {
+			return err
+		}
+	case reflect.Slice:
+		if withinLists {
+			if err := findCycle(typ.Elem(), withinLists, path, append(steps, "[i]")); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if !isSSZField(f) || isOpaqueField(f) {
+				continue
+			}
+			// Invalid tags are reported when the utils of typ are generated.
+			fType, err := determineFieldType(f)
+			if err != nil {
+				continue
+			}
+			if err := findCycle(fType, withinLists, path, append(steps, "."+f.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	// No cycle goes through typ, which only holds types without cycles.
+	acyclicTypes.Store(key, true)
+	return nil
+}
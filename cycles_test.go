@@ -0,0 +1,94 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type linkedNode struct {
+	Value uint64
+	Next  *linkedNode
+}
+
+type binaryNode struct {
+	Children [2]*binaryNode
+}
+
+type sizedNode struct {
+	Children []*sizedNode `ssz-size:"2"`
+}
+
+type pingNode struct {
+	Pong *pongNode
+}
+
+type pongNode struct {
+	Slot uint64
+	Ping pingNode
+}
+
+type treeNode struct {
+	Value    uint64
+	Children []*treeNode `ssz-max:"4"`
+}
+
+func TestCyclicTypes(t *testing.T) {
+	tests := []struct {
+		val interface{}
+		err string
+	}{
+		{val: &linkedNode{}, err: "type ssz.linkedNode holds itself at ssz.linkedNode.Next other than within a list"},
+		{val: binaryNode{}, err: "type ssz.binaryNode holds itself at ssz.binaryNode.Children[i]"},
+		{val: sizedNode{}, err: "type ssz.sizedNode holds itself at ssz.sizedNode.Children[i]"},
+		{val: pongNode{}, err: "type ssz.pongNode holds itself at ssz.pongNode.Ping.Pong"},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.val)
+		t.Run(typ.String(), func(t *testing.T) {
+			if _, err := Marshal(tt.val); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Marshal() error = %v, expected %q", err, tt.err)
+			}
+			if _, err := HashTreeRoot(tt.val); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("HashTreeRoot() error = %v, expected %q", err, tt.err)
+			}
+			target := reflect.New(typ).Interface()
+			if err := Unmarshal(make([]byte, 64), target); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Unmarshal() error = %v, expected %q", err, tt.err)
+			}
+			if _, err := Describe(typ); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Describe() error = %v, expected %q", err, tt.err)
+			}
+			if _, err := GeneralizedIndex(typ); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("GeneralizedIndex() error = %v, expected %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// Types holding themselves within lists are valid, as lists can be empty.
+func TestCyclicTypes_WithinLists(t *testing.T) {
+	item := &treeNode{Value: 1, Children: []*treeNode{
+		{Value: 2},
+		{Value: 3, Children: []*treeNode{{Value: 4}}},
+	}}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &treeNode{}
+	if err := Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, item) {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+	if _, err := HashTreeRoot(item); err != nil {
+		t.Fatal(err)
+	}
+	// Descriptors are trees, which cannot describe them.
+	want := "type ssz.treeNode holds itself at ssz.treeNode.Children[i]"
+	if _, err := Describe(reflect.TypeOf(item)); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Describe() error = %v, expected %q", err, want)
+	}
+}
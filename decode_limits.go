@@ -0,0 +1,77 @@
+package ssz
+
+// DecodeLimits bound the resources Unmarshal spends on decoding a single input, so
+// that hostile encodings can neither make it allocate gigabytes nor recurse until the
+// stack is exhausted. Fields left to zero leave their resource unbounded:
+//
+//  ssz.SetDecodeLimits(ssz.DecodeLimits{
+//      MaxInputSize:    10 << 20,
+//      MaxDepth:        16,
+//      MaxListElements: 1 << 20,
+//  })
+type DecodeLimits struct {
+	// MaxInputSize is the size in bytes of the largest input decoded.
+	MaxInputSize uint64
+	// MaxDepth is the deepest nesting of decoded containers and of lists and vectors of
+	// variable-size elements, a container of lists of containers being 3 levels deep.
+	MaxDepth int
+	// MaxListElements is the most elements decoded into any single list, byte lists
+	// holding one element per byte.
+	MaxListElements uint64
+}
+
+var decodeLimits DecodeLimits
+
+// SetDecodeLimits sets the limits Unmarshal and UnmarshalWithArena enforce on every
+// input they decode, none by default. It must not be called concurrently with decoding.
+func SetDecodeLimits(limits DecodeLimits) {
+	decodeLimits = limits
+}
+
+// checkInputSize fails for inputs larger than the MaxInputSize of limits.
+func (limits *DecodeLimits) checkInputSize(input []byte) error {
+	if limits.MaxInputSize > 0 && uint64(len(input)) > limits.MaxInputSize {
+		return errorf(ErrDecodeLimit, "input of %d bytes exceeds the limit of %d bytes", len(input), limits.MaxInputSize)
+	}
+	return nil
+}
+
+// withLimits returns the arena decoding a single input under limits, which allocates
+// from the regions of a, or from the heap if a is nil.
+func (a *Arena) withLimits(limits *DecodeLimits) *Arena {
+	limited := &Arena{limits: limits}
+	if a != nil {
+		limited.slabs = a.slabs
+	}
+	return limited
+}
+
+// enter records the decoding of a nested value, failing past the MaxDepth of the limits
+// of the arena. Successful calls must be paired with calls to leave.
+func (a *Arena) enter() error {
+	if a == nil || a.limits == nil || a.limits.MaxDepth == 0 {
+		return nil
+	}
+	if a.depth == a.limits.MaxDepth {
+		return errorf(ErrDecodeLimit, "nesting exceeds the maximum depth of %d", a.limits.MaxDepth)
+	}
+	a.depth++
+	return nil
+}
+
+// leave records the end of the decoding of a nested value.
+func (a *Arena) leave() {
+	if a == nil || a.limits == nil || a.limits.MaxDepth == 0 {
+		return
+	}
+	a.depth--
+}
+
+// checkListElements fails for lists of count elements exceeding the MaxListElements of
+// the limits of the arena, before they are allocated.
+func (a *Arena) checkListElements(count uint64) error {
+	if a == nil || a.limits == nil || a.limits.MaxListElements == 0 || count <= a.limits.MaxListElements {
+		return nil
+	}
+	return errorf(ErrDecodeLimit, "list of %d elements exceeds the limit of %d elements", count, a.limits.MaxListElements)
+}
@@ -0,0 +1,105 @@
+package ssz
+
+import (
+	"strings"
+	"testing"
+)
+
+type limitsLeaf struct {
+	Data []byte `ssz-max:"16"`
+}
+
+type limitsNode struct {
+	Slot     uint64
+	Balances []uint64     `ssz-max:"16"`
+	Leaves   []limitsLeaf `ssz-max:"8"`
+}
+
+type limitsRoot struct {
+	Node limitsNode
+}
+
+func newLimitsRoot() limitsRoot {
+	return limitsRoot{Node: limitsNode{
+		Slot:     1,
+		Balances: []uint64{1},
+		Leaves:   []limitsLeaf{{Data: []byte{1, 2}}, {Data: []byte{3, 4, 5}}},
+	}}
+}
+
+func TestSetDecodeLimits(t *testing.T) {
+	defer SetDecodeLimits(DecodeLimits{})
+	encoded, err := Marshal(newLimitsRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		limits DecodeLimits
+		err    string
+	}{
+		{
+			name:   "within limits",
+			limits: DecodeLimits{MaxInputSize: uint64(len(encoded)), MaxDepth: 4, MaxListElements: 3},
+		},
+		{
+			name:   "input size",
+			limits: DecodeLimits{MaxInputSize: uint64(len(encoded)) - 1},
+			err:    "exceeds the limit of",
+		},
+		{
+			// The root and the node are containers, holding a list of containers.
+			name:   "depth",
+			limits: DecodeLimits{MaxDepth: 3},
+			err:    "nesting exceeds the maximum depth of 3",
+		},
+		{
+			name:   "elements of a byte list",
+			limits: DecodeLimits{MaxListElements: 2},
+			err:    "list of 3 elements exceeds the limit of 2 elements",
+		},
+		{
+			name:   "elements of a list of containers",
+			limits: DecodeLimits{MaxListElements: 1},
+			err:    "list of 2 elements exceeds the limit of 1 elements",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDecodeLimits(tt.limits)
+			for _, arena := range []*Arena{nil, NewArena()} {
+				var decoded limitsRoot
+				err := UnmarshalWithArena(encoded, &decoded, arena)
+				if tt.err == "" {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !DeepEqual(decoded, newLimitsRoot()) {
+						t.Errorf("Expected %v, received %v", newLimitsRoot(), decoded)
+					}
+					continue
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected error containing %q, received %v", tt.err, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSetDecodeLimits_BasicList(t *testing.T) {
+	defer SetDecodeLimits(DecodeLimits{})
+	encoded, err := Marshal([]uint64{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDecodeLimits(DecodeLimits{MaxListElements: 4})
+	var decoded []uint64
+	if err := Unmarshal(encoded, &decoded); err == nil || !strings.Contains(err.Error(), "list of 5 elements") {
+		t.Errorf("Expected the list to exceed the limit of elements, received %v", err)
+	}
+	SetDecodeLimits(DecodeLimits{MaxListElements: 5})
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -109,6 +109,9 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) boo
 		return deepValueEqual(v1.Elem(), v2.Elem(), visited, depth+1)
 	case reflect.Struct:
 		for i, n := 0, v1.NumField(); i < n; i++ {
+			if !isSSZField(v1.Type().Field(i)) {
+				continue
+			}
 			if !deepValueEqual(v1.Field(i), v2.Field(i), visited, depth+1) {
 				return false
 			}
@@ -124,6 +127,8 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) boo
 		return v1.Interface().(uint8) == v2.Interface().(uint8)
 	case reflect.Bool:
 		return v1.Interface().(bool) == v2.Interface().(bool)
+	case reflect.String:
+		return v1.String() == v2.String()
 	default:
 		return false
 	}
@@ -136,8 +141,8 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) boo
 //
 // Array values are deeply equal when their corresponding elements are deeply equal.
 //
-// Struct values are deeply equal if their corresponding fields,
-// both exported and unexported, are deeply equal.
+// Struct values are deeply equal if their corresponding SSZ fields are deeply equal,
+// ignoring protobuf internal fields and unexported fields.
 //
 // Interface values are deeply equal if they hold deeply equal concrete values.
 //
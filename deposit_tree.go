@@ -0,0 +1,76 @@
+package ssz
+
+import (
+	"fmt"
+)
+
+// DepositContractTreeDepth is the depth of the Merkle tree of the deposits of the eth1
+// deposit contract, which holds at most 2^32 deposits.
+const DepositContractTreeDepth = 32
+
+// DepositTree is the Merkle tree of the deposits of the eth1 deposit contract, whose
+// root is the one get_deposit_root of the contract returns: the root of the list of
+// the roots of the deposit data of every deposit, as leaves. Proofs of deposits can
+// be verified with VerifyProof against the root of the tree, and their hashes are the
+// proofs of the Deposit containers of the consensus specs.
+//
+//  tree := NewDepositTree()
+//  for _, data := range depositData {
+//      if err := tree.InsertDepositData(data); err != nil {
+//          return err
+//      }
+//  }
+//  proof, err := tree.Proof(index)
+//  if err != nil {
+//      return err
+//  }
+//  deposit := &Deposit{Proof: proof.Hashes, Data: depositData[index]}
+//
+// Deposit trees are safe for concurrent use.
+type DepositTree struct {
+	leaves *ListHasher
+}
+
+// NewDepositTree returns the tree of a deposit contract without any deposit.
+func NewDepositTree() *DepositTree {
+	leaves, err := NewListHasher(rootType, 1<<DepositContractTreeDepth)
+	if err != nil {
+		// Roots are always hashable.
+		panic(err)
+	}
+	return &DepositTree{leaves: leaves}
+}
+
+// Insert adds the deposit whose deposit data has the hash tree root leaf to the tree.
+// An error wrapping ErrMaxLength is returned once the tree holds 2^32 deposits.
+func (t *DepositTree) Insert(leaf [32]byte) error {
+	return t.leaves.Append(leaf)
+}
+
+// InsertDepositData adds the deposit of data, a DepositData container or a pointer to
+// one, to the tree.
+func (t *DepositTree) InsertDepositData(data interface{}) error {
+	leaf, err := HashTreeRoot(data)
+	if err != nil {
+		return fmt.Errorf("could not hash deposit data: %w", err)
+	}
+	return t.Insert(leaf)
+}
+
+// Count returns the number of deposits of the tree.
+func (t *DepositTree) Count() uint64 {
+	return t.leaves.Len()
+}
+
+// Root returns the deposit root of the tree, mixing the number of deposits in.
+func (t *DepositTree) Root() [32]byte {
+	return t.leaves.Root()
+}
+
+// Proof returns the proof of the deposit at index against the root of the tree. Its
+// hashes are the DepositContractTreeDepth siblings of the path of the deposit followed
+// by the number of deposits of the tree, as is_valid_merkle_branch expects them with a
+// depth of DepositContractTreeDepth + 1.
+func (t *DepositTree) Proof(index uint64) (*Proof, error) {
+	return t.leaves.Prove(index)
+}
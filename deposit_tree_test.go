@@ -0,0 +1,66 @@
+package ssz
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestDepositTree_EmptyRoot(t *testing.T) {
+	// The deposit root of the deposit contract before any deposit.
+	want := "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e"
+	root := NewDepositTree().Root()
+	if got := hex.EncodeToString(root[:]); got != want {
+		t.Errorf("Expected empty deposit root %s, received %s", want, got)
+	}
+}
+
+func TestDepositTree(t *testing.T) {
+	tree := NewDepositTree()
+	var leaves [][32]byte
+	for i := 0; i < 5; i++ {
+		data := &testDepositData{
+			Pubkey:                make([]byte, 48),
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                32000000000 + uint64(i),
+			Signature:             make([]byte, 96),
+		}
+		leaf, err := HashTreeRoot(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves = append(leaves, leaf)
+		if err := tree.InsertDepositData(data); err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRootWithCapacity(leaves, 1<<DepositContractTreeDepth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root := tree.Root(); root != want {
+			t.Errorf("Expected deposit root %#x after %d deposits, received %#x", want, len(leaves), root)
+		}
+	}
+	if tree.Count() != uint64(len(leaves)) {
+		t.Errorf("Expected %d deposits, received %d", len(leaves), tree.Count())
+	}
+	root := tree.Root()
+	for i, leaf := range leaves {
+		proof, err := tree.Proof(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Leaf != leaf || len(proof.Hashes) != DepositContractTreeDepth+1 {
+			t.Fatalf("Unexpected proof of deposit %d: %+v", i, proof)
+		}
+		if ok, err := VerifyProof(root, proof); err != nil || !ok {
+			t.Errorf("Expected proof of deposit %d to be valid, received %v", i, err)
+		}
+	}
+	if _, err := tree.Proof(uint64(len(leaves))); err == nil {
+		t.Error("Expected proving a missing deposit to fail")
+	}
+	if err := tree.InsertDepositData(struct{ Amount int }{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected unhashable deposit data to fail, received %v", err)
+	}
+}
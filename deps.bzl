@@ -106,6 +106,47 @@ def go_ssz_dependencies():
         importpath = "github.com/minio/highwayhash",
     )
 
+    _maybe(
+        # Apache License 2.0
+        go_repository,
+        name = "com_github_minio_sha256_simd",
+        importpath = "github.com/minio/sha256-simd",
+        sum = "h1:6kaan5IFmwTNynnKKpDHe6FWHohJOHhCPchzK49dzMM=",
+        version = "v1.0.1",
+    )
+
+    _maybe(
+        # MIT License
+        go_repository,
+        name = "com_github_klauspost_cpuid_v2",
+        commit = "3c0ec06adeb260a595bfb1dff123742e8bac34fb",  # v2.2.3
+        importpath = "github.com/klauspost/cpuid/v2",
+    )
+
+    _maybe(
+        # MIT License
+        go_repository,
+        name = "com_github_ghodss_yaml",
+        commit = "0ca9ea5df5451ffdf184b4428c902747c2c11cd7",  # v1.0.0
+        importpath = "github.com/ghodss/yaml",
+    )
+
+    _maybe(
+        # Apache License 2.0
+        go_repository,
+        name = "in_gopkg_yaml_v2",
+        commit = "51d6538a90f86fe93ac480b35f37b2be17fef232",  # v2.2.2
+        importpath = "gopkg.in/yaml.v2",
+    )
+
+    _maybe(
+        # BSD 3-Clause "New" or "Revised" License
+        go_repository,
+        name = "com_github_golang_snappy",
+        commit = "43d5d4cd4e0e3390b0b645d5c3ef1187642403d8",  # v1.0.0
+        importpath = "github.com/golang/snappy",
+    )
+
 def _maybe(repo_rule, name, **kwargs):
     if name not in native.existing_rules():
         repo_rule(name = name, **kwargs)
@@ -0,0 +1,243 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Kinds of SSZ types reported by TypeDescriptor.
+const (
+	KindBoolean   = "boolean"
+	KindUint      = "uint"
+	KindVector    = "vector"
+	KindList      = "list"
+	KindBitlist   = "bitlist"
+	KindBitvector = "bitvector"
+	KindContainer = "container"
+	// KindCustom is the kind of the types registered with RegisterCodec, whose
+	// layout is left to their codec.
+	KindCustom = "custom"
+)
+
+// TypeDescriptor is the layout of a Go type as an SSZ type, as used for its
+// serialization and merkleization.
+type TypeDescriptor struct {
+	// Name is the Go name of the type.
+	Name string `json:"name"`
+	// Kind is the SSZ kind of the type, one of the Kind constants. The width
+	// of unsigned integers is given by their Size.
+	Kind string `json:"kind"`
+	// Variable reports whether serialized values have a variable size.
+	Variable bool `json:"variable"`
+	// Size is the serialized size of values of fixed-size types, and 0 for
+	// variable-size types.
+	Size uint64 `json:"size"`
+	// MinSize and MaxSize bound the serialized size of values. MaxSize is 0
+	// when a list without ssz-max makes the size unbounded.
+	MinSize uint64 `json:"min_size"`
+	MaxSize uint64 `json:"max_size"`
+	// Length is the number of elements of vectors or bits of bitvectors, and
+	// Limit the maximum number of elements or bits of lists, as set by their
+	// ssz-max tag.
+	Length uint64 `json:"length,omitempty"`
+	Limit  uint64 `json:"limit,omitempty"`
+	// Chunks is the number of leaves the contents of values are merkleized
+	// into, and Depth the depth of their tree, including the length mixed
+	// into the root of lists. Both are 0 for lists without ssz-max.
+	Chunks uint64 `json:"chunks"`
+	Depth  uint64 `json:"depth"`
+	// Elem describes the elements of vectors and lists.
+	Elem *TypeDescriptor `json:"elem,omitempty"`
+	// Fields describes the fields of containers, in merkleization order.
+	Fields []*FieldDescriptor `json:"fields,omitempty"`
+}
+
+// FieldDescriptor is the layout of a field of a container.
+type FieldDescriptor struct {
+	// Name is the name of the Go struct field, and Index its index among the
+	// fields of the Go struct, including skipped ones.
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+	// Offset is the offset of the field within the fixed part of serialized
+	// containers, and Size its size there: the size of its value for fixed-size
+	// fields, and the size of the offset of its value for variable-size ones.
+	Offset uint64 `json:"offset"`
+	Size   uint64 `json:"size"`
+	// GeneralizedIndex is the generalized index of the field within the tree
+	// of the container.
+	GeneralizedIndex uint64 `json:"generalized_index"`
+	// Opaque reports whether the field is encoded by an OpaqueCodec, in
+	// which case Type describes the byte list it is encoded to.
+	Opaque bool `json:"opaque,omitempty"`
+	// Progressive reports whether the field is merkleized progressively, in
+	// which case its lists have no limit and Chunks is meaningless.
+	Progressive bool            `json:"progressive,omitempty"`
+	Type        *TypeDescriptor `json:"type"`
+}
+
+// Describe returns the layout of typ as an SSZ type, for tools which need the offsets,
+// sizes, limits and tree shape of values without parsing struct tags themselves.
+//
+//  desc, err := Describe(reflect.TypeOf(BeaconState{}))
+//  if err != nil {
+//      return fmt.Errorf("failed to describe state: %v", err)
+//  }
+//  for _, f := range desc.Fields {
+//      fmt.Printf("%s at offset %d\n", f.Name, f.Offset)
+//  }
+func Describe(typ reflect.Type) (*TypeDescriptor, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	if err := checkAcyclic(typ); err != nil {
+		return nil, err
+	}
+	// Descriptors are trees, which cannot describe types holding themselves.
+	if err := checkNotRecursive(typ); err != nil {
+		return nil, err
+	}
+	return describe(typ, 0, nil)
+}
+
+// describe describes typ, whose lists are bounded by maxCapacity and the lists
+// nested within them by innerLimits.
+func describe(typ reflect.Type, maxCapacity uint64, innerLimits []uint64) (*TypeDescriptor, error) {
+	for typ.Kind() == reflect.Ptr && lookupCodec(typ) == nil {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.String {
+		// Strings are described as the byte lists they are encoded as.
+		desc, err := describe(byteSliceType, maxCapacity, innerLimits)
+		if err != nil {
+			return nil, err
+		}
+		desc.Name = typ.String()
+		return desc, nil
+	}
+	desc := &TypeDescriptor{
+		Name:     typ.String(),
+		Variable: isVariableSizeType(typ),
+	}
+	kind := typ.Kind()
+	switch {
+	case isBitlist(typ):
+		desc.Kind = KindBitlist
+		desc.Limit = maxCapacity
+		desc.MinSize = 1
+		if maxCapacity != 0 {
+			desc.MaxSize = maxCapacity/8 + 1
+			desc.Chunks = (maxCapacity + 255) / 256
+		}
+	case lookupCodec(typ) != nil:
+		desc.Kind = KindCustom
+		desc.Chunks = 1
+	case isBitvector(typ):
+		desc.Kind = KindBitvector
+		desc.Length, _ = bitvectorLength(typ)
+		desc.Chunks = (desc.Length + 255) / 256
+	case kind == reflect.Bool:
+		desc.Kind = KindBoolean
+		desc.Chunks = 1
+	case isBasicType(kind):
+		desc.Kind = KindUint
+		desc.Chunks = 1
+	case kind == reflect.Array || kind == reflect.Slice:
+		elemCapacity, elemLimits := splitLimits(innerLimits)
+		elem, err := describe(typ.Elem(), elemCapacity, elemLimits)
+		if err != nil {
+			return nil, err
+		}
+		desc.Elem = elem
+		count := maxCapacity
+		if kind == reflect.Array {
+			desc.Kind = KindVector
+			desc.Length = uint64(typ.Len())
+			count = desc.Length
+		} else {
+			desc.Kind = KindList
+			desc.Limit = maxCapacity
+		}
+		elemMaxSize := elem.MaxSize
+		if elem.Variable {
+			elemMaxSize += BytesPerLengthOffset
+		}
+		if elem.MaxSize != 0 || !elem.Variable {
+			desc.MaxSize = count * elemMaxSize
+		}
+		if isBasicType(typ.Elem().Kind()) {
+			desc.Chunks = (count*elem.Size + 31) / 32
+		} else {
+			desc.Chunks = count
+		}
+	case kind == reflect.Struct:
+		desc.Kind = KindContainer
+		fields, err := structFields(typ)
+		if err != nil {
+			return nil, err
+		}
+		desc.Chunks = uint64(len(fields))
+		bounded := true
+		offset := uint64(0)
+		for i, f := range fields {
+			fieldDesc, err := describe(f.typ, f.capacity, f.innerLimits)
+			if err != nil {
+				return nil, fmt.Errorf("could not describe field %s: %w", f.name, err)
+			}
+			if f.bits {
+				// The booleans of bits fields are packed into bitlists and bitvectors.
+				goType := typ.Field(f.index).Type
+				fieldDesc.Name = goType.String()
+				if goType.Kind() == reflect.Array {
+					fieldDesc.Kind = KindBitvector
+					fieldDesc.Length = uint64(goType.Len())
+					fieldDesc.Elem = nil
+				}
+			}
+			gindex, err := childIndex(1, uint64(len(fields)), uint64(i))
+			if err != nil {
+				return nil, err
+			}
+			fd := &FieldDescriptor{
+				Name:             f.name,
+				Index:            f.index,
+				Offset:           offset,
+				Size:             fieldDesc.Size,
+				GeneralizedIndex: gindex,
+				Opaque:           f.opaque != nil,
+				Progressive:      f.progressive,
+				Type:             fieldDesc,
+			}
+			if fieldDesc.Variable {
+				fd.Size = BytesPerLengthOffset
+				desc.MinSize += fieldDesc.MinSize
+				desc.MaxSize += fieldDesc.MaxSize
+				bounded = bounded && fieldDesc.MaxSize != 0
+			}
+			offset += fd.Size
+			desc.Fields = append(desc.Fields, fd)
+		}
+		desc.MinSize += offset
+		desc.MaxSize += offset
+		if !bounded {
+			desc.MaxSize = 0
+		}
+	default:
+		return nil, errorf(ErrUnsupportedType, "type %v is not supported", typ)
+	}
+
+	if !desc.Variable {
+		desc.Size = staticFixedSize(typ)
+		desc.MinSize = desc.Size
+		desc.MaxSize = desc.Size
+	} else if desc.Kind == KindVector {
+		desc.MinSize = desc.Length * (BytesPerLengthOffset + desc.Elem.MinSize)
+	}
+	if desc.Chunks > 1 {
+		desc.Depth = bitLength(desc.Chunks - 1)
+	}
+	if desc.Limit != 0 {
+		desc.Depth++
+	}
+	return desc, nil
+}
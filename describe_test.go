@@ -0,0 +1,94 @@
+package ssz
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDescribe_Container(t *testing.T) {
+	item := newTreeContainer()
+	item.Nil = &fork{}
+	typ := reflect.TypeOf(item)
+	desc, err := Describe(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Kind != KindContainer || !desc.Variable {
+		t.Errorf("Expected variable-size container, received %s variable=%v", desc.Kind, desc.Variable)
+	}
+	// Unlike other lists, bitlists hold at least the byte of their length bit.
+	if desc.MinSize != minimumSize(typ)+2 {
+		t.Errorf("Expected minimum size %d, received %d", minimumSize(typ)+2, desc.MinSize)
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range desc.Fields {
+		gindex, err := GeneralizedIndex(typ, f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.GeneralizedIndex != gindex {
+			t.Errorf("Expected field %s at generalized index %d, received %d", f.Name, gindex, f.GeneralizedIndex)
+		}
+		if f.Type.Variable {
+			continue
+		}
+		fieldEncoded, err := Marshal(reflect.ValueOf(item).Field(f.Index).Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded[f.Offset:f.Offset+f.Size], fieldEncoded) {
+			t.Errorf("Expected field %s at offset %d to be encoded as %#x", f.Name, f.Offset, fieldEncoded)
+		}
+	}
+
+	fields := make(map[string]*FieldDescriptor)
+	for _, f := range desc.Fields {
+		fields[f.Name] = f
+	}
+	balances := fields["Balances"].Type
+	if balances.Kind != KindList || balances.Limit != 1024 || balances.Chunks != 256 || balances.Depth != 9 {
+		t.Errorf("Unexpected balances layout: %+v", balances)
+	}
+	if balances.MaxSize != 1024*8 || balances.Elem.Kind != KindUint || balances.Elem.Size != 8 {
+		t.Errorf("Unexpected balances layout: %+v", balances)
+	}
+	bits := fields["Bits"].Type
+	if bits.Kind != KindBitlist || bits.Chunks != 8 || bits.Depth != 4 {
+		t.Errorf("Unexpected bits layout: %+v", bits)
+	}
+	custody := fields["Custody"].Type
+	if custody.Kind != KindVector || custody.Length != 4 || custody.Size != 32 || custody.Chunks != 1 {
+		t.Errorf("Unexpected custody layout: %+v", custody)
+	}
+	if !fields["Opaque"].Opaque {
+		t.Error("Expected opaque field to be reported")
+	}
+	if fields["EmptyForks"].Type.MaxSize != 0 || desc.MaxSize != 0 {
+		t.Error("Expected list without ssz-max to be unbounded")
+	}
+	if _, err := json.Marshal(desc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDescribe_FixedSize(t *testing.T) {
+	desc, err := Describe(reflect.TypeOf(&fork{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := staticFixedSize(reflect.TypeOf(fork{}))
+	if desc.Variable || desc.Size != size || desc.MinSize != size || desc.MaxSize != size {
+		t.Errorf("Expected fixed size %d, received %+v", size, desc)
+	}
+	if desc.Chunks != uint64(len(desc.Fields)) {
+		t.Errorf("Expected %d chunks, received %d", len(desc.Fields), desc.Chunks)
+	}
+	if _, err := Describe(reflect.TypeOf(float64(0))); err == nil {
+		t.Error("Expected error for unsupported type")
+	}
+}
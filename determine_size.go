@@ -1,9 +1,87 @@
 package ssz
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 )
 
+// typeSize holds the size information which only depends on a type, so that
+// sizing values does not walk the fixed-size parts of their types every time.
+type typeSize struct {
+	variable bool
+	// fixed is the serialized size of fixed-size types.
+	fixed uint64
+}
+
+var (
+	typeSizeCacheLock sync.RWMutex
+	typeSizeCache     = make(map[reflect.Type]typeSize)
+)
+
+// IsVariableSize reports whether the encodings of the values of typ vary in size, as
+// those of lists do, in which case containers hold them behind an offset rather than
+// in their fixed part.
+func IsVariableSize(typ reflect.Type) (bool, error) {
+	if err := checkSizedType(typ); err != nil {
+		return false, err
+	}
+	return isVariableSizeType(typ), nil
+}
+
+// FixedSize returns the size of the encoding of every value of typ, which must not be
+// variable-size. It allows precomputing frame sizes, and rejecting messages of any
+// other length before decoding them:
+//
+//  size, err := ssz.FixedSize(reflect.TypeOf(Checkpoint{}))
+//  if err != nil {
+//      return err
+//  }
+//  if uint64(len(data)) != size {
+//      return fmt.Errorf("expected %d bytes, received %d", size, len(data))
+//  }
+func FixedSize(typ reflect.Type) (uint64, error) {
+	if err := checkSizedType(typ); err != nil {
+		return 0, err
+	}
+	if isVariableSizeType(typ) {
+		return 0, fmt.Errorf("type %v is variable-size", typ)
+	}
+	return cachedTypeSize(typ).fixed, nil
+}
+
+// checkSizedType makes sure typ is supported before its size is looked up.
+func checkSizedType(typ reflect.Type) error {
+	if typ == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	if _, err := cachedSSZUtils(typ); err != nil {
+		return fmt.Errorf("could not get ssz utils for type: %v: %w", typ, err)
+	}
+	return nil
+}
+
+// cachedTypeSize returns the size information of typ, computing it on first use.
+func cachedTypeSize(typ reflect.Type) typeSize {
+	typeSizeCacheLock.RLock()
+	size, ok := typeSizeCache[typ]
+	typeSizeCacheLock.RUnlock()
+	if ok {
+		return size
+	}
+	// The size is computed without holding the lock, as computing it looks
+	// up the sizes of nested types.
+	size.variable = computeIsVariableSizeType(typ)
+	if !size.variable {
+		size.fixed = staticFixedSize(typ)
+	}
+	typeSizeCacheLock.Lock()
+	typeSizeCache[typ] = size
+	typeSizeCacheLock.Unlock()
+	return size
+}
+
 func isBasicType(kind reflect.Kind) bool {
 	return kind == reflect.Bool ||
 		kind == reflect.Uint8 ||
@@ -20,20 +98,42 @@ func isBasicTypeSlice(typ reflect.Type, kind reflect.Kind) bool {
 	return kind == reflect.Slice && isBasicType(typ.Elem().Kind())
 }
 
+// isPackedArray reports whether typ is a vector of uint16, uint32 or uint64, whose
+// elements are packed next to each other without going through their own marshalers.
+func isPackedArray(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Array {
+		return false
+	}
+	kind := typ.Elem().Kind()
+	return kind == reflect.Uint16 || kind == reflect.Uint32 || kind == reflect.Uint64
+}
+
 func isVariableSizeType(typ reflect.Type) bool {
+	return cachedTypeSize(typ).variable
+}
+
+func computeIsVariableSizeType(typ reflect.Type) bool {
+	if codec := lookupCodec(typ); codec != nil {
+		return codec.size == 0
+	}
 	kind := typ.Kind()
 	switch {
 	case isBasicType(kind):
 		return false
 	case isBasicTypeArray(typ, kind):
 		return false
-	case kind == reflect.Slice:
+	case isBitvector(typ):
+		return false
+	case kind == reflect.Slice || kind == reflect.String:
 		return true
 	case kind == reflect.Array:
 		return isVariableSizeType(typ.Elem())
 	case kind == reflect.Struct:
 		for i := 0; i < typ.NumField(); i++ {
 			f := typ.Field(i)
+			if !isSSZField(f) {
+				continue
+			}
 			fType, err := determineFieldType(f)
 			if err != nil {
 				return false
@@ -50,28 +150,54 @@ func isVariableSizeType(typ reflect.Type) bool {
 }
 
 func determineFixedSize(val reflect.Value, typ reflect.Type) uint64 {
+	if codec := lookupCodec(typ); codec != nil {
+		return codec.encodedSize(val)
+	}
 	kind := typ.Kind()
 	switch {
-	case kind == reflect.Bool:
-		return 1
-	case kind == reflect.Uint8:
-		return 1
-	case kind == reflect.Uint16:
-		return 2
-	case kind == reflect.Uint32:
-		return 4
-	case kind == reflect.Uint64:
-		return 8
-	case kind == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
-		return uint64(typ.Len())
+	case kind == reflect.Ptr:
+		// Nil pointers fail to marshal if they cannot be encoded as zero values.
+		elem, err := pointee(val)
+		if err != nil {
+			return 0
+		}
+		return determineFixedSize(elem, typ.Elem())
+	case isBitvector(typ):
+		return cachedTypeSize(typ).fixed
 	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
 		return uint64(val.Len())
-	case kind == reflect.Array || kind == reflect.Slice:
+	case kind == reflect.Slice:
 		var num uint64
 		for i := 0; i < val.Len(); i++ {
 			num += determineFixedSize(val.Index(i), typ.Elem())
 		}
 		return num
+	default:
+		// The size of fixed-size types only depends on the type.
+		return cachedTypeSize(typ).fixed
+	}
+}
+
+// staticFixedSize returns the serialized size of a fixed-size type. Unlike
+// determineFixedSize, it only inspects the type and does not need a value.
+func staticFixedSize(typ reflect.Type) uint64 {
+	if codec := lookupCodec(typ); codec != nil {
+		return codec.size
+	}
+	kind := typ.Kind()
+	switch {
+	case kind == reflect.Bool || kind == reflect.Uint8:
+		return 1
+	case kind == reflect.Uint16:
+		return 2
+	case kind == reflect.Uint32:
+		return 4
+	case kind == reflect.Uint64:
+		return 8
+	case kind == reflect.Array:
+		return uint64(typ.Len()) * staticFixedSize(typ.Elem())
+	case isBitvector(typ):
+		return bitvectorSize(typ)
 	case kind == reflect.Struct:
 		totalSize := uint64(0)
 		fields, err := structFields(typ)
@@ -79,29 +205,71 @@ func determineFixedSize(val reflect.Value, typ reflect.Type) uint64 {
 			return 0
 		}
 		for _, f := range fields {
-			totalSize += determineFixedSize(val.Field(f.index), f.typ)
+			totalSize += staticFixedSize(f.typ)
 		}
 		return totalSize
 	case kind == reflect.Ptr:
-		if val.IsNil() {
+		return staticFixedSize(typ.Elem())
+	default:
+		return 0
+	}
+}
+
+// minimumSize returns the smallest number of bytes a value of typ can be
+// serialized to. Lists can always be empty, while containers and vectors of
+// variable-size elements at least hold their fixed part and offsets.
+func minimumSize(typ reflect.Type) uint64 {
+	kind := typ.Kind()
+	switch {
+	case !isVariableSizeType(typ):
+		return staticFixedSize(typ)
+	case lookupCodec(typ) != nil:
+		return 0
+	case kind == reflect.Array:
+		return uint64(typ.Len()) * (BytesPerLengthOffset + minimumSize(typ.Elem()))
+	case kind == reflect.Struct:
+		totalSize := uint64(0)
+		fields, err := structFields(typ)
+		if err != nil {
 			return 0
 		}
-		return determineFixedSize(val.Elem(), typ.Elem())
+		for _, f := range fields {
+			if isVariableSizeType(f.typ) {
+				totalSize += BytesPerLengthOffset + minimumSize(f.typ)
+			} else {
+				totalSize += staticFixedSize(f.typ)
+			}
+		}
+		return totalSize
+	case kind == reflect.Ptr:
+		return minimumSize(typ.Elem())
 	default:
 		return 0
 	}
 }
 
 func determineVariableSize(val reflect.Value, typ reflect.Type) uint64 {
+	if codec := lookupCodec(typ); codec != nil {
+		return codec.encodedSize(val)
+	}
 	kind := typ.Kind()
 	switch {
+	case isBitlist(typ):
+		// Bitlists are encoded with their delimiter bit, even when empty.
+		return val.Interface().(Bitfield).Len()/8 + 1
 	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
 		return uint64(val.Len())
+	case kind == reflect.String:
+		return uint64(val.Len())
 	case kind == reflect.Slice || kind == reflect.Array:
+		elemSize := cachedTypeSize(typ.Elem())
+		if !elemSize.variable && typ.Elem().Kind() != reflect.Ptr {
+			return uint64(val.Len()) * elemSize.fixed
+		}
 		totalSize := uint64(0)
 		for i := 0; i < val.Len(); i++ {
 			varSize := determineSize(val.Index(i))
-			if isVariableSizeType(typ.Elem()) {
+			if elemSize.variable {
 				totalSize += varSize + BytesPerLengthOffset
 			} else {
 				totalSize += varSize
@@ -109,37 +277,52 @@ func determineVariableSize(val reflect.Value, typ reflect.Type) uint64 {
 		}
 		return totalSize
 	case kind == reflect.Struct:
-		totalSize := uint64(0)
-		fields, err := structFields(typ)
-		if err != nil {
-			return 0
-		}
-		for _, f := range fields {
-			if isVariableSizeType(f.typ) {
-				varSize := determineVariableSize(val.Field(f.index), f.typ)
-				totalSize += varSize + BytesPerLengthOffset
-			} else {
-				varSize := determineFixedSize(val.Field(f.index), f.typ)
-				totalSize += varSize
-			}
-		}
-		return totalSize
+		return determineStructSize(val, typ)
 	case kind == reflect.Ptr:
-		if val.IsNil() {
+		elem, err := pointee(val)
+		if err != nil {
 			return 0
 		}
-		return determineVariableSize(val.Elem(), val.Elem().Type())
+		return determineVariableSize(elem, typ.Elem())
 	default:
 		return 0
 	}
 }
 
+// determineStructSize returns the size of val, a value of the variable-size struct typ.
+func determineStructSize(val reflect.Value, typ reflect.Type) uint64 {
+	fields, err := structFields(typ)
+	if err != nil {
+		return 0
+	}
+	var name string
+	defer annotatePanic(&name)
+	totalSize := uint64(0)
+	for _, f := range fields {
+		name = f.name
+		if f.opaque != nil {
+			totalSize += opaqueSize(val.Field(f.index), f.opaque) + BytesPerLengthOffset
+		} else if isVariableSizeType(f.typ) {
+			varSize := determineVariableSize(fieldValue(val, f), f.typ)
+			totalSize += varSize + BytesPerLengthOffset
+		} else {
+			varSize := determineFixedSize(fieldValue(val, f), f.typ)
+			totalSize += varSize
+		}
+	}
+	return totalSize
+}
+
 func determineSize(val reflect.Value) uint64 {
+	if codec := lookupCodec(val.Type()); codec != nil {
+		return codec.encodedSize(val)
+	}
 	if val.Kind() == reflect.Ptr {
-		if val.IsNil() {
+		elem, err := pointee(val)
+		if err != nil {
 			return 0
 		}
-		return determineSize(val.Elem())
+		return determineSize(elem)
 	}
 	if isVariableSizeType(val.Type()) {
 		return determineVariableSize(val, val.Type())
@@ -0,0 +1,66 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFixedSize(t *testing.T) {
+	tests := []interface{}{
+		fork{},
+		&fork{},
+		protoCheckpoint{},
+		bigIntPayload{},
+		[3][32]byte{},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt)
+		encoded, err := Marshal(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		variable, err := IsVariableSize(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if variable {
+			t.Errorf("Expected %v to be fixed-size", typ)
+		}
+		size, err := FixedSize(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != uint64(len(encoded)) {
+			t.Errorf("Expected %v to have a fixed size of %d, received %d", typ, len(encoded), size)
+		}
+	}
+}
+
+func TestFixedSize_VariableSize(t *testing.T) {
+	for _, tt := range []interface{}{validateVarItem{}, stringContainer{}, []uint64{}, [2][]byte{}} {
+		typ := reflect.TypeOf(tt)
+		variable, err := IsVariableSize(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !variable {
+			t.Errorf("Expected %v to be variable-size", typ)
+		}
+		if _, err := FixedSize(typ); err == nil {
+			t.Errorf("Expected %v to have no fixed size", typ)
+		}
+	}
+}
+
+func TestFixedSize_UnsupportedType(t *testing.T) {
+	if _, err := IsVariableSize(reflect.TypeOf(0)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected an unsupported type error, received %v", err)
+	}
+	if _, err := FixedSize(reflect.TypeOf(map[string]uint64{})); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected an unsupported type error, received %v", err)
+	}
+	if _, err := FixedSize(nil); err == nil {
+		t.Error("Expected an error for untyped nil")
+	}
+}
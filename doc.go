@@ -13,5 +13,11 @@ Currently directly supported types:
   slice
   struct
   ptr
+
+Structs generated by protobuf compilers, such as the ones of Prysm, are supported as
+they are: their XXX_ fields and unexported fields, which hold protobuf internal state,
+are left out of the containers they describe. Wrapper types such as types.UInt64Value
+are thus containers of their single Value field, which share the hash tree root of the
+wrapped value, and its encoding when it is fixed-size.
 */
 package ssz
@@ -0,0 +1,62 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors returned by the package wrap one of these, for callers to tell the kinds
+// of failures apart with errors.Is rather than with their messages:
+//
+//  if err := ssz.Unmarshal(data, block); errors.Is(err, ssz.ErrSize) || errors.Is(err, ssz.ErrOffset) {
+//      return fmt.Errorf("peer sent a malformed block: %v", err)
+//  }
+var (
+	// ErrUnsupportedType is wrapped by the errors of types SSZ has no encoding for, such
+	// as int or map, of types holding themselves, and of fields of foreign types without
+	// a codec.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrInvalidTag is wrapped by the errors of struct tags which cannot be parsed or do
+	// not apply to the types of their fields.
+	ErrInvalidTag = errors.New("invalid tag")
+	// ErrSize is wrapped by the errors of encodings, or parts of encodings, whose size
+	// does not match their type.
+	ErrSize = errors.New("invalid size")
+	// ErrOffset is wrapped by the errors of encodings holding offsets which are out of
+	// bounds, out of order or do not match the fixed part of their container or list.
+	ErrOffset = errors.New("invalid offset")
+	// ErrMaxLength is wrapped by the errors of lists, bitlists and opaque fields longer
+	// than their ssz-max.
+	ErrMaxLength = errors.New("exceeds max length")
+	// ErrInvalidValue is wrapped by the errors of encodings of values their type cannot
+	// hold: booleans other than 0 or 1, bitlists without a delimiter bit and bitvectors
+	// with bits set past their length.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrDecodeLimit is wrapped by the errors of inputs exceeding the limits set with
+	// SetDecodeLimits.
+	ErrDecodeLimit = errors.New("exceeds decode limit")
+	// ErrNilPointer is wrapped by the errors of values holding nil pointers, which are
+	// only returned when SetNilPointerMode selects NilAsError.
+	ErrNilPointer = errors.New("nil pointer")
+)
+
+// kindError is an error of the kind of one of the errors above, which it unwraps to
+// without adding it to its message.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// errorf formats an error of the given kind, as fmt.Errorf does. Errors to wrap are
+// given to fmt.Errorf instead, as their kind is already set.
+func errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,111 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type invalidTagContainer struct {
+	Roots [][32]byte `ssz-max:"unknown"`
+}
+
+func TestErrorKinds(t *testing.T) {
+	defer SetDecodeLimits(DecodeLimits{})
+	var (
+		b    bool
+		slot uint64
+		leaf limitsLeaf
+	)
+	tests := []struct {
+		name string
+		run  func() error
+		kind error
+	}{
+		{
+			name: "unsupported type",
+			run: func() error {
+				_, err := Marshal(map[string]uint64{})
+				return err
+			},
+			kind: ErrUnsupportedType,
+		},
+		{
+			name: "cyclic type",
+			run: func() error {
+				_, err := HashTreeRoot(linkedNode{})
+				return err
+			},
+			kind: ErrUnsupportedType,
+		},
+		{
+			name: "invalid tag",
+			run: func() error {
+				_, err := Marshal(invalidTagContainer{})
+				return err
+			},
+			kind: ErrInvalidTag,
+		},
+		{
+			name: "size",
+			run:  func() error { return Unmarshal([]byte{1, 2, 3}, &slot) },
+			kind: ErrSize,
+		},
+		{
+			name: "offset",
+			run:  func() error { return Unmarshal([]byte{8, 0, 0, 0, 1, 2, 3, 4}, &leaf) },
+			kind: ErrOffset,
+		},
+		{
+			name: "max length",
+			run: func() error {
+				_, err := Marshal(limitsLeaf{Data: make([]byte, 17)})
+				return err
+			},
+			kind: ErrMaxLength,
+		},
+		{
+			name: "max length of an encoding",
+			run: func() error {
+				return Validate(append([]byte{4, 0, 0, 0}, make([]byte, 17)...), reflect.TypeOf(limitsLeaf{}))
+			},
+			kind: ErrMaxLength,
+		},
+		{
+			name: "invalid value",
+			run:  func() error { return Unmarshal([]byte{2}, &b) },
+			kind: ErrInvalidValue,
+		},
+		{
+			name: "decode limit",
+			run: func() error {
+				SetDecodeLimits(DecodeLimits{MaxInputSize: 4})
+				defer SetDecodeLimits(DecodeLimits{})
+				return Unmarshal(make([]byte, 8), &slot)
+			},
+			kind: ErrDecodeLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Expected error wrapping %v, received %v", tt.kind, err)
+			}
+		})
+	}
+}
+
+func TestErrorKinds_ValidateType(t *testing.T) {
+	err := ValidateType(reflect.TypeOf(invalidType{}))
+	if !errors.Is(err, ErrUnsupportedType) || !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expected problems of both kinds, received %v", err)
+	}
+	var problems TypeErrors
+	if !errors.As(err, &problems) || len(problems) == 0 {
+		t.Errorf("Expected the problems as TypeErrors, received %v", err)
+	}
+	if errors.Is(err, ErrSize) {
+		t.Errorf("Expected no size problem, received %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"reflect"
+)
+
+// The most common list shapes of eth2, []uint64 for balances and [][32]byte for
+// roots, are encoded and hashed from their Go slices directly, instead of going
+// through the reflect.Value of every element.
+var (
+	byteType        = reflect.TypeOf(byte(0))
+	uint64Type      = reflect.TypeOf(uint64(0))
+	uint64SliceType = reflect.TypeOf([]uint64{})
+	rootType        = reflect.TypeOf([32]byte{})
+	rootSliceType   = reflect.TypeOf([][32]byte{})
+)
+
+// uint64sOf returns the elements of val, a slice of uint64 of any named type.
+func uint64sOf(val reflect.Value) ([]uint64, bool) {
+	if val.Kind() != reflect.Slice || val.Type().Elem() != uint64Type || !val.CanInterface() {
+		return nil, false
+	}
+	return val.Convert(uint64SliceType).Interface().([]uint64), true
+}
+
+// rootsOf returns the elements of val, a slice of [32]byte of any named type.
+func rootsOf(val reflect.Value) ([][32]byte, bool) {
+	if val.Kind() != reflect.Slice || val.Type().Elem() != rootType || !val.CanInterface() {
+		return nil, false
+	}
+	return val.Convert(rootSliceType).Interface().([][32]byte), true
+}
+
+// bytesOf returns the memory backing val, a byte slice or an addressable byte array,
+// without copying it. The returned bytes alias val and must only be read.
+func bytesOf(val reflect.Value) ([]byte, bool) {
+	if val.Type().Elem() != byteType || !val.CanInterface() {
+		return nil, false
+	}
+	switch {
+	case val.Kind() == reflect.Slice:
+		return val.Bytes(), true
+	case val.Kind() == reflect.Array && val.CanAddr():
+		return val.Slice(0, val.Len()).Bytes(), true
+	default:
+		return nil, false
+	}
+}
+
+// putUint64s writes the little-endian serialization of values next to each other into buf.
+func putUint64s(buf []byte, values []uint64) {
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+}
+
+// putRoots writes roots next to each other into buf.
+func putRoots(buf []byte, roots [][32]byte) {
+	for i := range roots {
+		copy(buf[i*32:], roots[i][:])
+	}
+}
+
+// marshalRaw copies the memory of val into buf when it is laid out as SSZ encodes
+// it, which requires the ssz_unsafe build tag.
+func marshalRaw(val reflect.Value, buf []byte, startOffset uint64) (uint64, bool) {
+	raw, ok := rawBytes(val)
+	if !ok {
+		return 0, false
+	}
+	return startOffset + uint64(copy(buf[startOffset:], raw)), true
+}
+
+func marshalUint64Slice(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+	if end, ok := marshalRaw(val, buf, startOffset); ok {
+		return end, nil
+	}
+	values, ok := uint64sOf(val)
+	if !ok {
+		return marshalPackedSlice(val, buf, startOffset, 8)
+	}
+	end := startOffset + uint64(len(values))*8
+	putUint64s(buf[startOffset:end], values)
+	return end, nil
+}
+
+func marshalRootSlice(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+	if end, ok := marshalRaw(val, buf, startOffset); ok {
+		return end, nil
+	}
+	roots, ok := rootsOf(val)
+	if !ok {
+		for i := 0; i < val.Len(); i++ {
+			startOffset, _ = marshalByteArray(val.Index(i), buf, startOffset)
+		}
+		return startOffset, nil
+	}
+	end := startOffset + uint64(len(roots))*32
+	putRoots(buf[startOffset:end], roots)
+	return end, nil
+}
+
+// marshalPackedSlice writes the elements of val, a slice of uints of elemSize bytes,
+// next to each other into buf.
+func marshalPackedSlice(val reflect.Value, buf []byte, startOffset uint64, elemSize uint64) (uint64, error) {
+	end := startOffset + uint64(val.Len())*elemSize
+	putPacked(buf[startOffset:end], val, elemSize)
+	return end, nil
+}
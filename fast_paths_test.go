@@ -0,0 +1,202 @@
+package ssz
+
+import (
+	"bytes"
+	"testing"
+)
+
+type gwei uint64
+
+type namedBalances []uint64
+
+type namedRoot [32]byte
+
+type fastPathContainer struct {
+	Balances []uint64      `ssz-max:"1024"`
+	Named    namedBalances `ssz-max:"1024"`
+	Roots    [][32]byte    `ssz-max:"64"`
+}
+
+type slowPathContainer struct {
+	Balances []gwei      `ssz-max:"1024"`
+	Named    []gwei      `ssz-max:"1024"`
+	Roots    []namedRoot `ssz-max:"64"`
+}
+
+// The fast paths must encode and hash exactly like the generic paths, which are
+// taken by slices of named element types.
+func TestFastPaths_MatchGenericPaths(t *testing.T) {
+	fast := fastPathContainer{}
+	slow := slowPathContainer{}
+	for i := 0; i < 37; i++ {
+		fast.Balances = append(fast.Balances, uint64(i)*1e9)
+		slow.Balances = append(slow.Balances, gwei(i)*1e9)
+		fast.Named = append(fast.Named, uint64(i))
+		slow.Named = append(slow.Named, gwei(i))
+	}
+	for i := 0; i < 5; i++ {
+		fast.Roots = append(fast.Roots, [32]byte{byte(i), 1, 2})
+		slow.Roots = append(slow.Roots, namedRoot{byte(i), 1, 2})
+	}
+	fastEncoded, err := Marshal(fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slowEncoded, err := Marshal(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fastEncoded, slowEncoded) {
+		t.Errorf("Expected encoding %#x, received %#x", slowEncoded, fastEncoded)
+	}
+	fastRoot, err := HashTreeRoot(fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slowRoot, err := HashTreeRoot(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fastRoot != slowRoot {
+		t.Errorf("Expected root %#x, received %#x", slowRoot, fastRoot)
+	}
+	var decoded fastPathContainer
+	if err := Unmarshal(fastEncoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, fast) {
+		t.Errorf("Expected %v, received %v", fast, decoded)
+	}
+}
+
+type namedByte byte
+
+type byteArrayContainer struct {
+	Pubkey    [48]byte
+	Signature [96]byte
+	Blob      []byte `ssz-size:"131072"`
+}
+
+type namedByteArrayContainer struct {
+	Pubkey    [48]namedByte
+	Signature [96]namedByte
+	Blob      [131072]namedByte
+}
+
+// Byte arrays are encoded and hashed from their bytes directly, whether or not they
+// are addressable, exactly like arrays of named bytes are element by element.
+func TestFastPaths_ByteArrays(t *testing.T) {
+	fast := &byteArrayContainer{Blob: make([]byte, 131072)}
+	slow := &namedByteArrayContainer{}
+	for i := range fast.Pubkey {
+		fast.Pubkey[i] = byte(i)
+		slow.Pubkey[i] = namedByte(i)
+	}
+	for i := range fast.Signature {
+		fast.Signature[i] = byte(3 * i)
+		slow.Signature[i] = namedByte(3 * i)
+	}
+	for i := range fast.Blob {
+		fast.Blob[i] = byte(i % 251)
+		slow.Blob[i] = namedByte(i % 251)
+	}
+	slowEncoded, err := Marshal(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slowRoot, err := HashTreeRoot(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, val := range []interface{}{fast, *fast} {
+		encoded, err := Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, slowEncoded) {
+			t.Errorf("Expected encoding of %T to match that of named bytes", val)
+		}
+		root, err := HashTreeRoot(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != slowRoot {
+			t.Errorf("Expected root %#x of %T, received %#x", slowRoot, val, root)
+		}
+	}
+
+	var pubkeyChunks [][32]byte
+	for i := 0; i < len(fast.Pubkey); i += 32 {
+		var chunk [32]byte
+		copy(chunk[:], fast.Pubkey[i:])
+		pubkeyChunks = append(pubkeyChunks, chunk)
+	}
+	want := naiveMerkleize(pubkeyChunks, 2)
+	for _, val := range []interface{}{fast.Pubkey, &fast.Pubkey} {
+		root, err := HashTreeRoot(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x of %T, received %#x", want, val, root)
+		}
+	}
+
+	// Sized byte slices shorter than their vector are hashed zero-padded.
+	short := *fast
+	short.Blob = fast.Blob[:100]
+	padded := *fast
+	padded.Blob = append(append([]byte{}, fast.Blob[:100]...), make([]byte, 131072-100)...)
+	shortRoot, err := HashTreeRoot(short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paddedRoot, err := HashTreeRoot(padded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shortRoot != paddedRoot {
+		t.Errorf("Expected root %#x, received %#x", paddedRoot, shortRoot)
+	}
+}
+
+func BenchmarkMarshal_Uint64Slice(b *testing.B) {
+	balances := make([]uint64, 100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(balances); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHashTreeRoot_RootSlice(b *testing.B) {
+	roots := make([][32]byte, 8192)
+	for i := range roots {
+		roots[i][0] = byte(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := HashTreeRootWithCapacity(roots, 8192); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHashTreeRoot_Blob(b *testing.B) {
+	blob := new([131072]byte)
+	for i := range blob {
+		blob[i] = byte(i)
+	}
+	useCache = false
+	defer func() { useCache = true }()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := HashTreeRoot(blob); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
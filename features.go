@@ -0,0 +1,44 @@
+package ssz
+
+// Version is the semantic version of the library, bumped with every release.
+const Version = "0.1.0"
+
+// FeatureSet lists the capabilities of the library, so that dependent code can
+// detect them at runtime.
+type FeatureSet struct {
+	// Version is the semantic version of the library.
+	Version string
+	// Unions reports support for SSZ union types.
+	Unions bool
+	// StableContainers reports support for stable containers.
+	StableContainers bool
+	// Proofs reports support for generating and verifying Merkle proofs.
+	Proofs bool
+	// ParallelHashing reports support for hashing elements and fields concurrently,
+	// see SetTypeHints and HashTreeRootBatch.
+	ParallelHashing bool
+	// UnsafeFastPaths reports whether fast paths relying on the unsafe package
+	// are enabled.
+	UnsafeFastPaths bool
+	// BackingTree reports support for backing Merkle trees, see NewTree.
+	BackingTree bool
+	// OpaqueFields reports support for fields encoded by external codecs,
+	// see RegisterOpaqueCodec.
+	OpaqueFields bool
+}
+
+// Features returns the capabilities of the library.
+//
+//  if !ssz.Features().Proofs {
+//      return errors.New("ssz library does not support proofs")
+//  }
+func Features() FeatureSet {
+	return FeatureSet{
+		Version:         Version,
+		Proofs:          true,
+		ParallelHashing: true,
+		BackingTree:     true,
+		OpaqueFields:    true,
+		UnsafeFastPaths: unsafeFastPaths,
+	}
+}
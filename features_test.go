@@ -0,0 +1,22 @@
+package ssz
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	features := Features()
+	if !regexp.MustCompile(`^\d+\.\d+\.\d+$`).MatchString(features.Version) {
+		t.Errorf("Expected a semantic version, received %q", features.Version)
+	}
+	if features.Version != Version {
+		t.Errorf("Expected version %q, received %q", Version, features.Version)
+	}
+	if !features.Proofs || !features.ParallelHashing || !features.BackingTree || !features.OpaqueFields {
+		t.Errorf("Expected supported features to be reported, received %+v", features)
+	}
+	if features.Unions || features.StableContainers {
+		t.Errorf("Expected unsupported features not to be reported, received %+v", features)
+	}
+}
@@ -0,0 +1,155 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// FieldRoot computes the root of the node designated by path within val, as
+// resolved by GeneralizedIndex, without hashing the rest of val. Paths to
+// elements of lists and vectors of basic values designate the chunk holding
+// them, and LengthPathElement designates the chunk of the length of a list.
+//
+//  root, err := FieldRoot(state, "Validators")
+//  if err != nil {
+//      return fmt.Errorf("failed to compute validators root: %v", err)
+//  }
+func FieldRoot(val interface{}, path ...interface{}) ([32]byte, error) {
+	if val == nil {
+		return [32]byte{}, errors.New("untyped nil is not supported")
+	}
+	rval := reflect.ValueOf(val)
+	target := &pathTarget{gindex: 1, typ: rval.Type()}
+	var utils *sszUtils
+	var chunk *[32]byte
+	for i, p := range path {
+		if chunk != nil {
+			return [32]byte{}, fmt.Errorf("could not resolve path element %d (%v): chunks have no children", i, p)
+		}
+		for rval.Kind() == reflect.Ptr {
+			if rval.IsNil() {
+				return [32]byte{}, fmt.Errorf("could not resolve path element %d (%v): nil pointer", i, p)
+			}
+			rval = rval.Elem()
+		}
+		for target.typ.Kind() == reflect.Ptr {
+			target.typ = target.typ.Elem()
+		}
+		parent := *target
+		err := target.descend(p)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("could not resolve path element %d (%v): %w", i, p, err)
+		}
+		if chunk, err = fieldStep(&rval, &utils, &parent, p); err != nil {
+			return [32]byte{}, fmt.Errorf("could not resolve path element %d (%v): %w", i, p, err)
+		}
+	}
+	if chunk != nil {
+		return *chunk, nil
+	}
+	if len(path) == 0 {
+		return HashTreeRoot(val)
+	}
+
+	h := acquireHasher()
+	defer releaseHasher(h)
+	if target.isBitlist {
+		return bitlistHasher(h, rval, target.maxCapacity)
+	}
+	if target.innerLimits != nil {
+		sszUtilsCacheMutex.Lock()
+		nested, err := makeNestedHasher(rval.Type(), target.innerLimits)
+		sszUtilsCacheMutex.Unlock()
+		if err != nil {
+			return [32]byte{}, err
+		}
+		return nested(h, rval, target.maxCapacity)
+	}
+	if utils == nil {
+		var err error
+		if utils, err = cachedSSZUtils(rval.Type()); err != nil {
+			return [32]byte{}, err
+		}
+	}
+	if useCache && !target.progressive {
+		// Progressive fields skip the cache, as their keys would match those of
+		// the same values merkleized as usual.
+		return hashWithCache(h, rval, utils.hasher, utils.marshaler, target.maxCapacity)
+	}
+	return utils.hasher(h, rval, target.maxCapacity)
+}
+
+// fieldStep moves val to the child designated by p within the parent target. The
+// chunk of the child is returned instead when it is not a value of its own: a chunk
+// of packed basic values, of the bits of a bitlist or bitvector, or of the length of
+// a list.
+func fieldStep(val *reflect.Value, utils **sszUtils, parent *pathTarget, p interface{}) (*[32]byte, error) {
+	var chunk [32]byte
+	if name, ok := p.(string); ok && name == LengthPathElement {
+		if parent.isBitlist {
+			chunk = lengthChunk(val.Interface().(Bitfield).Len())
+		} else {
+			chunk = lengthChunk(uint64(val.Len()))
+		}
+		return &chunk, nil
+	}
+	typ := parent.typ
+	if typ.Kind() == reflect.Struct {
+		fields, err := structFields(typ)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if f.name == p.(string) {
+				*val = fieldValue(*val, f)
+				*utils = f.sszUtils
+				if f.bits {
+					// The bitfields of bits fields are hashed by the utils of their type.
+					*utils = nil
+				}
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("struct %v has no field %v", typ, p)
+	}
+	*utils = nil
+	index, err := pathIndex(p)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parent.isBitlist || isBitvector(typ) || parent.bitvector != 0:
+		var data []byte
+		if val.Kind() == reflect.Array {
+			// Vectors of booleans tagged bits are packed into vectors of bytes.
+			data = val.Slice(0, val.Len()).Bytes()
+		} else if !val.IsNil() {
+			data = val.Interface().(Bitfield).Bytes()
+		}
+		if start := index / 256 * 32; start < uint64(len(data)) {
+			copy(chunk[:], data[start:])
+		}
+		return &chunk, nil
+	case isBasicType(typ.Elem().Kind()):
+		elemUtils, err := cachedSSZUtils(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		elemSize := staticFixedSize(typ.Elem())
+		start := index * elemSize / 32 * (32 / elemSize)
+		offset := uint64(0)
+		for j := start; j < uint64(val.Len()) && j < start+32/elemSize; j++ {
+			if offset, err = elemUtils.marshaler(val.Index(int(j)), chunk[:], offset); err != nil {
+				return nil, err
+			}
+		}
+		return &chunk, nil
+	case index >= uint64(val.Len()):
+		// Elements past the length of a list are zero chunks padding its contents.
+		return &chunk, nil
+	default:
+		*val = val.Index(int(index))
+		return nil, nil
+	}
+}
@@ -0,0 +1,142 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// FieldRootCache memoizes the roots of the fields of a container, so that hashing it
+// again only recomputes the roots of the fields marked dirty since, and merkleizes
+// them along with the roots of the other fields. This suits values hashed over and
+// over while only a few of their fields change, such as beacon states between slots:
+//
+//  cache, err := NewFieldRootCache(reflect.TypeOf(BeaconState{}))
+//  if err != nil {
+//      return err
+//  }
+//  root, err := cache.HashTreeRoot(state)
+//  ...
+//  state.Slot++
+//  if err := cache.MarkDirty("Slot"); err != nil {
+//      return err
+//  }
+//  root, err = cache.HashTreeRoot(state)
+//
+// The cache trusts its caller: the roots of fields modified without being marked dirty
+// are stale, and so are those of all fields once the cache is used with another value.
+// Every field is dirty until the first hash. Caches are safe for concurrent use.
+type FieldRootCache struct {
+	typ    reflect.Type
+	fields []field
+
+	lock  sync.Mutex
+	roots [][32]byte
+	dirty []bool
+}
+
+// NewFieldRootCache returns the cache of the field roots of values of typ, a struct or
+// a pointer to one, whose fields are all dirty.
+func NewFieldRootCache(typ reflect.Type) (*FieldRootCache, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || lookupCodec(typ) != nil {
+		return nil, fmt.Errorf("type %v is not a container hashed field by field", typ)
+	}
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	c := &FieldRootCache{
+		typ:    typ,
+		fields: fields,
+		roots:  make([][32]byte, len(fields)),
+		dirty:  make([]bool, len(fields)),
+	}
+	c.MarkAllDirty()
+	return c, nil
+}
+
+// MarkDirty marks the fields with the given names as modified, so that their roots are
+// recomputed by the next hash.
+func (c *FieldRootCache) MarkDirty(names ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, name := range names {
+		found := false
+		for i, f := range c.fields {
+			if f.name == name {
+				c.dirty[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("struct %v has no field %s", c.typ, name)
+		}
+	}
+	return nil
+}
+
+// MarkAllDirty marks every field as modified, as when the cache is used with another
+// value.
+func (c *FieldRootCache) MarkAllDirty() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range c.dirty {
+		c.dirty[i] = true
+	}
+}
+
+// Copy returns a cache holding the same roots and dirty fields as c, for the copies of
+// the value c caches the roots of.
+func (c *FieldRootCache) Copy() *FieldRootCache {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return &FieldRootCache{
+		typ:    c.typ,
+		fields: c.fields,
+		roots:  append([][32]byte(nil), c.roots...),
+		dirty:  append([]bool(nil), c.dirty...),
+	}
+}
+
+// HashTreeRoot determines the root hash of val, a value of the type of the cache or a
+// pointer to one, recomputing the roots of its dirty fields only. The fields are clean
+// afterwards, unless hashing one of them fails.
+func (c *FieldRootCache) HashTreeRoot(val interface{}) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
+	rval := reflect.ValueOf(val)
+	for rval.Kind() == reflect.Ptr {
+		if rval.IsNil() {
+			return [32]byte{}, errorf(ErrNilPointer, "cannot hash nil %v", rval.Type())
+		}
+		rval = rval.Elem()
+	}
+	if !rval.IsValid() || rval.Type() != c.typ {
+		return [32]byte{}, fmt.Errorf("cannot hash %T with the field roots of %v", val, c.typ)
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, f := range c.fields {
+		if !c.dirty[i] {
+			continue
+		}
+		r, err := hashStructField(h, f, rval)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		c.roots[i] = r
+		c.dirty[i] = false
+	}
+	return h.merkleizeChunks(uint64(len(c.roots)), uint64(len(c.roots)), true /* has limit */, func(idx uint64) [32]byte {
+		return c.roots[idx]
+	})
+}
@@ -0,0 +1,74 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldRootCache(t *testing.T) {
+	item := newTreeContainer()
+	cache, err := NewFieldRootCache(reflect.TypeOf(&item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRoot := func(cache *FieldRootCache, item treeContainer) [32]byte {
+		t.Helper()
+		want, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := cache.HashTreeRoot(&item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x, received %#x", want, root)
+		}
+		return root
+	}
+	assertRoot(cache, item)
+
+	item.Slot++
+	item.Balances = append(item.Balances, 27)
+	if err := cache.MarkDirty("Slot", "Balances"); err != nil {
+		t.Fatal(err)
+	}
+	assertRoot(cache, item)
+
+	// The roots of fields modified without being marked dirty are stale.
+	copied := cache.Copy()
+	stale := item
+	stale.Flag = false
+	staleRoot, err := cache.HashTreeRoot(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staleRoot != assertRoot(copied, item) {
+		t.Errorf("Expected the root of Flag to be memoized, received root %#x", staleRoot)
+	}
+	if err := copied.MarkDirty("Flag"); err != nil {
+		t.Fatal(err)
+	}
+	assertRoot(copied, stale)
+	cache.MarkAllDirty()
+	assertRoot(cache, stale)
+}
+
+func TestFieldRootCache_Invalid(t *testing.T) {
+	if _, err := NewFieldRootCache(reflect.TypeOf([]uint64{})); err == nil {
+		t.Error("Expected lists to have no field roots")
+	}
+	cache, err := NewFieldRootCache(reflect.TypeOf(fork{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.MarkDirty("Missing"); err == nil {
+		t.Error("Expected marking an unknown field to fail")
+	}
+	if _, err := cache.HashTreeRoot(newTreeContainer()); err == nil {
+		t.Error("Expected hashing a value of another type to fail")
+	}
+	if _, err := cache.HashTreeRoot((*fork)(nil)); err == nil {
+		t.Error("Expected hashing a nil pointer to fail")
+	}
+}
@@ -0,0 +1,137 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldRoot_MatchesTree(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	tree, err := NewTree(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := [][]interface{}{
+		{},
+		{"Slot"},
+		{"Forks"},
+		{"Forks", 1},
+		{"Forks", 1, "PreviousVersion"},
+		{"Forks", 5},
+		{"Forks", LengthPathElement},
+		{"Balances"},
+		{"Balances", 4},
+		{"Balances", 900},
+		{"Roots", 1},
+		{"Custody", 3},
+		{"Pointer"},
+		{"Pointer", "Epoch"},
+		{"Bits"},
+		{"Bits", 299},
+		{"Bits", LengthPathElement},
+		{"Fixed", 1},
+		{"Vectors", 2},
+		{"Tagged"},
+		{"Opaque"},
+		{"Flag"},
+		{"ShortVector", 4},
+	}
+	for _, path := range paths {
+		gindex, err := GeneralizedIndex(reflect.TypeOf(&item), path...)
+		if err != nil {
+			t.Fatalf("Could not resolve %v: %v", path, err)
+		}
+		want, err := tree.Leaf(gindex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FieldRoot(&item, path...)
+		if err != nil {
+			t.Errorf("Could not compute root of %v: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Expected root of %v to be %#x, received %#x", path, want, got)
+		}
+	}
+}
+
+func TestFieldRoot_NestedLimits(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := nestedLists{
+		Lists:        [][]uint64{{1, 2, 3}, {}, {4, 5, 6, 7, 8}},
+		Transactions: [][]byte{{0xde, 0xad}},
+		Vectors:      [][2][]uint16{{{1}, {2, 3}}},
+	}
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := [][]interface{}{
+		{"Lists"},
+		{"Lists", 0},
+		{"Lists", 2, 7},
+		{"Lists", 2, LengthPathElement},
+		{"Transactions", 0},
+		{"Vectors", 0},
+		{"Vectors", 0, 1},
+	}
+	for _, path := range paths {
+		gindex, err := GeneralizedIndex(reflect.TypeOf(item), path...)
+		if err != nil {
+			t.Fatalf("Could not resolve %v: %v", path, err)
+		}
+		want, err := tree.Leaf(gindex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FieldRoot(item, path...)
+		if err != nil {
+			t.Errorf("Could not compute root of %v: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Expected root of %v to be %#x, received %#x", path, want, got)
+		}
+	}
+	err = Walk(item, func(path []string, gindex uint64, leaf []byte) error {
+		want, err := tree.Leaf(gindex)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(leaf, want[:]) {
+			t.Errorf("Expected leaf %v at %d to be %#x, received %#x", path, gindex, want, leaf)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFieldRoot_Errors(t *testing.T) {
+	item := newTreeContainer()
+	tests := [][]interface{}{
+		{"Missing"},
+		{"Slot", 0},
+		{"Balances", 1024},
+		{"Balances", 4, 0},
+		{"Forks", 5, "Epoch"},
+		{"Bits", 3, 0},
+	}
+	for _, path := range tests {
+		if _, err := FieldRoot(item, path...); err == nil {
+			t.Errorf("Expected error for path %v", path)
+		}
+	}
+	item.Pointer = nil
+	if _, err := FieldRoot(item, "Pointer", "Epoch"); err == nil {
+		t.Error("Expected error for path through a nil pointer")
+	}
+	if _, err := FieldRoot(nil); err == nil {
+		t.Error("Expected error for untyped nil")
+	}
+}
@@ -0,0 +1,298 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	forksLock sync.RWMutex
+	// forks are the forks of the consensus specs, in activation order.
+	forks = []string{"phase0", "altair", "bellatrix", "capella", "deneb", "electra", "fulu"}
+)
+
+// RegisterForks replaces the forks which ssz-fork and ssz-until tags can name, given
+// in activation order, which default to those of the consensus specs from phase0 to
+// fulu. Forks must be registered before the first call to ForFork.
+func RegisterForks(names ...string) {
+	forksLock.Lock()
+	defer forksLock.Unlock()
+	forks = append([]string(nil), names...)
+}
+
+// forkIndex returns the position of the fork name in activation order.
+func forkIndex(name string) (int, error) {
+	forksLock.RLock()
+	defer forksLock.RUnlock()
+	for i, fork := range forks {
+		if fork == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q", name)
+}
+
+// ForkCodec encodes, decodes and hashes values as of a fork, so that a single struct
+// can hold the containers of every fork. Fields tagged with ssz-fork are only part of
+// containers from the fork they name onwards, and fields tagged with ssz-until are only
+// part of containers before the fork they name:
+//
+//  type BeaconBlockBody struct {
+//      Graffiti         []byte            `ssz-size:"32"`
+//      SyncAggregate    *SyncAggregate    `ssz-fork:"altair"`
+//      ExecutionPayload *ExecutionPayload `ssz-fork:"bellatrix"`
+//  }
+//
+//  codec, err := ssz.ForFork("altair")
+//  if err != nil {
+//      return err
+//  }
+//  root, err := codec.HashTreeRoot(body)
+//
+// Marshal, Unmarshal and HashTreeRoot of the package, which are unaware of forks, treat
+// every field as part of containers.
+type ForkCodec struct {
+	name  string
+	index int
+}
+
+// ForFork returns the codec of the fork name.
+func ForFork(name string) (*ForkCodec, error) {
+	index, err := forkIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	return &ForkCodec{name: name, index: index}, nil
+}
+
+// Fork returns the name of the fork of the codec.
+func (c *ForkCodec) Fork() string {
+	return c.name
+}
+
+// Type returns the type values of typ are treated as by the codec, in which containers
+// only hold the fields of the fork. It is typ itself when none of the containers within
+// it have fields tagged with ssz-fork or ssz-until, and can be passed to the functions
+// of the package taking types, such as Describe or GeneralizedIndex.
+func (c *ForkCodec) Type(typ reflect.Type) (reflect.Type, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	return c.typeOf(typ)
+}
+
+// Marshal returns the SSZ encoding of val as of the fork of the codec.
+func (c *ForkCodec) Marshal(val interface{}) ([]byte, error) {
+	converted, err := c.convert(val)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(converted)
+}
+
+// Unmarshal decodes input, encoded as of the fork of the codec, into the value pointed
+// to by val. Fields which are not part of containers at the fork are zeroed.
+func (c *ForkCodec) Unmarshal(input []byte, val interface{}) error {
+	if val == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	rval := reflect.ValueOf(val)
+	if rval.Kind() != reflect.Ptr || rval.IsNil() {
+		return fmt.Errorf("can only unmarshal into a non-nil pointer target, received %T", val)
+	}
+	typ, err := c.typeOf(rval.Type().Elem())
+	if err != nil {
+		return err
+	}
+	if typ == rval.Type().Elem() {
+		return Unmarshal(input, val)
+	}
+	decoded := reflect.New(typ)
+	if err := Unmarshal(input, decoded.Interface()); err != nil {
+		return err
+	}
+	convertValue(rval.Elem(), decoded.Elem())
+	return nil
+}
+
+// HashTreeRoot returns the hash tree root of val as of the fork of the codec.
+func (c *ForkCodec) HashTreeRoot(val interface{}) ([32]byte, error) {
+	converted, err := c.convert(val)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return HashTreeRoot(converted)
+}
+
+// convert returns val as a value of the type of the codec for its type.
+func (c *ForkCodec) convert(val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	rval := reflect.ValueOf(val)
+	typ, err := c.typeOf(rval.Type())
+	if err != nil {
+		return nil, err
+	}
+	if typ == rval.Type() {
+		return val, nil
+	}
+	converted := reflect.New(typ).Elem()
+	convertValue(converted, rval)
+	return converted.Interface(), nil
+}
+
+// forkTypeKey identifies the type a type is treated as at a fork.
+type forkTypeKey struct {
+	typ  reflect.Type
+	fork int
+}
+
+// forkTypes caches the types of ForkCodec.Type.
+var forkTypes sync.Map
+
+func (c *ForkCodec) typeOf(typ reflect.Type) (reflect.Type, error) {
+	key := forkTypeKey{typ: typ, fork: c.index}
+	if t, ok := forkTypes.Load(key); ok {
+		return t.(reflect.Type), nil
+	}
+	t, err := c.deriveType(typ)
+	if err != nil {
+		return nil, err
+	}
+	forkTypes.Store(key, t)
+	return t, nil
+}
+
+// deriveType builds the type of ForkCodec.Type, in which the struct types holding
+// fields of other forks are replaced by struct types holding the fields of the fork.
+func (c *ForkCodec) deriveType(typ reflect.Type) (reflect.Type, error) {
+	if lookupCodec(typ) != nil {
+		return typ, nil
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		elem, err := c.typeOf(typ.Elem())
+		if err != nil || elem == typ.Elem() {
+			return typ, err
+		}
+		switch typ.Kind() {
+		case reflect.Ptr:
+			return reflect.PtrTo(elem), nil
+		case reflect.Slice:
+			return reflect.SliceOf(elem), nil
+		default:
+			return reflect.ArrayOf(typ.Len(), elem), nil
+		}
+	case reflect.Struct:
+		var fields []reflect.StructField
+		changed := false
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if !isSSZField(f) {
+				continue
+			}
+			tags, err := parseFieldTags(f)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse tags of field %s: %w", f.Name, err)
+			}
+			active, err := c.active(tags)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of %v: %w", f.Name, typ, err)
+			}
+			if !active {
+				changed = true
+				continue
+			}
+			// Opaque fields keep their type, which their codec is registered for.
+			if !tags.opaque {
+				fType, err := c.typeOf(f.Type)
+				if err != nil {
+					return nil, err
+				}
+				changed = changed || fType != f.Type
+				f.Type = fType
+			}
+			fields = append(fields, reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag})
+		}
+		if !changed {
+			return typ, nil
+		}
+		return reflect.StructOf(fields), nil
+	default:
+		return typ, nil
+	}
+}
+
+// active reports whether a field with the given tags is part of containers at the fork
+// of the codec.
+func (c *ForkCodec) active(tags *fieldTags) (bool, error) {
+	if tags.fork != "" {
+		index, err := forkIndex(tags.fork)
+		if err != nil {
+			return false, err
+		}
+		if c.index < index {
+			return false, nil
+		}
+	}
+	if tags.until != "" {
+		index, err := forkIndex(tags.until)
+		if err != nil {
+			return false, err
+		}
+		if c.index >= index {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// convertValue sets dst to src, of a type derived from that of dst by ForkCodec.Type or
+// the other way around. Struct fields are matched by name, and the fields of dst which
+// src does not have are zeroed.
+func convertValue(dst reflect.Value, src reflect.Value) {
+	if dst.Type() == src.Type() {
+		dst.Set(src)
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return
+		}
+		elem := reflect.New(dst.Type().Elem())
+		convertValue(elem.Elem(), src.Elem())
+		dst.Set(elem)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return
+		}
+		elems := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			convertValue(elems.Index(i), src.Index(i))
+		}
+		dst.Set(elems)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			convertValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		typ := dst.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			if !isSSZField(typ.Field(i)) {
+				continue
+			}
+			srcField, ok := src.Type().FieldByName(typ.Field(i).Name)
+			if !ok || len(srcField.Index) != 1 {
+				dst.Field(i).Set(reflect.Zero(typ.Field(i).Type))
+				continue
+			}
+			convertValue(dst.Field(i), src.Field(srcField.Index[0]))
+		}
+	}
+}
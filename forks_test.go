@@ -0,0 +1,161 @@
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type forkedBody struct {
+	Slot          uint64
+	Legacy        uint64   `ssz-until:"altair"`
+	SyncAggregate [4]byte  `ssz-fork:"altair"`
+	Transactions  [][]byte `ssz-fork:"bellatrix" ssz-max:"4,16"`
+}
+
+type phase0Body struct {
+	Slot   uint64
+	Legacy uint64
+}
+
+type altairBody struct {
+	Slot          uint64
+	SyncAggregate [4]byte
+}
+
+type bellatrixBody struct {
+	Slot          uint64
+	SyncAggregate [4]byte
+	Transactions  [][]byte `ssz-max:"4,16"`
+}
+
+type forkedBlock struct {
+	Bodies []*forkedBody `ssz-max:"8"`
+	Roots  [2][32]byte
+}
+
+func TestForkCodec_MatchesForkTypes(t *testing.T) {
+	body := forkedBody{
+		Slot:          5,
+		Legacy:        6,
+		SyncAggregate: [4]byte{1, 2, 3, 4},
+		Transactions:  [][]byte{{7}, {8, 9}},
+	}
+	tests := []struct {
+		fork string
+		want interface{}
+	}{
+		{"phase0", phase0Body{Slot: 5, Legacy: 6}},
+		{"altair", altairBody{Slot: 5, SyncAggregate: [4]byte{1, 2, 3, 4}}},
+		{"bellatrix", bellatrixBody{Slot: 5, SyncAggregate: [4]byte{1, 2, 3, 4}, Transactions: [][]byte{{7}, {8, 9}}}},
+		{"deneb", bellatrixBody{Slot: 5, SyncAggregate: [4]byte{1, 2, 3, 4}, Transactions: [][]byte{{7}, {8, 9}}}},
+	}
+	for _, tt := range tests {
+		codec, err := ForFork(tt.fork)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := codec.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Marshal(tt.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, want) {
+			t.Errorf("%s: expected encoding %#x, received %#x", tt.fork, want, encoded)
+		}
+		root, err := codec.HashTreeRoot(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRoot, err := HashTreeRoot(tt.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != wantRoot {
+			t.Errorf("%s: expected root %#x, received %#x", tt.fork, wantRoot, root)
+		}
+
+		decoded := forkedBody{Legacy: 1, Transactions: [][]byte{{1}}}
+		if err := codec.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		reencoded, err := codec.Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(reencoded, want) {
+			t.Errorf("%s: expected round trip to encode to %#x, received %#x", tt.fork, want, reencoded)
+		}
+		if tt.fork == "altair" && (decoded.Legacy != 0 || decoded.Transactions != nil) {
+			t.Errorf("Expected fields outside of altair to be zeroed, received %+v", decoded)
+		}
+	}
+}
+
+func TestForkCodec_NestedContainers(t *testing.T) {
+	codec, err := ForFork("altair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := forkedBlock{
+		Bodies: []*forkedBody{{Slot: 1, Legacy: 2, SyncAggregate: [4]byte{3}}, nil},
+		Roots:  [2][32]byte{{4}, {5}},
+	}
+	root, err := codec.HashTreeRoot(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := HashTreeRoot(struct {
+		Bodies []*altairBody `ssz-max:"8"`
+		Roots  [2][32]byte
+	}{
+		Bodies: []*altairBody{{Slot: 1, SyncAggregate: [4]byte{3}}, nil},
+		Roots:  block.Roots,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+
+	typ, err := codec.Type(reflect.TypeOf(block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ.Field(0).Type.Elem().Elem().NumField() != 2 {
+		t.Errorf("Expected bodies of %v to hold the 2 fields of altair", typ)
+	}
+	unforked, err := codec.Type(reflect.TypeOf(fork{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unforked != reflect.TypeOf(fork{}) {
+		t.Errorf("Expected types without fork tags to be left as is, received %v", unforked)
+	}
+}
+
+func TestForkCodec_Errors(t *testing.T) {
+	if _, err := ForFork("unknown"); err == nil {
+		t.Error("Expected unknown fork to fail")
+	}
+	codec, err := ForFork("altair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := struct {
+		Field uint64 `ssz-fork:"unknown"`
+	}{}
+	if _, err := codec.HashTreeRoot(item); err == nil {
+		t.Error("Expected field of unknown fork to fail")
+	}
+	if err := codec.Unmarshal([]byte{}, forkedBody{}); err == nil {
+		t.Error("Expected unmarshaling into a non-pointer to fail")
+	}
+	if _, err := codec.Marshal(nil); err == nil {
+		t.Error("Expected untyped nil to fail")
+	}
+}
@@ -0,0 +1,83 @@
+//go:build go1.18
+// +build go1.18
+
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+type fuzzBitfields struct {
+	Bits   bitfield.Bitlist    `ssz-max:"2048"`
+	Vector bitfield.Bitvector4 `ssz-size:"1"`
+	Flag   bool
+}
+
+type fuzzNested struct {
+	Items   []iteratedItem `ssz-max:"8"`
+	Pointer *fork
+	Roots   [][32]byte `ssz-max:"4"`
+	Lists   [][]uint16 `ssz-size:"?,2" ssz-max:"4"`
+	Bits    fuzzBitfields
+	Tail    []byte `ssz-max:"32"`
+}
+
+// fuzzUnmarshal decodes data into a new value of the type of sample, and checks that
+// decoded values encode to the same bytes, which decode to the same value again.
+func fuzzUnmarshal(f *testing.F, sample interface{}) {
+	typ := reflect.TypeOf(sample)
+	encoded, err := Marshal(sample)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(encoded)
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decoded := reflect.New(typ)
+		if err := Unmarshal(data, decoded.Interface()); err != nil {
+			return
+		}
+		reencoded, err := Marshal(decoded.Elem().Interface())
+		if err != nil {
+			// Decoding does not check list limits, which encoding enforces.
+			return
+		}
+		if !bytes.Equal(reencoded, data) {
+			t.Fatalf("Decoded %#x into %+v, which encodes to %#x", data, decoded.Elem().Interface(), reencoded)
+		}
+		again := reflect.New(typ)
+		if err := Unmarshal(reencoded, again.Interface()); err != nil {
+			t.Fatalf("Failed to decode re-encoded value %#x: %v", reencoded, err)
+		}
+		if !DeepEqual(again.Elem().Interface(), decoded.Elem().Interface()) {
+			t.Fatalf("Expected %+v, received %+v", decoded.Elem().Interface(), again.Elem().Interface())
+		}
+	})
+}
+
+func FuzzUnmarshal_Struct(f *testing.F) {
+	fuzzUnmarshal(f, fuzzNested{
+		Items:   []iteratedItem{{Slot: 1, Data: []byte{1, 2}}, {Slot: 2}},
+		Pointer: &fork{Epoch: 3},
+		Roots:   [][32]byte{{1}},
+		Lists:   [][]uint16{{1, 2}},
+		Bits:    fuzzBitfields{Bits: bitfield.Bitlist{0x0d}, Vector: bitfield.Bitvector4{0x05}, Flag: true},
+		Tail:    []byte{4, 5},
+	})
+}
+
+func FuzzUnmarshal_List(f *testing.F) {
+	fuzzUnmarshal(f, []iteratedItem{{Slot: 1, Data: []byte{1}}, {Slot: 2, Data: []byte{2, 3}}})
+}
+
+func FuzzUnmarshal_BasicList(f *testing.F) {
+	fuzzUnmarshal(f, []uint64{1, 2, 3})
+}
+
+func FuzzUnmarshal_Bitfields(f *testing.F) {
+	fuzzUnmarshal(f, fuzzBitfields{Bits: bitfield.Bitlist{0x0d}, Vector: bitfield.Bitvector4{0x05}})
+}
@@ -0,0 +1,265 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// LengthPathElement is the path element designating the length of a list,
+// which is mixed into its root.
+const LengthPathElement = "__len__"
+
+// GeneralizedIndex resolves a path of field names and element indices within a value
+// of type typ to the generalized index of the corresponding node of its backing tree.
+// Elements of lists and vectors of basic values share chunks, so their path resolves to
+// the chunk holding them. The length of a list is designated by LengthPathElement.
+//
+//  gindex, err := GeneralizedIndex(reflect.TypeOf(BeaconState{}), "Validators", 1234, "EffectiveBalance")
+//  if err != nil {
+//      return fmt.Errorf("failed to resolve path: %v", err)
+//  }
+func GeneralizedIndex(typ reflect.Type, path ...interface{}) (uint64, error) {
+	target, err := resolvePath(typ, path)
+	if err != nil {
+		return 0, err
+	}
+	return target.gindex, nil
+}
+
+// pathTarget is the node of a backing tree designated by a path.
+type pathTarget struct {
+	gindex      uint64
+	typ         reflect.Type
+	maxCapacity uint64
+	// innerLimits bound the lists nested within the node.
+	innerLimits []uint64
+	isBitlist   bool
+	// bitvector is the number of bits of the vectors of booleans of fields tagged
+	// with `ssz:"bits"`, which are packed into vectors of bytes.
+	bitvector uint64
+	// offset is the byte offset of a basic element within the chunk
+	// it is packed into.
+	offset uint64
+	// isBit marks the bits of bitlists and bitvectors, which cannot be read as
+	// basic elements.
+	isBit bool
+	// progressive marks the fields merkleized progressively, whose children are
+	// not designated by paths.
+	progressive bool
+}
+
+func resolvePath(typ reflect.Type, path []interface{}) (*pathTarget, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	if err := checkAcyclic(typ); err != nil {
+		return nil, err
+	}
+	target := &pathTarget{gindex: 1, typ: typ}
+	for i, p := range path {
+		for target.typ.Kind() == reflect.Ptr {
+			target.typ = target.typ.Elem()
+		}
+		if target.typ.Kind() == reflect.String {
+			// Strings are merkleized as the byte lists they are encoded as.
+			target.typ = byteSliceType
+		}
+		if err := target.descend(p); err != nil {
+			return nil, fmt.Errorf("could not resolve path element %d (%v): %w", i, p, err)
+		}
+	}
+	return target, nil
+}
+
+// descend resolves a single path element p within the target, which becomes
+// the designated child.
+func (t *pathTarget) descend(p interface{}) error {
+	if t.isBit {
+		return errors.New("bits have no children")
+	}
+	if t.progressive {
+		return errorf(ErrUnsupportedType, "paths cannot descend into progressive type %v", t.typ)
+	}
+	typ := t.typ
+	kind := typ.Kind()
+	isList := t.isBitlist || (kind == reflect.Slice && !isBitvector(typ))
+	if name, ok := p.(string); ok && name == LengthPathElement {
+		if !isList {
+			return fmt.Errorf("type %v is not a list", typ)
+		}
+		return t.set(childIndex(t.gindex, 2, 1))(reflect.TypeOf(uint64(0)), 0, nil)
+	}
+
+	if kind == reflect.Struct {
+		name, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("expected a field name for struct %v", typ)
+		}
+		fields, err := structFields(typ)
+		if err != nil {
+			return err
+		}
+		for i, f := range fields {
+			if f.name != name {
+				continue
+			}
+			if err := t.set(childIndex(t.gindex, uint64(len(fields)), uint64(i)))(f.typ, f.capacity, f.innerLimits); err != nil {
+				return err
+			}
+			t.isBitlist = isBitlist(f.typ)
+			t.progressive = f.progressive
+			if f.bits && f.typ.Kind() == reflect.Array {
+				t.bitvector = uint64(typ.Field(f.index).Type.Len())
+			}
+			return nil
+		}
+		return fmt.Errorf("struct %v has no field %s", typ, name)
+	}
+
+	index, err := pathIndex(p)
+	if err != nil {
+		return err
+	}
+	switch {
+	case t.isBitlist:
+		if index >= t.maxCapacity {
+			return fmt.Errorf("bit %d exceeds bitlist capacity of %d", index, t.maxCapacity)
+		}
+		if err := t.set(listChildIndex(t.gindex, (t.maxCapacity+255)/256, index/256))(reflect.TypeOf(false), 0, nil); err != nil {
+			return err
+		}
+		t.isBit = true
+		return nil
+	case isBitvector(typ) || t.bitvector != 0:
+		length := t.bitvector
+		if length == 0 {
+			length, _ = bitvectorLength(typ)
+		}
+		if index >= length {
+			return fmt.Errorf("bit %d exceeds bitvector length of %d", index, length)
+		}
+		if err := t.set(childIndex(t.gindex, (length+255)/256, index/256))(reflect.TypeOf(false), 0, nil); err != nil {
+			return err
+		}
+		t.isBit = true
+		return nil
+	case kind == reflect.Array && isBasicType(typ.Elem().Kind()):
+		if index >= uint64(typ.Len()) {
+			return fmt.Errorf("index %d exceeds vector length of %d", index, typ.Len())
+		}
+		elemSize := staticFixedSize(typ.Elem())
+		chunks := (uint64(typ.Len())*elemSize + 31) / 32
+		if err := t.set(childIndex(t.gindex, chunks, index*elemSize/32))(typ.Elem(), 0, nil); err != nil {
+			return err
+		}
+		t.offset = index * elemSize % 32
+		return nil
+	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
+		elemSize := staticFixedSize(typ.Elem())
+		limit := (t.maxCapacity*elemSize + 31) / 32
+		if limit == 0 {
+			limit = 1
+		}
+		if t.maxCapacity != 0 && index >= t.maxCapacity {
+			return fmt.Errorf("index %d exceeds list capacity of %d", index, t.maxCapacity)
+		}
+		if index*elemSize/32 >= limit {
+			return fmt.Errorf("index %d exceeds list limit of %d chunks", index, limit)
+		}
+		if err := t.set(listChildIndex(t.gindex, limit, index*elemSize/32))(typ.Elem(), 0, nil); err != nil {
+			return err
+		}
+		t.offset = index * elemSize % 32
+		return nil
+	case kind == reflect.Slice && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()):
+		limit := t.maxCapacity
+		if limit == 0 {
+			limit = 1
+		}
+		if index >= limit {
+			return fmt.Errorf("index %d exceeds list capacity of %d", index, limit)
+		}
+		return t.set(listChildIndex(t.gindex, limit, index))(typ.Elem(), 0, nil)
+	case kind == reflect.Slice:
+		if t.maxCapacity == 0 {
+			return fmt.Errorf("list of type %v has no ssz-max, its depth depends on its length", typ)
+		}
+		if index >= t.maxCapacity {
+			return fmt.Errorf("index %d exceeds list capacity of %d", index, t.maxCapacity)
+		}
+		return t.set(listChildIndex(t.gindex, t.maxCapacity, index))(splitElemLimits(typ.Elem(), t.innerLimits))
+	case kind == reflect.Array:
+		if index >= uint64(typ.Len()) {
+			return fmt.Errorf("index %d exceeds vector length of %d", index, typ.Len())
+		}
+		return t.set(childIndex(t.gindex, uint64(typ.Len()), index))(splitElemLimits(typ.Elem(), t.innerLimits))
+	default:
+		return fmt.Errorf("type %v has no children", typ)
+	}
+}
+
+// set returns a function moving the target to the node at gindex, of type typ,
+// unless err is not nil.
+func (t *pathTarget) set(gindex uint64, err error) func(typ reflect.Type, maxCapacity uint64, innerLimits []uint64) error {
+	return func(typ reflect.Type, maxCapacity uint64, innerLimits []uint64) error {
+		if err != nil {
+			return err
+		}
+		t.gindex = gindex
+		t.typ = typ
+		t.maxCapacity = maxCapacity
+		t.innerLimits = innerLimits
+		t.isBitlist = false
+		t.bitvector = 0
+		t.offset = 0
+		t.progressive = false
+		return nil
+	}
+}
+
+// splitElemLimits returns the element type of a list or vector along with the max
+// capacity and inner limits of its elements, given the inner limits of the list.
+func splitElemLimits(elemType reflect.Type, limits []uint64) (reflect.Type, uint64, []uint64) {
+	capacity, innerLimits := splitLimits(limits)
+	return elemType, capacity, innerLimits
+}
+
+// childIndex returns the generalized index of the position-th of count leaves of
+// the subtree rooted at gindex, the subtree being padded to a power of two leaves.
+func childIndex(gindex uint64, count uint64, position uint64) (uint64, error) {
+	depth := uint64(0)
+	if count > 1 {
+		depth = bitLength(count - 1)
+	}
+	if bitLength(gindex)+depth > 64 {
+		return 0, errors.New("generalized index does not fit in 64 bits")
+	}
+	return gindex<<depth | position, nil
+}
+
+// listChildIndex returns the generalized index of the position-th chunk of the
+// contents of a list rooted at gindex, the contents being the left child of the
+// branch mixing in the length of the list.
+func listChildIndex(gindex uint64, limit uint64, position uint64) (uint64, error) {
+	contents, err := childIndex(gindex, 2, 0)
+	if err != nil {
+		return 0, err
+	}
+	return childIndex(contents, limit, position)
+}
+
+func pathIndex(p interface{}) (uint64, error) {
+	v := reflect.ValueOf(p)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return 0, fmt.Errorf("negative index %d", v.Int())
+		}
+		return uint64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	default:
+		return 0, fmt.Errorf("expected an element index, received %T", p)
+	}
+}
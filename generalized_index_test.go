@@ -0,0 +1,137 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGeneralizedIndex_MatchesTree(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(item)
+	fieldRoot := func(val interface{}) [32]byte {
+		root, err := HashTreeRoot(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	tests := []struct {
+		path []interface{}
+		want [32]byte
+	}{
+		{path: []interface{}{"Slot"}, want: lengthChunk(item.Slot)},
+		{path: []interface{}{"Forks", 1}, want: fieldRoot(item.Forks[1])},
+		{path: []interface{}{"Forks", uint64(2), "Epoch"}, want: lengthChunk(item.Forks[2].Epoch)},
+		{path: []interface{}{"Forks", LengthPathElement}, want: lengthChunk(uint64(len(item.Forks)))},
+		{path: []interface{}{"Balances", 5}, want: packedChunk(item.Balances[4:])},
+		{path: []interface{}{"Balances", LengthPathElement}, want: lengthChunk(uint64(len(item.Balances)))},
+		{path: []interface{}{"Roots", 1}, want: item.Roots[1]},
+		{path: []interface{}{"Custody", 3}, want: packedChunk(item.Custody[:])},
+		{path: []interface{}{"Pointer", "Epoch"}, want: lengthChunk(item.Pointer.Epoch)},
+		{path: []interface{}{"Fixed", 1, "Epoch"}, want: lengthChunk(item.Fixed[1].Epoch)},
+		{path: []interface{}{"Vectors", 2}, want: item.Vectors[2]},
+		{path: []interface{}{"Tagged", 31}, want: toBytes32(item.Tagged)},
+		{path: []interface{}{"Bits", LengthPathElement}, want: lengthChunk(item.Bits.Len())},
+		{path: []interface{}{"Bits", 299}, want: toBytes32(item.Bits.Bytes()[32:])},
+		{path: []interface{}{"Opaque", 0}, want: toBytes32([]byte("foo"))},
+		{path: []interface{}{"ShortVector", 4}, want: packedChunk16(item.ShortVector[:])},
+	}
+	for _, tt := range tests {
+		gindex, err := GeneralizedIndex(typ, tt.path...)
+		if err != nil {
+			t.Fatalf("path %v: %v", tt.path, err)
+		}
+		got, err := tree.Leaf(gindex)
+		if err != nil {
+			t.Fatalf("path %v: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("path %v: expected node %#x at index %d, received %#x", tt.path, tt.want, gindex, got)
+		}
+	}
+}
+
+func packedChunk(vals []uint64) [32]byte {
+	var chunk [32]byte
+	for i := 0; i < len(vals) && i < 4; i++ {
+		c := lengthChunk(vals[i])
+		copy(chunk[i*8:], c[:8])
+	}
+	return chunk
+}
+
+func packedChunk16(vals []uint16) [32]byte {
+	var chunk [32]byte
+	for i, v := range vals {
+		chunk[i*2] = byte(v)
+		chunk[i*2+1] = byte(v >> 8)
+	}
+	return chunk
+}
+
+func TestGeneralizedIndex_Values(t *testing.T) {
+	typ := reflect.TypeOf(accountBalances{})
+	// The container has a single field, whose contents hold 2^38 chunks.
+	gindex, err := GeneralizedIndex(typ, "Balances", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(2)<<38 + 2; gindex != want {
+		t.Errorf("Expected generalized index %d, received %d", want, gindex)
+	}
+	gindex, err = GeneralizedIndex(typ, "Balances", LengthPathElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gindex != 3 {
+		t.Errorf("Expected generalized index 3, received %d", gindex)
+	}
+	gindex, err = GeneralizedIndex(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gindex != 1 {
+		t.Errorf("Expected generalized index 1 for the empty path, received %d", gindex)
+	}
+}
+
+func TestGeneralizedIndex_Errors(t *testing.T) {
+	typ := reflect.TypeOf(treeContainer{})
+	tests := []struct {
+		path []interface{}
+		err  string
+	}{
+		{path: []interface{}{"Missing"}, err: "has no field Missing"},
+		{path: []interface{}{0}, err: "expected a field name"},
+		{path: []interface{}{"Forks", 16}, err: "exceeds list capacity"},
+		{path: []interface{}{"Forks", -1}, err: "negative index"},
+		{path: []interface{}{"Forks", "first"}, err: "expected an element index"},
+		{path: []interface{}{"Custody", 4}, err: "exceeds vector length"},
+		{path: []interface{}{"Slot", 0}, err: "has no children"},
+		{path: []interface{}{"Custody", LengthPathElement}, err: "is not a list"},
+		{path: []interface{}{"EmptyForks", 0}, err: "has no ssz-max"},
+		{path: []interface{}{"Bits", 2048}, err: "exceeds bitlist capacity"},
+	}
+	for _, tt := range tests {
+		_, err := GeneralizedIndex(typ, tt.path...)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("path %v: expected error containing %q, received %v", tt.path, tt.err, err)
+		}
+	}
+	type deep struct {
+		Items []accountBalances `ssz-max:"1099511627776"`
+	}
+	if _, err := GeneralizedIndex(reflect.TypeOf(deep{}), "Items", 1, "Balances", 1); err == nil || !strings.Contains(err.Error(), "64 bits") {
+		t.Errorf("Expected error for generalized index overflowing 64 bits, received %v", err)
+	}
+	if _, err := GeneralizedIndex(nil); err == nil {
+		t.Error("Expected error for nil type")
+	}
+}
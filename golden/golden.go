@@ -0,0 +1,221 @@
+// Package golden pins the encodings and hash tree roots of known values, so that a
+// change of go-ssz altering either is caught before it reaches a release. The vectors
+// are checked into testdata/vectors.json, and regenerated after an intended change
+// with either of:
+//
+//  go test ./golden -update
+//  go run ./cmd/ssz-golden -out golden/testdata/vectors.json
+package golden
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+// Vector is a known value whose encoding and root are pinned.
+type Vector struct {
+	Name  string
+	Value interface{}
+}
+
+// Entry is the pinned encoding and root of a vector, as stored in the vectors file.
+type Entry struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Root     string `json:"root"`
+}
+
+// seq returns n bytes counting up from start, which keeps the vectors readable
+// while giving every byte a distinct value.
+func seq(n int, start byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = start + byte(i)
+	}
+	return b
+}
+
+func bits(n uint64, set ...uint64) bitfield.Bitlist {
+	b := bitfield.NewBitlist(n)
+	for _, i := range set {
+		b.SetBitAt(i, true)
+	}
+	return b
+}
+
+// Vectors returns the known values, covering every basic type and the phase 0
+// containers of the benchmarks package. The beacon state is left out, as its
+// vectors alone would encode to megabytes.
+func Vectors() []Vector {
+	checkpoint := benchmarks.Checkpoint{Epoch: 3, Root: seq(32, 0x10)}
+	data := benchmarks.AttestationData{
+		Slot:            100,
+		Index:           2,
+		BeaconBlockRoot: seq(32, 0x20),
+		Source:          benchmarks.Checkpoint{Epoch: 2, Root: seq(32, 0x30)},
+		Target:          checkpoint,
+	}
+	header := benchmarks.SignedBeaconBlockHeader{
+		Message: benchmarks.BeaconBlockHeader{
+			Slot:          100,
+			ProposerIndex: 7,
+			ParentRoot:    seq(32, 0x40),
+			StateRoot:     seq(32, 0x50),
+			BodyRoot:      seq(32, 0x60),
+		},
+		Signature: seq(96, 0x70),
+	}
+	indexed := benchmarks.IndexedAttestation{
+		AttestingIndices: []uint64{1, 5, 8},
+		Data:             data,
+		Signature:        seq(96, 0x80),
+	}
+	attestation := benchmarks.Attestation{
+		AggregationBits: bits(10, 0, 3, 9),
+		Data:            data,
+		Signature:       seq(96, 0x90),
+	}
+	depositData := benchmarks.DepositData{
+		Pubkey:                seq(48, 0xa0),
+		WithdrawalCredentials: seq(32, 0xb0),
+		Amount:                32000000000,
+		Signature:             seq(96, 0xc0),
+	}
+	proof := make([][]byte, benchmarks.DepositContractTreeDepth+1)
+	for i := range proof {
+		proof[i] = seq(32, byte(i))
+	}
+	deposit := benchmarks.Deposit{Proof: proof, Data: depositData}
+	exit := benchmarks.SignedVoluntaryExit{
+		Message:   benchmarks.VoluntaryExit{Epoch: 4, ValidatorIndex: 9},
+		Signature: seq(96, 0xd0),
+	}
+	eth1Data := benchmarks.Eth1Data{DepositRoot: seq(32, 0xe0), DepositCount: 12, BlockHash: seq(32, 0xf0)}
+	body := benchmarks.BeaconBlockBody{
+		RandaoReveal:      seq(96, 0x01),
+		Eth1Data:          eth1Data,
+		Graffiti:          seq(32, 0x02),
+		ProposerSlashings: []benchmarks.ProposerSlashing{{SignedHeader1: header, SignedHeader2: header}},
+		AttesterSlashings: []benchmarks.AttesterSlashing{{Attestation1: indexed, Attestation2: indexed}},
+		Attestations:      []benchmarks.Attestation{attestation, attestation},
+		Deposits:          []benchmarks.Deposit{deposit},
+		VoluntaryExits:    []benchmarks.SignedVoluntaryExit{exit},
+	}
+	block := benchmarks.SignedBeaconBlock{
+		Message: benchmarks.BeaconBlock{
+			Slot:          101,
+			ProposerIndex: 7,
+			ParentRoot:    seq(32, 0x03),
+			StateRoot:     seq(32, 0x04),
+			Body:          body,
+		},
+		Signature: seq(96, 0x05),
+	}
+	return []Vector{
+		{Name: "bool", Value: true},
+		{Name: "uint8", Value: uint8(0xa5)},
+		{Name: "uint16", Value: uint16(0xa55a)},
+		{Name: "uint32", Value: uint32(0xdeadbeef)},
+		{Name: "uint64", Value: uint64(0x0123456789abcdef)},
+		{Name: "bytes32", Value: [32]byte{1, 2, 3}},
+		{Name: "Fork", Value: benchmarks.Fork{PreviousVersion: seq(4, 0), CurrentVersion: seq(4, 1), Epoch: 5}},
+		{Name: "Checkpoint", Value: checkpoint},
+		{Name: "Validator", Value: benchmarks.Validator{
+			Pubkey:                     seq(48, 0x11),
+			WithdrawalCredentials:      seq(32, 0x22),
+			EffectiveBalance:           32000000000,
+			Slashed:                    true,
+			ActivationEligibilityEpoch: 1,
+			ActivationEpoch:            2,
+			ExitEpoch:                  ^uint64(0),
+			WithdrawableEpoch:          ^uint64(0),
+		}},
+		{Name: "AttestationData", Value: data},
+		{Name: "IndexedAttestation", Value: indexed},
+		{Name: "PendingAttestation", Value: benchmarks.PendingAttestation{
+			AggregationBits: bits(4, 1),
+			Data:            data,
+			InclusionDelay:  1,
+			ProposerIndex:   7,
+		}},
+		{Name: "Eth1Data", Value: eth1Data},
+		{Name: "DepositData", Value: depositData},
+		{Name: "BeaconBlockHeader", Value: header.Message},
+		{Name: "SignedBeaconBlockHeader", Value: header},
+		{Name: "ProposerSlashing", Value: benchmarks.ProposerSlashing{SignedHeader1: header, SignedHeader2: header}},
+		{Name: "AttesterSlashing", Value: benchmarks.AttesterSlashing{Attestation1: indexed, Attestation2: indexed}},
+		{Name: "Attestation", Value: attestation},
+		{Name: "Attestation/empty_bits", Value: benchmarks.Attestation{
+			AggregationBits: bits(0),
+			Data:            data,
+			Signature:       seq(96, 0),
+		}},
+		{Name: "Deposit", Value: deposit},
+		{Name: "VoluntaryExit", Value: exit.Message},
+		{Name: "SignedVoluntaryExit", Value: exit},
+		{Name: "BeaconBlockBody", Value: body},
+		{Name: "SignedBeaconBlock", Value: block},
+	}
+}
+
+// Generate computes the entries of the vectors with the current implementation.
+func Generate() ([]Entry, error) {
+	vectors := Vectors()
+	entries := make([]Entry, len(vectors))
+	for i, v := range vectors {
+		entry, err := generate(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate vector %s: %w", v.Name, err)
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+func generate(v Vector) (Entry, error) {
+	encoding, err := ssz.Marshal(v.Value)
+	if err != nil {
+		return Entry{}, err
+	}
+	root, err := ssz.HashTreeRoot(v.Value)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Name:     v.Name,
+		Type:     reflect.TypeOf(v.Value).String(),
+		Encoding: "0x" + hex.EncodeToString(encoding),
+		Root:     "0x" + hex.EncodeToString(root[:]),
+	}, nil
+}
+
+// Load reads the entries of a vectors file.
+func Load(path string) ([]Entry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("could not parse vectors file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Save writes entries to a vectors file, one field per line so that changes to
+// the vectors show up clearly in diffs.
+func Save(path string, entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,97 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden vectors instead of checking them")
+
+var vectorsPath = filepath.Join("testdata", "vectors.json")
+
+func TestGoldenVectors(t *testing.T) {
+	entries, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := Save(vectorsPath, entries); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := Load(vectorsPath)
+	if err != nil {
+		t.Fatalf("Failed to load golden vectors, run with -update to generate them: %v", err)
+	}
+	pinned := make(map[string]Entry, len(golden))
+	for _, e := range golden {
+		pinned[e.Name] = e
+	}
+	for _, e := range entries {
+		want, ok := pinned[e.Name]
+		if !ok {
+			t.Errorf("Vector %s is not pinned, run with -update to add it", e.Name)
+			continue
+		}
+		if e.Type != want.Type {
+			t.Errorf("Vector %s: expected type %s, received %s", e.Name, want.Type, e.Type)
+		}
+		if e.Encoding != want.Encoding {
+			t.Errorf("Vector %s: encoding changed from %s to %s", e.Name, want.Encoding, e.Encoding)
+		}
+		if e.Root != want.Root {
+			t.Errorf("Vector %s: root changed from %s to %s", e.Name, want.Root, e.Root)
+		}
+		delete(pinned, e.Name)
+	}
+	for name := range pinned {
+		t.Errorf("Pinned vector %s is not generated anymore", name)
+	}
+}
+
+// TestGoldenVectors_Decode checks that the pinned encodings still decode to the
+// known values.
+func TestGoldenVectors_Decode(t *testing.T) {
+	golden, err := Load(vectorsPath)
+	if err != nil {
+		t.Skipf("Golden vectors are not available: %v", err)
+	}
+	pinned := make(map[string]Entry, len(golden))
+	for _, e := range golden {
+		pinned[e.Name] = e
+	}
+	for _, v := range Vectors() {
+		e, ok := pinned[v.Name]
+		if !ok {
+			continue
+		}
+		encoding, err := hex.DecodeString(strings.TrimPrefix(e.Encoding, "0x"))
+		if err != nil {
+			t.Fatalf("Vector %s: %v", v.Name, err)
+		}
+		decoded := reflect.New(reflect.TypeOf(v.Value))
+		if err := ssz.Unmarshal(encoding, decoded.Interface()); err != nil {
+			t.Errorf("Vector %s: failed to decode pinned encoding: %v", v.Name, err)
+			continue
+		}
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ssz.Marshal(decoded.Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Vector %s: pinned encoding decodes to %+v", v.Name, decoded.Elem().Interface())
+		}
+	}
+}
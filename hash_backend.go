@@ -0,0 +1,88 @@
+package ssz
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	gohash "hash"
+
+	sha256simd "github.com/minio/sha256-simd"
+)
+
+// HashBackend specifies which sha256 implementation is used when
+// merkleizing values.
+type HashBackend int
+
+const (
+	// PooledSHA256 hashes with a sha256 digest object owned by the hasher, reused
+	// for every hashed pair of chunks rather than allocated per hash. The digests
+	// are pooled along with the hashers that own them. This is the default.
+	PooledSHA256 HashBackend = iota
+	// StdSHA256 calls sha256.Sum256 directly for every hash.
+	StdSHA256
+	// SIMDSHA256 uses a digest of a sha256 implementation accelerated
+	// with AVX2, AVX512 or SHA-NI instructions when the CPU supports them,
+	// falling back to the standard library implementation otherwise.
+	SIMDSHA256
+	// CustomHash uses the hash function a hasher was created with by
+	// NewHasherWithHashFunction.
+	CustomHash
+)
+
+// hashFunction is a hash function hashers merkleize values with, along with the
+// roots of the zero-filled subtrees it gives.
+type hashFunction struct {
+	backend HashBackend
+	// newHash creates the digest of hashers, nil when sum is used instead.
+	newHash    func() gohash.Hash
+	sum        func([]byte) [32]byte
+	zeroHashes [][]byte
+}
+
+// sha256Functions holds the hash function of every sha256 backend, which all
+// share the same zero hashes.
+var sha256Functions = map[HashBackend]*hashFunction{
+	PooledSHA256: {backend: PooledSHA256, newHash: sha256.New, zeroHashes: zeroHashes},
+	StdSHA256:    {backend: StdSHA256, sum: sha256.Sum256, zeroHashes: zeroHashes},
+	SIMDSHA256:   {backend: SIMDSHA256, newHash: sha256simd.New, zeroHashes: zeroHashes},
+}
+
+func newHashFunction(newHash func() gohash.Hash) (*hashFunction, error) {
+	if newHash == nil {
+		return nil, errors.New("hash function cannot be nil")
+	}
+	digest := newHash()
+	if size := digest.Size(); size != 32 {
+		return nil, fmt.Errorf("hash function must produce 32 byte digests, got %d bytes", size)
+	}
+	f := &hashFunction{
+		backend:    CustomHash,
+		newHash:    newHash,
+		zeroHashes: make([][]byte, maxTreeDepth+1),
+	}
+	fillZeroHashes(f.zeroHashes, func(data []byte) [32]byte {
+		var output [32]byte
+		digest.Reset()
+		// The hash interface never returns an error.
+		// #nosec G104
+		digest.Write(data)
+		copy(output[:], digest.Sum(nil))
+		return output
+	})
+	return f, nil
+}
+
+func (b HashBackend) String() string {
+	switch b {
+	case PooledSHA256:
+		return "pooled-sha256"
+	case StdSHA256:
+		return "std-sha256"
+	case SIMDSHA256:
+		return "simd-sha256"
+	case CustomHash:
+		return "custom"
+	default:
+		return fmt.Sprintf("HashBackend(%d)", int(b))
+	}
+}
@@ -0,0 +1,107 @@
+package ssz
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestNewHasherWithBackend(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	want := sha256.Sum256(data)
+	item := hintedContainer{Forks: []fork{{Epoch: 1}}, Balances: []uint64{1, 2, 3}, Epoch: 4}
+	wantRoot, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, backend := range []HashBackend{PooledSHA256, StdSHA256, SIMDSHA256} {
+		h, err := NewHasherWithBackend(backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Backend() != backend {
+			t.Errorf("Backend() = %v, want %v", h.Backend(), backend)
+		}
+		// Hash twice to make sure digests are reset between uses.
+		for i := 0; i < 2; i++ {
+			if got := h.hash(data); got != want {
+				t.Errorf("%v: hash() = %#x, want %#x", backend, got, want)
+			}
+		}
+		root, err := HashTreeRootWith(item, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != wantRoot {
+			t.Errorf("%v: expected root %#x, received %#x", backend, wantRoot, root)
+		}
+	}
+	if backend := NewHasher().Backend(); backend != PooledSHA256 {
+		t.Errorf("Expected hashers to use %v by default, got %v", PooledSHA256, backend)
+	}
+	if _, err := NewHasherWithBackend(HashBackend(100)); err == nil {
+		t.Error("Expected unknown hash backend to fail")
+	}
+	if _, err := NewHasherWithBackend(CustomHash); err == nil {
+		t.Error("Expected selecting the custom backend without a function to fail")
+	}
+}
+
+func benchmarkMerkleizeWithBackend(b *testing.B, backend HashBackend) {
+	h, err := NewHasherWithBackend(backend)
+	if err != nil {
+		b.Fatal(err)
+	}
+	input := make([][]byte, 8000)
+	for i := 0; i < len(input); i++ {
+		input[i] = make([]byte, BytesPerChunk)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		h.merkleize(input, 1, false /* has limit */)
+	}
+}
+
+func BenchmarkMerkleize_PooledSHA256(b *testing.B) {
+	benchmarkMerkleizeWithBackend(b, PooledSHA256)
+}
+
+func BenchmarkMerkleize_StdSHA256(b *testing.B) {
+	benchmarkMerkleizeWithBackend(b, StdSHA256)
+}
+
+func BenchmarkMerkleize_SIMDSHA256(b *testing.B) {
+	benchmarkMerkleizeWithBackend(b, SIMDSHA256)
+}
+
+func benchmarkHashTreeRootWithBackend(b *testing.B, backend HashBackend) {
+	h, err := NewHasherWithBackend(backend)
+	if err != nil {
+		b.Fatal(err)
+	}
+	useCache = false
+	defer func() { useCache = true }()
+	balances := make([]uint64, 100000)
+	for i := 0; i < len(balances); i++ {
+		balances[i] = 32000000000
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := HashTreeRootWith(balances, h); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHashTreeRoot_PooledSHA256(b *testing.B) {
+	benchmarkHashTreeRootWithBackend(b, PooledSHA256)
+}
+
+func BenchmarkHashTreeRoot_StdSHA256(b *testing.B) {
+	benchmarkHashTreeRootWithBackend(b, StdSHA256)
+}
+
+func BenchmarkHashTreeRoot_SIMDSHA256(b *testing.B) {
+	benchmarkHashTreeRootWithBackend(b, SIMDSHA256)
+}
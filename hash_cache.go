@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/karlseguin/ccache"
@@ -33,9 +35,66 @@ var (
 	})
 )
 
+// RootCache defines a store of previously computed hash tree roots, keyed by
+// a digest of the hashed value. Implementations must be safe for concurrent use.
+// A custom implementation, for example backed by ristretto or freecache, can be
+// plugged in using SetRootCache.
+type RootCache interface {
+	// Get returns the root stored under key, if any.
+	Get(key []byte) ([32]byte, bool)
+	// Put stores a root under key.
+	Put(key []byte, root [32]byte)
+	// Delete removes the root stored under key, if any.
+	Delete(key []byte)
+	// Stats reports usage statistics of the cache.
+	Stats() RootCacheStats
+}
+
+// RootCacheStats contains usage statistics of a RootCache.
+type RootCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   uint64
+	// ApproxMemory is an estimate of the number of bytes held by the entries.
+	ApproxMemory uint64
+}
+
+// approxRootEntrySize is an estimate of the memory used by a single entry of
+// the internal cache: the key, the root struct with its two 32 byte slices,
+// the bookkeeping done by the underlying LRU cache and the entry tracking
+// whether the root is still live for counting evictions.
+const approxRootEntrySize = 320
+
+// CacheStats returns usage statistics of the cache currently used to store
+// hash tree roots, which helps tuning its size.
+func CacheStats() RootCacheStats {
+	return rootCache.Stats()
+}
+
+// rootCache is the cache used by the tree hasher. It defaults to the internal
+// hashCache and can be replaced with SetRootCache.
+var rootCache RootCache = hashCache
+
+// SetRootCache allows to programmatically replace the cache used to store hash
+// tree roots. Passing nil restores the default internal cache.
+func SetRootCache(cache RootCache) {
+	if cache == nil {
+		rootCache = hashCache
+		return
+	}
+	rootCache = cache
+}
+
 // hashCacheS struct with one queue for looking up by hash.
 type hashCacheS struct {
 	hashCache *ccache.Cache
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	// live maps the keys of the cache to the roots last stored under them, telling
+	// the roots the cache prunes apart from those replaced or deleted.
+	live sync.Map
 }
 
 // root specifies the hash of data in a struct
@@ -47,9 +106,14 @@ type root struct {
 // newHashCache creates a new hash cache for storing/accessing root hashes from
 // memory.
 func newHashCache(maxCacheSize int64) *hashCacheS {
-	return &hashCacheS{
-		hashCache: ccache.New(ccache.Configure().MaxSize(maxCacheSize)),
-	}
+	b := &hashCacheS{}
+	b.hashCache = ccache.New(ccache.Configure().MaxSize(maxCacheSize).OnDelete(func(item *ccache.Item) {
+		// Replaced and deleted roots are no longer the live root of their key.
+		if r, ok := item.Value().(*root); ok && b.live.CompareAndDelete(string(r.Hash), r) {
+			atomic.AddUint64(&b.evictions, 1)
+		}
+	}))
+	return b
 }
 
 // RootByEncodedHash fetches Root by the encoded hash of the object. Returns true with a
@@ -57,9 +121,11 @@ func newHashCache(maxCacheSize int64) *hashCacheS {
 func (b *hashCacheS) RootByEncodedHash(h []byte) (bool, *root, error) {
 	item := b.hashCache.Get(string(h))
 	if item == nil {
+		atomic.AddUint64(&b.misses, 1)
 		hashCacheMiss.Inc()
 		return false, nil, nil
 	}
+	atomic.AddUint64(&b.hits, 1)
 	hashCacheHit.Inc()
 	hInfo, ok := item.Value().(*root)
 	if !ok {
@@ -69,37 +135,66 @@ func (b *hashCacheS) RootByEncodedHash(h []byte) (bool, *root, error) {
 	return true, hInfo, nil
 }
 
-func (b *hashCacheS) lookup(
+// Get fetches the root stored under the encoded hash of an object.
+func (b *hashCacheS) Get(key []byte) ([32]byte, bool) {
+	exists, fetchedInfo, err := b.RootByEncodedHash(key)
+	if err != nil || !exists {
+		return [32]byte{}, false
+	}
+	return toBytes32(fetchedInfo.MerkleRoot), true
+}
+
+// Put stores a root under the encoded hash of an object.
+func (b *hashCacheS) Put(key []byte, root [32]byte) {
+	// AddRoot never fails, see its implementation.
+	// #nosec G104
+	b.AddRoot(key, root[:])
+}
+
+// Delete removes the root stored under the encoded hash of an object.
+func (b *hashCacheS) Delete(key []byte) {
+	b.live.Delete(string(key))
+	b.hashCache.Delete(string(key))
+	hashCacheSize.Set(float64(b.hashCache.ItemCount()))
+}
+
+// Stats reports the hits, misses, evictions and size of the cache.
+func (b *hashCacheS) Stats() RootCacheStats {
+	entries := uint64(b.hashCache.ItemCount())
+	return RootCacheStats{
+		Hits:         atomic.LoadUint64(&b.hits),
+		Misses:       atomic.LoadUint64(&b.misses),
+		Evictions:    atomic.LoadUint64(&b.evictions),
+		Entries:      entries,
+		ApproxMemory: entries * approxRootEntrySize,
+	}
+}
+
+// hashWithCache looks up the root of a value in the root cache, computing
+// and storing it using the given hasher if it is not present yet.
+func hashWithCache(
+	h *Hasher,
 	rval reflect.Value,
 	hasher hasher,
 	marshaler marshaler,
 	maxCapacity uint64,
 ) ([32]byte, error) {
-	cacheKey, err := generateCacheKey(rval, marshaler, maxCapacity)
-	if err != nil {
-		return [32]byte{}, err
+	// The cache only holds sha256 roots.
+	if h.fn.backend == CustomHash || hintsFor(rval.Type()).NoCache {
+		return hasher(h, rval, maxCapacity)
 	}
-	// We take the hash of the generated cache key.
-	h, _ := highwayhash.New(make([]byte, 32))
-	if _, err := io.Copy(h, bytes.NewBuffer(cacheKey)); err != nil {
-		return [32]byte{}, err
-	}
-	hs := h.Sum(nil)
-	exists, fetchedInfo, err := b.RootByEncodedHash(hs)
+	hs, err := rootCacheKey(rval, marshaler, maxCapacity)
 	if err != nil {
 		return [32]byte{}, err
 	}
-	if exists {
-		return toBytes32(fetchedInfo.MerkleRoot), nil
-	}
-	res, err := hasher(rval, maxCapacity)
-	if err != nil {
-		return [32]byte{}, err
+	if r, ok := rootCache.Get(hs); ok {
+		return r, nil
 	}
-	err = b.AddRoot(hs, res[:])
+	res, err := hasher(h, rval, maxCapacity)
 	if err != nil {
 		return [32]byte{}, err
 	}
+	rootCache.Put(hs, res)
 	return res, nil
 }
 
@@ -112,34 +207,85 @@ func (b *hashCacheS) AddRoot(h []byte, rootB []byte) error {
 		Hash:       h,
 		MerkleRoot: rootB,
 	}
-	b.hashCache.Set(string(h), mr, time.Hour)
+	key := string(h)
+	b.live.Store(key, mr)
+	b.hashCache.Set(key, mr, time.Hour)
 	hashCacheSize.Set(float64(b.hashCache.ItemCount()))
 	return nil
 }
 
+// rootCacheKey returns the key a value's root is stored under in the root cache.
+func rootCacheKey(rval reflect.Value, marshaler marshaler, maxCapacity uint64) ([]byte, error) {
+	cacheKey, err := generateCacheKey(rval, marshaler, maxCapacity)
+	if err != nil {
+		return nil, err
+	}
+	// We take the hash of the generated cache key.
+	h, _ := highwayhash.New(make([]byte, 32))
+	if _, err := io.Copy(h, bytes.NewBuffer(cacheKey)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// CacheEntry associates a value with its known hash tree root.
+type CacheEntry struct {
+	Val  interface{}
+	Root [32]byte
+}
+
+// PrimeCache pre-populates the root cache with known roots, so that services
+// persisting object to root mappings can skip re-hashing their hot set after a
+// restart. The roots are trusted as given: priming the cache with a wrong root
+// makes HashTreeRoot return that root for the value.
+//
+//  entries := []CacheEntry{{Val: block, Root: storedBlockRoot}}
+//  if err := PrimeCache(entries); err != nil {
+//      return fmt.Errorf("failed to prime cache: %v", err)
+//  }
+func PrimeCache(entries []CacheEntry) error {
+	for i, entry := range entries {
+		if entry.Val == nil {
+			return fmt.Errorf("entry %d: untyped nil is not supported", i)
+		}
+		rval := reflect.ValueOf(entry.Val)
+		sszUtils, err := cachedSSZUtils(rval.Type())
+		if err != nil {
+			return fmt.Errorf("entry %d: could not get ssz utils for type: %v: %w", i, rval.Type(), err)
+		}
+		key, err := rootCacheKey(rval, sszUtils.marshaler, 0 /* max capacity */)
+		if err != nil {
+			return fmt.Errorf("entry %d: could not generate cache key for type: %v: %w", i, rval.Type(), err)
+		}
+		rootCache.Put(key, entry.Root)
+	}
+	return nil
+}
+
 func generateCacheKey(v reflect.Value, marshaler marshaler, maxCapacity uint64) ([]byte, error) {
 	encodedLength := make([]byte, 8)
 	encodedCapacity := make([]byte, 8)
+	encodedGeneration := make([]byte, 8)
 	binary.LittleEndian.PutUint64(encodedCapacity, maxCapacity)
+	binary.LittleEndian.PutUint64(encodedGeneration, hashGeneration)
 	var buf []byte
 	var err error
-	if v.Kind() == reflect.Struct {
+	if v.Kind() == reflect.Struct && lookupCodec(v.Type()) == nil {
 		buf, err = generateStructHashKey(v)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		if v.Kind() != reflect.Struct || (v.Kind() == reflect.Ptr && !v.IsNil()) {
-			buf = make([]byte, determineSize(v))
-			if _, err := marshaler(v, buf, 0); err != nil {
-				return nil, err
-			}
-			binary.LittleEndian.PutUint64(encodedLength, uint64(len(buf)))
+		buf = make([]byte, determineSize(v))
+		if _, err := marshaler(v, buf, 0); err != nil {
+			return nil, err
 		}
+		binary.LittleEndian.PutUint64(encodedLength, uint64(len(buf)))
 		buf = append(buf, []byte(v.Type().String())...)
 	}
 	lengthMetadata := append(encodedCapacity, encodedLength...)
 	buf = append(buf, lengthMetadata...)
+	buf = append(buf, encodedGeneration...)
 	return buf, nil
 }
 
@@ -149,12 +295,33 @@ func generateStructHashKey(v reflect.Value) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	var name string
+	defer annotatePanic(&name)
 	var buf bytes.Buffer
 	buf.WriteString(t.String())
 	for i := 0; i < len(fields); i++ {
 		f := fields[i]
+		name = f.name
 		buf.WriteString(f.typ.String())
 		buf.WriteString(f.name)
+		if f.opaque != nil {
+			encoded, err := f.opaque.Encode(v.Field(f.index).Interface())
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(fmt.Sprintf("%d", f.capacity))
+			writeKeyPart(&buf, encoded)
+			continue
+		}
+		// The values of registered types may hide their contents behind pointers.
+		if codec := lookupCodec(f.typ); codec != nil {
+			encoded, err := codec.encode(v.Field(f.index))
+			if err != nil {
+				return nil, err
+			}
+			writeKeyPart(&buf, encoded)
+			continue
+		}
 		if f.typ.Kind() == reflect.Array {
 			buf.WriteString(fmt.Sprintf("%d", f.typ.Len()))
 		}
@@ -162,8 +329,28 @@ func generateStructHashKey(v reflect.Value) ([]byte, error) {
 			buf.WriteString(fmt.Sprintf("%d", v.Field(f.index).Len()))
 		}
 		buf.WriteString(fmt.Sprintf("%d", f.capacity))
-		buf.WriteString(fmt.Sprintf("%v", v.Field(f.index).Interface()))
+		// Fields are keyed by their encoding rather than by how they print, as
+		// pointers print as their addresses, which decoding may reuse for other
+		// values, and strings print without quotes nor delimiters.
+		fieldVal := v.Field(f.index)
+		encoded := make([]byte, determineSize(fieldVal))
+		if _, err := f.sszUtils.marshaler(fieldVal, encoded, 0); err != nil {
+			return nil, fmt.Errorf("failed to hash field %s of struct: %w", f.name, err)
+		}
+		writeKeyPart(&buf, encoded)
 	}
-	buf.WriteString(string(len(fields)))
+	var count [8]byte
+	binary.LittleEndian.PutUint64(count[:], uint64(len(fields)))
+	buf.Write(count[:])
 	return buf.Bytes(), nil
 }
+
+// writeKeyPart writes part to the cache key buf, prefixed with its length so that
+// the parts of consecutive fields cannot run into each other.
+func writeKeyPart(buf *bytes.Buffer, part []byte) {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(part)))
+	buf.Write(length[:])
+	buf.Write(part)
+}
+
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -97,6 +98,208 @@ func TestCache_byHash(t *testing.T) {
 	}
 }
 
+type mapRootCache struct {
+	roots map[string][32]byte
+	stats RootCacheStats
+}
+
+func (m *mapRootCache) Get(key []byte) ([32]byte, bool) {
+	r, ok := m.roots[string(key)]
+	if ok {
+		m.stats.Hits++
+	} else {
+		m.stats.Misses++
+	}
+	return r, ok
+}
+
+func (m *mapRootCache) Put(key []byte, root [32]byte) {
+	m.roots[string(key)] = root
+}
+
+func (m *mapRootCache) Delete(key []byte) {
+	delete(m.roots, string(key))
+}
+
+func (m *mapRootCache) Stats() RootCacheStats {
+	m.stats.Entries = uint64(len(m.roots))
+	return m.stats
+}
+
+func TestSetRootCache(t *testing.T) {
+	useCache = true
+	custom := &mapRootCache{roots: make(map[string][32]byte)}
+	SetRootCache(custom)
+	defer SetRootCache(nil)
+
+	item := fork{Epoch: 5}
+	want, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.Stats().Entries == 0 {
+		t.Fatal("Expected custom cache to be populated")
+	}
+	got, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Expected cached root %#x, got %#x", want, got)
+	}
+	if custom.Stats().Hits == 0 {
+		t.Error("Expected custom cache to be hit")
+	}
+
+	SetRootCache(nil)
+	if rootCache != RootCache(hashCache) {
+		t.Error("Expected nil cache to restore the default cache")
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	useCache = true
+	cache := newHashCache(100000)
+	SetRootCache(cache)
+	defer SetRootCache(nil)
+
+	item := fork{Epoch: 6}
+	if _, err := HashTreeRoot(item); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HashTreeRoot(item); err != nil {
+		t.Fatal(err)
+	}
+	stats := CacheStats()
+	if stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("Expected both hits and misses, got %+v", stats)
+	}
+	if stats.Entries == 0 {
+		t.Errorf("Expected entries in the cache, got %+v", stats)
+	}
+	if stats.ApproxMemory != stats.Entries*approxRootEntrySize {
+		t.Errorf("ApproxMemory = %d, want %d", stats.ApproxMemory, stats.Entries*approxRootEntrySize)
+	}
+	if stats.Evictions != 0 {
+		t.Errorf("Expected no evictions, got %d", stats.Evictions)
+	}
+}
+
+func TestCacheStats_Evictions(t *testing.T) {
+	cache := newHashCache(100000)
+	for i := 0; i < 10; i++ {
+		cache.Put([]byte("key"), [32]byte{byte(i)})
+	}
+	if stats := cache.Stats(); stats.Evictions != 0 || stats.Entries != 1 {
+		t.Errorf("Expected overwritten roots not to be evictions, got %+v", stats)
+	}
+	// Roots put concurrently under the same key replace each other too.
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Put([]byte("concurrent"), [32]byte{1})
+		}()
+	}
+	wg.Wait()
+	if stats := cache.Stats(); stats.Evictions != 0 || stats.Entries != 2 {
+		t.Errorf("Expected concurrently overwritten roots not to be evictions, got %+v", stats)
+	}
+	if root, ok := cache.Get([]byte("key")); !ok || root != [32]byte{9} {
+		t.Errorf("Expected the last root put, received %#x", root)
+	}
+	cache.Delete([]byte("key"))
+	if stats := cache.Stats(); stats.Evictions != 0 {
+		t.Errorf("Expected deleted roots not to be evictions, got %+v", stats)
+	}
+
+	// Caches prune their least recently used roots, asynchronously, once they exceed
+	// their size.
+	cache = newHashCache(1)
+	cache.Put([]byte("first"), [32]byte{1})
+	cache.Put([]byte("second"), [32]byte{2})
+	for deadline := time.Now().Add(time.Second); cache.Stats().Evictions == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected pruned roots to be evictions, got %+v", cache.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type reusedInner struct {
+	A uint64
+}
+
+type reusedOuter struct {
+	Items []*reusedInner `ssz-max:"4"`
+}
+
+func TestCache_DecodeIntoReusedTarget(t *testing.T) {
+	useCache = true
+	SetRootCache(newHashCache(100000))
+	defer SetRootCache(nil)
+	a := reusedOuter{Items: []*reusedInner{{A: 1}, {A: 2}}}
+	b := reusedOuter{Items: []*reusedInner{{A: 3}, {A: 2}}}
+	useCache = false
+	want, err := HashTreeRoot(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useCache = true
+
+	// Decoding b into the target of a reuses the pointers of its elements.
+	target := &reusedOuter{}
+	for _, val := range []reusedOuter{a, b} {
+		encoded, err := Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Unmarshal(encoded, target); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := HashTreeRoot(*target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := HashTreeRoot(*target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x of the value decoded last, received %#x", want, root)
+	}
+}
+
+func TestPrimeCache(t *testing.T) {
+	useCache = true
+	SetRootCache(newHashCache(100000))
+	defer SetRootCache(nil)
+
+	item := fork{Epoch: 7}
+	// A root which cannot be computed by hashing lets us verify it was served from the cache.
+	primed := [32]byte{1, 2, 3}
+	if err := PrimeCache([]CacheEntry{{Val: item, Root: primed}}); err != nil {
+		t.Fatal(err)
+	}
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != primed {
+		t.Errorf("Expected primed root %#x, got %#x", primed, root)
+	}
+	if stats := CacheStats(); stats.Misses != 0 {
+		t.Errorf("Expected no cache misses, got %d", stats.Misses)
+	}
+}
+
+func TestPrimeCache_NilValue(t *testing.T) {
+	if err := PrimeCache([]CacheEntry{{Root: [32]byte{1}}}); err == nil {
+		t.Error("Expected priming the cache with a nil value to fail")
+	}
+}
+
 func BenchmarkHashWithoutCache(b *testing.B) {
 	useCache = false
 	First := generateJunkObject(100)
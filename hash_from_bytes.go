@@ -0,0 +1,282 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// HashTreeRootFromBytes determines the root hash of the value of type typ encoded in
+// data, merkleizing the encoding in place rather than decoding it into a value first.
+// The encoding is checked as Validate does, and its root is the one HashTreeRoot
+// returns for the decoded value, which lets archival pipelines storing raw SSZ compute
+// roots without allocating the values they hold.
+//
+//  root, err := HashTreeRootFromBytes(encodedBlock, reflect.TypeOf(BeaconBlock{}))
+//  if err != nil {
+//      return fmt.Errorf("failed to compute root: %v", err)
+//  }
+func HashTreeRootFromBytes(data []byte, typ reflect.Type) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
+	if typ == nil {
+		return [32]byte{}, errors.New("untyped nil is not supported")
+	}
+	if err := Validate(data, typ); err != nil {
+		return [32]byte{}, err
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	root, err := hashEncoding(h, data, typ, 0 /* max capacity */, nil)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("could not tree hash type: %v: %w", typ, err)
+	}
+	return root, nil
+}
+
+// hashEncoding returns the root of data, the valid encoding of a value of typ, as the
+// hasher of typ computes it with maxCapacity, or the nested hasher of typ does when the
+// lists nested within the value are bounded by innerLimits.
+func hashEncoding(h *Hasher, data []byte, typ reflect.Type, maxCapacity uint64, innerLimits []uint64) ([32]byte, error) {
+	kind := typ.Kind()
+	switch {
+	case lookupCodec(typ) != nil:
+		// Registered types can only be hashed by their codec, out of a decoded value.
+		utils, err := cachedSSZUtils(typ)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		val := reflect.New(typ).Elem()
+		if _, err := utils.unmarshaler(nil, data, val, 0); err != nil {
+			return [32]byte{}, err
+		}
+		return utils.hasher(h, val, maxCapacity)
+	case kind == reflect.Ptr:
+		return hashEncoding(h, data, typ.Elem(), maxCapacity, innerLimits)
+	case len(innerLimits) > 0:
+		return hashNestedEncoding(h, data, typ, maxCapacity, innerLimits)
+	case isBitvector(typ):
+		length, _ := bitvectorLength(typ)
+		return h.merkleizeBytes(data, (length+255)/256, true /* has limit */)
+	case isBasicType(kind) || isBasicTypeArray(typ, kind):
+		// Basic values and vectors of basic values are encoded as they are packed
+		// into chunks.
+		return h.merkleizeBytes(data, 1, false /* has limit */)
+	case kind == reflect.String:
+		return hashEncoding(h, data, byteSliceType, maxCapacity, nil)
+	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
+		elemSize := staticFixedSize(typ.Elem())
+		limit := (maxCapacity*elemSize + 31) / 32
+		if limit == 0 {
+			limit = 1
+		}
+		merkleRoot, err := h.merkleizeBytes(data, limit, true /* has limit */)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		output := lengthChunk(uint64(len(data)) / elemSize)
+		return h.mixInLength(merkleRoot, output[:]), nil
+	case kind == reflect.Array:
+		roots, _, err := hashElementEncodings(h, data, typ.Elem(), typ.Len(), 0, nil)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		defer h.putBuffer(roots)
+		return h.merkleizeBytes(*roots, uint64(typ.Len()), true /* has limit */)
+	case kind == reflect.Slice:
+		roots, count, err := hashElementEncodings(h, data, typ.Elem(), -1, 0, nil)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		defer h.putBuffer(roots)
+		limit := maxCapacity
+		if isBasicTypeArray(typ.Elem(), typ.Elem().Kind()) {
+			// Lists of vectors of basic values are hashed as lists of basic
+			// values are, which are bounded by a single chunk at least.
+			if limit == 0 {
+				limit = 1
+			}
+		} else if limit == 0 {
+			limit = count
+		}
+		merkleRoot, err := h.merkleizeBytes(*roots, limit, true /* has limit */)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		output := lengthChunk(count)
+		return h.mixInLength(merkleRoot, output[:]), nil
+	case kind == reflect.Struct:
+		return hashStructEncoding(h, data, typ)
+	default:
+		return [32]byte{}, errorf(ErrUnsupportedType, "type %v is not hashable", typ)
+	}
+}
+
+// hashNestedEncoding hashes data, the encoding of a list or vector of typ, as the
+// hasher returned by makeNestedHasher for innerLimits does.
+func hashNestedEncoding(h *Hasher, data []byte, typ reflect.Type, maxCapacity uint64, innerLimits []uint64) ([32]byte, error) {
+	length := -1
+	if typ.Kind() == reflect.Array {
+		length = typ.Len()
+	}
+	roots, count, err := hashElementEncodings(h, data, typ.Elem(), length, innerLimits[0], innerLimits[1:])
+	if err != nil {
+		return [32]byte{}, err
+	}
+	defer h.putBuffer(roots)
+	if typ.Kind() == reflect.Array {
+		return h.merkleizeBytes(*roots, count, true /* has limit */)
+	}
+	limit := maxCapacity
+	if limit == 0 {
+		limit = count
+	}
+	merkleRoot, err := h.merkleizeBytes(*roots, limit, true /* has limit */)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	output := lengthChunk(count)
+	return h.mixInLength(merkleRoot, output[:]), nil
+}
+
+// hashElementEncodings computes the roots of the elements of elemType encoded in data, a
+// list, or vector of length elements when length is not negative, hashing each of them
+// with maxCapacity and innerLimits. The roots are written next to each other into a
+// scratch buffer of h, which the caller must give back with putBuffer, and returned
+// along with their number.
+func hashElementEncodings(
+	h *Hasher,
+	data []byte,
+	elemType reflect.Type,
+	length int,
+	maxCapacity uint64,
+	innerLimits []uint64,
+) (*[]byte, uint64, error) {
+	variable := isVariableSizeType(elemType)
+	elemSize := uint64(0)
+	if !variable {
+		elemSize = staticFixedSize(elemType)
+	}
+	count := uint64(length)
+	if length < 0 {
+		count = 0
+		if variable && len(data) > 0 {
+			count = uint64(binary.LittleEndian.Uint32(data)) / BytesPerLengthOffset
+		} else if !variable && elemSize > 0 {
+			count = uint64(len(data)) / elemSize
+		}
+	}
+	roots := h.getBuffer(count * 32)
+	for i := uint64(0); i < count; i++ {
+		elem, err := elementEncoding(data, i, count, elemSize, variable)
+		if err != nil {
+			h.putBuffer(roots)
+			return nil, 0, err
+		}
+		r, err := hashEncoding(h, elem, elemType, maxCapacity, innerLimits)
+		if err != nil {
+			h.putBuffer(roots)
+			return nil, 0, fmt.Errorf("element %d: %w", i, err)
+		}
+		copy((*roots)[i*32:], r[:])
+	}
+	return roots, count, nil
+}
+
+// elementEncoding returns the encoding of the element i of the count elements encoded
+// in data, which are either variable-size or elemSize bytes each.
+func elementEncoding(data []byte, i uint64, count uint64, elemSize uint64, variable bool) ([]byte, error) {
+	if !variable {
+		return segment(data, i*elemSize, (i+1)*elemSize)
+	}
+	start, err := readOffset(data, i*BytesPerLengthOffset, 0)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(len(data))
+	if i+1 < count {
+		if end, err = readOffset(data, (i+1)*BytesPerLengthOffset, 0); err != nil {
+			return nil, err
+		}
+	}
+	return segment(data, start, end)
+}
+
+// hashStructEncoding hashes data, the encoding of a container of type typ, as the
+// hasher of its fields does.
+func hashStructEncoding(h *Hasher, data []byte, typ reflect.Type) ([32]byte, error) {
+	fields, err := structFields(typ)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	roots := h.getBuffer(uint64(len(fields)) * 32)
+	defer h.putBuffer(roots)
+	index := uint64(0)
+	for i, f := range fields {
+		start, end := index, index
+		if !isVariableSizeType(f.typ) {
+			end += staticFixedSize(f.typ)
+			index = end
+		} else {
+			if start, err = readOffset(data, index, 0); err != nil {
+				return [32]byte{}, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			index += BytesPerLengthOffset
+			// The field ends where the next variable-size field starts.
+			end = uint64(len(data))
+			next := index
+			for _, g := range fields[i+1:] {
+				if isVariableSizeType(g.typ) {
+					if end, err = readOffset(data, next, 0); err != nil {
+						return [32]byte{}, fmt.Errorf("field %s: %w", g.name, err)
+					}
+					break
+				}
+				next += staticFixedSize(g.typ)
+			}
+		}
+		fieldData, err := segment(data, start, end)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("field %s: %w", f.name, err)
+		}
+		var r [32]byte
+		if f.progressive {
+			// Progressive fields are decoded, then hashed by their own utils.
+			fieldVal := reflect.New(f.typ).Elem()
+			if fieldVal.Kind() == reflect.Ptr {
+				instantiateConcreteTypeForElement(nil, fieldVal, f.typ.Elem())
+			}
+			if _, err = f.sszUtils.unmarshaler(nil, fieldData, fieldVal, 0); err == nil {
+				r, err = f.sszUtils.hasher(h, fieldVal, f.capacity)
+			}
+		} else if isBitlist(f.typ) {
+			r, err = hashBitlistEncoding(h, fieldData, f.capacity)
+		} else {
+			r, err = hashEncoding(h, fieldData, f.typ, f.capacity, f.innerLimits)
+		}
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("failed to hash field %s of struct: %w", f.name, err)
+		}
+		copy((*roots)[i*32:], r[:])
+	}
+	return h.merkleizeBytes(*roots, uint64(len(fields)), true /* has limit */)
+}
+
+// hashBitlistEncoding hashes data, the encoding of a bitlist holding at most maxCapacity
+// bits, as bitlistHasher does.
+func hashBitlistEncoding(h *Hasher, data []byte, maxCapacity uint64) ([32]byte, error) {
+	bits := bitfield.Bitlist(data)
+	packed := bits.Bytes()
+	if len(packed) == 0 {
+		// Bitlists without any bit set are packed into a single zero chunk.
+		packed = zeroHashes[0]
+	}
+	merkleRoot, err := h.merkleizeBytes(packed, (maxCapacity+255)/256, true /* has limit */)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	output := lengthChunk(bits.Len())
+	return h.mixInLength(merkleRoot, output[:]), nil
+}
@@ -0,0 +1,94 @@
+package ssz
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+func TestHashTreeRootFromBytes(t *testing.T) {
+	tests := []interface{}{
+		fork{Epoch: 3, CurrentVersion: [4]byte{1}},
+		nestedLists{
+			Lists:        [][]uint64{{1, 2}, {}, {3}},
+			Transactions: [][]byte{{1, 2, 3}, {}},
+			Vectors:      [][2][]uint16{{{1}, {2, 3}}},
+		},
+		boolBitsContainer{Aggregation: []bool{true, false, true}, Committee: [12]bool{0: true, 11: true}, Slot: 5},
+		packedBitsContainer{Aggregation: bitfield.Bitlist{0x01}, Committee: [2]byte{0x01, 0x0a}},
+		stringContainer{Slot: 5, Name: "héllo", Labels: []string{"a", ""}},
+		opaqueContainer{Slot: 5, Words: opaqueWords{"foo", "bar"}, Root: [32]byte{1}},
+		bigIntPayload{GasUsed: 7, BaseFeePerGas: big.NewInt(9), Value: uint256Int{1}},
+		nilElementsContainer{Inners: []*nilPointerInner{{Slot: 1}}, Leaves: []*limitsLeaf{{Data: []byte{1}}}},
+		&fork{Epoch: 4},
+		[4]uint64{1, 2, 3, 4},
+	}
+	for _, item := range tests {
+		encoded, err := Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := HashTreeRootFromBytes(encoded, reflect.TypeOf(item))
+		if err != nil {
+			t.Fatalf("Failed to hash the encoding of %T: %v", item, err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x for %T, received %#x", want, item, root)
+		}
+	}
+}
+
+func TestHashTreeRootFromBytes_RandomValues(t *testing.T) {
+	types := []interface{}{
+		treeContainer{},
+		fastPathContainer{},
+		hintedContainer{},
+		testDepositData{},
+		rawLayoutItem{},
+	}
+	for _, sample := range types {
+		typ := reflect.TypeOf(sample)
+		r := rand.New(rand.NewSource(int64(len(typ.String()))))
+		for i := 0; i < 20; i++ {
+			val := randomValue(r, typ, nil, 0).Interface()
+			encoded, err := Marshal(val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := HashTreeRoot(val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := HashTreeRootFromBytes(encoded, typ)
+			if err != nil {
+				t.Fatalf("Failed to hash the encoding of %+v: %v", val, err)
+			}
+			if root != want {
+				t.Fatalf("Expected root %#x for %+v, received %#x", want, val, root)
+			}
+		}
+	}
+}
+
+func TestHashTreeRootFromBytes_InvalidEncoding(t *testing.T) {
+	encoded, err := Marshal(stringContainer{Name: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The offset of Name points past the end of the encoding.
+	encoded[8] = 0xff
+	if _, err := HashTreeRootFromBytes(encoded, reflect.TypeOf(stringContainer{})); !errors.Is(err, ErrOffset) {
+		t.Errorf("Expected an offset error, received %v", err)
+	}
+	if _, err := HashTreeRootFromBytes(encoded[:4], reflect.TypeOf(fork{})); !errors.Is(err, ErrSize) {
+		t.Errorf("Expected a size error, received %v", err)
+	}
+}
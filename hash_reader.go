@@ -0,0 +1,239 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+)
+
+// readBufferSize is the number of bytes of packed basic elements read from streams
+// at once, a multiple of the size of every basic type and of BytesPerChunk.
+const readBufferSize = 4096
+
+// HashTreeRootReader determines the root hash of the list of at most limit elements of
+// type elemType encoded in the stream r, merkleizing the elements as they are read rather
+// than loading the whole list first. Only one element is held at a time, along with the
+// offsets of variable-size elements, so that multi-gigabyte lists can be hashed in
+// bounded memory:
+//
+//  f, err := os.Open("balances.ssz")
+//  if err != nil {
+//      return err
+//  }
+//  defer f.Close()
+//  root, err := HashTreeRootReader(bufio.NewReader(f), reflect.TypeOf(uint64(0)), validatorRegistryLimit)
+//
+// The stream holds the encoding of the list as Marshal produces it, and its root is the
+// one HashTreeRootWithCapacity returns for the decoded list with capacity limit. The
+// elements are checked as Validate does, and an error wrapping ErrMaxLength is returned
+// as soon as the stream holds more than limit elements. Variable-size elements are read
+// up to the maximum size of their type, which their ssz-max tags must bound.
+func HashTreeRootReader(r io.Reader, elemType reflect.Type, limit uint64) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
+	if elemType == nil {
+		return [32]byte{}, errors.New("untyped nil is not supported")
+	}
+	if limit == 0 {
+		return [32]byte{}, errors.New("lists read from streams require a limit")
+	}
+	if _, err := cachedSSZUtils(elemType); err != nil {
+		return [32]byte{}, err
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	basic := isBasicType(elemType.Kind()) && lookupCodec(elemType) == nil
+	chunkLimit := limit
+	if basic {
+		chunkLimit = (limit*staticFixedSize(elemType) + 31) / 32
+	}
+	stream := h.newChunkStream(chunkLimit)
+	var length uint64
+	switch {
+	case basic:
+		length, err = hashBasicStream(&stream, r, elemType, limit)
+	case !isVariableSizeType(elemType):
+		length, err = hashFixedSizeStream(&stream, r, elemType, limit)
+	default:
+		length, err = hashVariableSizeStream(&stream, r, elemType, limit)
+	}
+	// The root is computed regardless, as it gives the stream back to h.
+	merkleRoot := stream.root()
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("could not tree hash list of type: %v: %w", elemType, err)
+	}
+	output := lengthChunk(length)
+	return h.mixInLength(merkleRoot, output[:]), nil
+}
+
+// hashBasicStream pushes the chunks the basic elements of r are packed into, as they
+// are encoded, to stream, and returns the number of elements.
+func hashBasicStream(stream *chunkStream, r io.Reader, elemType reflect.Type, limit uint64) (uint64, error) {
+	elemSize := staticFixedSize(elemType)
+	buf := stream.h.getBuffer(readBufferSize)
+	defer stream.h.putBuffer(buf)
+	size := uint64(0)
+	for {
+		n, err := io.ReadFull(r, *buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("failed to read element %d: %w", size/elemSize, err)
+		}
+		data := (*buf)[:n]
+		if elemType.Kind() == reflect.Bool {
+			for i, b := range data {
+				if b > 1 {
+					return 0, errorf(ErrInvalidValue, "element %d: expected 0 or 1 but received %d", size+uint64(i), b)
+				}
+			}
+		}
+		size += uint64(n)
+		if size/elemSize > limit {
+			return 0, errorf(ErrMaxLength, "list length exceeds max capacity %d", limit)
+		}
+		for i := 0; i < n; i += BytesPerChunk {
+			// The length of the list bounds its chunks.
+			_ = stream.push(toBytes32(data[i:]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	if size%elemSize != 0 {
+		return 0, errorf(ErrSize, "list of %d bytes is not a multiple of its element size %d", size, elemSize)
+	}
+	return size / elemSize, nil
+}
+
+// hashFixedSizeStream pushes the roots of the fixed-size composite elements of r to
+// stream, and returns the number of elements.
+func hashFixedSizeStream(stream *chunkStream, r io.Reader, elemType reflect.Type, limit uint64) (uint64, error) {
+	elemSize := staticFixedSize(elemType)
+	if elemSize == 0 {
+		return 0, errorf(ErrUnsupportedType, "lists of empty elements cannot be read from streams")
+	}
+	buf := stream.h.getBuffer(elemSize)
+	defer stream.h.putBuffer(buf)
+	for count := uint64(0); ; count++ {
+		_, err := io.ReadFull(r, *buf)
+		switch {
+		case err == io.EOF:
+			return count, nil
+		case err == io.ErrUnexpectedEOF:
+			return 0, errorf(ErrSize, "stream ends within the %d bytes of element %d", elemSize, count)
+		case err != nil:
+			return 0, fmt.Errorf("failed to read element %d: %w", count, err)
+		case count == limit:
+			return 0, errorf(ErrMaxLength, "list length exceeds max capacity %d", limit)
+		}
+		if err := hashStreamedElement(stream, *buf, elemType, count); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// hashVariableSizeStream pushes the roots of the variable-size elements of r, which are
+// found through the table of offsets starting the encoding, to stream, and returns the
+// number of elements.
+func hashVariableSizeStream(stream *chunkStream, r io.Reader, elemType reflect.Type, limit uint64) (uint64, error) {
+	offset := make([]byte, BytesPerLengthOffset)
+	if _, err := io.ReadFull(r, offset); err == io.EOF {
+		return 0, nil
+	} else if err == io.ErrUnexpectedEOF {
+		return 0, errorf(ErrSize, "list is too short to contain an offset")
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read offsets: %w", err)
+	}
+	firstOffset := uint64(binary.LittleEndian.Uint32(offset))
+	if firstOffset == 0 || firstOffset%BytesPerLengthOffset != 0 {
+		return 0, errorf(ErrOffset, "first offset %d is not a non-zero multiple of %d", firstOffset, BytesPerLengthOffset)
+	}
+	count := firstOffset / BytesPerLengthOffset
+	if count > limit {
+		return 0, errorf(ErrMaxLength, "list length %d exceeds max capacity %d", count, limit)
+	}
+	desc, err := Describe(elemType)
+	if err != nil {
+		return 0, err
+	}
+	maxElemSize := desc.MaxSize
+	if maxElemSize == 0 {
+		return 0, errorf(ErrUnsupportedType, "elements of type %v have no maximum size to be read from streams within", elemType)
+	}
+	offsets, err := readOffsets(r, offset, firstOffset)
+	if err != nil {
+		return 0, err
+	}
+	currentOffset := firstOffset
+	for i := uint64(0); i < count; i++ {
+		// The last element extends up to the end of the stream.
+		elemReader := r
+		nextOffset := uint64(0)
+		if i+1 < count {
+			nextIndex := (i + 1) * BytesPerLengthOffset
+			nextOffset = uint64(binary.LittleEndian.Uint32(offsets[nextIndex : nextIndex+BytesPerLengthOffset]))
+			if nextOffset < currentOffset {
+				return 0, errorf(ErrOffset, "offset %d of element %d is out of bounds", nextOffset, i+1)
+			}
+			if nextOffset-currentOffset > maxElemSize {
+				return 0, errorf(ErrOffset, "offset %d of element %d is out of bounds of elements of at most %d bytes", nextOffset, i+1, maxElemSize)
+			}
+			elemReader = io.LimitReader(r, int64(nextOffset-currentOffset))
+		} else {
+			elemReader = io.LimitReader(r, int64(maxElemSize)+1)
+		}
+		// Elements are read as they come rather than into buffers of the size their
+		// offsets claim, which the stream may not hold.
+		elem, err := ioutil.ReadAll(elemReader)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read element %d: %w", i, err)
+		}
+		if uint64(len(elem)) > maxElemSize {
+			return 0, errorf(ErrSize, "element %d exceeds the maximum size %d of its type", i, maxElemSize)
+		}
+		if i+1 < count && uint64(len(elem)) != nextOffset-currentOffset {
+			return 0, errorf(ErrOffset, "offset %d of element %d is out of bounds", nextOffset, i+1)
+		}
+		if err := hashStreamedElement(stream, elem, elemType, i); err != nil {
+			return 0, err
+		}
+		currentOffset = nextOffset
+	}
+	return count, nil
+}
+
+// readOffsets reads the table of offsets of size bytes starting a list from r, first
+// being its first offset. The table is read in chunks of readBufferSize bytes, so that
+// its memory grows with the bytes the stream actually holds rather than with the size
+// its first offset claims.
+func readOffsets(r io.Reader, first []byte, size uint64) ([]byte, error) {
+	offsets := append([]byte(nil), first...)
+	for uint64(len(offsets)) < size {
+		n := size - uint64(len(offsets))
+		if n > readBufferSize {
+			n = readBufferSize
+		}
+		start := len(offsets)
+		offsets = append(offsets, make([]byte, n)...)
+		if _, err := io.ReadFull(r, offsets[start:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errorf(ErrOffset, "first offset %d is out of bounds", size)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read offsets: %w", err)
+		}
+	}
+	return offsets, nil
+}
+
+// hashStreamedElement checks data, the encoding of the index-th element of a streamed
+// list, then pushes its root to stream.
+func hashStreamedElement(stream *chunkStream, data []byte, elemType reflect.Type, index uint64) error {
+	if err := validateEncoding(data, elemType, 0, nil); err != nil {
+		return fmt.Errorf("element %d: %w", index, err)
+	}
+	root, err := hashEncoding(stream.h, data, elemType, 0 /* max capacity */, nil)
+	if err != nil {
+		return fmt.Errorf("element %d: %w", index, err)
+	}
+	return stream.push(root)
+}
@@ -0,0 +1,102 @@
+package ssz
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashTreeRootReader(t *testing.T) {
+	balances := make([]uint64, 1000)
+	for i := range balances {
+		balances[i] = uint64(i) * 32000000000
+	}
+	tests := []struct {
+		list  interface{}
+		limit uint64
+	}{
+		{list: balances, limit: 1099511627776},
+		{list: []uint64{}, limit: 16},
+		{list: []bool{true, false, true}, limit: 10},
+		{list: [][32]byte{{1}, {2}, {3}}, limit: 64},
+		{list: []fork{{Epoch: 1}, {CurrentVersion: [4]byte{2}, Epoch: 3}}, limit: 16},
+		{list: []stringContainer{{Slot: 1, Name: "a"}, {Labels: []string{"b", "c"}}, {}}, limit: 8},
+		{list: []stringContainer{}, limit: 8},
+	}
+	for _, tt := range tests {
+		encoded, err := Marshal(tt.list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRootWithCapacity(tt.list, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		elemType := reflect.TypeOf(tt.list).Elem()
+		root, err := HashTreeRootReader(bytes.NewReader(encoded), elemType, tt.limit)
+		if err != nil {
+			t.Fatalf("Failed to hash stream of %T: %v", tt.list, err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x for %T, received %#x", want, tt.list, root)
+		}
+		// Streams returning a byte at a time are read the same way.
+		root, err = HashTreeRootReader(iotest.OneByteReader(bytes.NewReader(encoded)), elemType, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x for %T read a byte at a time, received %#x", want, tt.list, root)
+		}
+	}
+}
+
+func TestHashTreeRootReader_InvalidStream(t *testing.T) {
+	uint64Type := reflect.TypeOf(uint64(0))
+	if _, err := HashTreeRootReader(bytes.NewReader(make([]byte, 32)), uint64Type, 3); !errors.Is(err, ErrMaxLength) {
+		t.Errorf("Expected streams exceeding the limit to fail, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader(make([]byte, 12)), uint64Type, 3); !errors.Is(err, ErrSize) {
+		t.Errorf("Expected a size error, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader([]byte{1, 2}), reflect.TypeOf(false), 3); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected an invalid value error, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader(make([]byte, 20)), reflect.TypeOf(fork{}), 3); !errors.Is(err, ErrSize) {
+		t.Errorf("Expected a size error, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader(make([]byte, 48)), reflect.TypeOf(fork{}), 2); !errors.Is(err, ErrMaxLength) {
+		t.Errorf("Expected streams exceeding the limit to fail, received %v", err)
+	}
+	encoded, err := Marshal([]stringContainer{{Name: "a"}, {Name: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The offset of the second element points past the end of the stream.
+	encoded[4] = 0xff
+	if _, err := HashTreeRootReader(bytes.NewReader(encoded), reflect.TypeOf(stringContainer{}), 8); !errors.Is(err, ErrOffset) {
+		t.Errorf("Expected an offset error, received %v", err)
+	}
+	// The first offset claims a table of offsets of 4 GiB, which the stream does not hold.
+	huge := []byte{0xfc, 0xff, 0xff, 0xff}
+	if _, err := HashTreeRootReader(bytes.NewReader(huge), reflect.TypeOf(stringContainer{}), 1<<40); !errors.Is(err, ErrOffset) {
+		t.Errorf("Expected an offset error, received %v", err)
+	}
+	// The last element extends past the maximum size of its type.
+	encoded, err = Marshal([]stringContainer{{Name: "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded = append(encoded, make([]byte, 1024)...)
+	if _, err := HashTreeRootReader(bytes.NewReader(encoded), reflect.TypeOf(stringContainer{}), 8); !errors.Is(err, ErrSize) {
+		t.Errorf("Expected a size error, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader(encoded), reflect.TypeOf([]byte{}), 8); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected unbounded elements to be rejected, received %v", err)
+	}
+	if _, err := HashTreeRootReader(bytes.NewReader(nil), uint64Type, 0); err == nil {
+		t.Error("Expected streams without limit to fail")
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-
-	"github.com/prysmaticlabs/go-bitfield"
 )
 
 var useCache = true
@@ -33,62 +31,102 @@ func ToggleCache(enableTreeCache bool) {
 //      return fmt.Errorf("failed to compute root: %v", err)
 //  }
 func HashTreeRoot(val interface{}) ([32]byte, error) {
-	if val == nil {
-		return [32]byte{}, errors.New("untyped nil is not supported")
-	}
-	rval := reflect.ValueOf(val)
-	sszUtils, err := cachedSSZUtils(rval.Type())
-	if err != nil {
-		return [32]byte{}, fmt.Errorf("could not get ssz utils for type: %v: %v", rval.Type(), err)
-	}
-	var output [32]byte
-	if useCache {
-		output, err = hashCache.lookup(rval, sszUtils.hasher, sszUtils.marshaler, 0)
-	} else {
-		output, err = sszUtils.hasher(rval, 0)
-	}
-	if err != nil {
-		return [32]byte{}, fmt.Errorf("could not tree hash type: %v: %v", rval.Type(), err)
-	}
-	return output, nil
+	h := acquireHasher()
+	defer releaseHasher(h)
+	return HashTreeRootWith(val, h)
 }
 
 // HashTreeRootWithCapacity determines the root hash of a dynamic list
 // using SSZ's merkleization and applies a max capacity value when computing the root.
-// If the input is not a slice, the function returns an error.
+// Arrays and bitvectors are also accepted: their length is fixed, so the capacity
+// must either be 0 or equal to the length of the value. Bitlists use the capacity
+// as their maximum number of bits, and strings as their maximum number of bytes.
+// Any other input kind returns an error.
 //
 //  accountBalances := []uint64{1, 2, 3, 4}
 //  root, err := HashTreeRootWithCapacity(accountBalances, 100) // Max 100 accounts.
 //  if err != nil {
 //      return fmt.Errorf("failed to compute root: %v", err)
 //  }
-func HashTreeRootWithCapacity(val interface{}, maxCapacity uint64) ([32]byte, error) {
+func HashTreeRootWithCapacity(val interface{}, maxCapacity uint64) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
 	if val == nil {
 		return [32]byte{}, errors.New("untyped nil is not supported")
 	}
 	rval := reflect.ValueOf(val)
-	if rval.Kind() != reflect.Slice {
-		return [32]byte{}, fmt.Errorf("expected slice-kind input, received %v", rval.Kind())
+	h := acquireHasher()
+	defer releaseHasher(h)
+	if b, ok := val.(Bitfield); ok {
+		if isBitlist(rval.Type()) {
+			return bitlistHasher(h, rval, maxCapacity)
+		}
+		if maxCapacity != 0 && maxCapacity != b.Len() {
+			return [32]byte{}, errorf(ErrSize, "capacity %d does not match bitvector length %d", maxCapacity, b.Len())
+		}
+		return bitvectorHasher(h, rval, maxCapacity)
+	}
+	switch rval.Kind() {
+	case reflect.Slice, reflect.String:
+	case reflect.Array:
+		if maxCapacity != 0 && maxCapacity != uint64(rval.Len()) {
+			return [32]byte{}, errorf(ErrSize, "capacity %d does not match array length %d", maxCapacity, rval.Len())
+		}
+		maxCapacity = 0
+	default:
+		return [32]byte{}, errorf(ErrUnsupportedType, "expected slice, string, array or bitfield input, received %v", rval.Kind())
 	}
 	sszUtils, err := cachedSSZUtils(rval.Type())
 	if err != nil {
-		return [32]byte{}, fmt.Errorf("could not get ssz utils for type: %v: %v", rval.Type(), err)
+		return [32]byte{}, fmt.Errorf("could not get ssz utils for type: %v: %w", rval.Type(), err)
 	}
 	var output [32]byte
 	if useCache {
-		output, err = hashCache.lookup(rval, sszUtils.hasher, sszUtils.marshaler, maxCapacity)
+		output, err = hashWithCache(h, rval, sszUtils.hasher, sszUtils.marshaler, maxCapacity)
 	} else {
-		output, err = sszUtils.hasher(rval, maxCapacity)
+		output, err = sszUtils.hasher(h, rval, maxCapacity)
 	}
 	if err != nil {
-		return [32]byte{}, fmt.Errorf("could not tree hash type: %v: %v", rval.Type(), err)
+		return [32]byte{}, fmt.Errorf("could not tree hash type: %v: %w", rval.Type(), err)
 	}
 	return output, nil
 }
 
+// HashTreeRootBatch determines the root hash of every value in vals concurrently,
+// spreading them over up to GOMAXPROCS workers which each reuse a single Hasher.
+// The roots are returned in the same order as the values.
+//
+//  roots, err := HashTreeRootBatch(attestations)
+//  if err != nil {
+//      return fmt.Errorf("failed to compute roots: %v", err)
+//  }
+func HashTreeRootBatch(vals []interface{}) ([][32]byte, error) {
+	roots := make([][32]byte, len(vals))
+	if len(vals) == 0 {
+		return roots, nil
+	}
+	err := parallelFor(nil, len(vals), func(h *Hasher, i int) error {
+		r, err := HashTreeRootWith(vals[i], h)
+		if err != nil {
+			return fmt.Errorf("value %d: %w", i, err)
+		}
+		roots[i] = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
 func makeHasher(typ reflect.Type) (hasher, error) {
 	kind := typ.Kind()
 	switch {
+	case isBitvector(typ):
+		return bitvectorHasher, nil
+	case kind == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
+		return makeByteArrayHasher(typ)
+	case isPackedArray(typ):
+		return makePackedArrayHasher(typ)
 	case isBasicType(kind) || isBasicTypeArray(typ, kind):
 		return makeBasicTypeHasher(typ)
 	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
@@ -101,12 +139,14 @@ func makeHasher(typ reflect.Type) (hasher, error) {
 		return makeCompositeSliceHasher(typ)
 	case kind == reflect.Array:
 		return makeCompositeArrayHasher(typ)
+	case kind == reflect.String:
+		return makeStringHasher()
 	case kind == reflect.Struct:
 		return makeStructHasher(typ)
 	case kind == reflect.Ptr:
 		return makePtrHasher(typ)
 	default:
-		return nil, fmt.Errorf("type %v is not hashable", typ)
+		return nil, errorf(ErrUnsupportedType, "type %v is not hashable", typ)
 	}
 }
 
@@ -115,31 +155,76 @@ func makeBasicTypeHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		buf := make([]byte, determineSize(val))
-		if _, err = utils.marshaler(val, buf, 0); err != nil {
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		// We serialize the value into a zero-padded buffer, which is then
+		// directly split into chunks.
+		buf := h.getBuffer(paddedSize(determineSize(val)))
+		defer h.putBuffer(buf)
+		if _, err := utils.marshaler(val, *buf, 0); err != nil {
 			return [32]byte{}, err
 		}
-		chunks, err := pack([][]byte{buf})
-		if err != nil {
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		*chunks = chunkify(*chunks, *buf)
+		return h.merkleize(*chunks, 1, false /* has limit */)
+	}
+	return hasher, nil
+}
+
+// makeByteArrayHasher hashes vectors of bytes, such as public keys, signatures or blobs,
+// by merkleizing the bytes backing their values in place when they can be reached, and
+// a copy of them otherwise.
+func makeByteArrayHasher(typ reflect.Type) (hasher, error) {
+	size := uint64(typ.Len())
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		// Byte slices sized by an ssz-size tag are hashed as the vector of their type.
+		if data, ok := bytesOf(val); ok && uint64(len(data)) == size {
+			return h.merkleizeBytes(data, 1, false /* has limit */)
+		}
+		buf := h.getBuffer(size)
+		defer h.putBuffer(buf)
+		if val.Kind() == reflect.Slice {
+			copy(*buf, val.Bytes())
+		} else if _, err := marshalByteArray(val, *buf, 0); err != nil {
 			return [32]byte{}, err
 		}
-		return bitwiseMerkleize(chunks, 1, false /* has limit */)
+		return h.merkleizeBytes(*buf, 1, false /* has limit */)
 	}
 	return hasher, nil
 }
 
-func bitlistHasher(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+// makePackedArrayHasher hashes vectors of uint16, uint32 or uint64 by packing
+// their elements straight into zero-padded chunks.
+func makePackedArrayHasher(typ reflect.Type) (hasher, error) {
+	elemSize := staticFixedSize(typ.Elem())
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		buf := h.getBuffer(paddedSize(uint64(val.Len()) * elemSize))
+		defer h.putBuffer(buf)
+		putPacked(*buf, val, elemSize)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		*chunks = chunkify(*chunks, *buf)
+		return h.merkleize(*chunks, 1, false /* has limit */)
+	}
+	return hasher, nil
+}
+
+// bitlistHasher hashes a bitlist holding at most maxCapacity bits, failing on bitlists
+// without delimiter bit or exceeding their capacity. Nil bitlists hash as empty ones.
+func bitlistHasher(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
 	limit := (maxCapacity + 255) / 256
 	if val.IsNil() {
 		length := make([]byte, 32)
-		merkleRoot, err := bitwiseMerkleize([][]byte{}, limit, true /* has limit */)
+		merkleRoot, err := h.merkleize([][]byte{}, limit, true /* has limit */)
 		if err != nil {
 			return [32]byte{}, err
 		}
-		return mixInLength(merkleRoot, length), nil
+		return h.mixInLength(merkleRoot, length), nil
+	}
+	if err := validateBitlist(val.Bytes(), maxCapacity); err != nil {
+		return [32]byte{}, err
 	}
-	bfield := val.Interface().(bitfield.Bitlist)
+	bfield := val.Interface().(Bitfield)
 	chunks, err := pack([][]byte{bfield.Bytes()})
 	if err != nil {
 		return [32]byte{}, err
@@ -148,11 +233,57 @@ func bitlistHasher(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
 	binary.Write(buf, binary.LittleEndian, bfield.Len())
 	output := make([]byte, 32)
 	copy(output, buf.Bytes())
-	merkleRoot, err := bitwiseMerkleize(chunks, limit, true /* has limit */)
+	merkleRoot, err := h.merkleize(chunks, limit, true /* has limit */)
 	if err != nil {
 		return [32]byte{}, err
 	}
-	return mixInLength(merkleRoot, output), nil
+	return h.mixInLength(merkleRoot, output), nil
+}
+
+// bitvectorHasher hashes a bitfield of fixed length. Unlike bitlists, a bitvector
+// carries no delimiter bit and its length is not mixed into the root.
+func bitvectorHasher(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+	bfield := val.Interface().(Bitfield)
+	chunks, err := pack([][]byte{bfield.Bytes()})
+	if err != nil {
+		return [32]byte{}, err
+	}
+	limit := (bfield.Len() + 255) / 256
+	return h.merkleize(chunks, limit, true /* has limit */)
+}
+
+// elementRoots computes the hash tree root of every element of val, writing
+// them next to each other into a scratch buffer of h which the caller must give
+// back with putBuffer once it is done with the chunks, which are backed by it.
+func elementRoots(h *Hasher, val reflect.Value, utils *sszUtils, chunks [][]byte) ([][]byte, *[]byte, error) {
+	roots := h.getBuffer(uint64(val.Len()) * 32)
+	hashElement := func(h *Hasher, i int) error {
+		var r [32]byte
+		var err error
+		if useCache {
+			r, err = hashWithCache(h, val.Index(i), utils.hasher, utils.marshaler, 0)
+		} else {
+			r, err = utils.hasher(h, val.Index(i), 0)
+		}
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		copy((*roots)[i*32:], r[:])
+		return nil
+	}
+	var err error
+	if hintsFor(val.Type()).Parallel {
+		err = parallelFor(h, val.Len(), hashElement)
+	} else {
+		for i := 0; i < val.Len() && err == nil; i++ {
+			err = hashElement(h, i)
+		}
+	}
+	if err != nil {
+		h.putBuffer(roots)
+		return nil, nil, err
+	}
+	return chunkify(chunks, *roots), roots, nil
 }
 
 func makeBasicArrayHasher(typ reflect.Type) (hasher, error) {
@@ -160,23 +291,20 @@ func makeBasicArrayHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		var leaves [][]byte
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		leaves := h.getBuffer(uint64(val.Len()) * 32)
+		defer h.putBuffer(leaves)
 		for i := 0; i < val.Len(); i++ {
-			r, err := utils.hasher(val.Index(i), 0)
+			r, err := utils.hasher(h, val.Index(i), 0)
 			if err != nil {
-				return [32]byte{}, err
+				return [32]byte{}, fmt.Errorf("element %d: %w", i, err)
 			}
-			leaves = append(leaves, r[:])
-		}
-		chunks, err := pack(leaves)
-		if err != nil {
-			return [32]byte{}, err
+			copy((*leaves)[i*32:], r[:])
 		}
-		if val.Len() == 0 {
-			chunks = [][]byte{}
-		}
-		return bitwiseMerkleize(chunks, 1, false /* has limit */)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		*chunks = chunkify(*chunks, *leaves)
+		return h.merkleize(*chunks, 1, false /* has limit */)
 	}
 	return hasher, nil
 }
@@ -186,8 +314,7 @@ func makeCompositeArrayHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		roots := [][]byte{}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
 		elemSize := uint64(0)
 		if isBasicType(typ.Elem().Kind()) {
 			elemSize = determineFixedSize(val, typ.Elem())
@@ -195,26 +322,16 @@ func makeCompositeArrayHasher(typ reflect.Type) (hasher, error) {
 			elemSize = 32
 		}
 		limit := (uint64(val.Len())*elemSize + 31) / 32
-		for i := 0; i < val.Len(); i++ {
-			var r [32]byte
-			if useCache {
-				r, err = hashCache.lookup(val.Index(i), utils.hasher, utils.marshaler, 0)
-			} else {
-				r, err = utils.hasher(val.Index(i), 0)
-			}
-			if err != nil {
-				return [32]byte{}, err
-			}
-			roots = append(roots, r[:])
-		}
-		chunks, err := pack(roots)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		var roots *[]byte
+		var err error
+		*chunks, roots, err = elementRoots(h, val, utils, *chunks)
 		if err != nil {
 			return [32]byte{}, err
 		}
-		if val.Len() == 0 {
-			chunks = [][]byte{}
-		}
-		return bitwiseMerkleize(chunks, limit, true /* has limit */)
+		defer h.putBuffer(roots)
+		return h.merkleize(*chunks, limit, true /* has limit */)
 	}
 	return hasher, nil
 }
@@ -224,7 +341,7 @@ func makeBasicSliceHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
 		elemSize := uint64(0)
 		if isBasicType(typ.Elem().Kind()) {
 			elemSize = determineFixedSize(val, typ.Elem())
@@ -236,36 +353,55 @@ func makeBasicSliceHasher(typ reflect.Type) (hasher, error) {
 			limit = 1
 		}
 
-		var leaves [][]byte
-		for i := 0; i < val.Len(); i++ {
-			if isBasicType(val.Index(i).Kind()) {
-				innerBufSize := determineSize(val.Index(i))
-				innerBuf := make([]byte, innerBufSize)
-				if _, err = utils.marshaler(val.Index(i), innerBuf, 0); err != nil {
-					return [32]byte{}, err
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		if raw, ok := rawBytes(val); ok && isBasicType(typ.Elem().Kind()) {
+			leaves := h.getBuffer(paddedSize(uint64(len(raw))))
+			defer h.putBuffer(leaves)
+			copy(*leaves, raw)
+			*chunks = chunkify(*chunks, *leaves)
+		} else if values, ok := uint64sOf(val); ok {
+			leaves := h.getBuffer(paddedSize(uint64(len(values)) * 8))
+			defer h.putBuffer(leaves)
+			putUint64s(*leaves, values)
+			*chunks = chunkify(*chunks, *leaves)
+		} else if roots, ok := rootsOf(val); ok {
+			// The root of a [32]byte is the array itself.
+			leaves := h.getBuffer(uint64(len(roots)) * 32)
+			defer h.putBuffer(leaves)
+			putRoots(*leaves, roots)
+			*chunks = chunkify(*chunks, *leaves)
+		} else if isBasicType(typ.Elem().Kind()) {
+			// Basic elements are serialized next to each other into a zero-padded
+			// buffer, which is then directly split into chunks.
+			leaves := h.getBuffer(paddedSize(uint64(val.Len()) * elemSize))
+			defer h.putBuffer(leaves)
+			index := uint64(0)
+			var err error
+			for i := 0; i < val.Len(); i++ {
+				if index, err = utils.marshaler(val.Index(i), *leaves, index); err != nil {
+					return [32]byte{}, fmt.Errorf("element %d: %w", i, err)
 				}
-				leaves = append(leaves, innerBuf)
-			} else {
-				r, err := utils.hasher(val.Index(i), 0)
+			}
+			*chunks = chunkify(*chunks, *leaves)
+		} else {
+			leaves := h.getBuffer(uint64(val.Len()) * 32)
+			defer h.putBuffer(leaves)
+			for i := 0; i < val.Len(); i++ {
+				r, err := utils.hasher(h, val.Index(i), 0)
 				if err != nil {
-					return [32]byte{}, err
+					return [32]byte{}, fmt.Errorf("element %d: %w", i, err)
 				}
-				leaves = append(leaves, r[:])
+				copy((*leaves)[i*32:], r[:])
 			}
+			*chunks = chunkify(*chunks, *leaves)
 		}
-		chunks, err := pack(leaves)
-		if err != nil {
-			return [32]byte{}, err
-		}
-		buf := new(bytes.Buffer)
-		binary.Write(buf, binary.LittleEndian, uint64(val.Len()))
-		output := make([]byte, 32)
-		copy(output, buf.Bytes())
-		merkleRoot, err := bitwiseMerkleize(chunks, limit, true /* has limit */)
+		output := lengthChunk(uint64(val.Len()))
+		merkleRoot, err := h.merkleize(*chunks, limit, true /* has limit */)
 		if err != nil {
 			return [32]byte{}, err
 		}
-		return mixInLength(merkleRoot, output), nil
+		return h.mixInLength(merkleRoot, output[:]), nil
 	}
 	return hasher, nil
 }
@@ -275,45 +411,34 @@ func makeCompositeSliceHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		roots := [][]byte{}
-		output := make([]byte, 32)
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		output := lengthChunk(uint64(val.Len()))
 		if val.Len() == 0 && maxCapacity == 0 {
-			merkleRoot, err := bitwiseMerkleize([][]byte{}, 0, true /* has limit */)
+			merkleRoot, err := h.merkleize([][]byte{}, 0, true /* has limit */)
 			if err != nil {
 				return [32]byte{}, err
 			}
-			itemMerkleize := mixInLength(merkleRoot, output)
+			itemMerkleize := h.mixInLength(merkleRoot, output[:])
 			return itemMerkleize, nil
 		}
-		for i := 0; i < val.Len(); i++ {
-			var r [32]byte
-			if useCache {
-				r, err = hashCache.lookup(val.Index(i), utils.hasher, utils.marshaler, 0)
-			} else {
-				r, err = utils.hasher(val.Index(i), 0)
-			}
-			if err != nil {
-				return [32]byte{}, err
-			}
-			roots = append(roots, r[:])
-		}
-		chunks, err := pack(roots)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		var roots *[]byte
+		var err error
+		*chunks, roots, err = elementRoots(h, val, utils, *chunks)
 		if err != nil {
 			return [32]byte{}, err
 		}
-		buf := new(bytes.Buffer)
-		binary.Write(buf, binary.LittleEndian, uint64(val.Len()))
-		copy(output, buf.Bytes())
+		defer h.putBuffer(roots)
 		objLen := maxCapacity
 		if maxCapacity == 0 {
 			objLen = uint64(val.Len())
 		}
-		merkleRoot, err := bitwiseMerkleize(chunks, objLen, true /* has limit */)
+		merkleRoot, err := h.merkleize(*chunks, objLen, true /* has limit */)
 		if err != nil {
 			return [32]byte{}, err
 		}
-		return mixInLength(merkleRoot, output), nil
+		return h.mixInLength(merkleRoot, output[:]), nil
 	}
 	return hasher, nil
 }
@@ -327,32 +452,139 @@ func makeStructHasher(typ reflect.Type) (hasher, error) {
 }
 
 func makeFieldsHasher(fields []field) (hasher, error) {
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		roots := [][]byte{}
-		for _, f := range fields {
-			var r [32]byte
-			var err error
-			if _, ok := val.Field(f.index).Interface().(bitfield.Bitlist); ok {
-				r, err = bitlistHasher(val.Field(f.index), f.capacity)
-				roots = append(roots, r[:])
-				continue
-			}
-			if useCache {
-				r, err = hashCache.lookup(
-					val.Field(f.index),
-					f.sszUtils.hasher,
-					f.sszUtils.marshaler,
-					f.capacity,
-				)
-			} else {
-				r, err = f.sszUtils.hasher(val.Field(f.index), f.capacity)
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		roots, err := hashFields(h, fields, val)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		defer h.putBuffer(roots)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		*chunks = chunkify(*chunks, *roots)
+		return h.merkleize(*chunks, uint64(len(fields)), true /* has limit */)
+	}
+	return hasher, nil
+}
+
+// hashFields computes the hash tree roots of the fields of the struct val, writing them
+// next to each other into a scratch buffer of h which the caller must give back with
+// putBuffer.
+func hashFields(h *Hasher, fields []field, val reflect.Value) (*[]byte, error) {
+	roots := h.getBuffer(uint64(len(fields)) * 32)
+	hashField := func(h *Hasher, i int) error {
+		r, err := hashStructField(h, fields[i], val)
+		if err != nil {
+			return err
+		}
+		copy((*roots)[i*32:], r[:])
+		return nil
+	}
+	var err error
+	if parallelFields(h, val.Type(), len(fields)) {
+		err = parallelFor(h, len(fields), hashField)
+	} else {
+		for i := 0; i < len(fields) && err == nil; i++ {
+			err = hashField(h, i)
+		}
+	}
+	if err != nil {
+		h.putBuffer(roots)
+		return nil, err
+	}
+	return roots, nil
+}
+
+// hashStructField computes the hash tree root of the field f of the struct val.
+func hashStructField(h *Hasher, f field, val reflect.Value) ([32]byte, error) {
+	defer annotatePanic(&f.name)
+	var r [32]byte
+	var err error
+	if isBitlist(f.typ) {
+		r, err = bitlistHasher(h, fieldValue(val, f), f.capacity)
+	} else if f.nestedHasher != nil {
+		// Cache keys only account for the outermost limit, so fields
+		// with nested limits are hashed without the cache.
+		r, err = f.nestedHasher(h, val.Field(f.index), f.capacity)
+	} else if useCache && f.opaque == nil && !f.progressive {
+		// Opaque fields skip the cache, as generating their cache key
+		// would encode them just as hashing does, and progressive ones
+		// as their keys would match those of the same lists without limit.
+		r, err = hashWithCache(
+			h,
+			val.Field(f.index),
+			f.sszUtils.hasher,
+			f.sszUtils.marshaler,
+			f.capacity,
+		)
+	} else {
+		r, err = f.sszUtils.hasher(h, val.Field(f.index), f.capacity)
+	}
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to hash field %s of struct: %w", f.name, err)
+	}
+	return r, nil
+}
+
+// makeNestedHasher returns a hasher of typ merkleizing the lists nested within its
+// values with innerLimits, the limits of a multi-dimensional ssz-max tag after the
+// first, which is the max capacity passed to the hasher. Vectors take a dimension of
+// the tag as lists do, whose limit is ignored.
+func makeNestedHasher(typ reflect.Type, innerLimits []uint64) (hasher, error) {
+	if len(innerLimits) == 0 {
+		utils, err := cachedSSZUtilsNoAcquireLock(typ)
+		if err != nil {
+			return nil, err
+		}
+		return utils.hasher, nil
+	}
+	kind := typ.Kind()
+	if kind == reflect.Ptr {
+		elemHasher, err := makeNestedHasher(typ.Elem(), innerLimits)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			elem, err := pointee(val)
+			if err != nil {
+				return [32]byte{}, err
 			}
+			return elemHasher(h, elem, maxCapacity)
+		}, nil
+	}
+	if (kind != reflect.Slice && kind != reflect.Array) || lookupCodec(typ) != nil || isBasicType(typ.Elem().Kind()) {
+		return nil, errorf(ErrInvalidTag, "ssz-max has more dimensions than type %v", typ)
+	}
+	elemLimit := innerLimits[0]
+	elemHasher, err := makeNestedHasher(typ.Elem(), innerLimits[1:])
+	if err != nil {
+		return nil, err
+	}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		roots := h.getBuffer(uint64(val.Len()) * 32)
+		defer h.putBuffer(roots)
+		for i := 0; i < val.Len(); i++ {
+			r, err := elemHasher(h, val.Index(i), elemLimit)
 			if err != nil {
-				return [32]byte{}, fmt.Errorf("failed to hash field %s of struct: %v", f.name, err)
+				return [32]byte{}, fmt.Errorf("element %d: %w", i, err)
 			}
-			roots = append(roots, r[:])
+			copy((*roots)[i*32:], r[:])
 		}
-		return bitwiseMerkleize(roots, uint64(len(fields)), true /* has limit */)
+		chunks := h.getChunks()
+		defer h.putChunks(chunks)
+		*chunks = chunkify(*chunks, *roots)
+		if kind == reflect.Array {
+			return h.merkleize(*chunks, uint64(val.Len()), true /* has limit */)
+		}
+		limit := maxCapacity
+		if limit == 0 {
+			limit = uint64(val.Len())
+		}
+		merkleRoot, err := h.merkleize(*chunks, limit, true /* has limit */)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		output := lengthChunk(uint64(val.Len()))
+		return h.mixInLength(merkleRoot, output[:]), nil
 	}
 	return hasher, nil
 }
@@ -362,11 +594,12 @@ func makePtrHasher(typ reflect.Type) (hasher, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasher := func(val reflect.Value, maxCapacity uint64) ([32]byte, error) {
-		if val.IsNil() {
-			return [32]byte{}, nil
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		elem, err := pointee(val)
+		if err != nil {
+			return [32]byte{}, err
 		}
-		return elemSSZUtils.hasher(val.Elem(), maxCapacity)
+		return elemSSZUtils.hasher(h, elem, maxCapacity)
 	}
 	return hasher, nil
 }
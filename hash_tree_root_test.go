@@ -2,8 +2,13 @@ package ssz
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
+	"reflect"
 	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
 )
 
 func init() {
@@ -103,6 +108,93 @@ func TestHashTreeRootWithCapacity_HashesCorrectly(t *testing.T) {
 	useCache = true
 }
 
+func TestHashTreeRootWithCapacity_Array(t *testing.T) {
+	useCache = false
+	arr := [4]uint64{1, 2, 3, 4}
+	want, err := HashTreeRoot(arr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, capacity := range []uint64{0, 4} {
+		root, err := HashTreeRootWithCapacity(arr, capacity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("HashTreeRootWithCapacity(arr, %d) = %#x, want %#x", capacity, root, want)
+		}
+	}
+	if _, err := HashTreeRootWithCapacity(arr, 3); err == nil {
+		t.Error("Expected capacity differing from array length to fail")
+	}
+	useCache = true
+}
+
+func TestHashTreeRootWithCapacity_Bitvector(t *testing.T) {
+	useCache = false
+	bvec := bitfield.Bitvector4{0x0a}
+	root, err := HashTreeRootWithCapacity(bvec, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bitvector of at most 256 bits is its own single chunk, with no length mixed in.
+	want := [32]byte{0x0a}
+	if root != want {
+		t.Errorf("HashTreeRootWithCapacity(bitvector) = %#x, want %#x", root, want)
+	}
+	if _, err := HashTreeRootWithCapacity(bvec, 8); err == nil {
+		t.Error("Expected capacity differing from bitvector length to fail")
+	}
+	useCache = true
+}
+
+func TestHashTreeRootWithCapacity_Bitlist(t *testing.T) {
+	useCache = false
+	blist := bitfield.NewBitlist(8)
+	blist.SetBitAt(1, true)
+	root, err := HashTreeRootWithCapacity(blist, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A capacity of 2048 bits gives 8 chunks, the first of which holds the bits
+	// without their delimiter, and the root is mixed in with the length 8.
+	var chunk, zero [32]byte
+	chunk[0] = 0x02
+	zero1 := hash(append(zero[:], zero[:]...))
+	zero2 := hash(append(zero1[:], zero1[:]...))
+	want := hash(append(chunk[:], zero[:]...))
+	want = hash(append(want[:], zero1[:]...))
+	want = hash(append(want[:], zero2[:]...))
+	length := [32]byte{8}
+	want = hash(append(want[:], length[:]...))
+	if root != want {
+		t.Errorf("HashTreeRootWithCapacity(bitlist) = %#x, want %#x", root, want)
+	}
+	useCache = true
+}
+
+func TestHashTreeRoot_ValidatesBitlists(t *testing.T) {
+	tests := map[string]bitfield.Bitlist{
+		"empty":              {},
+		"missing delimiter":  {0x05, 0x00},
+		"exceeding capacity": {0xff, 0x02},
+	}
+	for name, bits := range tests {
+		if _, err := HashTreeRootWithCapacity(bits, 8); err == nil {
+			t.Errorf("%s: expected bitlist %#x to fail hashing", name, bits)
+		}
+		if _, err := HashTreeRoot(bitlistItem{Bits: bits}); err == nil {
+			t.Errorf("%s: expected bitlist field %#x to fail hashing", name, bits)
+		}
+		if _, err := NewTree(bitlistItem{Bits: bits}); err == nil {
+			t.Errorf("%s: expected tree of bitlist field %#x to fail", name, bits)
+		}
+	}
+	if _, err := HashTreeRoot(bitlistItem{Bits: bitfield.Bitlist{0x01}}); err != nil {
+		t.Errorf("Expected empty bitlist to hash, received %v", err)
+	}
+}
+
 // Regression test for https://github.com/prysmaticlabs/go-ssz/issues/46.
 func TestHashTreeRoot_EncodeSliceLengthCorrectly(t *testing.T) {
 	useCache = false
@@ -127,3 +219,182 @@ func TestHashTreeRoot_EncodeSliceLengthCorrectly(t *testing.T) {
 	}
 	useCache = true
 }
+
+func TestHashTreeRoot_PackedVectors(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	// A vector of basic values is packed into chunks, right-padded with zeroes,
+	// and merkleized without mixing in any length.
+	chunk := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(chunk[i*8:], uint64(i+1))
+	}
+	root, err := HashTreeRoot([4]uint64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(root[:], chunk) {
+		t.Errorf("Expected root %#x, received %#x", chunk, root)
+	}
+
+	second := make([]byte, 32)
+	binary.LittleEndian.PutUint64(second, 5)
+	want := hash(append(append([]byte{}, chunk...), second...))
+	root, err = HashTreeRoot([5]uint64{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+
+	want = [32]byte{}
+	binary.LittleEndian.PutUint32(want[0:], 7)
+	binary.LittleEndian.PutUint32(want[4:], 8)
+	binary.LittleEndian.PutUint32(want[8:], 9)
+	root, err = HashTreeRoot([3]uint32{7, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+}
+
+func TestHashTreeRoot_PackedVectorsMatchGenericPath(t *testing.T) {
+	values := []interface{}{
+		[4]uint64{1, 2, 3, 1 << 63},
+		[9]uint64{9, 8, 7, 6, 5, 4, 3, 2, 1},
+		[3]uint32{1, 2, 1 << 31},
+		[17]uint16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+		[0]uint64{},
+	}
+	h := NewHasher()
+	for _, v := range values {
+		val := reflect.ValueOf(v)
+		packed, err := makePackedArrayHasher(val.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+		generic, err := makeBasicTypeHasher(val.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := generic(h, val, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := packed(h, val, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Expected root %#x for %v, received %#x", want, v, got)
+		}
+	}
+}
+
+type nestedLists struct {
+	Lists        [][]uint64    `ssz-max:"4,8"`
+	Transactions [][]byte      `ssz-max:"16,1073741824"`
+	Vectors      [][2][]uint16 `ssz-max:"3,?,5"`
+}
+
+// naiveMerkleize merkleizes chunks padded with zero chunks up to limit, rounded
+// up to a power of two.
+func naiveMerkleize(chunks [][32]byte, limit uint64) [32]byte {
+	width := uint64(1)
+	for width < limit {
+		width *= 2
+	}
+	layer := make([][32]byte, width)
+	copy(layer, chunks)
+	for len(layer) > 1 {
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func naiveMixInLength(root [32]byte, length int) [32]byte {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:], uint64(length))
+	return sha256.Sum256(append(root[:], chunk[:]...))
+}
+
+func TestHashTreeRoot_NestedLimits(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := nestedLists{
+		Lists:        [][]uint64{{1, 2, 3}, {}, {4}},
+		Transactions: [][]byte{{0xde, 0xad}, make([]byte, 100)},
+		Vectors:      [][2][]uint16{{{1}, {2, 3}}},
+	}
+	innerRoots := func(val interface{}, limit uint64) [][32]byte {
+		rval := reflect.ValueOf(val)
+		roots := make([][32]byte, rval.Len())
+		for i := range roots {
+			r, err := HashTreeRootWithCapacity(rval.Index(i).Interface(), limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots[i] = r
+		}
+		return roots
+	}
+	lists := naiveMixInLength(naiveMerkleize(innerRoots(item.Lists, 8), 4), len(item.Lists))
+	transactions := naiveMixInLength(naiveMerkleize(innerRoots(item.Transactions, 1073741824), 16), len(item.Transactions))
+	var vectorRoots [][32]byte
+	for _, v := range item.Vectors {
+		vectorRoots = append(vectorRoots, naiveMerkleize(innerRoots(v, 5), 2))
+	}
+	vectors := naiveMixInLength(naiveMerkleize(vectorRoots, 3), len(item.Vectors))
+	want := naiveMerkleize([][32]byte{lists, transactions, vectors}, 3)
+
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != want {
+		t.Errorf("Expected tree root %#x, received %#x", want, tree.Root())
+	}
+
+	useCache = true
+	if root, err = HashTreeRoot(item); err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected cached root %#x, received %#x", want, root)
+	}
+}
+
+func TestHashTreeRoot_NestedLimitsExceedingDimensions(t *testing.T) {
+	item := struct {
+		Values []uint64 `ssz-max:"4,8"`
+	}{}
+	if _, err := HashTreeRoot(item); err == nil {
+		t.Error("Expected ssz-max with more dimensions than the field to fail")
+	}
+}
+
+func BenchmarkHashTreeRoot_PackedVector(b *testing.B) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := [64]uint64{}
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if _, err := HashTreeRoot(item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	gohash "hash"
+	"reflect"
+	"sync"
+)
+
+// Hasher owns the scratch buffers and hash state used to compute hash tree roots.
+// Hot loops can reuse a single Hasher with HashTreeRootWith instead of acquiring
+// internal state for every root. A Hasher is not safe for concurrent use, so each
+// goroutine should use its own.
+type Hasher struct {
+	digest gohash.Hash
+	sum    []byte
+	pair   [64]byte
+	// fn is the hash function the hasher merkleizes values with.
+	fn      *hashFunction
+	buffers []*[]byte
+	chunks  []*[][]byte
+	// worker reports whether the hasher is used by a goroutine of parallelFor.
+	worker bool
+}
+
+// NewHasher returns a Hasher using the default PooledSHA256 hash backend.
+func NewHasher() *Hasher {
+	return &Hasher{fn: sha256Functions[PooledSHA256]}
+}
+
+// NewHasherWithBackend returns a Hasher using the given sha256 implementation. Only
+// the roots computed with the returned hasher use it.
+//
+//  h, err := ssz.NewHasherWithBackend(ssz.SIMDSHA256)
+//  if err != nil {
+//      return fmt.Errorf("failed to create hasher: %v", err)
+//  }
+//  root, err := ssz.HashTreeRootWith(state, h)
+func NewHasherWithBackend(backend HashBackend) (*Hasher, error) {
+	f, ok := sha256Functions[backend]
+	if !ok {
+		if backend == CustomHash {
+			return nil, errors.New("custom hash functions must be set with NewHasherWithHashFunction")
+		}
+		return nil, fmt.Errorf("unknown hash backend %d", backend)
+	}
+	return &Hasher{fn: f}, nil
+}
+
+// NewHasherWithHashFunction returns a Hasher using another hash function than sha256,
+// such as keccak256 or blake2b, so that the SSZ merkleization rules can be reused for
+// non-eth2 Merkle structures. The function must return digests of 32 bytes. Only the
+// roots computed with the returned hasher use it, and they are never cached.
+//
+//  h, err := ssz.NewHasherWithHashFunction(sha3.NewLegacyKeccak256)
+//  if err != nil {
+//      return fmt.Errorf("failed to create hasher: %v", err)
+//  }
+//  root, err := ssz.HashTreeRootWith(tree, h)
+func NewHasherWithHashFunction(newHash func() gohash.Hash) (*Hasher, error) {
+	f, err := newHashFunction(newHash)
+	if err != nil {
+		return nil, err
+	}
+	return &Hasher{fn: f}, nil
+}
+
+// Backend returns the hash backend h merkleizes values with.
+func (h *Hasher) Backend() HashBackend {
+	return h.fn.backend
+}
+
+var hasherPool = sync.Pool{
+	New: func() interface{} {
+		return NewHasher()
+	},
+}
+
+func acquireHasher() *Hasher {
+	return hasherPool.Get().(*Hasher)
+}
+
+func releaseHasher(h *Hasher) {
+	hasherPool.Put(h)
+}
+
+// HashTreeRootWith determines the root hash of val like HashTreeRoot does, using the
+// scratch buffers and hash state of h.
+//
+//  h := ssz.NewHasher()
+//  for _, block := range blocks {
+//      root, err := ssz.HashTreeRootWith(block, h)
+//      if err != nil {
+//          return fmt.Errorf("failed to compute root: %v", err)
+//      }
+//      roots = append(roots, root)
+//  }
+func HashTreeRootWith(val interface{}, h *Hasher) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
+	if val == nil {
+		return [32]byte{}, errors.New("untyped nil is not supported")
+	}
+	if h == nil {
+		return [32]byte{}, errors.New("hasher cannot be nil")
+	}
+	rval := reflect.ValueOf(val)
+	sszUtils, err := cachedSSZUtils(rval.Type())
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("could not get ssz utils for type: %v: %w", rval.Type(), err)
+	}
+	var output [32]byte
+	if useCache {
+		output, err = hashWithCache(h, rval, sszUtils.hasher, sszUtils.marshaler, 0)
+	} else {
+		output, err = sszUtils.hasher(h, rval, 0)
+	}
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("could not tree hash type: %v: %w", rval.Type(), err)
+	}
+	return output, nil
+}
+
+// setHashFunction makes h hash with f.
+func (h *Hasher) setHashFunction(f *hashFunction) {
+	if h.fn == f {
+		return
+	}
+	h.fn = f
+	// The digest of f is created on the next hash.
+	h.digest = nil
+}
+
+// hash returns the hash of data using the digest owned by the hasher, which is
+// created on first use.
+func (h *Hasher) hash(data []byte) [32]byte {
+	if h.digest == nil {
+		if h.fn.newHash == nil {
+			return h.fn.sum(data)
+		}
+		h.digest = h.fn.newHash()
+	}
+	var output [32]byte
+	h.digest.Reset()
+	// The hash interface never returns an error, for that reason
+	// we are not handling the error below. For reference, it is
+	// stated here https://golang.org/pkg/hash/#Hash
+	// #nosec G104
+	h.digest.Write(data)
+	h.sum = h.digest.Sum(h.sum[:0])
+	copy(output[:], h.sum)
+	return output
+}
+
+// hashPair returns the hash of the concatenation of two 32 byte chunks.
+func (h *Hasher) hashPair(a []byte, b []byte) [32]byte {
+	copy(h.pair[:32], a)
+	copy(h.pair[32:], b)
+	return h.hash(h.pair[:])
+}
+
+// zeroHash returns the root of a subtree of the given depth whose leaves are all
+// zero chunks, under the hash function of h.
+func (h *Hasher) zeroHash(depth uint64) []byte {
+	return h.fn.zeroHashes[depth]
+}
+
+// getBuffer returns a zeroed scratch buffer of the given size. It must be given
+// back with putBuffer once it is not referenced anymore.
+func (h *Hasher) getBuffer(size uint64) *[]byte {
+	var b *[]byte
+	if n := len(h.buffers); n > 0 {
+		b = h.buffers[n-1]
+		h.buffers = h.buffers[:n-1]
+	} else {
+		b = new([]byte)
+	}
+	if uint64(cap(*b)) < size {
+		*b = make([]byte, size)
+		return b
+	}
+	*b = (*b)[:size]
+	for i := range *b {
+		(*b)[i] = 0
+	}
+	return b
+}
+
+func (h *Hasher) putBuffer(b *[]byte) {
+	h.buffers = append(h.buffers, b)
+}
+
+// getChunks returns an empty slice of chunks. It must be given back with
+// putChunks once it is not referenced anymore.
+func (h *Hasher) getChunks() *[][]byte {
+	var c *[][]byte
+	if n := len(h.chunks); n > 0 {
+		c = h.chunks[n-1]
+		h.chunks = h.chunks[:n-1]
+	} else {
+		c = new([][]byte)
+	}
+	*c = (*c)[:0]
+	return c
+}
+
+func (h *Hasher) putChunks(c *[][]byte) {
+	// We drop references to the chunks so reused slices do not keep them alive.
+	for i := range *c {
+		(*c)[i] = nil
+	}
+	h.chunks = append(h.chunks, c)
+}
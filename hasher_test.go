@@ -0,0 +1,162 @@
+package ssz
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashTreeRootWith_MatchesHashTreeRoot(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	h := NewHasher()
+	items := []interface{}{
+		fork{Epoch: 4},
+		&fork{Epoch: 5},
+		[4]uint64{1, 2, 3},
+		hintedContainer{Forks: []fork{{Epoch: 1}}, Balances: []uint64{4}},
+	}
+	for _, item := range items {
+		want, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The hasher is reused for every item, and twice for each of them.
+		for i := 0; i < 2; i++ {
+			got, err := HashTreeRootWith(item, h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("Expected root %#x for %v, received %#x", want, item, got)
+			}
+		}
+	}
+}
+
+func TestNewHasherWithHashFunction(t *testing.T) {
+	useCache = true
+	h, err := NewHasherWithHashFunction(sha512.New512_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := [2][32]byte{{1}, {2}}
+	root, err := HashTreeRootWith(pair, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha512.Sum512_256(append(pair[0][:], pair[1][:]...)); root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+	if want := sha512.Sum512_256(make([]byte, 64)); !bytes.Equal(h.zeroHash(1), want[:]) {
+		t.Errorf("Expected zero hash %#x, received %#x", want, h.zeroHash(1))
+	}
+
+	item := hintedContainer{
+		Forks:    []fork{{Epoch: 1}, {Epoch: 2}, {Epoch: 3}},
+		Balances: []uint64{1, 2, 3, 4, 5},
+		Epoch:    6,
+	}
+	sha256Root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customRoot, err := HashTreeRootWith(item, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if customRoot == sha256Root {
+		t.Error("Expected a different root with a different hash function, got the cached sha256 root")
+	}
+	// The workers hashing the fields and elements in parallel use the hash function too.
+	SetTypeHints(reflect.TypeOf(item), Hints{Parallel: true})
+	SetTypeHints(reflect.TypeOf(item.Forks), Hints{Parallel: true})
+	defer SetTypeHints(reflect.TypeOf(item), Hints{})
+	defer SetTypeHints(reflect.TypeOf(item.Forks), Hints{})
+	if root, err := HashTreeRootWith(item, h); err != nil {
+		t.Fatal(err)
+	} else if root != customRoot {
+		t.Errorf("Expected parallel hashing root %#x to match %#x", root, customRoot)
+	}
+	// Other hashers keep hashing with sha256, and no custom root was cached.
+	for _, cache := range []bool{false, true} {
+		useCache = cache
+		if root, err := HashTreeRootWith(item, NewHasher()); err != nil {
+			t.Fatal(err)
+		} else if root != sha256Root {
+			t.Errorf("Expected sha256 root %#x, received %#x", sha256Root, root)
+		}
+	}
+}
+
+func TestNewHasherWithHashFunction_InvalidFunction(t *testing.T) {
+	if _, err := NewHasherWithHashFunction(nil); err == nil {
+		t.Error("Expected nil hash function to fail")
+	}
+	if _, err := NewHasherWithHashFunction(sha512.New); err == nil {
+		t.Error("Expected hash function with 64 byte digests to fail")
+	}
+}
+
+func TestHashTreeRootWith_NilHasher(t *testing.T) {
+	if _, err := HashTreeRootWith(fork{}, nil); err == nil {
+		t.Error("Expected error for nil hasher")
+	}
+}
+
+func BenchmarkHashTreeRootWith(b *testing.B) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := hintedContainer{Forks: make([]fork, 256), Balances: make([]uint64, 1024)}
+	h := NewHasher()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := HashTreeRootWith(item, h); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestHashTreeRootBatch(t *testing.T) {
+	vals := make([]interface{}, 100)
+	for i := range vals {
+		vals[i] = fork{Epoch: uint64(i)}
+	}
+	vals[7] = &hintedContainer{Balances: []uint64{1, 2}, Epoch: 7}
+	roots, err := HashTreeRootBatch(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != len(vals) {
+		t.Fatalf("Expected %d roots, received %d", len(vals), len(roots))
+	}
+	for i, val := range vals {
+		want, err := HashTreeRoot(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if roots[i] != want {
+			t.Errorf("Expected root %#x for value %d, received %#x", want, i, roots[i])
+		}
+	}
+}
+
+func TestHashTreeRootBatch_Empty(t *testing.T) {
+	roots, err := HashTreeRootBatch(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 0 {
+		t.Errorf("Expected no roots, received %d", len(roots))
+	}
+}
+
+func TestHashTreeRootBatch_Error(t *testing.T) {
+	vals := []interface{}{fork{}, nil, fork{}}
+	if _, err := HashTreeRootBatch(vals); err == nil || !strings.Contains(err.Error(), "value 1") {
+		t.Errorf("Expected error for value 1, received %v", err)
+	}
+}
@@ -3,8 +3,8 @@ package ssz
 import (
 	"bytes"
 	"crypto/sha256"
-	"fmt"
-	"math"
+	"encoding/binary"
+	"math/bits"
 	"reflect"
 )
 
@@ -13,15 +13,25 @@ var (
 	BytesPerChunk = 32
 	// BytesPerLengthOffset defines a constant for off-setting serialized chunks.
 	BytesPerLengthOffset = uint64(4)
-	zeroHashes           = make([][]byte, 100)
+	// zeroHashes[i] is the root of a subtree of depth i whose leaves are all zero chunks.
+	// A limit of at most 2^64 chunks gives a tree depth of at most 64.
+	zeroHashes = make([][]byte, maxTreeDepth+1)
 )
 
+const maxTreeDepth = 64
+
 func init() {
-	zeroHashes[0] = make([]byte, 32)
-	for i := 1; i < 100; i++ {
-		leaf := append(zeroHashes[i-1], zeroHashes[i-1]...)
+	fillZeroHashes(zeroHashes, hash)
+	computeZeroNodes()
+}
+
+// fillZeroHashes fills table with the roots of zero-filled subtrees under hash.
+func fillZeroHashes(table [][]byte, hash func([]byte) [32]byte) {
+	table[0] = make([]byte, 32)
+	for i := 1; i < len(table); i++ {
+		leaf := append(table[i-1], table[i-1]...)
 		result := hash(leaf)
-		zeroHashes[i] = result[:]
+		table[i] = result[:]
 	}
 }
 
@@ -80,89 +90,168 @@ func pack(serializedItems [][]byte) ([][]byte, error) {
 // Note that merkleize on a single chunk is simply that chunk, i.e. the identity
 // when the number of chunks is one.
 func bitwiseMerkleize(chunks [][]byte, limit uint64, hasLimit bool) ([32]byte, error) {
+	h := acquireHasher()
+	defer releaseHasher(h)
+	return h.merkleize(chunks, limit, hasLimit)
+}
+
+func (h *Hasher) merkleize(chunks [][]byte, limit uint64, hasLimit bool) ([32]byte, error) {
+	return h.merkleizeChunks(uint64(len(chunks)), limit, hasLimit, func(idx uint64) [32]byte {
+		return toBytes32(chunks[idx])
+	})
+}
+
+// merkleizeBytes merkleizes data like merkleize does its chunks, reading the chunks
+// straight from data, the last of which is right-padded with zeroes, rather than
+// splitting it into a slice of chunks first.
+func (h *Hasher) merkleizeBytes(data []byte, limit uint64, hasLimit bool) ([32]byte, error) {
+	count := (len(data) + BytesPerChunk - 1) / BytesPerChunk
+	return h.merkleizeChunks(uint64(count), limit, hasLimit, func(idx uint64) [32]byte {
+		return toBytes32(data[int(idx)*BytesPerChunk:])
+	})
+}
+
+// merkleizeChunks merkleizes count chunks, returned in order by chunk.
+func (h *Hasher) merkleizeChunks(count uint64, limit uint64, hasLimit bool, chunk func(idx uint64) [32]byte) ([32]byte, error) {
 	padding := limit
 	if !hasLimit {
-		padding = uint64(len(chunks))
+		padding = count
 	}
-	count := uint64(len(chunks))
 
 	if count > padding {
-		return [32]byte{}, fmt.Errorf("chunk count = %d cannot be greater than padding = %d", count, padding)
+		return [32]byte{}, errorf(ErrMaxLength, "chunk count = %d cannot be greater than padding = %d", count, padding)
 	}
 	if padding == 0 {
 		return toBytes32(zeroHashes[0]), nil
 	}
+	stream := h.newChunkStream(padding)
+	for idx := uint64(0); idx < count; idx++ {
+		// The chunks never exceed the padding.
+		_ = stream.push(chunk(idx))
+	}
+	return stream.root(), nil
+}
 
-	depth := uint64(bitLength(0))
-	if bitLength(count-1) > depth {
-		depth = bitLength(count - 1)
+// chunkStream merkleizes chunks as they are pushed, without knowing their number
+// upfront, holding only the pending root of every layer of the tree.
+type chunkStream struct {
+	h      *Hasher
+	layers *[]byte
+	count  uint64
+	limit  uint64
+}
+
+// newChunkStream returns a stream merkleizing at most limit chunks, which must not
+// be 0, padded with zero chunks up to limit.
+func (h *Hasher) newChunkStream(limit uint64) chunkStream {
+	// The pending root of every layer is stored in a single scratch buffer,
+	// layer j occupying bytes [32*j, 32*(j+1)).
+	return chunkStream{
+		h:      h,
+		layers: h.getBuffer((bitLength(limit-1) + 1) * 32),
+		limit:  limit,
 	}
-	maxDepth := bitLength(padding - 1)
-	layers := make([][]byte, maxDepth+1)
+}
 
-	for idx, chunk := range chunks {
-		mergeChunks(layers, chunk, uint64(idx), count, depth)
+// push merkleizes the next chunk, failing once limit chunks have been pushed.
+func (s *chunkStream) push(chunk [32]byte) error {
+	if s.count == s.limit {
+		return errorf(ErrMaxLength, "chunk count = %d cannot be greater than padding = %d", s.count+1, s.limit)
 	}
+	// The chunk is not the last one yet, so no zero chunk is merged with it.
+	s.h.mergeChunks(*s.layers, chunk, s.count, s.count+1, 0)
+	s.count++
+	return nil
+}
 
-	if 1<<depth != count {
-		mergeChunks(layers, zeroHashes[0], count, count, depth)
+// root returns the root of the chunks pushed, then gives the stream back to its
+// hasher, after which it cannot be used.
+func (s *chunkStream) root() [32]byte {
+	defer s.h.putBuffer(s.layers)
+	maxDepth := bitLength(s.limit - 1)
+	// Without any chunk, the root is the precomputed root of an all-zero tree,
+	// no matter how large the limit is.
+	if s.count == 0 {
+		return toBytes32(s.h.zeroHash(maxDepth))
+	}
+	layers := *s.layers
+	depth := bitLength(s.count - 1)
+	if 1<<depth != s.count {
+		s.h.mergeChunks(layers, toBytes32(zeroHashes[0]), s.count, s.count, depth)
 	}
 
 	for i := depth; i < maxDepth; i++ {
-		res := hash(append(layers[i], zeroHashes[i]...))
-		layers[i+1] = res[:]
+		res := s.h.hashPair(layers[i*32:(i+1)*32], s.h.zeroHash(i))
+		copy(layers[(i+1)*32:(i+2)*32], res[:])
 	}
 
-	return toBytes32(layers[maxDepth]), nil
+	return toBytes32(layers[maxDepth*32 : (maxDepth+1)*32])
 }
 
-func mergeChunks(layers [][]byte, currentRoot []byte, i, count, depth uint64) {
+func (h *Hasher) mergeChunks(layers []byte, currentRoot [32]byte, i, count, depth uint64) {
 	j := uint64(0)
 	for {
 		if i&(1<<j) == 0 {
 			if i == count && j < depth {
-				res := hash(append(currentRoot[:], zeroHashes[j]...))
-				currentRoot = res[:]
+				currentRoot = h.hashPair(currentRoot[:], h.zeroHash(j))
 			} else {
 				break
 			}
 		} else {
-			res := hash(append(layers[j], currentRoot[:]...))
-			currentRoot = res[:]
+			currentRoot = h.hashPair(layers[j*32:(j+1)*32], currentRoot[:])
 		}
 		j++
 	}
-	layers[j] = currentRoot[:]
+	copy(layers[j*32:(j+1)*32], currentRoot[:])
 }
 
 func bitLength(n uint64) uint64 {
-	if n == 0 {
-		return 0
-	}
-	return uint64(math.Log2(float64(n))) + 1
+	return uint64(bits.Len64(n))
 }
 
 // Given a Merkle root root and a length length ("uint256" little-endian serialization)
 // return hash(root + length).
-func mixInLength(root [32]byte, length []byte) [32]byte {
-	return hash(append(root[:], length...))
+func (h *Hasher) mixInLength(root [32]byte, length []byte) [32]byte {
+	return h.hashPair(root[:], length)
+}
+
+// lengthChunk returns the little-endian serialization of a list length,
+// padded to a full chunk, as mixed into the root of lists.
+func lengthChunk(length uint64) [32]byte {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:8], length)
+	return chunk
 }
 
 // Instantiates a reflect value which may not have a concrete type to have a concrete type
 // for unmarshaling. For example, we cannot unmarshal into a nil value - instead, it must have
-// a concrete type even if all of its values are zero values.
-func instantiateConcreteTypeForElement(val reflect.Value, typ reflect.Type) {
-	val.Set(reflect.New(typ))
+// a concrete type even if all of its values are zero values. Pointers which are already set
+// are reused.
+func instantiateConcreteTypeForElement(a *Arena, val reflect.Value, typ reflect.Type) {
+	if !val.IsNil() {
+		return
+	}
+	val.Set(a.new(typ))
 }
 
 // Grows a slice to a new length and instantiates the element at length-1 with a concrete type
-// accordingly if it is set to a pointer.
-func growConcreteSliceType(val reflect.Value, typ reflect.Type, length int) {
-	newVal := reflect.MakeSlice(typ, length, length)
-	reflect.Copy(newVal, val)
-	val.Set(newVal)
+// accordingly if it is set to a pointer. The capacity of the slice is reused when it is large
+// enough, and grown geometrically otherwise, so that growing a slice one element at a time
+// does not reallocate it for every element.
+func growConcreteSliceType(a *Arena, val reflect.Value, typ reflect.Type, length int) {
+	if val.Cap() >= length {
+		val.SetLen(length)
+	} else {
+		capacity := 2 * val.Cap()
+		if capacity < length {
+			capacity = length
+		}
+		newVal := a.makeSlice(typ, length, capacity)
+		reflect.Copy(newVal, val)
+		val.Set(newVal)
+	}
 	if val.Index(length-1).Kind() == reflect.Ptr {
-		instantiateConcreteTypeForElement(val.Index(length-1), typ.Elem().Elem())
+		instantiateConcreteTypeForElement(a, val.Index(length-1), typ.Elem().Elem())
 	}
 }
 
@@ -175,17 +264,22 @@ func toBytes32(x []byte) [32]byte {
 	return y
 }
 
-// hash defines a function that returns the sha256 hash of the data passed in.
-func hash(data []byte) [32]byte {
-	var hash [32]byte
+// chunkify appends the chunks backed by buf to chunks. The length of buf must
+// be a multiple of BytesPerChunk, with the last chunk already right-padded with zeroes.
+func chunkify(chunks [][]byte, buf []byte) [][]byte {
+	for i := 0; i < len(buf); i += BytesPerChunk {
+		chunks = append(chunks, buf[i:i+BytesPerChunk:i+BytesPerChunk])
+	}
+	return chunks
+}
 
-	h := sha256.New()
-	// The hash interface never returns an error, for that reason
-	// we are not handling the error below. For reference, it is
-	// stated here https://golang.org/pkg/hash/#Hash
-	// #nosec G104
-	h.Write(data)
-	h.Sum(hash[:0])
+// paddedSize rounds size up to a multiple of BytesPerChunk.
+func paddedSize(size uint64) uint64 {
+	chunkSize := uint64(BytesPerChunk)
+	return (size + chunkSize - 1) / chunkSize * chunkSize
+}
 
-	return hash
+// hash defines a function that returns the sha256 hash of the data passed in.
+func hash(data []byte) [32]byte {
+	return sha256.Sum256(data)
 }
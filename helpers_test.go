@@ -121,6 +121,100 @@ func TestMerkleize_OK(t *testing.T) {
 		})
 	}
 }
+func TestMerkleize_NoChunksWithLimit(t *testing.T) {
+	root := make([]byte, BytesPerChunk)
+	for depth := 0; depth <= 40; depth++ {
+		got, err := bitwiseMerkleize([][]byte{}, 1<<uint(depth), true /* has limit */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got[:], root) {
+			t.Errorf("merkleize() with limit 2^%d = %#x, want %#x", depth, got, root)
+		}
+		next := hash(append(root, root...))
+		root = next[:]
+	}
+}
+
+func TestMerkleize_SparseChunksWithLimit(t *testing.T) {
+	chunk := make([]byte, BytesPerChunk)
+	chunk[0] = 1
+	// A single chunk padded to 8 leaves is hashed with the zero subtrees of depth 0, 1 and 2.
+	want := hash(append(chunk, zeroHashes[0]...))
+	want = hash(append(want[:], zeroHashes[1]...))
+	want = hash(append(want[:], zeroHashes[2]...))
+	got, err := bitwiseMerkleize([][]byte{chunk}, 8, true /* has limit */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("merkleize() = %#x, want %#x", got, want)
+	}
+}
+
+func TestBitLength(t *testing.T) {
+	tests := map[uint64]uint64{
+		0:                    0,
+		1:                    1,
+		2:                    2,
+		255:                  8,
+		256:                  9,
+		1<<53 + 1:            54,
+		18446744073709551615: 64,
+	}
+	for n, want := range tests {
+		if got := bitLength(n); got != want {
+			t.Errorf("bitLength(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func BenchmarkMerkleize_SparseHugeLimit(b *testing.B) {
+	input := [][]byte{make([]byte, BytesPerChunk)}
+	for n := 0; n < b.N; n++ {
+		if _, err := bitwiseMerkleize(input, 1<<40, true /* has limit */); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetBuffer_ReturnsZeroedBuffers(t *testing.T) {
+	h := NewHasher()
+	buf := h.getBuffer(64)
+	for i := range *buf {
+		(*buf)[i] = 0xff
+	}
+	h.putBuffer(buf)
+	for i := 0; i < 10; i++ {
+		buf = h.getBuffer(48)
+		if len(*buf) != 48 {
+			t.Fatalf("Expected buffer of length 48, got %d", len(*buf))
+		}
+		for j, b := range *buf {
+			if b != 0 {
+				t.Fatalf("Expected zeroed buffer, got %#x at index %d", b, j)
+			}
+		}
+		h.putBuffer(buf)
+	}
+}
+
+func TestChunkify(t *testing.T) {
+	buf := make([]byte, BytesPerChunk*3)
+	buf[BytesPerChunk] = 1
+	chunks := chunkify(nil, buf)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[1][0] != 1 {
+		t.Errorf("Expected second chunk to be backed by the buffer, got %#x", chunks[1])
+	}
+	// Chunks must not be able to grow into the next chunk of the buffer.
+	if cap(chunks[0]) != BytesPerChunk {
+		t.Errorf("Expected chunk capacity of %d, got %d", BytesPerChunk, cap(chunks[0]))
+	}
+}
+
 func BenchmarkPack(b *testing.B) {
 	input := [][]byte{make([]byte, BytesPerChunk*8000)}
 	for n := 0; n < b.N; n++ {
@@ -0,0 +1,337 @@
+// Package jsonx encodes SSZ values to and from JSON following the conventions of the
+// eth2 Beacon API: byte lists, byte vectors and bitfields are 0x-prefixed hex strings,
+// unsigned integers are decimal strings, and fields are named in snake case:
+//
+//  {"slot": "1", "parent_root": "0xcf8e...", "aggregation_bits": "0x01"}
+//
+// Options select other conventions, such as the Go field names and plain JSON numbers
+// used by the ssz command.
+package jsonx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Naming selects the keys of the fields of structs.
+type Naming int
+
+const (
+	// SnakeCase names fields like the consensus specs, such as parent_root for
+	// ParentRoot and signed_header_1 for SignedHeader1. The name given by a json
+	// struct tag takes precedence.
+	SnakeCase Naming = iota
+	// CamelCase names fields in lower camel case, such as parentRoot.
+	CamelCase
+	// GoNames keeps the names of the Go fields, such as ParentRoot.
+	GoNames
+)
+
+// Options are the conventions of an encoding. The zero value follows the eth2
+// Beacon API.
+type Options struct {
+	Naming Naming
+	// Numbers encodes unsigned integers as JSON numbers rather than as decimal
+	// strings. Both are accepted when decoding.
+	Numbers bool
+}
+
+// Marshal returns the encoding of val following the eth2 Beacon API conventions.
+func Marshal(val interface{}) ([]byte, error) {
+	return Options{}.Marshal(val)
+}
+
+// Unmarshal decodes data, following the eth2 Beacon API conventions, into the
+// value pointed to by val.
+func Unmarshal(data []byte, val interface{}) error {
+	return Options{}.Unmarshal(data, val)
+}
+
+// Marshal returns the encoding of val.
+func (o Options) Marshal(val interface{}) ([]byte, error) {
+	data, err := o.Value(reflect.ValueOf(val))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// MarshalIndent is like Marshal but indents the output, as json.MarshalIndent.
+func (o Options) MarshalIndent(val interface{}, prefix string, indent string) ([]byte, error) {
+	data, err := o.Value(reflect.ValueOf(val))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(data, prefix, indent)
+}
+
+// Unmarshal decodes data into the value pointed to by val. Every field of the
+// structs must be present, while unknown keys are ignored.
+func (o Options) Unmarshal(data []byte, val interface{}) error {
+	rval := reflect.ValueOf(val)
+	if rval.Kind() != reflect.Ptr || rval.IsNil() {
+		return fmt.Errorf("can only unmarshal into a non-nil pointer, received %T", val)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return err
+	}
+	return o.SetValue(decoded, rval.Elem())
+}
+
+// object is a JSON object which keeps the order of the fields of the struct it
+// was built from.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// skipField reports whether f is left out of the JSON representation, as it is
+// by the encoding.
+func skipField(f reflect.StructField) bool {
+	return f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX")
+}
+
+// key returns the key of the field f.
+func (o Options) key(f reflect.StructField) string {
+	switch o.Naming {
+	case GoNames:
+		return f.Name
+	case CamelCase:
+		return camelCase(f.Name)
+	}
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return snakeCase(f.Name)
+}
+
+// Value converts val to a value encoding/json renders following the options.
+func (o Options) Value(val reflect.Value) (interface{}, error) {
+	typ := val.Type()
+	switch typ.Kind() {
+	case reflect.Bool:
+		return val.Bool(), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := strconv.FormatUint(val.Uint(), 10)
+		if o.Numbers {
+			return json.Number(s), nil
+		}
+		return s, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, val.Len())
+			reflect.Copy(reflect.ValueOf(b), val)
+			return "0x" + hex.EncodeToString(b), nil
+		}
+		elems := make([]interface{}, val.Len())
+		for i := range elems {
+			elem, err := o.Value(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return elems, nil
+	case reflect.Struct:
+		obj := make(object, 0, typ.NumField())
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if skipField(f) {
+				continue
+			}
+			value, err := o.Value(val.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			obj = append(obj, member{key: o.key(f), value: value})
+		}
+		return obj, nil
+	case reflect.Ptr:
+		if val.IsNil() {
+			return nil, nil
+		}
+		return o.Value(val.Elem())
+	default:
+		return nil, fmt.Errorf("type %v is not supported", typ)
+	}
+}
+
+// SetValue sets val from data, as decoded by an encoding/json decoder using
+// json.Number for numbers.
+func (o Options) SetValue(data interface{}, val reflect.Value) error {
+	typ := val.Type()
+	switch typ.Kind() {
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, received %v", data)
+		}
+		val.SetBool(b)
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var s string
+		switch d := data.(type) {
+		case json.Number:
+			s = d.String()
+		case string:
+			s = d
+		default:
+			return fmt.Errorf("expected a number, received %v", data)
+		}
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return err
+		}
+		val.SetUint(n)
+		return nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			s, ok := data.(string)
+			if !ok {
+				return fmt.Errorf("expected a hex string, received %v", data)
+			}
+			b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+			if err != nil {
+				return err
+			}
+			return setElems(val, len(b), func(i int, elem reflect.Value) error {
+				elem.SetUint(uint64(b[i]))
+				return nil
+			})
+		}
+		elems, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list, received %v", data)
+		}
+		return setElems(val, len(elems), func(i int, elem reflect.Value) error {
+			if err := o.SetValue(elems[i], elem); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+			return nil
+		})
+	case reflect.Struct:
+		fields, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an object, received %v", data)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if skipField(f) {
+				continue
+			}
+			key := o.key(f)
+			value, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("missing field %s", key)
+			}
+			if err := o.SetValue(value, val.Field(i)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return nil
+	case reflect.Ptr:
+		if data == nil {
+			val.Set(reflect.Zero(typ))
+			return nil
+		}
+		val.Set(reflect.New(typ.Elem()))
+		return o.SetValue(data, val.Elem())
+	default:
+		return fmt.Errorf("type %v is not supported", typ)
+	}
+}
+
+// setElems sizes val to hold n elements, and sets each of them with set.
+func setElems(val reflect.Value, n int, set func(i int, elem reflect.Value) error) error {
+	if val.Kind() == reflect.Array {
+		if n != val.Len() {
+			return fmt.Errorf("expected %d elements, received %d", val.Len(), n)
+		}
+	} else {
+		val.Set(reflect.MakeSlice(val.Type(), n, n))
+	}
+	for i := 0; i < n; i++ {
+		if err := set(i, val.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// words splits a Go name into its words, keeping acronyms such as BLS whole and
+// splitting trailing digits, as in Signed, Header and 1 for SignedHeader1. Digits
+// within a name stay with the preceding word, as in Eth1 and Data for Eth1Data.
+func words(name string) []string {
+	runes := []rune(name)
+	trailing := len(runes)
+	for trailing > 0 && unicode.IsDigit(runes[trailing-1]) {
+		trailing--
+	}
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		split := false
+		switch {
+		case i == trailing:
+			split = true
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			split = true
+		case unicode.IsUpper(cur) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			split = true
+		}
+		if split {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+func snakeCase(name string) string {
+	w := words(name)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+	}
+	return strings.Join(w, "_")
+}
+
+func camelCase(name string) string {
+	w := words(name)
+	w[0] = strings.ToLower(w[0])
+	return strings.Join(w, "")
+}
@@ -0,0 +1,120 @@
+package jsonx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestNaming(t *testing.T) {
+	tests := []struct {
+		name, snake, camel string
+	}{
+		{"ParentRoot", "parent_root", "parentRoot"},
+		{"SignedHeader1", "signed_header_1", "signedHeader1"},
+		{"Eth1Data", "eth1_data", "eth1Data"},
+		{"BLSToExecutionChanges", "bls_to_execution_changes", "blsToExecutionChanges"},
+		{"Slot", "slot", "slot"},
+	}
+	for _, tt := range tests {
+		if got := snakeCase(tt.name); got != tt.snake {
+			t.Errorf("Expected %s in snake case to be %s, received %s", tt.name, tt.snake, got)
+		}
+		if got := camelCase(tt.name); got != tt.camel {
+			t.Errorf("Expected %s in camel case to be %s, received %s", tt.name, tt.camel, got)
+		}
+	}
+}
+
+type example struct {
+	Slot            uint64
+	ParentRoot      [4]byte
+	AggregationBits bitfield.Bitlist
+	Renamed         bool `json:"other_name"`
+	Indices         []uint16
+	Parent          *example
+	unexported      uint64
+}
+
+func TestMarshal_Conventions(t *testing.T) {
+	val := example{
+		Slot:            1,
+		ParentRoot:      [4]byte{0xcf, 0x8e, 0, 1},
+		AggregationBits: bitfield.Bitlist{0x01},
+		Renamed:         true,
+		Indices:         []uint16{2, 3},
+	}
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, `{"slot":"1","parent_root":"0xcf8e0001","aggregation_bits":"0x01","other_name":true,"indices":["2","3"],"parent":null}`},
+		{Options{Naming: CamelCase, Numbers: true}, `{"slot":1,"parentRoot":"0xcf8e0001","aggregationBits":"0x01","renamed":true,"indices":[2,3],"parent":null}`},
+		{Options{Naming: GoNames}, `{"Slot":"1","ParentRoot":"0xcf8e0001","AggregationBits":"0x01","Renamed":true,"Indices":["2","3"],"Parent":null}`},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%+v: expected %s, received %s", tt.opts, tt.want, got)
+		}
+		var decoded example
+		if err := tt.opts.Unmarshal(got, &decoded); err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		if !reflect.DeepEqual(decoded, val) {
+			t.Errorf("%+v: expected %+v, received %+v", tt.opts, val, decoded)
+		}
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing field":   `{"slot": "1"}`,
+		"invalid hex":     `{"slot": "1", "parent_root": "0xzz"}`,
+		"vector length":   `{"slot": "1", "parent_root": "0x00", "aggregation_bits": "0x01", "other_name": true, "indices": [], "parent": null}`,
+		"negative number": `{"slot": "-1"}`,
+		"not an object":   `[1, 2]`,
+	}
+	for name, input := range tests {
+		var val example
+		if err := Unmarshal([]byte(input), &val); err == nil {
+			t.Errorf("%s: expected an error decoding %s", name, input)
+		}
+	}
+	if err := Unmarshal([]byte(`{}`), example{}); err == nil {
+		t.Error("Expected an error decoding into a non-pointer")
+	}
+}
+
+func TestRoundTrip_Vectors(t *testing.T) {
+	for _, v := range golden.Vectors() {
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range []Options{{}, {Naming: CamelCase, Numbers: true}} {
+			encoded, err := opts.Marshal(v.Value)
+			if err != nil {
+				t.Fatalf("%s: %v", v.Name, err)
+			}
+			val := reflect.New(reflect.TypeOf(v.Value))
+			if err := opts.Unmarshal(encoded, val.Interface()); err != nil {
+				t.Fatalf("%s: %v\n%s", v.Name, err, encoded)
+			}
+			got, err := ssz.Marshal(val.Elem().Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: round trip through %s changed the value", v.Name, encoded)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// specTests are the conventions of the value.yaml files of the consensus spec tests:
+// fields in snake case, or named by their json tag, and integers as YAML integers.
+var specTests = Options{Naming: SnakeCase, Numbers: true}
+
+// MarshalYAML returns the representation of val in the format of the value.yaml
+// files of the consensus spec tests, keeping the fields of structs in order.
+func MarshalYAML(val interface{}) ([]byte, error) {
+	data, err := specTests.Value(reflect.ValueOf(val))
+	if err != nil {
+		return nil, err
+	}
+	return yamlv2.Marshal(toYAML(data))
+}
+
+// toYAML converts a value returned by Value to the types yaml.v2 renders in order.
+func toYAML(data interface{}) interface{} {
+	switch d := data.(type) {
+	case object:
+		m := make(yamlv2.MapSlice, len(d))
+		for i, member := range d {
+			m[i] = yamlv2.MapItem{Key: member.key, Value: toYAML(member.value)}
+		}
+		return m
+	case []interface{}:
+		elems := make([]interface{}, len(d))
+		for i := range d {
+			elems[i] = toYAML(d[i])
+		}
+		return elems
+	case json.Number:
+		n, err := strconv.ParseUint(d.String(), 10, 64)
+		if err != nil {
+			return d.String()
+		}
+		return n
+	default:
+		return d
+	}
+}
+
+// UnmarshalYAML decodes data, in the format of the value.yaml files of the consensus
+// spec tests, into the value pointed to by val. Hex strings must be quoted, as they
+// are in the spec tests, since YAML reads unquoted ones as integers.
+func UnmarshalYAML(data []byte, val interface{}) error {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	return specTests.Unmarshal(data, val)
+}
@@ -0,0 +1,71 @@
+package jsonx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestMarshalYAML_SpecFormat(t *testing.T) {
+	val := benchmarks.ProposerSlashing{
+		SignedHeader1: benchmarks.SignedBeaconBlockHeader{
+			Message: benchmarks.BeaconBlockHeader{
+				Slot:       18446744073709551615,
+				ParentRoot: []byte{0x01, 0x02},
+			},
+			Signature: []byte{0xff},
+		},
+	}
+	got, err := MarshalYAML(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `signed_header_1:
+  message:
+    slot: 18446744073709551615
+    proposer_index: 0
+    parent_root: "0x0102"
+    state_root: 0x
+    body_root: 0x
+  signature: "0xff"
+signed_header_2:
+  message:
+    slot: 0
+    proposer_index: 0
+    parent_root: 0x
+    state_root: 0x
+    body_root: 0x
+  signature: 0x
+`
+	if string(got) != want {
+		t.Errorf("Expected:\n%s\nreceived:\n%s", want, got)
+	}
+}
+
+func TestYAML_RoundTrip(t *testing.T) {
+	for _, v := range golden.Vectors() {
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := MarshalYAML(v.Value)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		val := reflect.New(reflect.TypeOf(v.Value))
+		if err := UnmarshalYAML(encoded, val.Interface()); err != nil {
+			t.Fatalf("%s: %v\n%s", v.Name, err, encoded)
+		}
+		got, err := ssz.Marshal(val.Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: round trip through\n%s\nchanged the value", v.Name, encoded)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package ssz
+
+// Paths of the nodes light clients need branches for, following the field
+// names of the Go types of the consensus specs. States and block bodies using
+// other field names can be passed to Branch with their own paths.
+var (
+	// FinalizedRootPath designates the finalized checkpoint root of a beacon state.
+	FinalizedRootPath = []interface{}{"FinalizedCheckpoint", "Root"}
+	// CurrentSyncCommitteePath designates the current sync committee of a beacon state.
+	CurrentSyncCommitteePath = []interface{}{"CurrentSyncCommittee"}
+	// NextSyncCommitteePath designates the next sync committee of a beacon state.
+	NextSyncCommitteePath = []interface{}{"NextSyncCommittee"}
+	// ExecutionPayloadPath designates the execution payload of a beacon block body.
+	ExecutionPayloadPath = []interface{}{"ExecutionPayload"}
+)
+
+// Branch returns the Merkle branch of the node designated by path within val, as
+// resolved by GeneralizedIndex, ordered from the bottom of the tree up like the
+// branches of light client updates.
+//
+//  branch, err := Branch(state, "FinalizedCheckpoint", "Root")
+//  if err != nil {
+//      return fmt.Errorf("failed to compute finality branch: %v", err)
+//  }
+func Branch(val interface{}, path ...interface{}) ([][32]byte, error) {
+	proof, err := Prove(val, path...)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Hashes, nil
+}
+
+// FinalizedRootBranch returns the branch of the finalized checkpoint root of state.
+func FinalizedRootBranch(state interface{}) ([][32]byte, error) {
+	return Branch(state, FinalizedRootPath...)
+}
+
+// CurrentSyncCommitteeBranch returns the branch of the current sync committee of state.
+func CurrentSyncCommitteeBranch(state interface{}) ([][32]byte, error) {
+	return Branch(state, CurrentSyncCommitteePath...)
+}
+
+// NextSyncCommitteeBranch returns the branch of the next sync committee of state.
+func NextSyncCommitteeBranch(state interface{}) ([][32]byte, error) {
+	return Branch(state, NextSyncCommitteePath...)
+}
+
+// ExecutionPayloadBranch returns the branch of the execution payload of a beacon
+// block body.
+func ExecutionPayloadBranch(body interface{}) ([][32]byte, error) {
+	return Branch(body, ExecutionPayloadPath...)
+}
@@ -0,0 +1,103 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+type lightClientCheckpoint struct {
+	Epoch uint64
+	Root  [32]byte
+}
+
+type lightClientSyncCommittee struct {
+	Pubkeys         [4][48]byte
+	AggregatePubkey [48]byte
+}
+
+// lightClientState has the layout of an Altair beacon state, with placeholders
+// for the fields light clients do not need.
+type lightClientState struct {
+	Field0, Field1, Field2, Field3, Field4, Field5, Field6, Field7, Field8, Field9  uint64
+	Field10, Field11, Field12, Field13, Field14, Field15, Field16, Field17, Field18 uint64
+
+	CurrentJustifiedCheckpoint lightClientCheckpoint
+	FinalizedCheckpoint        lightClientCheckpoint
+	InactivityScores           []uint64 `ssz-max:"1099511627776"`
+	CurrentSyncCommittee       lightClientSyncCommittee
+	NextSyncCommittee          lightClientSyncCommittee
+}
+
+// lightClientBody has the layout of a Bellatrix beacon block body.
+type lightClientBody struct {
+	Field0, Field1, Field2, Field3, Field4, Field5, Field6, Field7, Field8 uint64
+
+	ExecutionPayload lightClientCheckpoint
+}
+
+func TestLightClientBranches(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	state := &lightClientState{
+		FinalizedCheckpoint:  lightClientCheckpoint{Epoch: 3, Root: [32]byte{1, 2, 3}},
+		InactivityScores:     []uint64{1, 2},
+		CurrentSyncCommittee: lightClientSyncCommittee{AggregatePubkey: [48]byte{4}},
+		NextSyncCommittee:    lightClientSyncCommittee{AggregatePubkey: [48]byte{5}},
+	}
+	body := &lightClientBody{Field8: 7, ExecutionPayload: lightClientCheckpoint{Epoch: 8}}
+
+	// The generalized indices of the consensus specs.
+	tests := []struct {
+		name   string
+		val    interface{}
+		branch func(interface{}) ([][32]byte, error)
+		path   []interface{}
+		gindex uint64
+	}{
+		{"finalized root", state, FinalizedRootBranch, FinalizedRootPath, 105},
+		{"current sync committee", state, CurrentSyncCommitteeBranch, CurrentSyncCommitteePath, 54},
+		{"next sync committee", state, NextSyncCommitteeBranch, NextSyncCommitteePath, 55},
+		{"execution payload", body, ExecutionPayloadBranch, ExecutionPayloadPath, 25},
+	}
+	for _, tt := range tests {
+		gindex, err := GeneralizedIndex(reflect.TypeOf(tt.val), tt.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gindex != tt.gindex {
+			t.Errorf("%s: expected generalized index %d, received %d", tt.name, tt.gindex, gindex)
+		}
+		branch, err := tt.branch(tt.val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(branch) != int(bitLength(gindex))-1 {
+			t.Errorf("%s: expected branch of depth %d, received %d", tt.name, bitLength(gindex)-1, len(branch))
+		}
+		root, err := HashTreeRoot(tt.val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := Prove(tt.val, tt.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof.Hashes = branch
+		if ok, err := VerifyProof(root, proof); err != nil || !ok {
+			t.Errorf("%s: expected branch to verify, received %v, %v", tt.name, ok, err)
+		}
+	}
+}
+
+func TestBranch_CustomPath(t *testing.T) {
+	if _, err := FinalizedRootBranch(&lightClientBody{}); err == nil {
+		t.Error("Expected error for a value without a finalized checkpoint")
+	}
+	branch, err := Branch(&lightClientBody{}, "Field8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branch) != 4 {
+		t.Errorf("Expected branch of depth 4, received %d", len(branch))
+	}
+}
@@ -0,0 +1,231 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ListHasher keeps the Merkle tree of a long list in memory, so that the root of the
+// list can be recomputed after elements are updated or appended by rehashing their
+// paths to the root only, rather than the whole list, as for validator registries or
+// balances:
+//
+//  validators, err := NewListHasher(reflect.TypeOf(&Validator{}), validatorRegistryLimit)
+//  if err != nil {
+//      return err
+//  }
+//  for _, v := range state.Validators {
+//      if err := validators.Append(v); err != nil {
+//          return err
+//      }
+//  }
+//  root := validators.Root()
+//  ...
+//  if err := validators.Update(index, state.Validators[index]); err != nil {
+//      return err
+//  }
+//  root = validators.Root()
+//
+// Elements are hashed as soon as they are updated or appended, while their paths are
+// rehashed by the next call to Root, so that building the tree of n elements costs
+// O(n) hashes, and updating k elements O(k log n). The root is the one
+// HashTreeRootWithCapacity returns for the list with capacity limit. List hashers are
+// safe for concurrent use.
+type ListHasher struct {
+	elemType reflect.Type
+	utils    *sszUtils
+	// elemSize is the size of basic elements, which are packed into the leaves,
+	// and 0 for composite elements, whose roots are the leaves.
+	elemSize uint64
+	limit    uint64
+	depth    uint64
+
+	lock   sync.Mutex
+	length uint64
+	// layers[d] holds the nodes of the tree at height d, up to the last one
+	// covering an element, layers[0] holding the leaves.
+	layers [][][32]byte
+	// dirty holds the indices of the leaves modified since the paths of the
+	// tree were last rehashed.
+	dirty []uint64
+}
+
+// NewListHasher returns the hasher of an empty list of at most limit elements of type
+// elemType.
+func NewListHasher(elemType reflect.Type, limit uint64) (*ListHasher, error) {
+	if elemType == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	if limit == 0 {
+		return nil, errors.New("list hashers require a limit")
+	}
+	utils, err := cachedSSZUtils(elemType)
+	if err != nil {
+		return nil, err
+	}
+	l := &ListHasher{
+		elemType: elemType,
+		utils:    utils,
+		limit:    limit,
+	}
+	chunkLimit := limit
+	if isBasicType(elemType.Kind()) && lookupCodec(elemType) == nil {
+		l.elemSize = staticFixedSize(elemType)
+		chunkLimit = (limit*l.elemSize + 31) / 32
+	}
+	l.depth = bitLength(chunkLimit - 1)
+	l.layers = make([][][32]byte, l.depth+1)
+	return l, nil
+}
+
+// Len returns the number of elements of the list.
+func (l *ListHasher) Len() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.length
+}
+
+// Append adds elem, a value of the element type of the list, at the end of the list.
+// An error wrapping ErrMaxLength is returned if the list is full.
+func (l *ListHasher) Append(elem interface{}) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.length == l.limit {
+		return errorf(ErrMaxLength, "list of %d elements is full", l.limit)
+	}
+	if err := l.set(l.length, elem); err != nil {
+		return err
+	}
+	l.length++
+	return nil
+}
+
+// Update replaces the element of the list at index with elem, a value of the element
+// type of the list.
+func (l *ListHasher) Update(index uint64, elem interface{}) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if index >= l.length {
+		return fmt.Errorf("index %d is out of bounds of list of %d elements", index, l.length)
+	}
+	return l.set(index, elem)
+}
+
+// set writes the leaf of elem, the element at index, and marks it dirty. The leaf is
+// added to the tree if it is the first of the leaves of the list to hold index.
+func (l *ListHasher) set(index uint64, elem interface{}) (err error) {
+	defer recoverPanic(&err)
+	val := reflect.ValueOf(elem)
+	if !val.IsValid() || val.Type() != l.elemType {
+		return fmt.Errorf("cannot add %T to list of %v", elem, l.elemType)
+	}
+	leaf := index
+	if l.elemSize != 0 {
+		leaf = index * l.elemSize / 32
+	}
+	if leaf == uint64(len(l.layers[0])) {
+		l.layers[0] = append(l.layers[0], [32]byte{})
+	}
+	if l.elemSize != 0 {
+		if _, err := l.utils.marshaler(val, l.layers[0][leaf][:], index*l.elemSize%32); err != nil {
+			return fmt.Errorf("element %d: %w", index, err)
+		}
+	} else {
+		h := acquireHasher()
+		defer releaseHasher(h)
+		r, err := l.utils.hasher(h, val, 0)
+		if err != nil {
+			return fmt.Errorf("element %d: %w", index, err)
+		}
+		l.layers[0][leaf] = r
+	}
+	l.dirty = append(l.dirty, leaf)
+	return nil
+}
+
+// Root returns the root of the list, rehashing the paths of the elements updated or
+// appended since the last call.
+func (l *ListHasher) Root() [32]byte {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	h := acquireHasher()
+	defer releaseHasher(h)
+	l.rehash(h)
+	root := toBytes32(zeroHashes[l.depth])
+	if len(l.layers[l.depth]) > 0 {
+		root = l.layers[l.depth][0]
+	}
+	output := lengthChunk(l.length)
+	return h.mixInLength(root, output[:])
+}
+
+// rehash recomputes the nodes of the tree above the dirty leaves, one layer at a time,
+// so that the nodes shared by several dirty paths are only hashed once.
+func (l *ListHasher) rehash(h *Hasher) {
+	if len(l.dirty) == 0 {
+		return
+	}
+	dirty := l.dirty
+	sort.Slice(dirty, func(i, j int) bool { return dirty[i] < dirty[j] })
+	for d := uint64(0); d < l.depth; d++ {
+		layer := l.layers[d]
+		parents := dirty[:0]
+		for _, i := range dirty {
+			p := i / 2
+			if len(parents) > 0 && parents[len(parents)-1] == p {
+				continue
+			}
+			parents = append(parents, p)
+			right := zeroHashes[d]
+			if 2*p+1 < uint64(len(layer)) {
+				right = layer[2*p+1][:]
+			}
+			node := h.hashPair(layer[2*p][:], right)
+			// Parents are visited in order, so that new nodes are appended.
+			if p == uint64(len(l.layers[d+1])) {
+				l.layers[d+1] = append(l.layers[d+1], node)
+			} else {
+				l.layers[d+1][p] = node
+			}
+		}
+		dirty = parents
+	}
+	l.dirty = l.dirty[:0]
+}
+
+// Prove returns the Merkle proof of the leaf holding the element at index against the
+// root of the list, with the generalized index of the leaf within the tree of the list.
+// The leaf is the root of composite elements, and the chunk packing basic ones.
+func (l *ListHasher) Prove(index uint64) (*Proof, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if index >= l.length {
+		return nil, fmt.Errorf("index %d is out of bounds of list of %d elements", index, l.length)
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	l.rehash(h)
+	leaf := index
+	if l.elemSize != 0 {
+		leaf = index * l.elemSize / 32
+	}
+	// The tree of the elements is the left child of the root of the list, whose
+	// right child holds its length.
+	hashes := make([][32]byte, 0, l.depth+1)
+	for d, i := uint64(0), leaf; d < l.depth; d, i = d+1, i/2 {
+		sibling := toBytes32(zeroHashes[d])
+		if i^1 < uint64(len(l.layers[d])) {
+			sibling = l.layers[d][i^1]
+		}
+		hashes = append(hashes, sibling)
+	}
+	hashes = append(hashes, lengthChunk(l.length))
+	return &Proof{
+		Index:  2<<l.depth + leaf,
+		Leaf:   l.layers[0][leaf],
+		Hashes: hashes,
+	}, nil
+}
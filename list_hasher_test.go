@@ -0,0 +1,127 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestListHasher(t *testing.T) {
+	type checkFn func(l *ListHasher, list interface{})
+	check := func(t *testing.T, limit uint64) checkFn {
+		return func(l *ListHasher, list interface{}) {
+			t.Helper()
+			want, err := HashTreeRootWithCapacity(list, limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root := l.Root(); root != want {
+				t.Errorf("Expected root %#x for %v, received %#x", want, list, root)
+			}
+		}
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		check := check(t, 1024)
+		l, err := NewListHasher(reflect.TypeOf(uint64(0)), 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var balances []uint64
+		check(l, balances)
+		for i := uint64(0); i < 9; i++ {
+			balances = append(balances, 32+i)
+			if err := l.Append(balances[i]); err != nil {
+				t.Fatal(err)
+			}
+			check(l, balances)
+		}
+		balances[2], balances[7] = 1, 2
+		for _, i := range []uint64{2, 7} {
+			if err := l.Update(i, balances[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check(l, balances)
+		if l.Len() != uint64(len(balances)) {
+			t.Errorf("Expected %d elements, received %d", len(balances), l.Len())
+		}
+		// Balance 7 is packed into the second chunk of the list.
+		proof, err := l.Prove(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The 256 chunks of the list are the leaves of a tree of depth 8, below the
+		// left child of the root.
+		if proof.Index != 512+1 {
+			t.Errorf("Expected generalized index 513, received %d", proof.Index)
+		}
+		if ok, err := VerifyProof(l.Root(), proof); err != nil || !ok {
+			t.Errorf("Expected proof of balance 7 to be valid, received %v", err)
+		}
+	})
+
+	t.Run("composite", func(t *testing.T) {
+		check := check(t, 16)
+		l, err := NewListHasher(reflect.TypeOf(fork{}), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var forks []fork
+		for i := uint64(0); i < 5; i++ {
+			forks = append(forks, fork{Epoch: i})
+			if err := l.Append(forks[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check(l, forks)
+		forks[4].CurrentVersion = [4]byte{1}
+		if err := l.Update(4, forks[4]); err != nil {
+			t.Fatal(err)
+		}
+		forks = append(forks, fork{Epoch: 9})
+		if err := l.Append(forks[5]); err != nil {
+			t.Fatal(err)
+		}
+		check(l, forks)
+	})
+
+	t.Run("roots", func(t *testing.T) {
+		check := check(t, 8)
+		l, err := NewListHasher(reflect.TypeOf([32]byte{}), 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots := [][32]byte{{1}, {2}, {3}}
+		for _, r := range roots {
+			if err := l.Append(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check(l, roots)
+	})
+}
+
+func TestListHasher_Invalid(t *testing.T) {
+	l, err := NewListHasher(reflect.TypeOf(fork{}), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.Append(fork{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Append(fork{}); !errors.Is(err, ErrMaxLength) {
+		t.Errorf("Expected appending to a full list to fail, received %v", err)
+	}
+	if err := l.Update(2, fork{}); err == nil {
+		t.Error("Expected updating past the end of the list to fail")
+	}
+	if err := l.Update(0, &fork{}); err == nil {
+		t.Error("Expected elements of another type to be rejected")
+	}
+	if _, err := NewListHasher(reflect.TypeOf(fork{}), 0); err == nil {
+		t.Error("Expected lists without limit to be rejected")
+	}
+}
@@ -0,0 +1,166 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ListIterator steps through the elements of an encoded SSZ list one at a time,
+// decoding only the elements asked for, so that a list can be filtered or streamed
+// without being decoded as a whole.
+//
+//  it, err := NewListIterator(encodedAttestations, reflect.TypeOf(&Attestation{}))
+//  if err != nil {
+//      return fmt.Errorf("failed to read attestations: %v", err)
+//  }
+//  for it.Next() {
+//      var att *Attestation
+//      if err := it.Decode(&att); err != nil {
+//          return fmt.Errorf("failed to decode attestation %d: %v", it.Index(), err)
+//      }
+//      process(att)
+//  }
+//  if err := it.Err(); err != nil {
+//      return fmt.Errorf("failed to read attestations: %v", err)
+//  }
+type ListIterator struct {
+	data     []byte
+	elemType reflect.Type
+	utils    *sszUtils
+	// elemSize is the size of fixed-size elements, and 0 for variable-size
+	// elements, which are located through their offsets.
+	elemSize uint64
+	count    uint64
+
+	// index is the index of the current element plus one, so that the
+	// iterator starts before the first element.
+	index   uint64
+	current []byte
+	err     error
+}
+
+// NewListIterator returns an iterator over the elements of data, the encoding of a
+// list of elements of type elemType. The size of data and the first offset are checked
+// upfront, while elements are only checked as they are reached.
+func NewListIterator(data []byte, elemType reflect.Type) (*ListIterator, error) {
+	if elemType == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	utils, err := cachedSSZUtils(elemType)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize unmarshaler for type: %v, %w", elemType, err)
+	}
+	it := &ListIterator{data: data, elemType: elemType, utils: utils}
+	if !isVariableSizeType(elemType) {
+		it.elemSize = staticFixedSize(elemType)
+		if it.elemSize == 0 || uint64(len(data))%it.elemSize != 0 {
+			return nil, errorf(ErrSize, "%d bytes cannot encode a list of %v", len(data), elemType)
+		}
+		it.count = uint64(len(data)) / it.elemSize
+		return it, nil
+	}
+	if len(data) == 0 {
+		return it, nil
+	}
+	firstOffset, err := readOffset(data, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if firstOffset == 0 || firstOffset%BytesPerLengthOffset != 0 {
+		return nil, errorf(ErrOffset, "first offset %d is not a multiple of %d", firstOffset, BytesPerLengthOffset)
+	}
+	it.count = firstOffset / BytesPerLengthOffset
+	return it, nil
+}
+
+// Len returns the number of elements of the list.
+func (it *ListIterator) Len() uint64 {
+	return it.count
+}
+
+// Next advances the iterator to the next element, and reports whether there is
+// one. It returns false at the end of the list, and once an element cannot be
+// located, in which case Err returns the error.
+func (it *ListIterator) Next() bool {
+	if it.err != nil || it.index >= it.count {
+		it.current = nil
+		return false
+	}
+	i := it.index
+	var start, end uint64
+	if it.elemSize != 0 {
+		start, end = i*it.elemSize, (i+1)*it.elemSize
+	} else {
+		var err error
+		if start, err = readOffset(it.data, i*BytesPerLengthOffset, 0); err != nil {
+			it.fail(err)
+			return false
+		}
+		end = uint64(len(it.data))
+		if i+1 < it.count {
+			if end, err = readOffset(it.data, (i+1)*BytesPerLengthOffset, 0); err != nil {
+				it.fail(err)
+				return false
+			}
+		}
+	}
+	current, err := segment(it.data, start, end)
+	if err != nil {
+		it.fail(err)
+		return false
+	}
+	it.current = current
+	it.index++
+	return true
+}
+
+// Index returns the index of the current element.
+func (it *ListIterator) Index() uint64 {
+	if it.index == 0 {
+		return 0
+	}
+	return it.index - 1
+}
+
+// Bytes returns the encoding of the current element, which aliases the data of
+// the iterator.
+func (it *ListIterator) Bytes() []byte {
+	return it.current
+}
+
+// Decode decodes the current element into the object pointed by out, which must
+// be of the element type of the iterator.
+func (it *ListIterator) Decode(out interface{}) error {
+	if out == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	rval := reflect.ValueOf(out)
+	if rval.Kind() != reflect.Ptr || rval.IsNil() {
+		return errors.New("can only unmarshal into a non-nil pointer target")
+	}
+	if rval.Elem().Type() != it.elemType {
+		return fmt.Errorf("cannot decode %v into %T", it.elemType, out)
+	}
+	if it.current == nil {
+		return errors.New("iterator is not positioned on an element")
+	}
+	val := rval.Elem()
+	if val.Kind() == reflect.Ptr && val.IsNil() {
+		val.Set(reflect.New(it.elemType.Elem()))
+	}
+	if _, err := it.utils.unmarshaler(nil, it.current, val, 0); err != nil {
+		return fmt.Errorf("could not unmarshal input into type: %v, %w", it.elemType, err)
+	}
+	return nil
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+func (it *ListIterator) fail(err error) {
+	it.err = fmt.Errorf("could not locate element %d: %w", it.index, err)
+	it.current = nil
+}
@@ -0,0 +1,114 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type iteratedItem struct {
+	Slot uint64
+	Data []byte `ssz-max:"16"`
+}
+
+func TestListIterator(t *testing.T) {
+	tests := []struct {
+		name  string
+		items interface{}
+	}{
+		{name: "fixed-size elements", items: []fork{{Epoch: 1}, {Epoch: 2}, {CurrentVersion: [4]byte{3}}}},
+		{name: "pointer elements", items: []*fork{{Epoch: 1}, {Epoch: 2}}},
+		{name: "basic elements", items: []uint64{5, 6, 7, 8}},
+		{name: "variable-size elements", items: []iteratedItem{{Slot: 1, Data: []byte{1, 2}}, {Slot: 2, Data: []byte{}}, {Slot: 3, Data: []byte{3}}}},
+		{name: "empty list", items: []iteratedItem{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Marshal(tt.items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items := reflect.ValueOf(tt.items)
+			it, err := NewListIterator(encoded, items.Type().Elem())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if it.Len() != uint64(items.Len()) {
+				t.Errorf("Expected %d elements, received %d", items.Len(), it.Len())
+			}
+			decoded := reflect.MakeSlice(items.Type(), 0, 0)
+			for it.Next() {
+				elem := reflect.New(items.Type().Elem())
+				if err := it.Decode(elem.Interface()); err != nil {
+					t.Fatal(err)
+				}
+				if it.Index() != uint64(decoded.Len()) {
+					t.Errorf("Expected index %d, received %d", decoded.Len(), it.Index())
+				}
+				decoded = reflect.Append(decoded, elem.Elem())
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Interface(), tt.items) {
+				t.Errorf("Expected %v, received %v", tt.items, decoded.Interface())
+			}
+		})
+	}
+}
+
+func TestListIterator_Bytes(t *testing.T) {
+	items := []iteratedItem{{Slot: 1, Data: []byte{1}}, {Slot: 2, Data: []byte{2, 3}}}
+	encoded, err := Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it, err := NewListIterator(encoded, reflect.TypeOf(iteratedItem{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; it.Next(); i++ {
+		want, err := Marshal(items[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(it.Bytes(), want) {
+			t.Errorf("Expected element %d to be encoded as %#x, received %#x", i, want, it.Bytes())
+		}
+	}
+}
+
+func TestListIterator_Errors(t *testing.T) {
+	if _, err := NewListIterator(make([]byte, 10), reflect.TypeOf(uint64(0))); err == nil {
+		t.Error("Expected error for data which is not a multiple of the element size")
+	}
+	if _, err := NewListIterator([]byte{3, 0, 0, 0}, reflect.TypeOf(iteratedItem{})); err == nil {
+		t.Error("Expected error for a first offset which is not a multiple of the offset size")
+	}
+
+	// The third offset points past the end of the data.
+	data := []byte{12, 0, 0, 0, 12, 0, 0, 0, 64, 0, 0, 0}
+	it, err := NewListIterator(data, reflect.TypeOf([]byte{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() {
+		t.Fatalf("Expected the first element to be located: %v", it.Err())
+	}
+	if it.Next() {
+		t.Error("Expected the second element not to be located")
+	}
+	if it.Err() == nil || !strings.Contains(it.Err().Error(), "element 1") {
+		t.Errorf("Expected error locating element 1, received %v", it.Err())
+	}
+
+	it, err = NewListIterator(make([]byte, 8), reflect.TypeOf(uint64(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrong uint32
+	it.Next()
+	if err := it.Decode(&wrong); err == nil {
+		t.Error("Expected error decoding into the wrong type")
+	}
+}
@@ -5,10 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-
-	"github.com/prysmaticlabs/go-bitfield"
 )
 
+var checkMaxLength = true
+
+// ToggleMaxLengthCheck allows to programmatically enable/disable the check
+// which rejects marshaling lists holding more elements than their ssz-max tag allows.
+// It is enabled by default, and should only be disabled by test tooling which
+// intentionally builds invalid objects.
+func ToggleMaxLengthCheck(enableMaxLengthCheck bool) {
+	checkMaxLength = enableMaxLengthCheck
+}
+
 // Marshal a value and output the result into a byte slice.
 // Given a struct with the following fields, one can marshal it as follows:
 //  type exampleStruct struct {
@@ -43,20 +51,21 @@ import (
 //
 // This will treat `Field2` as type [][32]byte when marshaling a
 // struct of that type.
-func Marshal(val interface{}) ([]byte, error) {
+func Marshal(val interface{}) (_ []byte, err error) {
+	defer recoverPanic(&err)
 	if val == nil {
 		return nil, errors.New("untyped-value nil cannot be marshaled")
 	}
 	rval := reflect.ValueOf(val)
-
-	// We pre-allocate a buffer-size depending on the value's calculated total byte size.
-	buf := make([]byte, determineSize(rval))
 	sszUtils, err := cachedSSZUtils(rval.Type())
 	if err != nil {
-		return nil, fmt.Errorf("could not initialize marshaler for type: %v", rval.Type())
+		return nil, fmt.Errorf("could not initialize marshaler for type: %v, %w", rval.Type(), err)
 	}
+
+	// We pre-allocate a buffer-size depending on the value's calculated total byte size.
+	buf := make([]byte, determineSize(rval))
 	if _, err = sszUtils.marshaler(rval, buf, 0 /* start offset */); err != nil {
-		return nil, fmt.Errorf("failed to marshal for type: %v", rval.Type())
+		return nil, fmt.Errorf("failed to marshal for type: %v: %w", rval.Type(), err)
 	}
 	return buf, nil
 }
@@ -74,10 +83,20 @@ func makeMarshaler(typ reflect.Type) (marshaler, error) {
 		return marshalUint32, nil
 	case kind == reflect.Uint64:
 		return marshalUint64, nil
+	case isBitlist(typ):
+		return marshalBitlist, nil
+	case isBitvector(typ):
+		return makeBitvectorMarshaler(typ)
 	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
 		return marshalByteSlice, nil
 	case kind == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
 		return marshalByteArray, nil
+	case isPackedArray(typ):
+		return makePackedArrayMarshaler(typ)
+	case kind == reflect.Slice && typ.Elem() == uint64Type:
+		return marshalUint64Slice, nil
+	case kind == reflect.Slice && typ.Elem() == rootType:
+		return marshalRootSlice, nil
 	case kind == reflect.Slice && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()):
 		return makeBasicSliceMarshaler(typ)
 	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
@@ -86,12 +105,14 @@ func makeMarshaler(typ reflect.Type) (marshaler, error) {
 		return makeBasicSliceMarshaler(typ)
 	case kind == reflect.Slice || kind == reflect.Array:
 		return makeCompositeSliceMarshaler(typ)
+	case kind == reflect.String:
+		return marshalString, nil
 	case kind == reflect.Struct:
 		return makeStructMarshaler(typ)
 	case kind == reflect.Ptr:
 		return makePtrMarshaler(typ)
 	default:
-		return nil, fmt.Errorf("type %v is not serializable", typ)
+		return nil, errorf(ErrUnsupportedType, "type %v is not serializable", typ)
 	}
 }
 
@@ -112,63 +133,124 @@ func marshalUint8(val reflect.Value, buf []byte, startOffset uint64) (uint64, er
 
 func marshalUint16(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
 	v := val.Uint()
-	b := make([]byte, 2)
-	binary.LittleEndian.PutUint16(b, uint16(v))
-	copy(buf[startOffset:startOffset+2], b)
+	binary.LittleEndian.PutUint16(buf[startOffset:startOffset+2], uint16(v))
 	return startOffset + 2, nil
 }
 
 func marshalUint32(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
 	v := val.Uint()
-	b := make([]byte, 4)
-	binary.LittleEndian.PutUint32(b, uint32(v))
-	copy(buf[startOffset:startOffset+4], b)
+	binary.LittleEndian.PutUint32(buf[startOffset:startOffset+4], uint32(v))
 	return startOffset + 4, nil
 }
 
 func marshalUint64(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
 	v := val.Uint()
-	b := make([]byte, 8)
-	binary.LittleEndian.PutUint64(b, uint64(v))
-	copy(buf[startOffset:startOffset+8], b)
+	binary.LittleEndian.PutUint64(buf[startOffset:startOffset+8], v)
 	return startOffset + 8, nil
 }
 
 func marshalByteSlice(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
-	if _, ok := val.Interface().(bitfield.Bitfield); ok {
-		newVal := reflect.New(reflect.TypeOf([]byte{})).Elem()
-		newVal.Set(val)
-		newSlice := newVal.Interface().([]byte)
-		copy(buf[startOffset:startOffset+uint64(val.Len())], newSlice)
-		return startOffset + uint64(len(newSlice)), nil
-	}
-	slice := val.Slice(0, val.Len()).Interface().([]byte)
+	// Bytes also accepts byte slice types other than []byte.
+	slice := val.Bytes()
 	copy(buf[startOffset:startOffset+uint64(len(slice))], slice)
-	return startOffset + uint64(val.Len()), nil
+	return startOffset + uint64(len(slice)), nil
+}
+
+// marshalBitlist encodes bitlists from their bits and length, followed by their delimiter
+// bit, so that nil and empty bitlists are encoded as their delimiter bit only.
+func marshalBitlist(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+	bfield := val.Interface().(Bitfield)
+	length := bfield.Len()
+	data := bfield.Bytes()
+	if uint64(len(data)) > (length+7)/8 {
+		return 0, errorf(ErrSize, "bitlist of type %v holds %d bytes, expected at most %d", val.Type(), len(data), (length+7)/8)
+	}
+	end := startOffset + length/8 + 1
+	for i := startOffset + uint64(copy(buf[startOffset:end], data)); i < end; i++ {
+		buf[i] = 0
+	}
+	buf[end-1] |= 1 << (length % 8)
+	return end, nil
+}
+
+// makeBitvectorMarshaler returns the marshaler of the bitvector type typ, which writes
+// the bytes of bitvectors, as masked by their Bytes method, padded with zeros to the
+// size of the type.
+func makeBitvectorMarshaler(typ reflect.Type) (marshaler, error) {
+	size := bitvectorSize(typ)
+	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+		data := val.Interface().(Bitfield).Bytes()
+		if uint64(len(data)) > size {
+			return 0, errorf(ErrSize, "bitvector of type %v holds %d bytes, expected at most %d", typ, len(data), size)
+		}
+		end := startOffset + size
+		for i := startOffset + uint64(copy(buf[startOffset:end], data)); i < end; i++ {
+			buf[i] = 0
+		}
+		return end, nil
+	}
+	return marshaler, nil
 }
 
 func marshalByteArray(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
-	rawBytes := make([]byte, val.Len())
+	end := startOffset + uint64(val.Len())
+	// Arrays of bytes, such as public keys, signatures or blobs, are copied at once
+	// rather than byte by byte, whether or not they are addressable.
+	if val.Type().Elem() == byteType && val.CanInterface() {
+		reflect.Copy(reflect.ValueOf(buf[startOffset:end]), val)
+		return end, nil
+	}
 	for i := 0; i < val.Len(); i++ {
-		rawBytes[i] = uint8(val.Index(i).Uint())
+		buf[startOffset+uint64(i)] = uint8(val.Index(i).Uint())
+	}
+	return end, nil
+}
+
+func makePackedArrayMarshaler(typ reflect.Type) (marshaler, error) {
+	elemSize := staticFixedSize(typ.Elem())
+	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+		end := startOffset + uint64(val.Len())*elemSize
+		putPacked(buf[startOffset:end], val, elemSize)
+		return end, nil
+	}
+	return marshaler, nil
+}
+
+// putPacked writes the little-endian serialization of every uint element of val,
+// each elemSize bytes long, next to each other into buf.
+func putPacked(buf []byte, val reflect.Value, elemSize uint64) {
+	switch elemSize {
+	case 2:
+		for i := 0; i < val.Len(); i++ {
+			binary.LittleEndian.PutUint16(buf[i*2:], uint16(val.Index(i).Uint()))
+		}
+	case 4:
+		for i := 0; i < val.Len(); i++ {
+			binary.LittleEndian.PutUint32(buf[i*4:], uint32(val.Index(i).Uint()))
+		}
+	case 8:
+		for i := 0; i < val.Len(); i++ {
+			binary.LittleEndian.PutUint64(buf[i*8:], val.Index(i).Uint())
+		}
 	}
-	copy(buf[startOffset:startOffset+uint64(len(rawBytes))], rawBytes)
-	return startOffset + uint64(len(rawBytes)), nil
 }
 
 func makeBasicSliceMarshaler(typ reflect.Type) (marshaler, error) {
 	elemSSZUtils, err := cachedSSZUtilsNoAcquireLock(typ.Elem())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ssz utils: %v", err)
+		return nil, fmt.Errorf("failed to get ssz utils: %w", err)
 	}
 
 	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+		if end, ok := marshalRaw(val, buf, startOffset); ok {
+			return end, nil
+		}
 		index := startOffset
 		var err error
 		for i := 0; i < val.Len(); i++ {
 			index, err = elemSSZUtils.marshaler(val.Index(i), buf, index)
 			if err != nil {
-				return 0, err
+				return 0, fmt.Errorf("element %d: %w", i, err)
 			}
 		}
 		return index, nil
@@ -179,7 +261,7 @@ func makeBasicSliceMarshaler(typ reflect.Type) (marshaler, error) {
 func makeCompositeSliceMarshaler(typ reflect.Type) (marshaler, error) {
 	elemSSZUtils, err := cachedSSZUtilsNoAcquireLock(typ.Elem())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ssz utils: %v", err)
+		return nil, fmt.Errorf("failed to get ssz utils: %w", err)
 	}
 
 	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
@@ -191,7 +273,7 @@ func makeCompositeSliceMarshaler(typ reflect.Type) (marshaler, error) {
 				// into the buffer at the last index we wrote at.
 				index, err = elemSSZUtils.marshaler(val.Index(i), buf, index)
 				if err != nil {
-					return 0, err
+					return 0, fmt.Errorf("element %d: %w", i, err)
 				}
 			}
 		} else {
@@ -203,12 +285,10 @@ func makeCompositeSliceMarshaler(typ reflect.Type) (marshaler, error) {
 			for i := 0; i < val.Len(); i++ {
 				nextOffsetIndex, err = elemSSZUtils.marshaler(val.Index(i), buf, currentOffsetIndex)
 				if err != nil {
-					return 0, err
+					return 0, fmt.Errorf("element %d: %w", i, err)
 				}
 				// Write the offset.
-				offsetBuf := make([]byte, BytesPerLengthOffset)
-				binary.LittleEndian.PutUint32(offsetBuf, uint32(currentOffsetIndex-startOffset))
-				copy(buf[fixedIndex:fixedIndex+BytesPerLengthOffset], offsetBuf)
+				binary.LittleEndian.PutUint32(buf[fixedIndex:fixedIndex+BytesPerLengthOffset], uint32(currentOffsetIndex-startOffset))
 
 				// We increase the offset indices accordingly.
 				currentOffsetIndex = nextOffsetIndex
@@ -226,36 +306,62 @@ func makeStructMarshaler(typ reflect.Type) (marshaler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The fixed part of the struct is only sized by its values for fields holding
+	// pointers or slices: the size of the others is computed once.
+	variable := make([]bool, len(fields))
+	sizedByValue := make([]bool, len(fields))
+	staticLength := uint64(0)
+	for i, f := range fields {
+		variable[i] = isVariableSizeType(f.typ)
+		switch {
+		case variable[i]:
+			staticLength += BytesPerLengthOffset
+		case typ.Field(f.index).Type.Kind() == reflect.Ptr || f.typ.Kind() == reflect.Slice:
+			sizedByValue[i] = true
+		default:
+			staticLength += staticFixedSize(f.typ)
+		}
+	}
 	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+		var name string
+		defer annotatePanic(&name)
 		fixedIndex := startOffset
-		fixedLength := uint64(0)
-		// For every field, we add up the total length of the items depending if they
-		// are variable or fixed-size fields.
-		for _, f := range fields {
-			if isVariableSizeType(f.typ) {
-				fixedLength += BytesPerLengthOffset
-			} else {
+		fixedLength := staticLength
+		for i, f := range fields {
+			if sizedByValue[i] {
 				fixedLength += determineFixedSize(val.Field(f.index), f.typ)
 			}
 		}
 		currentOffsetIndex := startOffset + fixedLength
 		nextOffsetIndex := currentOffsetIndex
+		trace := activeTracer()
 		var err error
 		for i, f := range fields {
-			if !isVariableSizeType(f.typ) {
-				fixedIndex, err = f.sszUtils.marshaler(val.Field(i), buf, fixedIndex)
-				if err != nil {
+			name = f.name
+			if checkMaxLength && f.hasCapacity && f.opaque == nil {
+				if err := checkFieldLength(val.Field(f.index), f); err != nil {
 					return 0, err
 				}
+			}
+			if !variable[i] {
+				fieldIndex := fixedIndex
+				fixedIndex, err = f.sszUtils.marshaler(val.Field(f.index), buf, fixedIndex)
+				if err != nil {
+					return 0, fmt.Errorf("field %s: %w", f.name, err)
+				}
+				if trace != nil {
+					traceField(trace, TraceMarshal, typ, f, false, fieldIndex-startOffset, fixedIndex-startOffset)
+				}
 			} else {
 				nextOffsetIndex, err = f.sszUtils.marshaler(val.Field(f.index), buf, currentOffsetIndex)
 				if err != nil {
-					return 0, err
+					return 0, fmt.Errorf("field %s: %w", f.name, err)
+				}
+				if trace != nil {
+					traceField(trace, TraceMarshal, typ, f, true, currentOffsetIndex-startOffset, nextOffsetIndex-startOffset)
 				}
 				// Write the offset.
-				offsetBuf := make([]byte, BytesPerLengthOffset)
-				binary.LittleEndian.PutUint32(offsetBuf, uint32(currentOffsetIndex-startOffset))
-				copy(buf[fixedIndex:fixedIndex+BytesPerLengthOffset], offsetBuf)
+				binary.LittleEndian.PutUint32(buf[fixedIndex:fixedIndex+BytesPerLengthOffset], uint32(currentOffsetIndex-startOffset))
 
 				// We increase the offset indices accordingly.
 				currentOffsetIndex = nextOffsetIndex
@@ -267,17 +373,71 @@ func makeStructMarshaler(typ reflect.Type) (marshaler, error) {
 	return marshaler, nil
 }
 
+// checkFieldLength verifies a list field, and the lists nested within it, do not hold
+// more elements than allowed by the dimensions of its ssz-max tag. Bitlists are measured
+// in bits.
+func checkFieldLength(val reflect.Value, f field) error {
+	if f.typ.Kind() == reflect.Slice || f.typ.Kind() == reflect.String {
+		if length := listLength(val, f.typ); length > f.capacity {
+			return errorf(ErrMaxLength, "field %s has length %d, exceeding its ssz-max of %d", f.name, length, f.capacity)
+		}
+	}
+	if err := checkNestedLengths(val, f.typ, f.innerLimits); err != nil {
+		return fmt.Errorf("field %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// checkNestedLengths verifies the lists nested within val, a value of the list or vector
+// type typ, do not hold more elements than the limits of their dimension, 0 leaving them
+// unbounded.
+func checkNestedLengths(val reflect.Value, typ reflect.Type, limits []uint64) error {
+	if len(limits) == 0 || typ.Kind() == reflect.String {
+		return nil
+	}
+	elemType := typ.Elem()
+	elemKind := elemType.Kind()
+	if elemKind != reflect.Slice && elemKind != reflect.Array && elemKind != reflect.String {
+		return nil
+	}
+	limit, innerLimits := splitLimits(limits)
+	for i := 0; i < val.Len(); i++ {
+		elem := val.Index(i)
+		if limit > 0 && (elemKind == reflect.Slice || elemKind == reflect.String) {
+			if length := listLength(elem, elemType); length > limit {
+				return errorf(ErrMaxLength, "element %d has length %d, exceeding its ssz-max of %d", i, length, limit)
+			}
+		}
+		if err := checkNestedLengths(elem, elemType, innerLimits); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// listLength returns the length of val, a value of the list type typ, which is measured in
+// bits for bitlists.
+func listLength(val reflect.Value, typ reflect.Type) uint64 {
+	if isBitlist(typ) {
+		// Bits fields hold the booleans of their bitlist rather than a bitfield.
+		if b, ok := val.Interface().(Bitfield); ok {
+			return b.Len()
+		}
+	}
+	return uint64(val.Len())
+}
+
 func makePtrMarshaler(typ reflect.Type) (marshaler, error) {
 	elemSSZUtils, err := cachedSSZUtilsNoAcquireLock(typ.Elem())
 	if err != nil {
 		return nil, err
 	}
 	marshaler := func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
-		// Nil encodes to []byte{}.
-		if val.IsNil() {
-			return 0, nil
+		elem, err := pointee(val)
+		if err != nil {
+			return 0, err
 		}
-		return elemSSZUtils.marshaler(val.Elem(), buf, startOffset)
+		return elemSSZUtils.marshaler(elem, buf, startOffset)
 	}
 
 	return marshaler, nil
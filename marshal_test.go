@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"strings"
 	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
 )
 
 type testDepositData struct {
@@ -43,3 +46,191 @@ func TestMarshalDepositDataLen(t *testing.T) {
 		`, serializedData, expectedResult)
 	}
 }
+
+type maxLengthCase struct {
+	Balances []uint64         `ssz-max:"2"`
+	Bits     bitfield.Bitlist `ssz-max:"4"`
+}
+
+func TestMarshal_ExceedsMaxLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   maxLengthCase
+		wantErr bool
+	}{
+		{
+			name:  "within limits",
+			input: maxLengthCase{Balances: []uint64{1, 2}, Bits: bitfield.NewBitlist(4)},
+		},
+		{
+			name:    "list exceeding ssz-max",
+			input:   maxLengthCase{Balances: []uint64{1, 2, 3}, Bits: bitfield.NewBitlist(4)},
+			wantErr: true,
+		},
+		{
+			name:    "bitlist exceeding ssz-max",
+			input:   maxLengthCase{Balances: []uint64{1}, Bits: bitfield.NewBitlist(5)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "ssz-max") {
+				t.Errorf("Expected error to mention ssz-max, got %v", err)
+			}
+		})
+	}
+}
+
+type maxLengthOuter struct {
+	Slot  uint64
+	Inner maxLengthCase
+}
+
+type nestedMaxLengthCase struct {
+	Transactions [][]byte        `ssz-max:"4,3"`
+	Vectors      [][2][]uint16   `ssz-max:"3,?,2"`
+	Roots        [][]byte        `ssz-size:"2,?" ssz-max:"?,2"`
+	Bits         []bool          `ssz:"bits,max=4"`
+	Containers   []maxLengthCase `ssz-max:"2"`
+}
+
+// newNestedMaxLengthCase returns lists within the dimensions of their ssz-max.
+func newNestedMaxLengthCase() nestedMaxLengthCase {
+	return nestedMaxLengthCase{
+		Transactions: [][]byte{{1, 2, 3}, {}},
+		Vectors:      [][2][]uint16{{{1, 2}, {3}}},
+		Roots:        [][]byte{{1, 2}, {3}},
+		Bits:         []bool{true, false, true, true},
+		Containers:   []maxLengthCase{{Balances: []uint64{1}, Bits: bitfield.NewBitlist(4)}},
+	}
+}
+
+func TestMarshal_ExceedsNestedMaxLength(t *testing.T) {
+	if _, err := Marshal(newNestedMaxLengthCase()); err != nil {
+		t.Fatalf("Expected lists within their ssz-max to be marshaled, received %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(*nestedMaxLengthCase)
+		err    string
+	}{
+		{
+			name:   "inner list",
+			modify: func(c *nestedMaxLengthCase) { c.Transactions[1] = []byte{1, 2, 3, 4} },
+			err:    "field Transactions: element 1 has length 4, exceeding its ssz-max of 3",
+		},
+		{
+			name:   "list within a vector",
+			modify: func(c *nestedMaxLengthCase) { c.Vectors[0][1] = []uint16{1, 2, 3} },
+			err:    "field Vectors: element 0: element 1 has length 3, exceeding its ssz-max of 2",
+		},
+		{
+			name:   "list within a sized slice",
+			modify: func(c *nestedMaxLengthCase) { c.Roots[0] = []byte{1, 2, 3} },
+			err:    "field Roots: element 0 has length 3, exceeding its ssz-max of 2",
+		},
+		{
+			name:   "bits",
+			modify: func(c *nestedMaxLengthCase) { c.Bits = append(c.Bits, true) },
+			err:    "field Bits has length 5, exceeding its ssz-max of 4",
+		},
+		{
+			name:   "list within a list of containers",
+			modify: func(c *nestedMaxLengthCase) { c.Containers[0].Balances = []uint64{1, 2, 3} },
+			err:    "field Containers: element 0: field Balances has length 3, exceeding its ssz-max of 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := newNestedMaxLengthCase()
+			tt.modify(&item)
+			if _, err := Marshal(item); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestMarshal_ExceedsMaxLengthNamesField(t *testing.T) {
+	item := maxLengthOuter{Inner: maxLengthCase{Balances: []uint64{1, 2, 3}, Bits: bitfield.NewBitlist(4)}}
+	want := "field Inner: field Balances has length 3, exceeding its ssz-max of 2"
+	if _, err := Marshal(item); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+}
+
+func TestMarshal_MaxLengthCheckDisabled(t *testing.T) {
+	ToggleMaxLengthCheck(false)
+	defer ToggleMaxLengthCheck(true)
+	input := maxLengthCase{Balances: []uint64{1, 2, 3}, Bits: bitfield.NewBitlist(4)}
+	if _, err := Marshal(input); err != nil {
+		t.Errorf("Expected marshaling to succeed with the check disabled, got %v", err)
+	}
+}
+
+type packedVectors struct {
+	Custody  [4]uint64
+	Counters [3]uint32
+	Flags    [2]uint16
+	Tagged   []uint64 `ssz-size:"2"`
+}
+
+func TestMarshal_PackedVectors(t *testing.T) {
+	item := packedVectors{
+		Custody:  [4]uint64{1, 2, 3, 1 << 63},
+		Counters: [3]uint32{4, 5, 6},
+		Flags:    [2]uint16{7, 8},
+		Tagged:   []uint64{9, 10},
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 0, 62)
+	for _, v := range item.Custody {
+		want = append(want, make([]byte, 8)...)
+		binary.LittleEndian.PutUint64(want[len(want)-8:], v)
+	}
+	for _, v := range item.Counters {
+		want = append(want, make([]byte, 4)...)
+		binary.LittleEndian.PutUint32(want[len(want)-4:], v)
+	}
+	for _, v := range item.Flags {
+		want = append(want, make([]byte, 2)...)
+		binary.LittleEndian.PutUint16(want[len(want)-2:], v)
+	}
+	for _, v := range item.Tagged {
+		want = append(want, make([]byte, 8)...)
+		binary.LittleEndian.PutUint64(want[len(want)-8:], v)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("Expected %#x, received %#x", want, encoded)
+	}
+	var decoded packedVectors
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(item, decoded) {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+	if err := Unmarshal(encoded[:20], &decoded); err == nil {
+		t.Error("Expected error when decoding truncated packed vector")
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	item := newTreeContainer()
+	item.Nil = &fork{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
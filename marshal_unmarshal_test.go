@@ -132,3 +132,66 @@ func TestMarshalUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshal_RespectsByteBudget(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		ptr   interface{}
+	}{
+		{
+			name:  "basic type larger than input",
+			input: []byte{1, 2, 3},
+			ptr:   new(uint64),
+		},
+		{
+			name:  "fixed-size struct larger than input",
+			input: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			ptr:   new(fork),
+		},
+		{
+			name:  "struct offset pointing past the input",
+			input: []byte{8, 0, 0, 0, 200, 0, 0, 0},
+			ptr:   new(varItem),
+		},
+		{
+			name:  "struct offsets decreasing",
+			input: []byte{12, 0, 0, 0, 8, 0, 0, 0, 1, 0, 2, 0},
+			ptr:   new(varItem),
+		},
+		{
+			name:  "list element offsets pointing past their segment",
+			input: []byte{8, 0, 0, 0, 100, 0, 0, 0},
+			ptr:   new([][]byte),
+		},
+		{
+			name: "nested element offsets pointing past their own segment",
+			// The first varItem segment is 12 bytes long, but its last offset
+			// claims its data ends at byte 16, which is past the segment while
+			// still within the capacity of the outer input.
+			input: []byte{
+				8, 0, 0, 0, 20, 0, 0, 0,
+				8, 0, 0, 0, 16, 0, 0, 0, 1, 0, 2, 0,
+				8, 0, 0, 0, 8, 0, 0, 0,
+			},
+			ptr: new([]varItem),
+		},
+		{
+			name:  "list first offset not a multiple of the offset size",
+			input: []byte{5, 0, 0, 0, 0},
+			ptr:   new([][]byte),
+		},
+		{
+			name:  "basic list not a multiple of its element size",
+			input: []byte{1, 0, 2},
+			ptr:   new([]uint16),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ssz.Unmarshal(tt.input, tt.ptr); err == nil {
+				t.Errorf("Expected unmarshaling %#x into %T to fail", tt.input, tt.ptr)
+			}
+		})
+	}
+}
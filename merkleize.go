@@ -0,0 +1,85 @@
+package ssz
+
+// Merkleize returns the root of the binary Merkle tree of chunks, padded with zero chunks
+// up to limit chunks, rounded up to a power of two. It is the merkleize function of the
+// spec, which the hashers of the package build on, exposed for structures kept outside
+// of SSZ values, such as deposit trees or custom accumulators:
+//
+//  chunks := ssz.Pack(serializedBalances)
+//  root, err := ssz.Merkleize(chunks, (validatorRegistryLimit*8+31)/32)
+//  if err != nil {
+//      return err
+//  }
+//  balancesRoot := ssz.MixInLength(root, uint64(len(serializedBalances)))
+//
+// A limit of 0 pads the chunks up to the next power of two of their number, as the spec
+// does when no limit is given. Otherwise, an error wrapping ErrMaxLength is returned if
+// there are more chunks than limit.
+func Merkleize(chunks [][32]byte, limit uint64) ([32]byte, error) {
+	h := acquireHasher()
+	defer releaseHasher(h)
+	return h.merkleizeChunks(uint64(len(chunks)), limit, limit != 0 /* has limit */, func(idx uint64) [32]byte {
+		return chunks[idx]
+	})
+}
+
+// Pack returns the chunks the serialized basic values are packed into: the values are
+// concatenated, then split into chunks of BytesPerChunk bytes, the last of which is
+// right-padded with zeroes. Without any byte to pack, a single zero chunk is returned.
+func Pack(serialized [][]byte) [][32]byte {
+	size := 0
+	for _, item := range serialized {
+		size += len(item)
+	}
+	count := (size + BytesPerChunk - 1) / BytesPerChunk
+	if count == 0 {
+		count = 1
+	}
+	chunks := make([][32]byte, count)
+	offset := 0
+	for _, item := range serialized {
+		for len(item) > 0 {
+			n := copy(chunks[offset/BytesPerChunk][offset%BytesPerChunk:], item)
+			item = item[n:]
+			offset += n
+		}
+	}
+	return chunks
+}
+
+// MixInLength returns the root of a list of length elements whose elements merkleize to
+// root, which is the hash of root and of the little-endian encoding of length, padded to
+// a chunk.
+func MixInLength(root [32]byte, length uint64) [32]byte {
+	h := acquireHasher()
+	defer releaseHasher(h)
+	output := lengthChunk(length)
+	return h.mixInLength(root, output[:])
+}
+
+// MixInSelector returns the root of a union holding the type of index selector, whose
+// value hashes to root, which is the hash of root and of selector, padded to a chunk.
+// The package has no union type of its own, see FeatureSet.Unions, but values of union
+// types built by callers hash as the spec expects with it.
+func MixInSelector(root [32]byte, selector uint8) [32]byte {
+	h := acquireHasher()
+	defer releaseHasher(h)
+	var chunk [32]byte
+	chunk[0] = selector
+	return h.hashPair(root[:], chunk[:])
+}
+
+// MerkleizeProgressive returns the root of the progressive Merkle tree of chunks, as
+// defined by EIP-7916, which fields tagged with `ssz:"progressive"` are merkleized
+// into: the root holds the first chunk in its right subtree, and recursively the rest
+// in its left subtree, whose right subtree holds four times as many chunks as the
+// previous one. No chunks merkleize to a zero root.
+func MerkleizeProgressive(chunks [][32]byte) [32]byte {
+	h := acquireHasher()
+	defer releaseHasher(h)
+	data := make([]byte, len(chunks)*BytesPerChunk)
+	for i, chunk := range chunks {
+		copy(data[i*BytesPerChunk:], chunk[:])
+	}
+	return h.merkleizeProgressive(data, 1)
+}
@@ -0,0 +1,84 @@
+package ssz
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestMerkleize(t *testing.T) {
+	chunks := [][32]byte{{1}, {2}, {3}}
+	for _, limit := range []uint64{3, 4, 16} {
+		root, err := Merkleize(chunks, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := naiveMerkleize(chunks, limit); root != want {
+			t.Errorf("Expected root %#x with limit %d, received %#x", want, limit, root)
+		}
+	}
+	root, err := Merkleize(chunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := naiveMerkleize(chunks, 4); root != want {
+		t.Errorf("Expected chunks to be padded to a power of two without limit, received %#x", root)
+	}
+	if _, err := Merkleize(chunks, 2); !errors.Is(err, ErrMaxLength) {
+		t.Errorf("Expected chunks exceeding the limit to fail, received %v", err)
+	}
+}
+
+func TestPack(t *testing.T) {
+	items := [][]byte{make([]byte, BytesPerChunk), {1, 2}, make([]byte, BytesPerChunk-1), {3}}
+	chunks := Pack(items)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, received %d", len(chunks))
+	}
+	if chunks[1][0] != 1 || chunks[1][1] != 2 || chunks[2][1] != 3 {
+		t.Errorf("Expected items to be concatenated, received %#x", chunks)
+	}
+	if chunks := Pack(nil); len(chunks) != 1 || chunks[0] != [32]byte{} {
+		t.Errorf("Expected a single zero chunk, received %#x", chunks)
+	}
+}
+
+// TestMerkleize_ListRoot rebuilds the root of a list of basic values out of the public
+// primitives.
+func TestMerkleize_ListRoot(t *testing.T) {
+	balances := []uint64{1, 2, 3, 4, 5}
+	want, err := HashTreeRootWithCapacity(balances, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized := make([][]byte, len(balances))
+	for i, b := range balances {
+		serialized[i] = make([]byte, 8)
+		binary.LittleEndian.PutUint64(serialized[i], b)
+	}
+	root, err := Merkleize(Pack(serialized), (100*8+31)/32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listRoot := MixInLength(root, uint64(len(balances)))
+	if listRoot != want {
+		t.Errorf("Expected root %#x, received %#x", want, listRoot)
+	}
+	if listRoot != naiveMixInLength(root, len(balances)) {
+		t.Errorf("Expected the length to be mixed in as the spec does, received %#x", listRoot)
+	}
+}
+
+func TestMixInSelector(t *testing.T) {
+	root := [32]byte{1, 2, 3}
+	var selector [32]byte
+	selector[0] = 2
+	if got, want := MixInSelector(root, 2), sha256.Sum256(append(root[:], selector[:]...)); got != want {
+		t.Errorf("Expected root %#x, received %#x", want, got)
+	}
+	// The None variant of a union is mixed in with selector 0 and a zero root.
+	if got, want := MixInSelector([32]byte{}, 0), MixInLength([32]byte{}, 0); got != want {
+		t.Errorf("Expected root %#x, received %#x", want, got)
+	}
+}
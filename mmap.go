@@ -0,0 +1,75 @@
+package ssz
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// MappedView is the View of an encoding memory-mapped from a file: opening it costs no
+// more than mapping the file, and only the pages holding the offsets and fields
+// accessed through the view are read from disk, so that tools can read a handful of
+// fields out of a large state file without decoding or loading all of it.
+//
+//  view, err := OpenView("state.ssz", reflect.TypeOf(BeaconState{}))
+//  if err != nil {
+//      return fmt.Errorf("failed to open state: %v", err)
+//  }
+//  defer view.Close()
+//  var slot uint64
+//  if err := view.Load(&slot, "Slot"); err != nil {
+//      return fmt.Errorf("failed to decode slot: %v", err)
+//  }
+//
+// The encoding is only valid until Close: neither the view, the views it returns, nor
+// the byte slices decoded from it when zero-copy decoding is enabled, see
+// ToggleZeroCopy, may be used afterwards. Other values are copied out of the mapping.
+// The file must not be modified while it is mapped. On platforms without mmap, the
+// file is read into memory instead.
+type MappedView struct {
+	*View
+
+	closeOnce sync.Once
+	unmap     func() error
+	closeErr  error
+}
+
+// OpenView returns the view of the encoding of a value of type typ held by the file at
+// path, which is memory-mapped read-only. The size of the file is checked against the
+// bounds of typ, while its contents are only checked as they are decoded.
+func OpenView(path string, typ reflect.Type) (*MappedView, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping outlives the file descriptor it was created from.
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if int64(int(size)) != size {
+		return nil, errorf(ErrSize, "file %s of %d bytes cannot be mapped", path, size)
+	}
+	data, unmap, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s: %w", path, err)
+	}
+	view, err := NewView(data, typ)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+	return &MappedView{View: view, unmap: unmap}, nil
+}
+
+// Close unmaps the file wrapped by the view. Closing a view more than once returns the
+// result of the first call.
+func (v *MappedView) Close() error {
+	v.closeOnce.Do(func() {
+		v.closeErr = v.unmap()
+	})
+	return v.closeErr
+}
@@ -0,0 +1,76 @@
+package ssz
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenView(t *testing.T) {
+	item := newTreeContainer()
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "item.ssz")
+	if err := os.WriteFile(path, encoded, 0600); err != nil {
+		t.Fatal(err)
+	}
+	view, err := OpenView(path, reflect.TypeOf(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slot uint64
+	if err := view.Load(&slot, "Slot"); err != nil {
+		t.Fatal(err)
+	}
+	if slot != item.Slot {
+		t.Errorf("Expected slot %d, received %d", item.Slot, slot)
+	}
+	forks, err := view.Get("Forks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := view.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Decoded values are copied out of the mapping, and outlive it.
+	if !reflect.DeepEqual(forks, item.Forks) {
+		t.Errorf("Expected forks %v, received %v", item.Forks, forks)
+	}
+	if err := view.Close(); err != nil {
+		t.Errorf("Expected closing twice to succeed, received %v", err)
+	}
+}
+
+func TestOpenView_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenView(filepath.Join(dir, "missing.ssz"), reflect.TypeOf(fork{})); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file error, received %v", err)
+	}
+	path := filepath.Join(dir, "short.ssz")
+	if err := os.WriteFile(path, make([]byte, 3), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenView(path, reflect.TypeOf(fork{})); !errors.Is(err, ErrSize) {
+		t.Errorf("Expected a size error, received %v", err)
+	}
+	empty := filepath.Join(dir, "empty.ssz")
+	if err := os.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	view, err := OpenView(empty, reflect.TypeOf([]uint64{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+	list, err := view.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := list.([]uint64); len(l) != 0 {
+		t.Errorf("Expected an empty list, received %v", l)
+	}
+}
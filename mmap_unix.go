@@ -0,0 +1,23 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package ssz
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the size bytes of f read-only into memory, returning the mapping along
+// with the function unmapping it.
+func mapFile(f *os.File, size int) ([]byte, func() error, error) {
+	if size == 0 {
+		// Empty files cannot be mapped, and hold no bytes to read anyway.
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
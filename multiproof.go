@@ -0,0 +1,231 @@
+package ssz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Multiproof is a compact Merkle proof of several nodes of the backing tree of a
+// value, which only holds each helper node once. Multiproofs can be serialized with
+// Marshal, as a container of the fields below, and to JSON or YAML following the
+// arguments of verify_merkle_multiproof in the consensus specs:
+//
+//  {"leaves": ["0x...", "0x..."], "indices": [8, 14], "proof": ["0x...", "0x..."]}
+type Multiproof struct {
+	// Indices are the generalized indices of the proven nodes.
+	Indices []uint64 `ssz-max:"65536"`
+	// Leaves are the roots of the proven nodes, in the order of Indices.
+	Leaves [][32]byte `ssz-max:"65536"`
+	// Hashes are the roots of the helper nodes, in the order of HelperIndices(Indices).
+	Hashes [][32]byte `ssz-max:"65536"`
+}
+
+type multiproofJSON struct {
+	Leaves  []string `json:"leaves"`
+	Indices []uint64 `json:"indices"`
+	Proof   []string `json:"proof"`
+}
+
+// MarshalJSON encodes the multiproof following the consensus specs.
+func (p *Multiproof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(multiproofJSON{
+		Leaves:  encodeHexRoots(p.Leaves),
+		Indices: p.Indices,
+		Proof:   encodeHexRoots(p.Hashes),
+	})
+}
+
+// UnmarshalJSON decodes a multiproof following the consensus specs.
+func (p *Multiproof) UnmarshalJSON(data []byte) error {
+	var enc multiproofJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	leaves, err := decodeHexRoots(enc.Leaves)
+	if err != nil {
+		return fmt.Errorf("invalid leaves: %w", err)
+	}
+	hashes, err := decodeHexRoots(enc.Proof)
+	if err != nil {
+		return fmt.Errorf("invalid proof: %w", err)
+	}
+	p.Indices = enc.Indices
+	p.Leaves = leaves
+	p.Hashes = hashes
+	return nil
+}
+
+// SiblingIndex returns the generalized index of the sibling of the node at gindex.
+func SiblingIndex(gindex uint64) uint64 {
+	return gindex ^ 1
+}
+
+// ParentIndex returns the generalized index of the parent of the node at gindex.
+func ParentIndex(gindex uint64) uint64 {
+	return gindex / 2
+}
+
+// BranchIndices returns the generalized indices of the nodes needed to prove the
+// node at gindex, that is the siblings of the nodes on its path to the root, ordered bottom-up.
+func BranchIndices(gindex uint64) []uint64 {
+	var indices []uint64
+	for i := gindex; i > 1; i = ParentIndex(i) {
+		indices = append(indices, SiblingIndex(i))
+	}
+	return indices
+}
+
+// PathIndices returns the generalized indices of the nodes on the path from the
+// node at gindex to the root, excluding the root, ordered bottom-up.
+func PathIndices(gindex uint64) []uint64 {
+	var indices []uint64
+	for i := gindex; i > 1; i = ParentIndex(i) {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// HelperIndices returns the generalized indices of the nodes needed to prove all of
+// the given nodes at once, in decreasing order.
+func HelperIndices(gindices []uint64) []uint64 {
+	helpers := make(map[uint64]bool)
+	paths := make(map[uint64]bool)
+	for _, gindex := range gindices {
+		for _, i := range BranchIndices(gindex) {
+			helpers[i] = true
+		}
+		for _, i := range PathIndices(gindex) {
+			paths[i] = true
+		}
+	}
+	indices := make([]uint64, 0, len(helpers))
+	for i := range helpers {
+		if !paths[i] {
+			indices = append(indices, i)
+		}
+	}
+	sort.Slice(indices, func(a, b int) bool {
+		return indices[a] > indices[b]
+	})
+	return indices
+}
+
+// ProveMulti returns the multiproof of the nodes designated by paths within val,
+// as resolved by GeneralizedIndex, against the hash tree root of val.
+//
+//  proof, err := ProveMulti(state, []interface{}{"Slot"}, []interface{}{"LatestBlockHeader", "StateRoot"})
+//  if err != nil {
+//      return fmt.Errorf("failed to prove fields: %v", err)
+//  }
+func ProveMulti(val interface{}, paths ...[]interface{}) (*Multiproof, error) {
+	if val == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	typ := reflect.TypeOf(val)
+	indices := make([]uint64, len(paths))
+	for i, path := range paths {
+		gindex, err := GeneralizedIndex(typ, path...)
+		if err != nil {
+			return nil, fmt.Errorf("path %d: %w", i, err)
+		}
+		indices[i] = gindex
+	}
+	tree, err := NewTree(val)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ProveMulti(indices)
+}
+
+// ProveMulti returns the multiproof of the nodes at the given generalized indices
+// against the root of the tree.
+func (t *Tree) ProveMulti(gindices []uint64) (*Multiproof, error) {
+	if len(gindices) == 0 {
+		return nil, errors.New("no generalized index to prove")
+	}
+	proof := &Multiproof{
+		Indices: append([]uint64{}, gindices...),
+		Leaves:  make([][32]byte, len(gindices)),
+	}
+	for i, gindex := range gindices {
+		leaf, err := t.Leaf(gindex)
+		if err != nil {
+			return nil, err
+		}
+		proof.Leaves[i] = leaf
+	}
+	helpers := HelperIndices(gindices)
+	proof.Hashes = make([][32]byte, len(helpers))
+	for i, gindex := range helpers {
+		h, err := t.Leaf(gindex)
+		if err != nil {
+			return nil, err
+		}
+		proof.Hashes[i] = h
+	}
+	return proof, nil
+}
+
+// VerifyMultiproof reports whether proof proves all of its leaves at their generalized
+// indices against root. Like VerifyProof, it needs neither the proven value nor its type.
+// An error is returned for malformed proofs.
+func VerifyMultiproof(root [32]byte, proof *Multiproof) (bool, error) {
+	if proof == nil {
+		return false, errors.New("proof cannot be nil")
+	}
+	if len(proof.Indices) == 0 {
+		return false, errors.New("proof has no generalized index")
+	}
+	if len(proof.Leaves) != len(proof.Indices) {
+		return false, fmt.Errorf("expected %d leaves, received %d", len(proof.Indices), len(proof.Leaves))
+	}
+	for _, gindex := range proof.Indices {
+		if gindex == 0 {
+			return false, errors.New("generalized index 0 is not a valid index")
+		}
+	}
+	helpers := HelperIndices(proof.Indices)
+	if len(proof.Hashes) != len(helpers) {
+		return false, fmt.Errorf("expected %d hashes, received %d", len(helpers), len(proof.Hashes))
+	}
+
+	nodes := make(map[uint64][32]byte, len(proof.Indices)+len(helpers))
+	for i, gindex := range proof.Indices {
+		nodes[gindex] = proof.Leaves[i]
+	}
+	for i, gindex := range helpers {
+		nodes[gindex] = proof.Hashes[i]
+	}
+	keys := make([]uint64, 0, len(nodes))
+	for gindex := range nodes {
+		keys = append(keys, gindex)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a] > keys[b]
+	})
+
+	h := acquireHasher()
+	defer releaseHasher(h)
+	// Parents are appended after all of the deeper nodes, so that they are
+	// combined with their own sibling once it has been computed.
+	for pos := 0; pos < len(keys); pos++ {
+		k := keys[pos]
+		if k == 1 {
+			continue
+		}
+		left, hasLeft := nodes[k&^1]
+		right, hasRight := nodes[k|1]
+		if _, hasParent := nodes[ParentIndex(k)]; hasLeft && hasRight && !hasParent {
+			nodes[ParentIndex(k)] = h.hashPair(left[:], right[:])
+			keys = append(keys, ParentIndex(k))
+		}
+	}
+	computed, ok := nodes[1]
+	if !ok {
+		return false, errors.New("proof does not reach the root")
+	}
+	return computed == root, nil
+}
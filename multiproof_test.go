@@ -0,0 +1,131 @@
+package ssz
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHelperIndices(t *testing.T) {
+	// Nodes on the path of one proven node are never helpers of another.
+	tests := []struct {
+		indices []uint64
+		want    []uint64
+	}{
+		{indices: []uint64{8}, want: []uint64{9, 5, 3}},
+		{indices: []uint64{8, 9}, want: []uint64{5, 3}},
+		{indices: []uint64{8, 14}, want: []uint64{15, 9, 6, 5}},
+		{indices: []uint64{1}, want: []uint64{}},
+	}
+	for _, tt := range tests {
+		got := HelperIndices(tt.indices)
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("HelperIndices(%v) = %v, want %v", tt.indices, got, tt.want)
+		}
+	}
+	if got := BranchIndices(12); !reflect.DeepEqual(got, []uint64{13, 7, 2}) {
+		t.Errorf("BranchIndices(12) = %v, want [13 7 2]", got)
+	}
+	if got := PathIndices(12); !reflect.DeepEqual(got, []uint64{12, 6, 3}) {
+		t.Errorf("PathIndices(12) = %v, want [12 6 3]", got)
+	}
+}
+
+func TestProveMulti(t *testing.T) {
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := [][]interface{}{
+		{"Slot"},
+		{"Forks", 1, "Epoch"},
+		{"Forks", 2, "Epoch"},
+		{"Balances", 4},
+		{"Balances", LengthPathElement},
+		{"Custody", 0},
+	}
+	proof, err := ProveMulti(item, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := VerifyMultiproof(root, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Expected multiproof to verify")
+	}
+
+	// The multiproof is smaller than the separate proofs of its leaves.
+	separate := 0
+	for _, path := range paths {
+		single, err := Prove(item, path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		separate += len(single.Hashes)
+	}
+	if len(proof.Hashes) >= separate {
+		t.Errorf("Expected multiproof with fewer than %d hashes, received %d", separate, len(proof.Hashes))
+	}
+
+	proof.Leaves[1] = lengthChunk(100)
+	if ok, err := VerifyMultiproof(root, proof); err != nil || ok {
+		t.Errorf("Expected tampered multiproof not to verify, received %v, %v", ok, err)
+	}
+}
+
+func TestVerifyMultiproof_Malformed(t *testing.T) {
+	tests := []*Multiproof{
+		nil,
+		{},
+		{Indices: []uint64{8}, Leaves: [][32]byte{}},
+		{Indices: []uint64{0}, Leaves: [][32]byte{{}}},
+		{Indices: []uint64{8}, Leaves: [][32]byte{{}}, Hashes: [][32]byte{{}}},
+	}
+	for i, proof := range tests {
+		if _, err := VerifyMultiproof([32]byte{}, proof); err == nil {
+			t.Errorf("Expected error for malformed proof %d", i)
+		}
+	}
+}
+
+func TestMultiproof_Serialization(t *testing.T) {
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := ProveMulti(item, []interface{}{"Slot"}, []interface{}{"Forks", 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := Marshal(*proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Multiproof
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*proof, decoded) {
+		t.Errorf("Expected %v, received %v", proof, decoded)
+	}
+
+	jsonEncoded, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jsonEncoded), `"indices":[`) {
+		t.Errorf("Expected indices in JSON encoding, received %s", jsonEncoded)
+	}
+	decoded = Multiproof{}
+	if err := json.Unmarshal(jsonEncoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMultiproof(root, &decoded); err != nil || !ok {
+		t.Errorf("Expected decoded multiproof to verify, received %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,53 @@
+package ssz
+
+import (
+	"reflect"
+)
+
+// NilPointerMode is how nil pointers are encoded and hashed, see SetNilPointerMode.
+type NilPointerMode int
+
+const (
+	// NilAsZero encodes and hashes nil pointers as the zero value of the type they point
+	// to, as SSZ has no encoding for the absence of a value. Nil elements of lists of
+	// pointers are then encoded as zero values too, with offsets of their own. This is
+	// the default.
+	NilAsZero NilPointerMode = iota
+	// NilAsError fails to encode and hash values holding nil pointers, with an error
+	// wrapping ErrNilPointer and naming the field and element holding them.
+	NilAsError
+)
+
+var (
+	nilPointerMode = NilAsZero
+	// hashGeneration changes every time the roots of values may change, so that
+	// roots computed before are not served from the root cache.
+	hashGeneration uint64
+)
+
+// SetNilPointerMode allows to programmatically select how Marshal, HashTreeRoot and
+// the functions building trees and proofs of values treat nil pointers:
+//
+//  ssz.SetNilPointerMode(ssz.NilAsError)
+//
+// Either way, the encodings of values holding nil pointers decode into pointers to zero
+// values, as Unmarshal allocates the values pointers point to.
+func SetNilPointerMode(mode NilPointerMode) {
+	if mode != nilPointerMode {
+		// The roots of values holding nil pointers change with the mode.
+		hashGeneration++
+	}
+	nilPointerMode = mode
+}
+
+// pointee returns the value the pointer val points to, or, if val is nil, the zero
+// value of its element type or an error, depending on the NilPointerMode.
+func pointee(val reflect.Value) (reflect.Value, error) {
+	if !val.IsNil() {
+		return val.Elem(), nil
+	}
+	if nilPointerMode == NilAsError {
+		return reflect.Value{}, errorf(ErrNilPointer, "nil pointer to %v", val.Type().Elem())
+	}
+	return reflect.Zero(val.Type().Elem()), nil
+}
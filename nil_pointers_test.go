@@ -0,0 +1,146 @@
+package ssz
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type nilPointerInner struct {
+	Slot uint64
+	Root [32]byte
+}
+
+type nilPointerOuter struct {
+	Index uint64
+	Inner *nilPointerInner
+	Items []uint64 `ssz-max:"4"`
+}
+
+func TestSetNilPointerMode_Zero(t *testing.T) {
+	withNil := nilPointerOuter{Index: 1, Items: []uint64{2, 3}}
+	withZero := nilPointerOuter{Index: 1, Inner: &nilPointerInner{}, Items: []uint64{2, 3}}
+	encoded, err := Marshal(withNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(withZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected nil pointers to encode as zero values, received %x, expected %x", encoded, want)
+	}
+	root, err := HashTreeRoot(withNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(withZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected nil pointers to hash as zero values, received %#x, expected %#x", root, wantRoot)
+	}
+	tree, err := NewTree(withNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != wantRoot {
+		t.Errorf("Expected the tree of nil pointers to match, received %#x, expected %#x", tree.Root(), wantRoot)
+	}
+	var decoded nilPointerOuter
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Inner == nil || *decoded.Inner != (nilPointerInner{}) {
+		t.Errorf("Expected a pointer to a zero value, received %v", decoded.Inner)
+	}
+}
+
+func TestSetNilPointerMode_Error(t *testing.T) {
+	item := nilPointerOuter{Index: 1}
+	// Roots computed in the default mode must not be served from the root cache.
+	if _, err := HashTreeRoot(item); err != nil {
+		t.Fatal(err)
+	}
+	SetNilPointerMode(NilAsError)
+	defer SetNilPointerMode(NilAsZero)
+	if _, err := Marshal(item); !errors.Is(err, ErrNilPointer) || !strings.Contains(err.Error(), "field Inner") {
+		t.Errorf("Expected a nil pointer error naming the field, received %v", err)
+	}
+	if _, err := HashTreeRoot(item); !errors.Is(err, ErrNilPointer) || !strings.Contains(err.Error(), "field Inner") {
+		t.Errorf("Expected a nil pointer error naming the field, received %v", err)
+	}
+	if _, err := NewTree(item); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("Expected a nil pointer error, received %v", err)
+	}
+	item.Inner = &nilPointerInner{Slot: 2}
+	if _, err := Marshal(item); err != nil {
+		t.Errorf("Expected values without nil pointers to encode, received %v", err)
+	}
+}
+
+type nilElementsContainer struct {
+	Inners []*nilPointerInner `ssz-max:"4"`
+	Leaves []*limitsLeaf      `ssz-max:"4"`
+}
+
+func TestSetNilPointerMode_Elements(t *testing.T) {
+	withNil := nilElementsContainer{
+		Inners: []*nilPointerInner{{Slot: 1}, nil, {Slot: 3}},
+		Leaves: []*limitsLeaf{{Data: []byte{1}}, nil, {Data: []byte{2, 3}}},
+	}
+	withZero := nilElementsContainer{
+		Inners: []*nilPointerInner{{Slot: 1}, {}, {Slot: 3}},
+		Leaves: []*limitsLeaf{{Data: []byte{1}}, {}, {Data: []byte{2, 3}}},
+	}
+	encoded, err := Marshal(withNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(withZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected nil elements to encode as zero values, received %x, expected %x", encoded, want)
+	}
+	var decoded nilElementsContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, withZero) {
+		t.Errorf("Expected %v, received %v", withZero, decoded)
+	}
+	root, err := HashTreeRoot(withNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(withZero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected nil elements to hash as zero values, received %#x, expected %#x", root, wantRoot)
+	}
+
+	SetNilPointerMode(NilAsError)
+	defer SetNilPointerMode(NilAsZero)
+	for _, item := range []nilElementsContainer{
+		{Inners: withNil.Inners},
+		{Leaves: withNil.Leaves},
+	} {
+		field := "field Inners: element 1: "
+		if item.Inners == nil {
+			field = "field Leaves: element 1: "
+		}
+		if _, err := Marshal(item); !errors.Is(err, ErrNilPointer) || !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected a nil pointer error containing %q, received %v", field, err)
+		}
+		if _, err := HashTreeRoot(item); !errors.Is(err, ErrNilPointer) || !strings.Contains(err.Error(), "element 1: ") {
+			t.Errorf("Expected a nil pointer error naming the element, received %v", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ssz
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// OpaqueCodec encodes and decodes values of a foreign encoding, such as RLP-encoded
+// execution transactions, embedded in SSZ containers. See RegisterOpaqueCodec.
+type OpaqueCodec interface {
+	// Encode returns the foreign encoding of val.
+	Encode(val interface{}) ([]byte, error)
+	// Decode returns the value encoded in data, which must be assignable to
+	// the type the codec is registered for.
+	Decode(data []byte) (interface{}, error)
+}
+
+var (
+	opaqueCodecsLock sync.RWMutex
+	opaqueCodecs     = make(map[reflect.Type]OpaqueCodec)
+)
+
+// RegisterOpaqueCodec registers the codec producing and consuming the bytes of struct fields
+// of type typ tagged with `ssz:"opaque"`. SSZ treats such fields as byte lists, limited by
+// their mandatory ssz-max tag, whose contents are left to the codec:
+//
+//  type executionPayload struct {
+//      Transactions []*types.Transaction `ssz:"opaque" ssz-max:"1073741824"`
+//  }
+//
+//  ssz.RegisterOpaqueCodec(reflect.TypeOf([]*types.Transaction{}), rlpCodec{})
+//
+// Codecs must be registered before the first use of the containers holding such fields.
+func RegisterOpaqueCodec(typ reflect.Type, codec OpaqueCodec) {
+	opaqueCodecsLock.Lock()
+	defer opaqueCodecsLock.Unlock()
+	opaqueCodecs[typ] = codec
+}
+
+// opaqueType is the type SSZ treats opaque fields as.
+var opaqueType = reflect.TypeOf([]byte{})
+
+// makeOpaqueUtils returns the ssz utils of a struct field tagged with `ssz:"opaque"`,
+// which encode the field with its registered codec and treat the result as a byte
+// list holding at most maxLength bytes.
+func makeOpaqueUtils(field reflect.StructField, maxLength uint64) (*sszUtils, OpaqueCodec, error) {
+	opaqueCodecsLock.RLock()
+	codec, ok := opaqueCodecs[field.Type]
+	opaqueCodecsLock.RUnlock()
+	if !ok {
+		return nil, nil, errorf(ErrUnsupportedType, "no opaque codec registered for type %v of field %s", field.Type, field.Name)
+	}
+	bytesUtils, err := cachedSSZUtilsNoAcquireLock(opaqueType)
+	if err != nil {
+		return nil, nil, err
+	}
+	encode := func(val reflect.Value) ([]byte, error) {
+		encoded, err := codec.Encode(val.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode opaque field %s: %w", field.Name, err)
+		}
+		if uint64(len(encoded)) > maxLength {
+			return nil, errorf(
+				ErrMaxLength,
+				"opaque field %s has length %d, exceeding its ssz-max of %d",
+				field.Name,
+				len(encoded),
+				maxLength,
+			)
+		}
+		return encoded, nil
+	}
+	utils := &sszUtils{
+		marshaler: func(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+			encoded, err := encode(val)
+			if err != nil {
+				return 0, err
+			}
+			return bytesUtils.marshaler(reflect.ValueOf(encoded), buf, startOffset)
+		},
+		unmarshaler: func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+			if uint64(len(input))-startOffset > maxLength {
+				return 0, errorf(
+					ErrMaxLength,
+					"opaque field %s has length %d, exceeding its ssz-max of %d",
+					field.Name,
+					uint64(len(input))-startOffset,
+					maxLength,
+				)
+			}
+			// The codec gets its own copy of the bytes, so decoded values never
+			// alias the input buffer.
+			data := make([]byte, uint64(len(input))-startOffset)
+			copy(data, input[startOffset:])
+			decoded, err := codec.Decode(data)
+			if err != nil {
+				return 0, fmt.Errorf("failed to decode opaque field %s: %w", field.Name, err)
+			}
+			if decoded == nil {
+				val.Set(reflect.Zero(field.Type))
+				return uint64(len(input)), nil
+			}
+			dval := reflect.ValueOf(decoded)
+			if !dval.Type().AssignableTo(field.Type) {
+				return 0, fmt.Errorf("opaque codec decoded type %v, not assignable to field %s of type %v", dval.Type(), field.Name, field.Type)
+			}
+			val.Set(dval)
+			return uint64(len(input)), nil
+		},
+		hasher: func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			encoded, err := encode(val)
+			if err != nil {
+				return [32]byte{}, err
+			}
+			return bytesUtils.hasher(h, reflect.ValueOf(encoded), maxCapacity)
+		},
+	}
+	return utils, codec, nil
+}
+
+// opaqueSize returns the length of the foreign encoding of an opaque field.
+func opaqueSize(val reflect.Value, codec OpaqueCodec) uint64 {
+	encoded, err := codec.Encode(val.Interface())
+	if err != nil {
+		return 0
+	}
+	return uint64(len(encoded))
+}
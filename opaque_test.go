@@ -0,0 +1,169 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type opaqueWords []string
+
+// wordsCodec encodes words as a comma-separated list.
+type wordsCodec struct{}
+
+func (wordsCodec) Encode(val interface{}) ([]byte, error) {
+	return []byte(strings.Join(val.(opaqueWords), ",")), nil
+}
+
+func (wordsCodec) Decode(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return opaqueWords(nil), nil
+	}
+	if data[0] == ',' {
+		return nil, errors.New("empty first word")
+	}
+	return opaqueWords(strings.Split(string(data), ",")), nil
+}
+
+type opaqueContainer struct {
+	Slot  uint64
+	Words opaqueWords `ssz:"opaque" ssz-max:"16"`
+	Root  [32]byte
+}
+
+// opaqueEquivalent is the container SSZ sees once the opaque field is encoded.
+type opaqueEquivalent struct {
+	Slot  uint64
+	Words []byte `ssz-max:"16"`
+	Root  [32]byte
+}
+
+func init() {
+	RegisterOpaqueCodec(reflect.TypeOf(opaqueWords{}), wordsCodec{})
+}
+
+func TestOpaqueField_RoundTrip(t *testing.T) {
+	item := opaqueContainer{Slot: 5, Words: opaqueWords{"foo", "bar"}, Root: [32]byte{1}}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(opaqueEquivalent{Slot: 5, Words: []byte("foo,bar"), Root: [32]byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	var decoded opaqueContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item, decoded) {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+}
+
+func TestOpaqueField_HashTreeRoot(t *testing.T) {
+	item := opaqueContainer{Slot: 5, Words: opaqueWords{"foo", "bar"}}
+	want, err := HashTreeRoot(opaqueEquivalent{Slot: 5, Words: []byte("foo,bar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cache := range []bool{true, false} {
+		useCache = cache
+		got, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Expected root %#x, received %#x", want, got)
+		}
+	}
+	useCache = true
+	item.Words = opaqueWords{"baz"}
+	got, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == want {
+		t.Error("Expected a different root once the opaque field changed")
+	}
+}
+
+func TestOpaqueField_CacheKeys(t *testing.T) {
+	type pair struct {
+		A opaqueWords `ssz:"opaque" ssz-max:"64"`
+		B opaqueWords `ssz:"opaque" ssz-max:"64"`
+	}
+	type bytesPair struct {
+		A []byte `ssz-max:"64"`
+		B []byte `ssz-max:"64"`
+	}
+	// Without delimiters, the encoding of the first field runs into the type, name
+	// and limit of the second one, which cache keys are made of.
+	values := []pair{
+		{A: opaqueWords{"x"}, B: opaqueWords{"[]uint8B64y"}},
+		{A: opaqueWords{"x[]uint8B64"}, B: opaqueWords{"y"}},
+	}
+	for _, val := range values {
+		root, err := HashTreeRoot(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRoot(bytesPair{A: []byte(val.A[0]), B: []byte(val.B[0])})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x for %+v, received %#x", want, val, root)
+		}
+	}
+}
+
+func TestOpaqueField_ExceedsMax(t *testing.T) {
+	item := opaqueContainer{Words: opaqueWords{"a very long", "list of words"}}
+	if _, err := Marshal(item); err == nil || !strings.Contains(err.Error(), "exceeding its ssz-max") {
+		t.Errorf("Expected ssz-max error, received %v", err)
+	}
+	ToggleMaxLengthCheck(false)
+	encoded, err := Marshal(opaqueEquivalent{Words: []byte("a very long,list of words")})
+	ToggleMaxLengthCheck(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded opaqueContainer
+	if err := Unmarshal(encoded, &decoded); err == nil || !strings.Contains(err.Error(), "exceeding its ssz-max") {
+		t.Errorf("Expected ssz-max error, received %v", err)
+	}
+}
+
+func TestOpaqueField_DecodeError(t *testing.T) {
+	encoded, err := Marshal(opaqueEquivalent{Words: []byte(",foo")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded opaqueContainer
+	if err := Unmarshal(encoded, &decoded); err == nil || !strings.Contains(err.Error(), "empty first word") {
+		t.Errorf("Expected codec error, received %v", err)
+	}
+}
+
+func TestOpaqueField_Unregistered(t *testing.T) {
+	type unregistered struct {
+		Words []string `ssz:"opaque" ssz-max:"16"`
+	}
+	if _, err := Marshal(unregistered{}); err == nil {
+		t.Error("Expected error for opaque field without a registered codec")
+	}
+}
+
+func TestOpaqueField_RequiresMax(t *testing.T) {
+	type unbounded struct {
+		Words opaqueWords `ssz:"opaque"`
+	}
+	if _, err := Marshal(unbounded{}); err == nil {
+		t.Error("Expected error for opaque field without ssz-max tag")
+	}
+}
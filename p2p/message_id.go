@@ -0,0 +1,85 @@
+package p2p
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// MessageIDSize is the size of the gossipsub message IDs of the networking specs.
+const MessageIDSize = 20
+
+// MessageID is the ID of a gossipsub message, used to deduplicate messages.
+type MessageID [MessageIDSize]byte
+
+// Message domains of the networking specs, prefixing the hashed data of message
+// IDs depending on whether it is a valid snappy block.
+var (
+	MessageDomainInvalidSnappy = [4]byte{0x00, 0x00, 0x00, 0x00}
+	MessageDomainValidSnappy   = [4]byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// ComputeMessageID returns the ID of a gossipsub message published on topic, whose
+// data is the snappy block compression of an SSZ encoding, following the rules of
+// Altair and later forks: the first 20 bytes of the SHA256 hash of the message domain,
+// the length of the topic as a little-endian uint64, the topic, and the decompressed
+// data. The raw data is hashed under the invalid domain when it does not decompress
+// within MaxPayloadSize. Message IDs use SHA256 whatever the hash backend.
+func ComputeMessageID(topic string, data []byte) MessageID {
+	if decoded, ok := decompress(data); ok {
+		return hashMessage(MessageDomainValidSnappy, &topic, decoded)
+	}
+	return hashMessage(MessageDomainInvalidSnappy, &topic, data)
+}
+
+// ComputeMessageIDPhase0 returns the ID of a gossipsub message following the rules
+// of phase 0, which leave the topic out of the hash.
+func ComputeMessageIDPhase0(data []byte) MessageID {
+	if decoded, ok := decompress(data); ok {
+		return hashMessage(MessageDomainValidSnappy, nil, decoded)
+	}
+	return hashMessage(MessageDomainInvalidSnappy, nil, data)
+}
+
+// MessageIDOf returns the ID of the gossipsub message publishing val on topic, as
+// ComputeMessageID with the SSZ encoding of val, without compressing it.
+func MessageIDOf(topic string, val interface{}) (MessageID, error) {
+	encoded, err := ssz.Marshal(val)
+	if err != nil {
+		return MessageID{}, err
+	}
+	return hashMessage(MessageDomainValidSnappy, &topic, encoded), nil
+}
+
+// hashMessage returns the message ID of data under domain, binding topic unless it
+// is nil.
+func hashMessage(domain [4]byte, topic *string, data []byte) MessageID {
+	h := sha256.New()
+	h.Write(domain[:])
+	if topic != nil {
+		var length [8]byte
+		binary.LittleEndian.PutUint64(length[:], uint64(len(*topic)))
+		h.Write(length[:])
+		h.Write([]byte(*topic))
+	}
+	h.Write(data)
+	var id MessageID
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// decompress returns the decompression of data, a snappy block, and whether it is
+// a valid one of at most MaxPayloadSize bytes.
+func decompress(data []byte) ([]byte, bool) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil || size > MaxPayloadSize {
+		return nil, false
+	}
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
@@ -0,0 +1,47 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+func TestComputeMessageID(t *testing.T) {
+	topic := "/eth2/b5303f2a/beacon_block/ssz_snappy"
+	compressed := snappy.Encode(nil, []byte("hello"))
+	tests := []struct {
+		name string
+		id   MessageID
+		want string
+	}{
+		{"valid snappy", ComputeMessageID(topic, compressed), "3d7a296829e282226186d1490994d29c05faf37a"},
+		{"invalid snappy", ComputeMessageID(topic, []byte{0xff, 0xff}), "58ed8514745de67923bc83292225535418211fe3"},
+		{"phase 0", ComputeMessageIDPhase0(compressed), "79d62a59d0e47597aeb73cb85ba034c3f67f90e8"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.id[:]); got != tt.want {
+			t.Errorf("%s: expected message ID %s, received %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMessageIDOf(t *testing.T) {
+	topic := "/eth2/b5303f2a/beacon_attestation_1/ssz_snappy"
+	val := benchmarks.Checkpoint{Epoch: 5, Root: make([]byte, 32)}
+	compressed, err := MarshalSnappy(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := MessageIDOf(topic, val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ComputeMessageID(topic, compressed); id != want {
+		t.Errorf("Expected message ID %#x, received %#x", want, id)
+	}
+	if other := ComputeMessageID(topic+"x", compressed); other == id {
+		t.Error("Expected the topic to change the message ID")
+	}
+}
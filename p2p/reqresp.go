@@ -0,0 +1,183 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// ResponseCode is the result byte preceding each chunk of a req/resp response.
+type ResponseCode byte
+
+// Response codes of the networking specs.
+const (
+	ResponseSuccess             ResponseCode = 0
+	ResponseInvalidRequest      ResponseCode = 1
+	ResponseServerError         ResponseCode = 2
+	ResponseResourceUnavailable ResponseCode = 3
+)
+
+// MaxErrorMessageSize is the limit of the ErrorMessage byte list of error responses.
+const MaxErrorMessageSize = 256
+
+// maxVarintSize is the size of the longest protobuf varint.
+const maxVarintSize = 10
+
+// ResponseError is an error response read by ReadResponse.
+type ResponseError struct {
+	Code    ResponseCode
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("error response %d: %s", e.Code, e.Message)
+}
+
+// maxCompressedSize is the largest size of the snappy frames of n bytes, as given
+// by max_compressed_len in the networking specs.
+func maxCompressedSize(n uint64) uint64 {
+	return 32 + n + n/6
+}
+
+// WriteChunk writes the req/resp encoding of val to w: the length of its SSZ encoding
+// as a protobuf varint, followed by the snappy frames of the encoding. Requests are
+// made of a single chunk.
+func WriteChunk(w io.Writer, val interface{}) error {
+	encoded, err := ssz.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return writeEncodedChunk(w, encoded)
+}
+
+func writeEncodedChunk(w io.Writer, encoded []byte) error {
+	var header [maxVarintSize]byte
+	n := binary.PutUvarint(header[:], uint64(len(encoded)))
+	if _, err := w.Write(header[:n]); err != nil {
+		return err
+	}
+	sw := snappy.NewBufferedWriter(w)
+	if _, err := sw.Write(encoded); err != nil {
+		return err
+	}
+	// Closing the snappy writer flushes its frames, leaving w open.
+	return sw.Close()
+}
+
+// ReadChunk reads a chunk written by WriteChunk from r, and decodes it into the value
+// pointed to by val. The announced length is checked against the bounds of the SSZ
+// size of the type of val before reading the frames, whose compressed size is bounded
+// as well. ReadChunk does not read past the chunk, so that the next chunks of r can be
+// read in turn.
+func ReadChunk(r io.Reader, val interface{}) error {
+	if val == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	size, err := readVarint(r)
+	if err != nil {
+		return err
+	}
+	if err := checkSize(reflect.TypeOf(val), size); err != nil {
+		return err
+	}
+	encoded, err := readFrames(r, size)
+	if err != nil {
+		return err
+	}
+	return ssz.Unmarshal(encoded, val)
+}
+
+// readFrames reads the snappy frames of size uncompressed bytes from r.
+func readFrames(r io.Reader, size uint64) ([]byte, error) {
+	limited := io.LimitReader(r, int64(maxCompressedSize(size)))
+	encoded := make([]byte, size)
+	if _, err := io.ReadFull(snappy.NewReader(limited), encoded); err != nil {
+		return nil, fmt.Errorf("could not read %d bytes of snappy frames: %w", size, err)
+	}
+	return encoded, nil
+}
+
+// readVarint reads a protobuf varint from r one byte at a time, so as not to read
+// past it.
+func readVarint(r io.Reader) (uint64, error) {
+	var b [1]byte
+	var x uint64
+	for i := 0; i < maxVarintSize; i++ {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if i == maxVarintSize-1 && b[0] > 1 {
+			break
+		}
+		x |= uint64(b[0]&0x7f) << (7 * uint(i))
+		if b[0] < 0x80 {
+			return x, nil
+		}
+	}
+	return 0, errors.New("varint length prefix overflows a uint64")
+}
+
+// WriteResponse writes a successful response chunk holding val to w.
+func WriteResponse(w io.Writer, val interface{}) error {
+	if _, err := w.Write([]byte{byte(ResponseSuccess)}); err != nil {
+		return err
+	}
+	return WriteChunk(w, val)
+}
+
+// WriteErrorResponse writes an error response chunk to w, with a message truncated
+// to MaxErrorMessageSize bytes.
+func WriteErrorResponse(w io.Writer, code ResponseCode, message string) error {
+	if code == ResponseSuccess {
+		return errors.New("error responses cannot have the success code")
+	}
+	if len(message) > MaxErrorMessageSize {
+		message = message[:MaxErrorMessageSize]
+	}
+	if _, err := w.Write([]byte{byte(code)}); err != nil {
+		return err
+	}
+	return writeEncodedChunk(w, []byte(message))
+}
+
+// ReadResponse reads a response chunk from r, decoding a successful one into the value
+// pointed to by val, and returning a *ResponseError for an error one. It returns io.EOF
+// when r ends before the chunk, which marks the end of a stream of chunks.
+func ReadResponse(r io.Reader, val interface{}) error {
+	var code [1]byte
+	if _, err := io.ReadFull(r, code[:]); err != nil {
+		return err
+	}
+	if ResponseCode(code[0]) == ResponseSuccess {
+		if err := ReadChunk(r, val); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		return nil
+	}
+	size, err := readVarint(r)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if size > MaxErrorMessageSize {
+		return fmt.Errorf("error message of %d bytes exceeds the limit of %d", size, MaxErrorMessageSize)
+	}
+	message, err := readFrames(r, size)
+	if err != nil {
+		return err
+	}
+	return &ResponseError{Code: ResponseCode(code[0]), Message: string(message)}
+}
@@ -0,0 +1,115 @@
+package p2p
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestChunk_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	vectors := golden.Vectors()
+	for _, v := range vectors {
+		if err := WriteChunk(&buf, v.Value); err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+	}
+	// The chunks are read back in turn from the same stream.
+	for _, v := range vectors {
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val := reflect.New(reflect.TypeOf(v.Value))
+		if err := ReadChunk(&buf, val.Interface()); err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		got, err := ssz.Marshal(val.Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: round trip changed the value", v.Name)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected the chunks to consume the stream, %d bytes left", buf.Len())
+	}
+}
+
+func TestReadChunk_Errors(t *testing.T) {
+	frames := func(prefix []byte, data []byte) []byte {
+		var buf bytes.Buffer
+		buf.Write(prefix)
+		sw := snappy.NewBufferedWriter(&buf)
+		if _, err := sw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := sw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "EOF"},
+		{"truncated varint", []byte{0x80}, "unexpected EOF"},
+		{"overflowing varint", bytes.Repeat([]byte{0xff}, 11), "overflows"},
+		{"size out of bounds", frames([]byte{41}, make([]byte, 41)), "out of the bounds"},
+		{"huge size", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, "out of the bounds"},
+		{"missing frames", []byte{40}, "snappy frames"},
+		{"short frames", frames([]byte{40}, make([]byte, 20)), "snappy frames"},
+	}
+	for _, tt := range tests {
+		err := ReadChunk(bytes.NewReader(tt.data), &benchmarks.Checkpoint{})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected an error containing %q, received %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestResponse_Stream(t *testing.T) {
+	var buf bytes.Buffer
+	checkpoint := benchmarks.Checkpoint{Epoch: 3, Root: bytes.Repeat([]byte{1}, 32)}
+	if err := WriteResponse(&buf, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteErrorResponse(&buf, ResponseResourceUnavailable, strings.Repeat("x", 300)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteErrorResponse(&buf, ResponseSuccess, "ok"); err == nil {
+		t.Error("Expected an error writing an error response with the success code")
+	}
+
+	var got benchmarks.Checkpoint
+	if err := ReadResponse(&buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, checkpoint) {
+		t.Errorf("Expected %+v, received %+v", checkpoint, got)
+	}
+	err := ReadResponse(&buf, &got)
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("Expected a *ResponseError, received %v", err)
+	}
+	if respErr.Code != ResponseResourceUnavailable || respErr.Message != strings.Repeat("x", MaxErrorMessageSize) {
+		t.Errorf("Unexpected error response %+v", respErr)
+	}
+	if err := ReadResponse(&buf, &got); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the stream, received %v", err)
+	}
+	if err := ReadResponse(bytes.NewReader([]byte{0}), &got); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for a truncated chunk, received %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package p2p composes SSZ with the encodings of the eth2 networking layer: the
+// snappy block compression of gossipsub messages and their IDs, and the
+// length-prefixed snappy frames of req/resp streams.
+package p2p
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// MaxPayloadSize is the MAX_PAYLOAD_SIZE of the networking specs, bounding the
+// uncompressed size of the messages of types whose SSZ size is unbounded.
+const MaxPayloadSize = 10 * 1 << 20
+
+// sizeBounds are the bounds of the SSZ size of the values of a type.
+type sizeBounds struct {
+	min, max uint64
+}
+
+var sizeBoundsCache sync.Map
+
+// typeSizeBounds returns the bounds of the SSZ size of the values of typ, with the
+// maximum of unbounded types capped to MaxPayloadSize.
+func typeSizeBounds(typ reflect.Type) (sizeBounds, error) {
+	if bounds, ok := sizeBoundsCache.Load(typ); ok {
+		return bounds.(sizeBounds), nil
+	}
+	desc, err := ssz.Describe(typ)
+	if err != nil {
+		return sizeBounds{}, err
+	}
+	bounds := sizeBounds{min: desc.MinSize, max: desc.MaxSize}
+	if bounds.max == 0 || bounds.max > MaxPayloadSize {
+		bounds.max = MaxPayloadSize
+	}
+	sizeBoundsCache.Store(typ, bounds)
+	return bounds, nil
+}
+
+// checkSize returns an error if size cannot be the SSZ size of a value of typ.
+func checkSize(typ reflect.Type, size uint64) error {
+	bounds, err := typeSizeBounds(typ)
+	if err != nil {
+		return err
+	}
+	if size < bounds.min || size > bounds.max {
+		return fmt.Errorf("uncompressed size %d is out of the bounds [%d, %d] of %v", size, bounds.min, bounds.max, typ)
+	}
+	return nil
+}
+
+// MarshalSnappy returns the snappy block compression of the SSZ encoding of val,
+// the ssz_snappy encoding of gossipsub messages.
+func MarshalSnappy(val interface{}) ([]byte, error) {
+	encoded, err := ssz.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, encoded), nil
+}
+
+// UnmarshalSnappy decompresses data, a snappy block, and decodes the result into
+// the value pointed to by val. The uncompressed size announced by data is checked
+// against the bounds of the SSZ size of the type of val before decompressing, so
+// that a small message cannot claim a huge allocation.
+func UnmarshalSnappy(data []byte, val interface{}) error {
+	if val == nil {
+		return fmt.Errorf("cannot unmarshal into untyped, nil value")
+	}
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return fmt.Errorf("could not read uncompressed size: %w", err)
+	}
+	if err := checkSize(reflect.TypeOf(val), uint64(size)); err != nil {
+		return err
+	}
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return fmt.Errorf("could not decompress: %w", err)
+	}
+	return ssz.Unmarshal(decoded, val)
+}
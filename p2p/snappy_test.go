@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func TestSnappy_RoundTrip(t *testing.T) {
+	for _, v := range golden.Vectors() {
+		want, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed, err := MarshalSnappy(v.Value)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		val := reflect.New(reflect.TypeOf(v.Value))
+		if err := UnmarshalSnappy(compressed, val.Interface()); err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		got, err := ssz.Marshal(val.Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: round trip changed the value", v.Name)
+		}
+	}
+}
+
+func TestUnmarshalSnappy_SizeBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		val  interface{}
+	}{
+		{"fixed size too large", make([]byte, 41), &benchmarks.Checkpoint{}},
+		{"fixed size too small", make([]byte, 39), &benchmarks.Checkpoint{}},
+		{"exceeds the type maximum", make([]byte, 2048*8+300), &benchmarks.IndexedAttestation{}},
+		{"exceeds the payload maximum", make([]byte, MaxPayloadSize+1), &benchmarks.BeaconState{}},
+	}
+	for _, tt := range tests {
+		err := UnmarshalSnappy(snappy.Encode(nil, tt.data), tt.val)
+		if err == nil || !strings.Contains(err.Error(), "out of the bounds") {
+			t.Errorf("%s: expected a size error, received %v", tt.name, err)
+		}
+	}
+	// The announced size is checked before decompressing, so a truncated block
+	// claiming a huge size is rejected without allocating it.
+	huge := snappy.Encode(nil, make([]byte, 1<<20))[:8]
+	if err := UnmarshalSnappy(huge, &benchmarks.Checkpoint{}); err == nil {
+		t.Error("Expected an error for a truncated block")
+	}
+}
@@ -0,0 +1,47 @@
+package ssz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldPanic is a panic raised while walking the fields of a value, such as an index
+// out of range on a malformed input or a nil dereference within a custom codec, along
+// with the path of the fields it was raised at, outermost first.
+type fieldPanic struct {
+	path  []string
+	value interface{}
+}
+
+// annotatePanic is deferred by the walkers of containers. It adds the name of the field
+// being walked to the path of a panic raised within it, then lets the panic go on for
+// the entry points of the package to recover, see recoverPanic.
+func annotatePanic(name *string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if *name == "" {
+		panic(r)
+	}
+	if p, ok := r.(*fieldPanic); ok {
+		p.path = append([]string{*name}, p.path...)
+		panic(p)
+	}
+	panic(&fieldPanic{path: []string{*name}, value: r})
+}
+
+// recoverPanic is deferred by the entry points of the package, which must not panic
+// on invalid values or hostile inputs. It sets *err to an error describing a panic
+// raised while walking a value, naming the field it was raised at.
+func recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if p, ok := r.(*fieldPanic); ok {
+		*err = fmt.Errorf("recovered from panic at field %s: %v", strings.Join(p.path, "."), p.value)
+		return
+	}
+	*err = fmt.Errorf("recovered from panic: %v", r)
+}
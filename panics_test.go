@@ -0,0 +1,94 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sloppyHandle stands for a value whose codec trusts its input, reading past the end
+// of encodings shorter than the 9 bytes it expects.
+type sloppyHandle struct {
+	last byte
+}
+
+func init() {
+	RegisterCodec(reflect.TypeOf(sloppyHandle{}),
+		func(val interface{}) ([]byte, error) {
+			return make([]byte, 9), nil
+		},
+		func(data []byte, val interface{}) error {
+			val.(*sloppyHandle).last = data[8]
+			return nil
+		},
+		func(val interface{}) ([32]byte, error) {
+			return [32]byte{}, nil
+		},
+	)
+}
+
+type panicInner struct {
+	Slot   uint64
+	Name   *internedName
+	Handle sloppyHandle
+}
+
+type panicOuter struct {
+	Index uint64
+	Inner panicInner
+}
+
+func TestRecoverPanic_Marshal(t *testing.T) {
+	// The codec of interned names indexes the names with their id.
+	item := panicOuter{Inner: panicInner{Name: &internedName{id: 7}}}
+	want := "recovered from panic at field Inner.Name: runtime error: index out of range"
+	if _, err := Marshal(item); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+	for _, cache := range []bool{true, false} {
+		useCache = cache
+		if _, err := HashTreeRoot(item); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, received %v", want, err)
+		}
+	}
+	useCache = true
+	if _, err := HashTreeRoot(&internedName{id: 7}); err == nil || !strings.Contains(err.Error(), "recovered from panic: ") {
+		t.Errorf("Expected a panic to be recovered outside of fields, received %v", err)
+	}
+}
+
+func TestRecoverPanic_ParallelHashing(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	for _, typ := range []reflect.Type{reflect.TypeOf(panicOuter{}), reflect.TypeOf(panicInner{})} {
+		SetTypeHints(typ, Hints{Parallel: true})
+		defer SetTypeHints(typ, Hints{})
+	}
+	items := []interface{}{
+		panicOuter{Inner: panicInner{Name: &internedName{id: 1}}},
+		panicOuter{Inner: panicInner{Name: &internedName{id: 7}}},
+	}
+	want := "value 1: recovered from panic at field Inner.Name"
+	if _, err := HashTreeRootBatch(items); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+}
+
+func TestRecoverPanic_Unmarshal(t *testing.T) {
+	item := panicOuter{Index: 1, Inner: panicInner{Slot: 2, Name: &internedName{id: 1}}}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded panicOuter
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// The handle is encoded last, so that truncating the encoding makes its codec read
+	// past the end of its bytes.
+	truncated := encoded[:len(encoded)-1]
+	want := "recovered from panic at field Inner.Handle: runtime error: index out of range"
+	if err := Unmarshal(truncated, &decoded); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+}
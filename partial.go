@@ -0,0 +1,187 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Partial is a value of which only a subset of the backing tree is known: the nodes
+// proven by multiproofs, along with their helper nodes. The paths leading to known
+// nodes can be read without the value itself, and the partial can be verified against
+// a known root, which enables stateless verification of parts of large values.
+//
+//  partial, err := NewPartial(reflect.TypeOf(BeaconState{}), proof)
+//  if err != nil {
+//      return fmt.Errorf("failed to build partial: %v", err)
+//  }
+//  if ok, err := partial.Verify(stateRoot); err != nil || !ok {
+//      return errors.New("invalid state proof")
+//  }
+//  slot, err := partial.Uint("Slot")
+type Partial struct {
+	typ   reflect.Type
+	nodes map[uint64][32]byte
+}
+
+// NewPartial returns the partial value of type typ holding the nodes of proof. The
+// proof is not verified, which is done by Verify once the partial is complete.
+func NewPartial(typ reflect.Type, proof *Multiproof) (*Partial, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	if proof == nil {
+		return nil, errors.New("proof cannot be nil")
+	}
+	if len(proof.Leaves) != len(proof.Indices) {
+		return nil, fmt.Errorf("expected %d leaves, received %d", len(proof.Indices), len(proof.Leaves))
+	}
+	helpers := HelperIndices(proof.Indices)
+	if len(proof.Hashes) != len(helpers) {
+		return nil, fmt.Errorf("expected %d hashes, received %d", len(helpers), len(proof.Hashes))
+	}
+	p := &Partial{typ: typ, nodes: make(map[uint64][32]byte, len(proof.Indices)+len(helpers))}
+	for i, gindex := range proof.Indices {
+		if gindex == 0 {
+			return nil, errors.New("generalized index 0 is not a valid index")
+		}
+		p.nodes[gindex] = proof.Leaves[i]
+	}
+	for i, gindex := range helpers {
+		p.nodes[gindex] = proof.Hashes[i]
+	}
+	return p, nil
+}
+
+// ProvePartial returns the partial of val holding the nodes designated by paths.
+func ProvePartial(val interface{}, paths ...[]interface{}) (*Partial, error) {
+	proof, err := ProveMulti(val, paths...)
+	if err != nil {
+		return nil, err
+	}
+	return NewPartial(reflect.TypeOf(val), proof)
+}
+
+// Type returns the type of the value the partial is part of.
+func (p *Partial) Type() reflect.Type {
+	return p.typ
+}
+
+// Merge adds the nodes known by other to the partial. Both partials must be parts of
+// values of the same type and agree on the nodes they both know.
+func (p *Partial) Merge(other *Partial) error {
+	if other == nil {
+		return errors.New("partial cannot be nil")
+	}
+	if p.typ != other.typ {
+		return fmt.Errorf("cannot merge partial of type %v into partial of type %v", other.typ, p.typ)
+	}
+	for gindex, node := range other.nodes {
+		if known, ok := p.nodes[gindex]; ok && known != node {
+			return fmt.Errorf("conflicting roots for generalized index %d", gindex)
+		}
+	}
+	for gindex, node := range other.nodes {
+		p.nodes[gindex] = node
+	}
+	return nil
+}
+
+// Root returns the root computed from the nodes known by the partial. An error is
+// returned if they do not reach the root or are inconsistent with each other.
+func (p *Partial) Root() ([32]byte, error) {
+	return p.node(1)
+}
+
+// Verify reports whether the nodes known by the partial prove it against root.
+func (p *Partial) Verify(root [32]byte) (bool, error) {
+	computed, err := p.Root()
+	if err != nil {
+		return false, err
+	}
+	return computed == root, nil
+}
+
+// Node returns the root of the node designated by path, as resolved by
+// GeneralizedIndex. An error is returned if it cannot be computed from the
+// known nodes.
+func (p *Partial) Node(path ...interface{}) ([32]byte, error) {
+	target, err := resolvePath(p.typ, path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return p.node(target.gindex)
+}
+
+// Uint reads the boolean or unsigned integer designated by path. Booleans are
+// read as 0 or 1.
+//
+//  balance, err := partial.Uint("Balances", 1234)
+func (p *Partial) Uint(path ...interface{}) (uint64, error) {
+	target, err := resolvePath(p.typ, path)
+	if err != nil {
+		return 0, err
+	}
+	if target.isBit {
+		return 0, errors.New("bits of bitlists cannot be read")
+	}
+	if !isBasicType(target.typ.Kind()) {
+		return 0, fmt.Errorf("type %v is not a basic type", target.typ)
+	}
+	chunk, err := p.node(target.gindex)
+	if err != nil {
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[:], chunk[target.offset:target.offset+staticFixedSize(target.typ)])
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// node returns the root of the node at gindex, computed from the deepest known
+// nodes below it.
+func (p *Partial) node(gindex uint64) ([32]byte, error) {
+	ancestors := make(map[uint64]bool, len(p.nodes))
+	for k := range p.nodes {
+		for k > 1 {
+			k = ParentIndex(k)
+			if ancestors[k] {
+				break
+			}
+			ancestors[k] = true
+		}
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	root, ok, err := p.compute(h, gindex, ancestors)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if !ok {
+		return [32]byte{}, fmt.Errorf("node at generalized index %d is not available", gindex)
+	}
+	return root, nil
+}
+
+func (p *Partial) compute(h *Hasher, gindex uint64, ancestors map[uint64]bool) ([32]byte, bool, error) {
+	known, isKnown := p.nodes[gindex]
+	if !ancestors[gindex] {
+		return known, isKnown, nil
+	}
+	left, hasLeft, err := p.compute(h, gindex*2, ancestors)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	right, hasRight, err := p.compute(h, gindex*2+1, ancestors)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	if !hasLeft || !hasRight {
+		return known, isKnown, nil
+	}
+	root := h.hashPair(left[:], right[:])
+	if isKnown && known != root {
+		return [32]byte{}, false, fmt.Errorf("inconsistent root for generalized index %d", gindex)
+	}
+	return root, true, nil
+}
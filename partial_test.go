@@ -0,0 +1,154 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartial_ReadAndVerify(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := ProvePartial(item,
+		[]interface{}{"Slot"},
+		[]interface{}{"Forks", 1, "Epoch"},
+		[]interface{}{"Balances", 4},
+		[]interface{}{"Balances", LengthPathElement},
+		[]interface{}{"ShortVector", 3},
+		[]interface{}{"Flag"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := partial.Verify(root); err != nil || !ok {
+		t.Fatalf("Expected partial to verify, received %v, %v", ok, err)
+	}
+
+	tests := []struct {
+		path []interface{}
+		want uint64
+	}{
+		{path: []interface{}{"Slot"}, want: item.Slot},
+		{path: []interface{}{"Forks", 1, "Epoch"}, want: item.Forks[1].Epoch},
+		{path: []interface{}{"Balances", 4}, want: item.Balances[4]},
+		{path: []interface{}{"Balances", 5}, want: item.Balances[5]},
+		{path: []interface{}{"Balances", LengthPathElement}, want: uint64(len(item.Balances))},
+		{path: []interface{}{"ShortVector", 3}, want: uint64(item.ShortVector[3])},
+		{path: []interface{}{"Flag"}, want: 1},
+	}
+	for _, tt := range tests {
+		got, err := partial.Uint(tt.path...)
+		if err != nil {
+			t.Errorf("Could not read %v: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expected %v to be %d, received %d", tt.path, tt.want, got)
+		}
+	}
+
+	forkRoot, err := HashTreeRoot(item.Forks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, err := partial.Node("Forks", 1); err != nil || node != forkRoot {
+		t.Errorf("Expected fork root %#x, received %#x, %v", forkRoot, node, err)
+	}
+	if _, err := partial.Uint("Forks", 0, "Epoch"); err == nil {
+		t.Error("Expected error reading a path missing from the partial")
+	}
+	if _, err := partial.Uint("Forks", 1); err == nil {
+		t.Error("Expected error reading a container as a basic value")
+	}
+}
+
+func TestPartial_Merge(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := ProvePartial(item, []interface{}{"Slot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ProvePartial(item, []interface{}{"Custody", 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Uint("Custody", 2); err == nil {
+		t.Error("Expected error reading a path missing from the partial")
+	}
+	if err := first.Merge(second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := first.Verify(root); err != nil || !ok {
+		t.Fatalf("Expected merged partial to verify, received %v, %v", ok, err)
+	}
+	for i, want := range []uint64{item.Slot, item.Custody[2]} {
+		path := [][]interface{}{{"Slot"}, {"Custody", 2}}[i]
+		if got, err := first.Uint(path...); err != nil || got != want {
+			t.Errorf("Expected %v to be %d, received %d, %v", path, want, got, err)
+		}
+	}
+
+	item.Slot++
+	conflicting, err := ProvePartial(item, []interface{}{"Slot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Merge(conflicting); err == nil {
+		t.Error("Expected error merging conflicting partials")
+	}
+	if err := first.Merge(&Partial{typ: reflect.TypeOf(fork{})}); err == nil {
+		t.Error("Expected error merging partials of different types")
+	}
+}
+
+func TestPartial_Inconsistent(t *testing.T) {
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := ProvePartial(item, []interface{}{"Forks", 1, "Epoch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ProvePartial(item, []interface{}{"Forks", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gindex, err := GeneralizedIndex(reflect.TypeOf(item), "Forks", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.nodes[gindex] = [32]byte{1}
+	if err := partial.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := partial.Verify(root); err == nil {
+		t.Error("Expected error verifying inconsistent partial")
+	}
+}
+
+func TestNewPartial_Malformed(t *testing.T) {
+	typ := reflect.TypeOf(fork{})
+	tests := []*Multiproof{
+		nil,
+		{Indices: []uint64{8}, Leaves: [][32]byte{}},
+		{Indices: []uint64{0}, Leaves: [][32]byte{{}}},
+		{Indices: []uint64{8}, Leaves: [][32]byte{{}}, Hashes: [][32]byte{{}}},
+	}
+	for i, proof := range tests {
+		if _, err := NewPartial(typ, proof); err == nil {
+			t.Errorf("Expected error for malformed proof %d", i)
+		}
+	}
+}
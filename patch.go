@@ -0,0 +1,88 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// PatchField overwrites, within encoded, the encoding of the value of type typ, the
+// fixed-size value designated by path with newValue. Paths follow UnmarshalPath, and
+// only lead to the value through static offsets and the offsets of variable-size
+// fields, so that stored encodings can be updated without being decoded:
+//
+//  if err := PatchField(encodedState, reflect.TypeOf(BeaconState{}), []interface{}{"Slot"}, slot+1); err != nil {
+//      return fmt.Errorf("failed to update slot: %v", err)
+//  }
+//
+// Variable-size values change the size of the encoding and cannot be patched.
+func PatchField(encoded []byte, typ reflect.Type, path []interface{}, newValue interface{}) error {
+	if typ == nil || newValue == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	c, err := locatePath(encoded, typ, typ, path)
+	if err != nil {
+		return err
+	}
+	if c.scalar != nil || c.opaque != nil || c.bits != nil {
+		return errors.New("lengths, bits and opaque fields cannot be patched")
+	}
+	sszType := c.typ
+	for sszType.Kind() == reflect.Ptr {
+		sszType = sszType.Elem()
+	}
+	if isVariableSizeType(sszType) {
+		return fmt.Errorf("variable-size type %v cannot be patched", c.valueType())
+	}
+	val := reflect.ValueOf(newValue)
+	for val.Kind() == reflect.Ptr && val.Type() != c.valueType() {
+		if val.IsNil() {
+			return errors.New("cannot patch with a nil pointer")
+		}
+		val = val.Elem()
+	}
+	if val.Type() != c.valueType() {
+		return fmt.Errorf("cannot patch %v with %T", c.valueType(), newValue)
+	}
+	utils, err := cachedSSZUtils(sszType)
+	if err != nil {
+		return fmt.Errorf("could not initialize marshaler for type: %v, %w", sszType, err)
+	}
+	size := staticFixedSize(sszType)
+	if uint64(len(c.input)) != size {
+		return errorf(ErrSize, "value of type %v is encoded with %d bytes, expected %d", c.valueType(), len(c.input), size)
+	}
+	sized, err := sizedValue(val, sszType)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, size)
+	if _, err := utils.marshaler(sized, buf, 0); err != nil {
+		return fmt.Errorf("failed to marshal %v: %w", c.valueType(), err)
+	}
+	copy(c.input, buf)
+	return nil
+}
+
+// sizedValue returns val, a value of a type with size tags, as a value of typ, the
+// type of arrays it is encoded as.
+func sizedValue(val reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	if val.Type() == typ {
+		return val, nil
+	}
+	if typ.Kind() != reflect.Array || val.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("cannot encode %v as %v", val.Type(), typ)
+	}
+	if val.Len() != typ.Len() {
+		return reflect.Value{}, fmt.Errorf("expected %d elements, received %d", typ.Len(), val.Len())
+	}
+	sized := reflect.New(typ).Elem()
+	for i := 0; i < val.Len(); i++ {
+		elem, err := sizedValue(val.Index(i), typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		sized.Index(i).Set(elem)
+	}
+	return sized, nil
+}
@@ -0,0 +1,119 @@
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type patchedContainer struct {
+	Slot    uint64
+	Forks   []fork `ssz-max:"16"`
+	Pointer *fork
+	Tag     []byte         `ssz-size:"4"`
+	Items   []iteratedItem `ssz-max:"4"`
+	Flag    bool
+}
+
+func TestPatchField(t *testing.T) {
+	newContainer := func() patchedContainer {
+		return patchedContainer{
+			Slot:    1,
+			Forks:   []fork{{Epoch: 1}, {Epoch: 2}},
+			Pointer: &fork{Epoch: 3},
+			Tag:     []byte{1, 2, 3, 4},
+			Items:   []iteratedItem{{Slot: 4, Data: []byte{1}}, {Slot: 5, Data: []byte{2, 3}}},
+		}
+	}
+	typ := reflect.TypeOf(patchedContainer{})
+	tests := []struct {
+		name   string
+		path   []interface{}
+		value  interface{}
+		modify func(*patchedContainer)
+	}{
+		{
+			name:   "basic field",
+			path:   []interface{}{"Slot"},
+			value:  uint64(42),
+			modify: func(c *patchedContainer) { c.Slot = 42 },
+		},
+		{
+			name:   "bool field",
+			path:   []interface{}{"Flag"},
+			value:  true,
+			modify: func(c *patchedContainer) { c.Flag = true },
+		},
+		{
+			name:   "list element",
+			path:   []interface{}{"Forks", 1},
+			value:  fork{CurrentVersion: [4]byte{9}, Epoch: 10},
+			modify: func(c *patchedContainer) { c.Forks[1] = fork{CurrentVersion: [4]byte{9}, Epoch: 10} },
+		},
+		{
+			name:   "pointer field",
+			path:   []interface{}{"Pointer"},
+			value:  &fork{Epoch: 11},
+			modify: func(c *patchedContainer) { c.Pointer = &fork{Epoch: 11} },
+		},
+		{
+			name:   "sized field",
+			path:   []interface{}{"Tag"},
+			value:  []byte{5, 6, 7, 8},
+			modify: func(c *patchedContainer) { c.Tag = []byte{5, 6, 7, 8} },
+		},
+		{
+			name:   "field of a variable-size element",
+			path:   []interface{}{"Items", 1, "Slot"},
+			value:  uint64(12),
+			modify: func(c *patchedContainer) { c.Items[1].Slot = 12 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := newContainer()
+			encoded, err := Marshal(item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := PatchField(encoded, typ, tt.path, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&item)
+			want, err := Marshal(item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, want) {
+				t.Errorf("Expected %#x, received %#x", want, encoded)
+			}
+		})
+	}
+}
+
+func TestPatchField_Errors(t *testing.T) {
+	item := patchedContainer{Pointer: &fork{}, Tag: make([]byte, 4)}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(patchedContainer{})
+	tests := []struct {
+		name  string
+		path  []interface{}
+		value interface{}
+	}{
+		{name: "variable-size field", path: []interface{}{"Forks"}, value: []fork{}},
+		{name: "list length", path: []interface{}{"Forks", LengthPathElement}, value: uint64(1)},
+		{name: "mismatched type", path: []interface{}{"Slot"}, value: uint32(1)},
+		{name: "mismatched size", path: []interface{}{"Tag"}, value: []byte{1}},
+		{name: "unknown field", path: []interface{}{"Unknown"}, value: uint64(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PatchField(encoded, typ, tt.path, tt.value); err == nil {
+				t.Error("Expected error, received nil")
+			}
+		})
+	}
+}
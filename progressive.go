@@ -0,0 +1,176 @@
+package ssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Struct fields tagged with `ssz:"progressive"` are merkleized progressively, following
+// EIP-7916 and EIP-7495, rather than into trees whose depth is set by their ssz-max:
+//
+//  type exampleStruct struct {
+//      Transactions [][]byte `ssz:"progressive"`
+//      Header       header   `ssz:"progressive"`
+//  }
+//
+// Progressive lists take no ssz-max, and the lists nested within them are progressive
+// too, while containers are merkleized as progressive containers with all of their
+// fields active. Only their roots differ from those of other fields, as they encode the
+// same way. Trees, proofs and generalized indices do not reach into them.
+
+// makeProgressiveUtils returns the ssz utils of a field of type typ tagged with
+// `ssz:"progressive"`.
+func makeProgressiveUtils(typ reflect.Type) (*sszUtils, error) {
+	utils, err := cachedSSZUtilsNoAcquireLock(typ)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := makeProgressiveHasher(typ)
+	if err != nil {
+		return nil, err
+	}
+	return &sszUtils{
+		marshaler:   utils.marshaler,
+		unmarshaler: utils.unmarshaler,
+		hasher:      hasher,
+	}, nil
+}
+
+// makeProgressiveHasher returns the hasher merkleizing the values of typ progressively:
+// lists and strings as progressive lists, and containers as progressive containers.
+func makeProgressiveHasher(typ reflect.Type) (hasher, error) {
+	kind := typ.Kind()
+	switch {
+	case lookupCodec(typ) != nil || isBitlist(typ) || isBitvector(typ):
+		return nil, errorf(ErrInvalidTag, "type %v cannot be merkleized progressively", typ)
+	case kind == reflect.Ptr:
+		elemHasher, err := makeProgressiveHasher(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			elem, err := pointee(val)
+			if err != nil {
+				return [32]byte{}, err
+			}
+			return elemHasher(h, elem, maxCapacity)
+		}, nil
+	case kind == reflect.String:
+		bytesHasher, err := makeProgressiveBasicListHasher(byteSliceType)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+			if err := checkUTF8(val.String()); err != nil {
+				return [32]byte{}, err
+			}
+			return bytesHasher(h, stringBytes(val), maxCapacity)
+		}, nil
+	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
+		return makeProgressiveBasicListHasher(typ)
+	case kind == reflect.Slice:
+		return makeProgressiveListHasher(typ)
+	case kind == reflect.Struct:
+		return makeProgressiveContainerHasher(typ)
+	default:
+		return nil, errorf(ErrInvalidTag, "type %v is neither a list nor a container, and cannot be merkleized progressively", typ)
+	}
+}
+
+// makeProgressiveBasicListHasher hashes lists of basic values, which are packed into
+// the chunks of their progressive tree.
+func makeProgressiveBasicListHasher(typ reflect.Type) (hasher, error) {
+	utils, err := cachedSSZUtilsNoAcquireLock(typ)
+	if err != nil {
+		return nil, err
+	}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		buf := h.getBuffer(determineSize(val))
+		defer h.putBuffer(buf)
+		if _, err := utils.marshaler(val, *buf, 0); err != nil {
+			return [32]byte{}, err
+		}
+		output := lengthChunk(uint64(val.Len()))
+		return h.mixInLength(h.merkleizeProgressive(*buf, 1), output[:]), nil
+	}
+	return hasher, nil
+}
+
+// makeProgressiveListHasher hashes lists of composite values, whose roots are the chunks
+// of their progressive tree. Lists and strings nested within them are progressive too.
+func makeProgressiveListHasher(typ reflect.Type) (hasher, error) {
+	var elemHasher hasher
+	elemType := typ.Elem()
+	if kind := elemType.Kind(); (kind == reflect.Slice || kind == reflect.String) &&
+		lookupCodec(elemType) == nil && !isBitlist(elemType) && !isBitvector(elemType) {
+		var err error
+		if elemHasher, err = makeProgressiveHasher(elemType); err != nil {
+			return nil, err
+		}
+	} else {
+		utils, err := cachedSSZUtilsNoAcquireLock(elemType)
+		if err != nil {
+			return nil, err
+		}
+		elemHasher = utils.hasher
+	}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		roots := h.getBuffer(uint64(val.Len()) * 32)
+		defer h.putBuffer(roots)
+		for i := 0; i < val.Len(); i++ {
+			r, err := elemHasher(h, val.Index(i), 0)
+			if err != nil {
+				return [32]byte{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			copy((*roots)[i*32:], r[:])
+		}
+		output := lengthChunk(uint64(val.Len()))
+		return h.mixInLength(h.merkleizeProgressive(*roots, 1), output[:]), nil
+	}
+	return hasher, nil
+}
+
+// makeProgressiveContainerHasher hashes containers as progressive containers whose
+// fields are all active: the roots of the fields are merkleized progressively, and the
+// bitvector of active fields is mixed into the result.
+func makeProgressiveContainerHasher(typ reflect.Type) (hasher, error) {
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 256 {
+		return nil, errorf(ErrUnsupportedType, "progressive container %v has %d fields, exceeding 256", typ, len(fields))
+	}
+	var activeFields [32]byte
+	for i := range fields {
+		activeFields[i/8] |= 1 << uint(i%8)
+	}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		roots, err := hashFields(h, fields, val)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		defer h.putBuffer(roots)
+		root := h.merkleizeProgressive(*roots, 1)
+		return h.hashPair(root[:], activeFields[:]), nil
+	}
+	return hasher, nil
+}
+
+// merkleizeProgressive merkleizes the chunks of data, the last of which is right-padded
+// with zeroes, into the progressive tree of EIP-7916: the first numLeaves chunks are
+// merkleized into the right subtree of the root, and the rest into its left subtree,
+// holding four times as many chunks at each level.
+func (h *Hasher) merkleizeProgressive(data []byte, numLeaves uint64) [32]byte {
+	if len(data) == 0 {
+		return [32]byte{}
+	}
+	size := numLeaves * 32
+	if size > uint64(len(data)) {
+		size = uint64(len(data))
+	}
+	left := h.merkleizeProgressive(data[size:], numLeaves*4)
+	// The chunks of the subtree never exceed its number of leaves.
+	right, _ := h.merkleizeBytes(data[:size], numLeaves, true /* has limit */)
+	return h.hashPair(left[:], right[:])
+}
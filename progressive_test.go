@@ -0,0 +1,176 @@
+package ssz
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type progressiveContainer struct {
+	Slot         uint64
+	Balances     []uint64 `ssz:"progressive"`
+	Transactions [][]byte `ssz:"progressive"`
+	Name         string   `ssz:"progressive"`
+	Header       *fork    `ssz:"progressive"`
+}
+
+// naiveMerkleizeProgressive merkleizes chunks as merkleize_progressive of EIP-7916.
+func naiveMerkleizeProgressive(chunks [][32]byte, numLeaves uint64) [32]byte {
+	if len(chunks) == 0 {
+		return [32]byte{}
+	}
+	n := numLeaves
+	if n > uint64(len(chunks)) {
+		n = uint64(len(chunks))
+	}
+	left := naiveMerkleizeProgressive(chunks[n:], numLeaves*4)
+	right := naiveMerkleize(chunks[:n], numLeaves)
+	return sha256.Sum256(append(left[:], right[:]...))
+}
+
+// naiveChunks splits data into chunks, without any chunk for empty data.
+func naiveChunks(data []byte) [][32]byte {
+	chunks := make([][32]byte, (len(data)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], data[i*32:])
+	}
+	return chunks
+}
+
+func naiveProgressiveBytes(data []byte) [32]byte {
+	return naiveMixInLength(naiveMerkleizeProgressive(naiveChunks(data), 1), len(data))
+}
+
+func TestMerkleizeProgressive(t *testing.T) {
+	for _, count := range []int{0, 1, 2, 5, 6, 21, 22, 100} {
+		chunks := make([][32]byte, count)
+		for i := range chunks {
+			chunks[i][0] = byte(i + 1)
+		}
+		if got, want := MerkleizeProgressive(chunks), naiveMerkleizeProgressive(chunks, 1); got != want {
+			t.Errorf("Expected root %#x for %d chunks, received %#x", want, count, got)
+		}
+	}
+}
+
+func TestHashTreeRoot_Progressive(t *testing.T) {
+	item := progressiveContainer{
+		Slot:         7,
+		Balances:     []uint64{1, 2, 3, 4, 5},
+		Transactions: [][]byte{{1, 2, 3}, {}, make([]byte, 100)},
+		Name:         "héllo",
+		Header:       &fork{CurrentVersion: [4]byte{1}, Epoch: 3},
+	}
+	var slot, epoch [32]byte
+	binary.LittleEndian.PutUint64(slot[:], item.Slot)
+	binary.LittleEndian.PutUint64(epoch[:], item.Header.Epoch)
+	balances := make([]byte, 8*len(item.Balances))
+	for i, b := range item.Balances {
+		binary.LittleEndian.PutUint64(balances[i*8:], b)
+	}
+	txRoots := make([][32]byte, len(item.Transactions))
+	for i, tx := range item.Transactions {
+		txRoots[i] = naiveProgressiveBytes(tx)
+	}
+	// The three fields of the header are active.
+	headerRoot := naiveMerkleizeProgressive([][32]byte{{}, {1}, epoch}, 1)
+	activeFields := [32]byte{0x07}
+	headerRoot = sha256.Sum256(append(headerRoot[:], activeFields[:]...))
+	want := naiveMerkleize([][32]byte{
+		slot,
+		naiveMixInLength(naiveMerkleizeProgressive(naiveChunks(balances), 1), len(item.Balances)),
+		naiveMixInLength(naiveMerkleizeProgressive(txRoots, 1), len(txRoots)),
+		naiveProgressiveBytes([]byte(item.Name)),
+		headerRoot,
+	}, 5)
+
+	for _, cache := range []bool{true, false} {
+		useCache = cache
+		root, err := HashTreeRoot(item)
+		useCache = true
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != want {
+			t.Errorf("Expected root %#x with cache %v, received %#x", want, cache, root)
+		}
+	}
+
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := HashTreeRootFromBytes(encoded, reflect.TypeOf(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Errorf("Expected root %#x from bytes, received %#x", want, root)
+	}
+	nameRoot, err := FieldRoot(item, "Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nameRoot != naiveProgressiveBytes([]byte(item.Name)) {
+		t.Errorf("Expected the root of field Name to be progressive, received %#x", nameRoot)
+	}
+}
+
+func TestProgressive_EncodingUnchanged(t *testing.T) {
+	type plainContainer struct {
+		Slot         uint64
+		Balances     []uint64 `ssz-max:"1024"`
+		Transactions [][]byte `ssz-max:"16,1024"`
+		Name         string   `ssz-max:"64"`
+		Header       *fork
+	}
+	item := progressiveContainer{
+		Slot:         7,
+		Balances:     []uint64{1, 2},
+		Transactions: [][]byte{{1}, {2, 3}},
+		Name:         "abc",
+		Header:       &fork{Epoch: 3},
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Marshal(plainContainer(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, plain) {
+		t.Errorf("Expected encoding %#x, received %#x", plain, encoded)
+	}
+	var decoded progressiveContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, item) {
+		t.Errorf("Expected %+v, received %+v", item, decoded)
+	}
+}
+
+func TestProgressive_Unsupported(t *testing.T) {
+	item := progressiveContainer{Header: &fork{}}
+	if _, err := NewTree(item); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected backing trees to reject progressive fields, received %v", err)
+	}
+	if _, err := GeneralizedIndex(reflect.TypeOf(item), "Balances", 0); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected paths not to descend into progressive fields, received %v", err)
+	}
+	type limitedContainer struct {
+		Balances []uint64 `ssz:"progressive,max=16"`
+	}
+	if _, err := HashTreeRoot(limitedContainer{}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expected progressive fields with an ssz-max to fail, received %v", err)
+	}
+	if err := ValidateType(reflect.TypeOf(limitedContainer{})); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expected progressive fields with an ssz-max to be invalid, received %v", err)
+	}
+	if err := ValidateType(reflect.TypeOf(item)); err != nil {
+		t.Errorf("Expected progressive fields to be valid, received %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package ssz
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Proof is a Merkle proof of a single node of the backing tree of a value.
+// Proofs can be serialized with Marshal, as a container of the fields below,
+// and to JSON or YAML in the format of the single Merkle proofs of the
+// consensus spec tests:
+//
+//  {"leaf": "0x...", "leaf_index": 105, "branch": ["0x...", "0x..."]}
+type Proof struct {
+	// Index is the generalized index of the proven node.
+	Index uint64
+	// Leaf is the root of the proven node.
+	Leaf [32]byte
+	// Hashes are the roots of the siblings of the nodes on the path from the
+	// proven node to the root, ordered bottom-up.
+	Hashes [][32]byte `ssz-max:"64"`
+}
+
+type proofJSON struct {
+	Leaf      string   `json:"leaf"`
+	LeafIndex uint64   `json:"leaf_index"`
+	Branch    []string `json:"branch"`
+}
+
+// MarshalJSON encodes the proof in the format of the consensus spec tests.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(proofJSON{
+		Leaf:      encodeHexRoot(p.Leaf),
+		LeafIndex: p.Index,
+		Branch:    encodeHexRoots(p.Hashes),
+	})
+}
+
+// UnmarshalJSON decodes a proof in the format of the consensus spec tests.
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	var enc proofJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	leaf, err := decodeHexRoot(enc.Leaf)
+	if err != nil {
+		return fmt.Errorf("invalid leaf: %w", err)
+	}
+	hashes, err := decodeHexRoots(enc.Branch)
+	if err != nil {
+		return fmt.Errorf("invalid branch: %w", err)
+	}
+	p.Index = enc.LeafIndex
+	p.Leaf = leaf
+	p.Hashes = hashes
+	return nil
+}
+
+// Prove returns the Merkle proof of the node designated by path within val, as
+// resolved by GeneralizedIndex, against the hash tree root of val.
+//
+//  proof, err := Prove(state, "FinalizedCheckpoint", "Root")
+//  if err != nil {
+//      return fmt.Errorf("failed to prove finalized root: %v", err)
+//  }
+func Prove(val interface{}, path ...interface{}) (*Proof, error) {
+	if val == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	gindex, err := GeneralizedIndex(reflect.TypeOf(val), path...)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := NewTree(val)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Prove(gindex)
+}
+
+// Prove returns the Merkle proof of the node at the given generalized index
+// against the root of the tree.
+func (t *Tree) Prove(gindex uint64) (*Proof, error) {
+	if gindex == 0 {
+		return nil, errors.New("generalized index 0 is not a valid index")
+	}
+	depth := int(bitLength(gindex)) - 1
+	hashes := make([][32]byte, depth)
+	n := t.root
+	for i := depth - 1; i >= 0; i-- {
+		if n.IsLeaf() {
+			return nil, fmt.Errorf("generalized index %d is below a leaf", gindex)
+		}
+		if gindex&(1<<uint(i)) == 0 {
+			hashes[i] = n.right.Root()
+			n = n.left
+		} else {
+			hashes[i] = n.left.Root()
+			n = n.right
+		}
+	}
+	return &Proof{
+		Index:  gindex,
+		Leaf:   n.Root(),
+		Hashes: hashes,
+	}, nil
+}
+
+// VerifyProof reports whether proof proves its leaf at its generalized index
+// against root. It only hashes the proof, so it needs neither the proven value
+// nor its type. An error is returned for malformed proofs.
+//
+//  ok, err := VerifyProof(stateRoot, proof)
+//  if err != nil {
+//      return fmt.Errorf("malformed proof: %v", err)
+//  }
+func VerifyProof(root [32]byte, proof *Proof) (bool, error) {
+	if proof == nil {
+		return false, errors.New("proof cannot be nil")
+	}
+	if proof.Index == 0 {
+		return false, errors.New("generalized index 0 is not a valid index")
+	}
+	depth := int(bitLength(proof.Index)) - 1
+	if len(proof.Hashes) != depth {
+		return false, fmt.Errorf("expected %d hashes for generalized index %d, received %d", depth, proof.Index, len(proof.Hashes))
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	node := proof.Leaf
+	for i, sibling := range proof.Hashes {
+		if proof.Index&(1<<uint(i)) == 0 {
+			node = h.hashPair(node[:], sibling[:])
+		} else {
+			node = h.hashPair(sibling[:], node[:])
+		}
+	}
+	return node == root, nil
+}
+
+func encodeHexRoot(root [32]byte) string {
+	return "0x" + hex.EncodeToString(root[:])
+}
+
+func encodeHexRoots(roots [][32]byte) []string {
+	encoded := make([]string, len(roots))
+	for i, root := range roots {
+		encoded[i] = encodeHexRoot(root)
+	}
+	return encoded
+}
+
+func decodeHexRoot(s string) ([32]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if len(b) != 32 {
+		return [32]byte{}, errorf(ErrSize, "expected 32 bytes, received %d", len(b))
+	}
+	return toBytes32(b), nil
+}
+
+func decodeHexRoots(s []string) ([][32]byte, error) {
+	roots := make([][32]byte, len(s))
+	for i := range s {
+		root, err := decodeHexRoot(s[i])
+		if err != nil {
+			return nil, fmt.Errorf("root %d: %w", i, err)
+		}
+		roots[i] = root
+	}
+	return roots, nil
+}
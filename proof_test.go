@@ -0,0 +1,196 @@
+package ssz
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// proofRoot folds the hashes of a proof into the root they prove the leaf against.
+func proofRoot(proof *Proof) [32]byte {
+	root := proof.Leaf
+	for i, sibling := range proof.Hashes {
+		if proof.Index&(1<<uint(i)) == 0 {
+			root = hash(append(root[:], sibling[:]...))
+		} else {
+			root = hash(append(sibling[:], root[:]...))
+		}
+	}
+	return root
+}
+
+func TestProve(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forkRoot, err := HashTreeRoot(item.Forks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path []interface{}
+		leaf [32]byte
+	}{
+		{path: []interface{}{"Slot"}, leaf: lengthChunk(item.Slot)},
+		{path: []interface{}{"Forks", 1}, leaf: forkRoot},
+		{path: []interface{}{"Forks", 2, "Epoch"}, leaf: lengthChunk(item.Forks[2].Epoch)},
+		{path: []interface{}{"Balances", LengthPathElement}, leaf: lengthChunk(uint64(len(item.Balances)))},
+		{path: []interface{}{"Roots", 1}, leaf: item.Roots[1]},
+		// Elements beyond the length of a list are proven to be zero.
+		{path: []interface{}{"Roots", 7}, leaf: [32]byte{}},
+		{path: nil, leaf: root},
+	}
+	for _, tt := range tests {
+		proof, err := Prove(item, tt.path...)
+		if err != nil {
+			t.Fatalf("path %v: %v", tt.path, err)
+		}
+		gindex, err := GeneralizedIndex(reflect.TypeOf(item), tt.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Index != gindex {
+			t.Errorf("path %v: expected index %d, received %d", tt.path, gindex, proof.Index)
+		}
+		if len(proof.Hashes) != int(bitLength(gindex))-1 {
+			t.Errorf("path %v: expected %d hashes, received %d", tt.path, bitLength(gindex)-1, len(proof.Hashes))
+		}
+		if proof.Leaf != tt.leaf {
+			t.Errorf("path %v: expected leaf %#x, received %#x", tt.path, tt.leaf, proof.Leaf)
+		}
+		if got := proofRoot(proof); got != root {
+			t.Errorf("path %v: expected proof against root %#x, received %#x", tt.path, root, got)
+		}
+	}
+}
+
+func TestProve_Errors(t *testing.T) {
+	if _, err := Prove(nil); err == nil {
+		t.Error("Expected error for untyped nil")
+	}
+	if _, err := Prove(fork{}, "Missing"); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+	tree, err := NewTree(fork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Prove(0); err == nil {
+		t.Error("Expected error for generalized index 0")
+	}
+	if _, err := tree.Prove(4 << 1); err == nil {
+		t.Error("Expected error for generalized index below a leaf")
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := Prove(item, "Forks", 2, "Epoch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := VerifyProof(root, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("Expected proof to verify")
+	}
+
+	tampered := *proof
+	tampered.Leaf = lengthChunk(item.Forks[2].Epoch + 1)
+	if ok, err := VerifyProof(root, &tampered); err != nil || ok {
+		t.Errorf("Expected proof with tampered leaf not to verify, received %v, %v", ok, err)
+	}
+	tampered = *proof
+	tampered.Index = proof.Index ^ 1
+	if ok, err := VerifyProof(root, &tampered); err != nil || ok {
+		t.Errorf("Expected proof with tampered index not to verify, received %v, %v", ok, err)
+	}
+	if ok, err := VerifyProof([32]byte{1}, proof); err != nil || ok {
+		t.Errorf("Expected proof against another root not to verify, received %v, %v", ok, err)
+	}
+}
+
+func TestVerifyProof_Malformed(t *testing.T) {
+	if _, err := VerifyProof([32]byte{}, nil); err == nil {
+		t.Error("Expected error for nil proof")
+	}
+	if _, err := VerifyProof([32]byte{}, &Proof{}); err == nil {
+		t.Error("Expected error for generalized index 0")
+	}
+	if _, err := VerifyProof([32]byte{}, &Proof{Index: 4, Hashes: make([][32]byte, 1)}); err == nil {
+		t.Error("Expected error for wrong number of hashes")
+	}
+	leaf := [32]byte{1}
+	ok, err := VerifyProof(leaf, &Proof{Index: 1, Leaf: leaf})
+	if err != nil || !ok {
+		t.Errorf("Expected proof of the root itself to verify, received %v, %v", ok, err)
+	}
+}
+
+func TestProof_Serialization(t *testing.T) {
+	item := newTreeContainer()
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := Prove(item, "Forks", 1, "Epoch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := Marshal(*proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Index, leaf, offset of the hashes, then the hashes themselves.
+	if want := 8 + 32 + 4 + 32*len(proof.Hashes); len(encoded) != want {
+		t.Errorf("Expected encoding of %d bytes, received %d", want, len(encoded))
+	}
+	var decoded Proof
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*proof, decoded) {
+		t.Errorf("Expected %v, received %v", proof, decoded)
+	}
+
+	jsonEncoded, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(jsonEncoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["leaf"] != "0x"+hex.EncodeToString(proof.Leaf[:]) {
+		t.Errorf("Expected hex encoded leaf, received %v", fields["leaf"])
+	}
+	if fields["leaf_index"] != float64(proof.Index) {
+		t.Errorf("Expected leaf index %d, received %v", proof.Index, fields["leaf_index"])
+	}
+	if branch, ok := fields["branch"].([]interface{}); !ok || len(branch) != len(proof.Hashes) {
+		t.Errorf("Expected branch of %d hashes, received %v", len(proof.Hashes), fields["branch"])
+	}
+	decoded = Proof{}
+	if err := json.Unmarshal(jsonEncoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyProof(root, &decoded); err != nil || !ok {
+		t.Errorf("Expected decoded proof to verify, received %v, %v", ok, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"leaf": "0x01", "leaf_index": 1, "branch": []}`), &decoded); err == nil {
+		t.Error("Expected error for short leaf")
+	}
+}
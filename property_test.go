@@ -0,0 +1,173 @@
+package ssz
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// maxRandomListLength bounds the length of random lists, whose ssz-max tags are
+// usually far too large to fill.
+const maxRandomListLength = 8
+
+var bitlistType = reflect.TypeOf(bitfield.Bitlist{})
+
+// randomValue returns a random value of typ. The sizes of vectors, and the limits of
+// lists, come from the ssz-size and ssz-max tags of the field being generated: sizes
+// holds one size per dimension, zero for lists, and limit bounds the outer list.
+func randomValue(r *rand.Rand, typ reflect.Type, sizes []uint64, limit uint64) reflect.Value {
+	val := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Bool:
+		val.SetBool(r.Intn(2) == 1)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val.SetUint(r.Uint64())
+	case reflect.Array:
+		var inner []uint64
+		if len(sizes) > 1 {
+			inner = sizes[1:]
+		}
+		for i := 0; i < typ.Len(); i++ {
+			val.Index(i).Set(randomValue(r, typ.Elem(), inner, 0))
+		}
+	case reflect.Slice:
+		if typ == bitlistType {
+			return reflect.ValueOf(randomBitlist(r, limit))
+		}
+		length := randomLength(r, limit)
+		if len(sizes) > 0 && sizes[0] != 0 {
+			length = int(sizes[0])
+		}
+		var inner []uint64
+		if len(sizes) > 1 {
+			inner = sizes[1:]
+		}
+		val.Set(reflect.MakeSlice(typ, length, length))
+		for i := 0; i < length; i++ {
+			val.Index(i).Set(randomValue(r, typ.Elem(), inner, 0))
+		}
+		// Bitvectors only hold as many bits as their type name tells.
+		if strings.HasPrefix(typ.Name(), "Bitvector") && length > 0 {
+			if bits, ok := val.Interface().(bitfield.Bitfield); ok {
+				mask := byte(1<<(bits.Len()%8)) - 1
+				if mask != 0 {
+					val.Index(length - 1).SetUint(val.Index(length-1).Uint() & uint64(mask))
+				}
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			// Opaque fields are left to their codecs, and keep their zero value.
+			if !isSSZField(f) || isOpaqueField(f) {
+				continue
+			}
+			fieldSizes, _, err := parseSSZFieldTags(f)
+			if err != nil {
+				panic(fmt.Sprintf("invalid size tags of field %s: %v", f.Name, err))
+			}
+			fieldLimit, _ := determineFieldCapacity(f)
+			val.Field(i).Set(randomValue(r, f.Type, fieldSizes, fieldLimit))
+		}
+	case reflect.Ptr:
+		val.Set(reflect.New(typ.Elem()))
+		val.Elem().Set(randomValue(r, typ.Elem(), sizes, limit))
+	default:
+		panic(fmt.Sprintf("cannot generate values of type %v", typ))
+	}
+	return val
+}
+
+func randomLength(r *rand.Rand, limit uint64) int {
+	bound := uint64(maxRandomListLength)
+	if limit != 0 && limit < bound {
+		bound = limit
+	}
+	return r.Intn(int(bound) + 1)
+}
+
+func randomBitlist(r *rand.Rand, limit uint64) bitfield.Bitlist {
+	bound := uint64(4 * maxRandomListLength * 8)
+	if limit != 0 && limit < bound {
+		bound = limit
+	}
+	bits := bitfield.NewBitlist(uint64(r.Int63n(int64(bound) + 1)))
+	for i := uint64(0); i < bits.Len(); i++ {
+		bits.SetBitAt(i, r.Intn(2) == 1)
+	}
+	return bits
+}
+
+// checkRoundTrip asserts that random values of typ encode to bytes which decode to
+// values encoding to the same bytes, and that their roots are stable.
+func checkRoundTrip(t *testing.T, typ reflect.Type, iterations int) {
+	r := rand.New(rand.NewSource(int64(len(typ.String()))))
+	hash := HashTreeRoot
+	if typ.Kind() == reflect.Slice {
+		// Top-level lists carry no ssz-max tag, their limit is given instead.
+		hash = func(val interface{}) ([32]byte, error) {
+			return HashTreeRootWithCapacity(val, maxRandomListLength)
+		}
+	}
+	for i := 0; i < iterations; i++ {
+		val := randomValue(r, typ, nil, 0)
+		encoded, err := Marshal(val.Interface())
+		if err != nil {
+			t.Fatalf("Failed to marshal %+v: %v", val.Interface(), err)
+		}
+		decoded := reflect.New(typ)
+		if err := Unmarshal(encoded, decoded.Interface()); err != nil {
+			t.Fatalf("Failed to unmarshal %#x into %v: %v", encoded, typ, err)
+		}
+		reencoded, err := Marshal(decoded.Elem().Interface())
+		if err != nil {
+			t.Fatalf("Failed to marshal decoded %+v: %v", decoded.Elem().Interface(), err)
+		}
+		if !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("Expected %+v to encode to %#x after a round trip, received %#x", val.Interface(), encoded, reencoded)
+		}
+		root, err := hash(val.Interface())
+		if err != nil {
+			t.Fatalf("Failed to hash %+v: %v", val.Interface(), err)
+		}
+		again, err := hash(val.Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedRoot, err := hash(decoded.Elem().Interface())
+		if err != nil {
+			t.Fatalf("Failed to hash decoded %+v: %v", decoded.Elem().Interface(), err)
+		}
+		if root != again || root != decodedRoot {
+			t.Fatalf("Expected stable roots for %+v, received %#x, %#x and %#x", val.Interface(), root, again, decodedRoot)
+		}
+	}
+}
+
+func TestRoundTrip_RandomValues(t *testing.T) {
+	types := []interface{}{
+		fork{},
+		iteratedItem{},
+		treeContainer{},
+		reusedContainer{},
+		patchedContainer{},
+		fastPathContainer{},
+		hintedContainer{},
+		testDepositData{},
+		rawLayoutItem{},
+		[]iteratedItem{},
+		[]uint64{},
+		[][32]byte{},
+	}
+	for _, sample := range types {
+		typ := reflect.TypeOf(sample)
+		t.Run(typ.String(), func(t *testing.T) {
+			checkRoundTrip(t, typ, 50)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ssz
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Root is a hash tree root, which prints, and encodes to text and JSON, as a 0x-prefixed
+// hex string, as the consensus specs and the beacon APIs write roots:
+//
+//  root, err := ssz.RootOf(block)
+//  if err != nil {
+//      return err
+//  }
+//  log.Printf("Processed block %s", root)
+//
+// It is encoded and hashed like the [32]byte it is.
+type Root [32]byte
+
+// RootOf returns the hash tree root of val like HashTreeRoot, as a Root.
+func RootOf(val interface{}) (Root, error) {
+	root, err := HashTreeRoot(val)
+	return Root(root), err
+}
+
+// String returns the 0x-prefixed hex encoding of the root.
+func (r Root) String() string {
+	return encodeHexRoot(r)
+}
+
+// MarshalText encodes the root as a 0x-prefixed hex string.
+func (r Root) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a hex string of 32 bytes, with or without a 0x prefix.
+func (r *Root) UnmarshalText(text []byte) error {
+	root, err := decodeHexRoot(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid root %q: %w", text, err)
+	}
+	*r = root
+	return nil
+}
+
+// MarshalJSON encodes the root as a JSON string of its 0x-prefixed hex encoding.
+func (r Root) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON decodes a JSON string of the hex encoding of a root.
+func (r *Root) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return r.UnmarshalText([]byte(s))
+}
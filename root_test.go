@@ -0,0 +1,83 @@
+package ssz
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type rootContainer struct {
+	Slot       uint64
+	ParentRoot Root
+	StateRoot  [32]byte
+}
+
+func TestRoot_Text(t *testing.T) {
+	root := Root{0xab, 0x01}
+	want := "0xab01" + strings.Repeat("00", 30)
+	if root.String() != want {
+		t.Errorf("Expected %s, received %s", want, root.String())
+	}
+	encoded, err := json.Marshal(map[string]Root{"root": root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"root":"`+want+`"}` {
+		t.Errorf("Unexpected JSON encoding %s", encoded)
+	}
+	var decoded map[Root]Root
+	if err := json.Unmarshal([]byte(`{"`+want+`":"`+want+`"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded[root] != root {
+		t.Errorf("Expected %v to be decoded from JSON, received %v", root, decoded)
+	}
+	for _, invalid := range []string{`"0xab01"`, `"0xzz"`, `1`} {
+		var r Root
+		if err := json.Unmarshal([]byte(invalid), &r); err == nil {
+			t.Errorf("Expected an error decoding %s", invalid)
+		}
+	}
+}
+
+func TestRootOf(t *testing.T) {
+	item := rootContainer{Slot: 5, ParentRoot: Root{1, 2, 3}, StateRoot: [32]byte{4, 5, 6}}
+	want, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := RootOf(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != Root(want) {
+		t.Errorf("Expected root %s, received %s", Root(want), root)
+	}
+	// Roots are encoded and hashed like [32]byte.
+	raw := struct {
+		Slot       uint64
+		ParentRoot [32]byte
+		StateRoot  [32]byte
+	}{Slot: 5, ParentRoot: [32]byte{1, 2, 3}, StateRoot: [32]byte{4, 5, 6}}
+	rawRoot, err := HashTreeRoot(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rawRoot != want {
+		t.Errorf("Expected root %#x, received %#x", rawRoot, want)
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded rootContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != item {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+	if _, err := RootOf(nil); err == nil {
+		t.Error("Expected an error hashing untyped nil")
+	}
+}
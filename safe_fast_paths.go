@@ -0,0 +1,17 @@
+//go:build !ssz_unsafe
+// +build !ssz_unsafe
+
+package ssz
+
+import (
+	"reflect"
+)
+
+// unsafeFastPaths reports whether the library is built with the ssz_unsafe tag,
+// see unsafe_fast_paths.go.
+const unsafeFastPaths = false
+
+// rawBytes never reinterprets memory without the ssz_unsafe tag.
+func rawBytes(val reflect.Value) ([]byte, bool) {
+	return nil, false
+}
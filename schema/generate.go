@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+)
+
+// goType is the Go type of a field of a generated struct, following the conventions
+// of prysm: vectors and lists are slices, whose sizes go in the ssz-size tag with a
+// question mark for lists. Types derived from basic types are not named.
+type goType struct {
+	elem  string
+	sizes []string
+}
+
+func (t goType) String() string {
+	return strings.Repeat("[]", len(t.sizes)) + t.elem
+}
+
+// tag returns the struct tag of a field of type t named name in the schema.
+func (t goType) tag(name string, st *sszType) string {
+	tag := fmt.Sprintf(`json:"%s"`, name)
+	for _, size := range t.sizes {
+		if size != "?" {
+			tag += fmt.Sprintf(` ssz-size:"%s"`, strings.Join(t.sizes, ","))
+			break
+		}
+	}
+	if st.isList {
+		tag += fmt.Sprintf(` ssz-max:"%d"`, st.limit)
+	}
+	return tag
+}
+
+// Generate writes the Go source of a package named pkg declaring the constants
+// and containers of the schema, as tagged structs ready for Marshal, Unmarshal and
+// HashTreeRoot. source names the schema in the generated header.
+func (s *Schema) Generate(w io.Writer, pkg string, source string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by ssz-gen from %s. DO NOT EDIT.\n\npackage %s\n\n", source, pkg)
+	var containers []*definition
+	var constants []string
+	bitlists := false
+	for _, name := range s.order {
+		def := s.defs[name]
+		if val, ok := s.constants[name]; ok && val.Sign() >= 0 && val.IsUint64() {
+			constants = append(constants, fmt.Sprintf("%s = %d", constantName(name), val.Uint64()))
+		}
+		if t, ok := s.types[name]; ok && t.gen.elem == name {
+			containers = append(containers, def)
+			for _, f := range def.fields {
+				ft, err := s.typeOf(f.typ)
+				if err != nil {
+					return err
+				}
+				bitlists = bitlists || ft.gen.elem == "bitfield.Bitlist"
+			}
+		}
+	}
+	if bitlists {
+		b.WriteString("import \"github.com/prysmaticlabs/go-bitfield\"\n\n")
+	}
+	if len(constants) != 0 {
+		b.WriteString("const (\n")
+		for _, c := range constants {
+			fmt.Fprintf(&b, "%s\n", c)
+		}
+		b.WriteString(")\n\n")
+	}
+	for _, def := range containers {
+		fmt.Fprintf(&b, "type %s struct {\n", def.name)
+		for _, f := range def.fields {
+			ft, err := s.typeOf(f.typ)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%s %s `%s`\n", fieldName(f.name), ft.gen, ft.gen.tag(f.name, ft))
+		}
+		b.WriteString("}\n\n")
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("could not format the generated source: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// constantName returns the exported Go name of a constant named in upper snake
+// case, such as MaxValidatorsPerCommittee for MAX_VALIDATORS_PER_COMMITTEE.
+func constantName(name string) string {
+	if strings.ToUpper(name) != name {
+		return fieldName(name)
+	}
+	return fieldName(strings.ToLower(name))
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	gotoken "go/token"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden generated source instead of checking it")
+
+func TestGenerate_Golden(t *testing.T) {
+	s := parseFile(t, "testdata/phase0.py")
+	var src bytes.Buffer
+	if err := s.Generate(&src, "phase0", "phase0.py"); err != nil {
+		t.Fatal(err)
+	}
+	const path = "testdata/phase0.go.golden"
+	if *update {
+		if err := ioutil.WriteFile(path, src.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the golden source, run with -update to generate it: %v", err)
+	}
+	if !bytes.Equal(src.Bytes(), want) {
+		t.Errorf("Generated source changed, run with -update if intended:\n%s", src.String())
+	}
+}
+
+// TestGenerate_MatchesFixtures checks the ssz tags of the generated structs against
+// the hand-written phase 0 fixtures of the benchmarks package.
+func TestGenerate_MatchesFixtures(t *testing.T) {
+	s := parseFile(t, "testdata/phase0.py")
+	var src bytes.Buffer
+	if err := s.Generate(&src, "phase0", "phase0.py"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parser.ParseFile(gotoken.NewFileSet(), "phase0.go", src.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := map[string]reflect.Type{
+		"Attestation":      reflect.TypeOf(benchmarks.Attestation{}),
+		"AttesterSlashing": reflect.TypeOf(benchmarks.AttesterSlashing{}),
+		"BeaconBlockBody":  reflect.TypeOf(benchmarks.BeaconBlockBody{}),
+		"Deposit":          reflect.TypeOf(benchmarks.Deposit{}),
+		"DepositData":      reflect.TypeOf(benchmarks.DepositData{}),
+		"Eth1Data":         reflect.TypeOf(benchmarks.Eth1Data{}),
+		"ProposerSlashing": reflect.TypeOf(benchmarks.ProposerSlashing{}),
+	}
+	checked := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		fixture, ok := fixtures[spec.Name.Name]
+		if !ok {
+			return false
+		}
+		checked++
+		fields := spec.Type.(*ast.StructType).Fields.List
+		if len(fields) != fixture.NumField() {
+			t.Errorf("%s: expected %d fields, received %d", spec.Name.Name, fixture.NumField(), len(fields))
+			return false
+		}
+		for i, f := range fields {
+			want := fixture.Field(i)
+			tag, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := reflect.StructTag(tag)
+			if f.Names[0].Name != want.Name {
+				t.Errorf("%s: expected field %s, received %s", spec.Name.Name, want.Name, f.Names[0].Name)
+			}
+			for _, key := range []string{"ssz-size", "ssz-max"} {
+				if got.Get(key) != want.Tag.Get(key) {
+					t.Errorf("%s.%s: expected %s %q, received %q", spec.Name.Name, want.Name, key, want.Tag.Get(key), got.Get(key))
+				}
+			}
+		}
+		return false
+	})
+	if checked != len(fixtures) {
+		t.Errorf("Expected %d generated structs, found %d", len(fixtures), checked)
+	}
+}
@@ -0,0 +1,372 @@
+package schema
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// definition is a class or an assignment of a schema, resolved on first use.
+type definition struct {
+	name string
+	line int
+	// base is the expression following the name of an assignment, or the base
+	// class of a class.
+	base []token
+	// fields are the fields of a container, nil for the other definitions.
+	fields  []fieldDef
+	isClass bool
+}
+
+type fieldDef struct {
+	name string
+	line int
+	typ  []token
+}
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	numberToken
+	punctToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits an expression into identifiers, numbers and punctuation.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{kind: identToken, text: s[i:j]})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] == 'x' || s[j] == 'X' || unicode.Is(unicode.ASCII_Hex_Digit, rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{kind: numberToken, text: s[i:j]})
+			i = j
+		case strings.HasPrefix(s[i:], "**"):
+			tokens = append(tokens, token{kind: punctToken, text: "**"})
+			i += 2
+		case strings.ContainsRune("[](),*+-", c):
+			tokens = append(tokens, token{kind: punctToken, text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+// parse reads the definitions of a schema. The syntax is the subset of Python
+// the consensus specs define their types with: assignments of constants and type
+// aliases, and classes deriving from Container or, for aliases, from another type.
+func parse(r io.Reader) ([]*definition, error) {
+	var defs []*definition
+	var class *definition
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(strings.TrimSpace(text), "```") {
+			continue
+		}
+		indented := text[0] == ' ' || text[0] == '\t'
+		text = strings.TrimSpace(text)
+		if indented {
+			if class == nil {
+				return nil, fmt.Errorf("line %d: unexpected indentation", line)
+			}
+			if text == "pass" {
+				continue
+			}
+			field, err := parseField(text, line)
+			if err != nil {
+				return nil, err
+			}
+			class.fields = append(class.fields, field)
+			continue
+		}
+		class = nil
+		def, err := parseDefinition(text, line)
+		if err != nil {
+			return nil, err
+		}
+		if def.isClass {
+			class = def
+		}
+		defs = append(defs, def)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// parseDefinition parses an unindented line, either a class header such as
+// `class Checkpoint(Container):` or an assignment such as `Epoch = uint64`.
+func parseDefinition(text string, line int) (*definition, error) {
+	if strings.HasPrefix(text, "class ") {
+		header := strings.TrimSpace(strings.TrimPrefix(text, "class "))
+		body := ""
+		if i := strings.IndexByte(header, ':'); i >= 0 {
+			header, body = header[:i], strings.TrimSpace(header[i+1:])
+		} else {
+			return nil, fmt.Errorf("line %d: expected a colon after the class header", line)
+		}
+		if body != "" && body != "pass" {
+			return nil, fmt.Errorf("line %d: unexpected %q after the class header", line, body)
+		}
+		open := strings.IndexByte(header, '(')
+		if open < 0 || !strings.HasSuffix(header, ")") {
+			return nil, fmt.Errorf("line %d: expected a base class", line)
+		}
+		name := strings.TrimSpace(header[:open])
+		base, err := tokenize(header[open+1 : len(header)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !isIdentifier(name) || len(base) == 0 {
+			return nil, fmt.Errorf("line %d: invalid class header %q", line, text)
+		}
+		return &definition{name: name, line: line, base: base, isClass: true}, nil
+	}
+	i := strings.IndexByte(text, '=')
+	if i < 0 {
+		return nil, fmt.Errorf("line %d: expected a class or an assignment", line)
+	}
+	name := strings.TrimSpace(text[:i])
+	// Annotated assignments, such as `GENESIS_SLOT: Slot = 0`, drop the annotation.
+	if j := strings.IndexByte(name, ':'); j >= 0 {
+		name = strings.TrimSpace(name[:j])
+	}
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("line %d: invalid name %q", line, name)
+	}
+	value, err := tokenize(text[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	if len(value) == 0 {
+		return nil, fmt.Errorf("line %d: missing value of %s", line, name)
+	}
+	return &definition{name: name, line: line, base: value}, nil
+}
+
+// parseField parses a field of a container, such as `epoch: Epoch`.
+func parseField(text string, line int) (fieldDef, error) {
+	i := strings.IndexByte(text, ':')
+	if i < 0 {
+		return fieldDef{}, fmt.Errorf("line %d: expected a field", line)
+	}
+	name := strings.TrimSpace(text[:i])
+	if !isIdentifier(name) {
+		return fieldDef{}, fmt.Errorf("line %d: invalid field name %q", line, name)
+	}
+	typ, err := tokenize(text[i+1:])
+	if err != nil {
+		return fieldDef{}, fmt.Errorf("line %d: %w", line, err)
+	}
+	if len(typ) == 0 {
+		return fieldDef{}, fmt.Errorf("line %d: missing type of field %s", line, name)
+	}
+	return fieldDef{name: name, line: line, typ: typ}, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitArgs splits the tokens between the brackets of a parameterized type,
+// such as List[Validator, VALIDATOR_REGISTRY_LIMIT], on their top-level commas.
+func splitArgs(tokens []token) ([][]token, error) {
+	var args [][]token
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.kind != punctToken {
+			continue
+		}
+		switch t.text {
+		case "[", "(":
+			depth++
+		case "]", ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	args = append(args, tokens[start:])
+	for _, arg := range args {
+		if len(arg) == 0 {
+			return nil, fmt.Errorf("empty type parameter")
+		}
+	}
+	return args, nil
+}
+
+// evaluator evaluates integer expressions made of numbers, constants, the
+// operators +, -, * and **, parentheses, and casts such as uint64(2**40).
+type evaluator struct {
+	tokens []token
+	pos    int
+	lookup func(name string) (*big.Int, error)
+}
+
+func evaluate(tokens []token, lookup func(name string) (*big.Int, error)) (*big.Int, error) {
+	e := &evaluator{tokens: tokens, lookup: lookup}
+	val, err := e.sum()
+	if err != nil {
+		return nil, err
+	}
+	if e.pos != len(tokens) {
+		return nil, fmt.Errorf("unexpected %q", tokens[e.pos].text)
+	}
+	return val, nil
+}
+
+func (e *evaluator) peek(text string) bool {
+	return e.pos < len(e.tokens) && e.tokens[e.pos].kind == punctToken && e.tokens[e.pos].text == text
+}
+
+func (e *evaluator) sum() (*big.Int, error) {
+	val, err := e.product()
+	if err != nil {
+		return nil, err
+	}
+	for e.peek("+") || e.peek("-") {
+		op := e.tokens[e.pos].text
+		e.pos++
+		rhs, err := e.product()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			val.Add(val, rhs)
+		} else {
+			val.Sub(val, rhs)
+		}
+	}
+	return val, nil
+}
+
+func (e *evaluator) product() (*big.Int, error) {
+	val, err := e.power()
+	if err != nil {
+		return nil, err
+	}
+	for e.peek("*") {
+		e.pos++
+		rhs, err := e.power()
+		if err != nil {
+			return nil, err
+		}
+		val.Mul(val, rhs)
+	}
+	return val, nil
+}
+
+func (e *evaluator) power() (*big.Int, error) {
+	val, err := e.operand()
+	if err != nil {
+		return nil, err
+	}
+	if !e.peek("**") {
+		return val, nil
+	}
+	e.pos++
+	exp, err := e.power()
+	if err != nil {
+		return nil, err
+	}
+	if exp.Sign() < 0 || exp.BitLen() > 16 {
+		return nil, fmt.Errorf("unsupported exponent %v", exp)
+	}
+	return val.Exp(val, exp, nil), nil
+}
+
+func (e *evaluator) operand() (*big.Int, error) {
+	if e.pos == len(e.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := e.tokens[e.pos]
+	e.pos++
+	switch {
+	case t.kind == numberToken:
+		val, ok := new(big.Int).SetString(strings.Replace(t.text, "_", "", -1), 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %s", t.text)
+		}
+		return val, nil
+	case t.kind == identToken && e.peek("("):
+		// Casts, such as Gwei(2**5 * 10**9), leave their operand unchanged.
+		return e.operand()
+	case t.kind == identToken:
+		val, err := e.lookup(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Set(val), nil
+	case t.text == "(":
+		val, err := e.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !e.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		e.pos++
+		return val, nil
+	case t.text == "-":
+		val, err := e.operand()
+		if err != nil {
+			return nil, err
+		}
+		return val.Neg(val), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+}
+
+func formatTokens(tokens []token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && t.kind != punctToken && tokens[i-1].kind != punctToken {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+		if t.text == "," {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
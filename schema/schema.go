@@ -0,0 +1,412 @@
+// Package schema builds SSZ types at runtime from definitions written in the Python
+// syntax of the consensus specs, for tools handling types unknown at compile time:
+//
+//  Epoch = uint64
+//  Root = Bytes32
+//  MAX_VALIDATORS_PER_COMMITTEE = 2**11
+//
+//  class Checkpoint(Container):
+//      epoch: Epoch
+//      root: Root
+//
+//  class Attestation(Container):
+//      aggregation_bits: Bitlist[MAX_VALIDATORS_PER_COMMITTEE]
+//      target: Checkpoint
+//
+// Containers become Go structs built with reflect.StructOf, whose fields are named after
+// the fields of the schema in camel case and carry the ssz tags of their limits, so that
+// their values go through Marshal, Unmarshal and HashTreeRoot like any other. Generate
+// writes the equivalent Go source instead, for types known ahead of time.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// Schema holds the constants and types defined by a schema.
+type Schema struct {
+	defs      map[string]*definition
+	order     []string
+	constants map[string]*big.Int
+	types     map[string]*sszType
+	// resolving marks the definitions being resolved, to detect cycles.
+	resolving map[string]bool
+}
+
+// sszType is a resolved type along with the limit of lists, which is carried
+// by the ssz-max tag of the fields holding them.
+type sszType struct {
+	typ    reflect.Type
+	isList bool
+	limit  uint64
+	// gen is the Go type emitted for the type by Generate.
+	gen goType
+}
+
+// Parse reads the definitions of a schema and resolves its types.
+func Parse(r io.Reader) (*Schema, error) {
+	defs, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{
+		defs:      make(map[string]*definition),
+		constants: make(map[string]*big.Int),
+		types:     make(map[string]*sszType),
+		resolving: make(map[string]bool),
+	}
+	for _, def := range defs {
+		if _, ok := s.defs[def.name]; ok || builtin(def.name) {
+			return nil, fmt.Errorf("line %d: %s is already defined", def.line, def.name)
+		}
+		s.defs[def.name] = def
+		s.order = append(s.order, def.name)
+	}
+	for _, name := range s.order {
+		def := s.defs[name]
+		if def.isClass {
+			if _, err := s.resolveType(name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Assignments define either a constant or a type alias.
+		if _, err := s.resolveConstant(name); err != nil {
+			if _, typeErr := s.resolveType(name); typeErr != nil {
+				return nil, fmt.Errorf("line %d: %s is neither a constant (%v) nor a type (%v)", def.line, name, err, typeErr)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Types returns the names of the types defined by the schema, in definition order.
+func (s *Schema) Types() []string {
+	var names []string
+	for _, name := range s.order {
+		if _, ok := s.types[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Constants returns the constants defined by the schema, which fit in a uint64.
+func (s *Schema) Constants() map[string]uint64 {
+	constants := make(map[string]uint64, len(s.constants))
+	for name, val := range s.constants {
+		if val.IsUint64() {
+			constants[name] = val.Uint64()
+		}
+	}
+	return constants
+}
+
+// Type returns the Go type of a type defined by the schema.
+func (s *Schema) Type(name string) (reflect.Type, error) {
+	t, ok := s.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", name)
+	}
+	if t.isList {
+		// The limit of top-level lists is not carried by any struct tag.
+		return nil, fmt.Errorf("type %s is a list, which is only supported as a container field", name)
+	}
+	return t.typ, nil
+}
+
+// Codec returns the codec of a type defined by the schema.
+func (s *Schema) Codec(name string) (*Codec, error) {
+	typ, err := s.Type(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{name: name, typ: typ}, nil
+}
+
+func builtin(name string) bool {
+	switch name {
+	case "boolean", "bit", "byte", "Container", "Vector", "List", "ByteVector", "ByteList", "Bitvector", "Bitlist":
+		return true
+	}
+	return uintPattern.MatchString(name) || bytesPattern.MatchString(name)
+}
+
+var (
+	uintPattern  = regexp.MustCompile(`^uint(8|16|32|64|128|256)$`)
+	bytesPattern = regexp.MustCompile(`^Bytes([1-9][0-9]*)$`)
+)
+
+func (s *Schema) resolveConstant(name string) (*big.Int, error) {
+	if val, ok := s.constants[name]; ok {
+		return val, nil
+	}
+	def, ok := s.defs[name]
+	if !ok || def.isClass {
+		return nil, fmt.Errorf("unknown constant %s", name)
+	}
+	if s.resolving[name] {
+		return nil, fmt.Errorf("constant %s depends on itself", name)
+	}
+	s.resolving[name] = true
+	defer delete(s.resolving, name)
+	val, err := evaluate(def.base, s.resolveConstant)
+	if err != nil {
+		return nil, err
+	}
+	s.constants[name] = val
+	return val, nil
+}
+
+func (s *Schema) resolveType(name string) (*sszType, error) {
+	if t, ok := s.types[name]; ok {
+		return t, nil
+	}
+	def, ok := s.defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", name)
+	}
+	if s.resolving[name] {
+		return nil, fmt.Errorf("type %s depends on itself", name)
+	}
+	s.resolving[name] = true
+	defer delete(s.resolving, name)
+	var t *sszType
+	var err error
+	if def.isClass && len(def.base) == 1 && def.base[0].text == "Container" {
+		t, err = s.container(def)
+	} else {
+		if def.isClass && len(def.fields) != 0 {
+			return nil, fmt.Errorf("line %d: class %s has fields but does not derive from Container", def.line, name)
+		}
+		if t, err = s.typeOf(def.base); err != nil {
+			err = fmt.Errorf("line %d: %s: %w", def.line, name, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.types[name] = t
+	return t, nil
+}
+
+func (s *Schema) container(def *definition) (*sszType, error) {
+	if len(def.fields) == 0 {
+		return nil, fmt.Errorf("line %d: container %s has no fields", def.line, def.name)
+	}
+	fields := make([]reflect.StructField, len(def.fields))
+	seen := make(map[string]bool)
+	for i, f := range def.fields {
+		t, err := s.typeOf(f.typ)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: field %s of %s: %w", f.line, f.name, def.name, err)
+		}
+		name := fieldName(f.name)
+		if seen[name] {
+			return nil, fmt.Errorf("line %d: field %s of %s is already defined", f.line, f.name, def.name)
+		}
+		seen[name] = true
+		tag := fmt.Sprintf(`json:"%s"`, f.name)
+		if t.isList {
+			tag = fmt.Sprintf(`%s ssz-max:"%d"`, tag, t.limit)
+		}
+		fields[i] = reflect.StructField{Name: name, Type: t.typ, Tag: reflect.StructTag(tag)}
+	}
+	return &sszType{typ: reflect.StructOf(fields), gen: goType{elem: def.name}}, nil
+}
+
+// typeOf resolves a type expression, such as List[Validator, VALIDATOR_REGISTRY_LIMIT].
+func (s *Schema) typeOf(tokens []token) (*sszType, error) {
+	if tokens[0].kind != identToken {
+		return nil, fmt.Errorf("invalid type %s", formatTokens(tokens))
+	}
+	name := tokens[0].text
+	if len(tokens) == 1 {
+		return s.namedType(name)
+	}
+	if len(tokens) < 4 || tokens[1].text != "[" || tokens[len(tokens)-1].text != "]" {
+		return nil, fmt.Errorf("invalid type %s", formatTokens(tokens))
+	}
+	args, err := splitArgs(tokens[2 : len(tokens)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %s: %w", formatTokens(tokens), err)
+	}
+	switch name {
+	case "ByteVector", "ByteList", "Bitvector", "Bitlist":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a length, received %s", name, formatTokens(tokens))
+		}
+		n, err := s.length(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "ByteVector":
+			return byteVector(n), nil
+		case "ByteList":
+			return &sszType{typ: reflect.TypeOf([]byte{}), isList: true, limit: n, gen: goType{elem: "byte", sizes: []string{"?"}}}, nil
+		case "Bitvector":
+			return byteVector((n + 7) / 8), nil
+		default:
+			return &sszType{typ: reflect.TypeOf(bitfield.Bitlist{}), isList: true, limit: n, gen: goType{elem: "bitfield.Bitlist"}}, nil
+		}
+	case "Vector", "List":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s takes an element type and a length, received %s", name, formatTokens(tokens))
+		}
+		elem, err := s.typeOf(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if elem.isList {
+			return nil, fmt.Errorf("%s: lists nested in vectors and lists are not supported", formatTokens(tokens))
+		}
+		n, err := s.length(args[1])
+		if err != nil {
+			return nil, err
+		}
+		gen := goType{elem: elem.gen.elem, sizes: append([]string{strconv.FormatUint(n, 10)}, elem.gen.sizes...)}
+		if name == "Vector" {
+			return &sszType{typ: reflect.ArrayOf(int(n), elem.typ), gen: gen}, nil
+		}
+		gen.sizes[0] = "?"
+		return &sszType{typ: reflect.SliceOf(elem.typ), isList: true, limit: n, gen: gen}, nil
+	}
+	return nil, fmt.Errorf("unknown parameterized type %s", name)
+}
+
+func (s *Schema) namedType(name string) (*sszType, error) {
+	switch name {
+	case "boolean", "bit":
+		return basicType(false, "bool"), nil
+	case "byte", "uint8":
+		return basicType(uint8(0), "uint8"), nil
+	case "uint16":
+		return basicType(uint16(0), "uint16"), nil
+	case "uint32":
+		return basicType(uint32(0), "uint32"), nil
+	case "uint64":
+		return basicType(uint64(0), "uint64"), nil
+	case "uint128":
+		// Wider integers are held as their little-endian bytes, which encode
+		// and hash the same.
+		return byteVector(16), nil
+	case "uint256":
+		return byteVector(32), nil
+	}
+	if m := bytesPattern.FindStringSubmatch(name); m != nil {
+		n, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return byteVector(n), nil
+	}
+	return s.resolveType(name)
+}
+
+func basicType(val interface{}, name string) *sszType {
+	return &sszType{typ: reflect.TypeOf(val), gen: goType{elem: name}}
+}
+
+func byteVector(n uint64) *sszType {
+	return &sszType{
+		typ: reflect.ArrayOf(int(n), reflect.TypeOf(byte(0))),
+		gen: goType{elem: "byte", sizes: []string{strconv.FormatUint(n, 10)}},
+	}
+}
+
+// length evaluates the length parameter of a type, which must be positive.
+func (s *Schema) length(tokens []token) (uint64, error) {
+	val, err := evaluate(tokens, s.resolveConstant)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %s: %w", formatTokens(tokens), err)
+	}
+	if val.Sign() <= 0 || !val.IsUint64() || val.Uint64() > 1<<40 {
+		return 0, fmt.Errorf("length %s = %v is out of range", formatTokens(tokens), val)
+	}
+	return val.Uint64(), nil
+}
+
+// fieldName returns the exported Go name of a field named in snake case.
+func fieldName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]))
+		b.WriteString(word[1:])
+	}
+	if b.Len() == 0 {
+		return "Field"
+	}
+	return b.String()
+}
+
+// Codec encodes, decodes and hashes the values of a type of a schema, which are
+// pointers to values of the type returned by Type.
+type Codec struct {
+	name string
+	typ  reflect.Type
+}
+
+// Name returns the name of the type in the schema.
+func (c *Codec) Name() string {
+	return c.name
+}
+
+// Type returns the Go type built for the type.
+func (c *Codec) Type() reflect.Type {
+	return c.typ
+}
+
+// New returns a pointer to a zero value of the type.
+func (c *Codec) New() interface{} {
+	return reflect.New(c.typ).Interface()
+}
+
+// Marshal encodes val, a value of the type or a pointer to it.
+func (c *Codec) Marshal(val interface{}) ([]byte, error) {
+	if err := c.check(val); err != nil {
+		return nil, err
+	}
+	return ssz.Marshal(val)
+}
+
+// Unmarshal decodes data into a new value of the type, returning a pointer to it.
+func (c *Codec) Unmarshal(data []byte) (interface{}, error) {
+	val := c.New()
+	if err := ssz.Unmarshal(data, val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// HashTreeRoot returns the root of val, a value of the type or a pointer to it.
+func (c *Codec) HashTreeRoot(val interface{}) ([32]byte, error) {
+	if err := c.check(val); err != nil {
+		return [32]byte{}, err
+	}
+	return ssz.HashTreeRoot(val)
+}
+
+func (c *Codec) check(val interface{}) error {
+	if val == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	typ := reflect.TypeOf(val)
+	if typ != c.typ && typ != reflect.PtrTo(c.typ) {
+		return fmt.Errorf("expected a value of type %s, received %v", c.name, typ)
+	}
+	return nil
+}
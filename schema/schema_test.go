@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/golden"
+)
+
+func parseFile(t *testing.T, path string) *Schema {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSchema_MatchesStaticTypes(t *testing.T) {
+	s := parseFile(t, "testdata/phase0.py")
+	checked := 0
+	for _, v := range golden.Vectors() {
+		codec, err := s.Codec(v.Name)
+		if err != nil {
+			continue
+		}
+		checked++
+		encoded, err := ssz.Marshal(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRoot, err := ssz.HashTreeRoot(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := codec.Unmarshal(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		reencoded, err := codec.Marshal(val)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		if !bytes.Equal(reencoded, encoded) {
+			t.Errorf("%s: expected the dynamic type to encode as %#x, received %#x", v.Name, encoded, reencoded)
+		}
+		root, err := codec.HashTreeRoot(val)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		if root != wantRoot {
+			t.Errorf("%s: expected root %#x, received %#x", v.Name, wantRoot, root)
+		}
+	}
+	if checked < 10 {
+		t.Errorf("Expected the schema to cover the golden containers, only %d matched", checked)
+	}
+}
+
+func TestSchema_Definitions(t *testing.T) {
+	src := `
+FAR_FUTURE_EPOCH = 2**64 - 1
+LIMIT = 4 * (1 + 1)
+class Slot(uint64): pass
+Bits = Bitvector[12]
+
+class Example(Container):
+    slot: Slot
+    bits: Bits
+    big: uint256
+    flags: List[boolean, LIMIT]
+    aggregation_bits: Bitlist[LIMIT]
+`
+	s, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants := s.Constants()
+	if constants["FAR_FUTURE_EPOCH"] != 1<<64-1 || constants["LIMIT"] != 8 {
+		t.Errorf("Unexpected constants %v", constants)
+	}
+	if names := s.Types(); !reflect.DeepEqual(names, []string{"Slot", "Bits", "Example"}) {
+		t.Errorf("Unexpected types %v", names)
+	}
+	typ, err := s.Type("Example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		typ reflect.Type
+		tag reflect.StructTag
+	}{
+		"Slot":            {reflect.TypeOf(uint64(0)), `json:"slot"`},
+		"Bits":            {reflect.TypeOf([2]byte{}), `json:"bits"`},
+		"Big":             {reflect.TypeOf([32]byte{}), `json:"big"`},
+		"Flags":           {reflect.TypeOf([]bool{}), `json:"flags" ssz-max:"8"`},
+		"AggregationBits": {reflect.TypeOf(bitfield.Bitlist{}), `json:"aggregation_bits" ssz-max:"8"`},
+	}
+	if typ.NumField() != len(want) {
+		t.Fatalf("Expected %d fields, received %v", len(want), typ)
+	}
+	for name, w := range want {
+		f, ok := typ.FieldByName(name)
+		if !ok || f.Type != w.typ || f.Tag != w.tag {
+			t.Errorf("Expected field %s of type %v tagged %s, received %+v", name, w.typ, w.tag, f)
+		}
+	}
+}
+
+func TestSchema_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":      "class A(Container):\n    a: Unknown\n",
+		"empty container":   "class A(Container):\n    pass\n",
+		"cycle":             "class A(Container):\n    b: B\nclass B(Container):\n    a: A\n",
+		"constant cycle":    "A = B\nB = A\n",
+		"nested lists":      "class A(Container):\n    a: List[List[uint64, 2], 2]\n",
+		"redefinition":      "A = 1\nA = 2\n",
+		"builtin":           "uint64 = 1\n",
+		"duplicate field":   "class A(Container):\n    a: uint8\n    a: uint8\n",
+		"invalid length":    "class A(Container):\n    a: Vector[uint8, 0]\n",
+		"unexpected indent": "    a: uint8\n",
+		"syntax":            "class A(Container):\n    a: List[uint8, 2\n",
+	}
+	for name, src := range tests {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: expected an error parsing %q", name, src)
+		}
+	}
+	s, err := Parse(strings.NewReader("Indices = List[uint64, 4]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Codec("Indices"); err == nil {
+		t.Error("Expected an error for a top-level list")
+	}
+}
+
+func TestCodec_RejectsOtherTypes(t *testing.T) {
+	s := parseFile(t, "testdata/phase0.py")
+	codec, err := s.Codec("Checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.Marshal(uint64(1)); err == nil {
+		t.Error("Expected an error marshaling a value of another type")
+	}
+	val := codec.New()
+	if _, err := codec.HashTreeRoot(val); err != nil {
+		t.Errorf("Expected a zero value to hash, received %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package signing holds the containers and helpers of the consensus specs computing the
+// roots signed by validators: the domains separating signatures by purpose and fork,
+// and the signing roots mixing them with the roots of the signed objects:
+//
+//  domain, err := signing.ComputeDomain(signing.DomainBeaconProposer, fork.CurrentVersion, genesisValidatorsRoot)
+//  if err != nil {
+//      return err
+//  }
+//  root, err := signing.ComputeSigningRoot(block, domain)
+package signing
+
+import (
+	"fmt"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// Domain types of the consensus specs, prefixing the domains of the signatures they
+// separate.
+var (
+	DomainBeaconProposer              = [4]byte{0x00, 0x00, 0x00, 0x00}
+	DomainBeaconAttester              = [4]byte{0x01, 0x00, 0x00, 0x00}
+	DomainRandao                      = [4]byte{0x02, 0x00, 0x00, 0x00}
+	DomainDeposit                     = [4]byte{0x03, 0x00, 0x00, 0x00}
+	DomainVoluntaryExit               = [4]byte{0x04, 0x00, 0x00, 0x00}
+	DomainSelectionProof              = [4]byte{0x05, 0x00, 0x00, 0x00}
+	DomainAggregateAndProof           = [4]byte{0x06, 0x00, 0x00, 0x00}
+	DomainSyncCommittee               = [4]byte{0x07, 0x00, 0x00, 0x00}
+	DomainSyncCommitteeSelectionProof = [4]byte{0x08, 0x00, 0x00, 0x00}
+	DomainContributionAndProof        = [4]byte{0x09, 0x00, 0x00, 0x00}
+	DomainBLSToExecutionChange        = [4]byte{0x0a, 0x00, 0x00, 0x00}
+	DomainApplicationMask             = [4]byte{0x00, 0x00, 0x00, 0x01}
+)
+
+// ForkData is the container of the consensus specs whose root identifies a fork of a
+// chain, out of which fork digests and domains are computed.
+type ForkData struct {
+	CurrentVersion        [4]byte
+	GenesisValidatorsRoot [32]byte
+}
+
+// SigningData is the container of the consensus specs whose root is signed, mixing the
+// root of the signed object with the domain of the signature.
+type SigningData struct {
+	ObjectRoot [32]byte
+	Domain     [32]byte
+}
+
+// ComputeForkDataRoot returns the root of the fork data of the fork of version
+// currentVersion of the chain of genesis validators root genesisValidatorsRoot.
+func ComputeForkDataRoot(currentVersion [4]byte, genesisValidatorsRoot [32]byte) ([32]byte, error) {
+	return ssz.HashTreeRoot(ForkData{
+		CurrentVersion:        currentVersion,
+		GenesisValidatorsRoot: genesisValidatorsRoot,
+	})
+}
+
+// ComputeForkDigest returns the fork digest of the fork of version currentVersion of the
+// chain of genesis validators root genesisValidatorsRoot, which are the first 4 bytes
+// of the root of its fork data, as found in the names of gossip topics.
+func ComputeForkDigest(currentVersion [4]byte, genesisValidatorsRoot [32]byte) ([4]byte, error) {
+	var digest [4]byte
+	root, err := ComputeForkDataRoot(currentVersion, genesisValidatorsRoot)
+	if err != nil {
+		return digest, err
+	}
+	copy(digest[:], root[:])
+	return digest, nil
+}
+
+// ComputeDomain returns the domain of type domainType of the fork of version
+// forkVersion of the chain of genesis validators root genesisValidatorsRoot: the domain
+// type followed by the first 28 bytes of the root of the fork data. Deposits are signed
+// with the genesis fork version and a zero genesis validators root, which the specs
+// default to, so that they are valid across forks.
+func ComputeDomain(domainType [4]byte, forkVersion [4]byte, genesisValidatorsRoot [32]byte) ([32]byte, error) {
+	var domain [32]byte
+	root, err := ComputeForkDataRoot(forkVersion, genesisValidatorsRoot)
+	if err != nil {
+		return domain, err
+	}
+	copy(domain[:4], domainType[:])
+	copy(domain[4:], root[:28])
+	return domain, nil
+}
+
+// ComputeSigningRoot returns the root signed to sign object, an SSZ value, within
+// domain: the root of the signing data of the root of object and of domain.
+func ComputeSigningRoot(object interface{}, domain [32]byte) ([32]byte, error) {
+	objectRoot, err := ssz.HashTreeRoot(object)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("could not hash signed object: %w", err)
+	}
+	return ssz.HashTreeRoot(SigningData{
+		ObjectRoot: objectRoot,
+		Domain:     domain,
+	})
+}
@@ -0,0 +1,67 @@
+package signing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeRoot(t *testing.T, s string) [32]byte {
+	var root [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(root[:], b)
+	return root
+}
+
+func TestComputeForkDigest(t *testing.T) {
+	// The genesis fork digest of mainnet, found in its gossip topics.
+	genesisValidatorsRoot := mustDecodeRoot(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+	digest, err := ComputeForkDigest([4]byte{}, genesisValidatorsRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(digest[:]); got != "b5303f2a" {
+		t.Errorf("Expected fork digest b5303f2a, received %s", got)
+	}
+}
+
+func TestComputeDomain(t *testing.T) {
+	// The domain deposits are signed within on mainnet.
+	domain, err := ComputeDomain(DomainDeposit, [4]byte{}, [32]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9"
+	if got := hex.EncodeToString(domain[:]); got != want {
+		t.Errorf("Expected domain %s, received %s", want, got)
+	}
+}
+
+func TestComputeSigningRoot(t *testing.T) {
+	type checkpoint struct {
+		Epoch uint64
+		Root  [32]byte
+	}
+	object := &checkpoint{Epoch: 3, Root: [32]byte{1}}
+	domain, err := ComputeDomain(DomainBeaconAttester, [4]byte{1}, [32]byte{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ComputeSigningRoot(object, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The signing data holds two chunks, hashed together.
+	var epoch [32]byte
+	epoch[0] = 3
+	objectRoot := sha256.Sum256(append(epoch[:], object.Root[:]...))
+	if want := sha256.Sum256(append(objectRoot[:], domain[:]...)); root != want {
+		t.Errorf("Expected signing root %#x, received %#x", want, root)
+	}
+	if _, err := ComputeSigningRoot(map[string]int{}, domain); err == nil {
+		t.Error("Expected unhashable objects to fail")
+	}
+}
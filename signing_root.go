@@ -9,7 +9,8 @@ import (
 // SigningRoot truncates the last property of the struct passed in
 // and returns its tree hash. This is done because the last property
 // usually contains the signature that which this data is the root for.
-func SigningRoot(val interface{}) ([32]byte, error) {
+func SigningRoot(val interface{}) (_ [32]byte, err error) {
+	defer recoverPanic(&err)
 	valObj := reflect.ValueOf(val)
 	kind := valObj.Kind()
 
@@ -39,7 +40,9 @@ func truncateAndHash(val reflect.Value) ([32]byte, error) {
 	if err != nil {
 		return [32]byte{}, err
 	}
-	output, err := hasher(val, 0)
+	h := acquireHasher()
+	defer releaseHasher(h)
+	output, err := hasher(h, val, 0)
 	if err != nil {
 		return [32]byte{}, err
 	}
@@ -0,0 +1,373 @@
+package autogenerated
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/ghodss/yaml"
+	"github.com/golang/snappy"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/go-ssz/benchmarks"
+	"github.com/prysmaticlabs/go-ssz/jsonx"
+)
+
+// The consensus-spec-tests fixtures are fetched by bazel, see the WORKSPACE. Outside of
+// bazel, CONSENSUS_SPEC_TESTS_DIR can point to a directory holding the extracted general
+// and mainnet archives, which share their tests directory:
+//
+//  CONSENSUS_SPEC_TESTS_DIR=/path/to/consensus-spec-tests go test ./spectests -run ConsensusSpec
+const consensusSpecTestsDirEnv = "CONSENSUS_SPEC_TESTS_DIR"
+
+// consensusSpecTestsDir returns the directory holding the fixtures of workspace,
+// under the path rel, skipping the test if they are not available.
+func consensusSpecTestsDir(t *testing.T, workspace string, rel string) string {
+	if dir := os.Getenv(consensusSpecTestsDirEnv); dir != "" {
+		return filepath.Join(dir, rel)
+	}
+	dir, err := bazel.Runfile(filepath.Join("/", workspace, rel))
+	if err != nil {
+		t.Skipf("Consensus spec tests are not available, set %s to run them: %v", consensusSpecTestsDirEnv, err)
+	}
+	return dir
+}
+
+// specRoot holds the roots.yaml and meta.yaml files of the fixtures.
+type specRoot struct {
+	Root string `json:"root"`
+}
+
+func readSpecRoot(t *testing.T, file string) [32]byte {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r specRoot
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Failed to read root of %s: %v", file, err)
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(r.Root, "0x"))
+	if err != nil || len(decoded) != 32 {
+		t.Fatalf("Invalid root %q in %s", r.Root, file)
+	}
+	var root [32]byte
+	copy(root[:], decoded)
+	return root
+}
+
+func readSerialized(t *testing.T, dir string) []byte {
+	compressed, err := ioutil.ReadFile(filepath.Join(dir, "serialized.ssz_snappy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		t.Fatalf("Failed to decompress serialized value: %v", err)
+	}
+	return serialized
+}
+
+func subdirectories(t *testing.T, dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// specCase is the Go shape of a fixture: the type to decode its serialization into,
+// and whether the serialization is wrapped in a single-field container, which lets
+// top-level lists carry their limit in an ssz-max tag. A container with a single
+// field shares the root of the field, and encodes as the offset of the field
+// followed by the encoding of the field. Bitvectors represented as byte arrays
+// do not reject set padding bits, so their invalid cases are loose.
+type specCase struct {
+	typ     reflect.Type
+	wrapped bool
+	loose   bool
+}
+
+func (c specCase) decode(serialized []byte) (reflect.Value, error) {
+	if c.wrapped {
+		serialized = append([]byte{4, 0, 0, 0}, serialized...)
+	}
+	val := reflect.New(c.typ)
+	if err := ssz.Unmarshal(serialized, val.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return val.Elem(), nil
+}
+
+func (c specCase) encode(val reflect.Value) ([]byte, error) {
+	encoded, err := ssz.Marshal(val.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if c.wrapped {
+		encoded = encoded[4:]
+	}
+	return encoded, nil
+}
+
+// checkValidCase checks that the serialization in dir decodes, encodes back to the
+// same bytes, and hashes to the root of the fixture.
+func checkValidCase(t *testing.T, c specCase, dir string, rootFile string) {
+	serialized := readSerialized(t, dir)
+	val, err := c.decode(serialized)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal %#x into %v: %v", serialized, c.typ, err)
+	}
+	encoded, err := c.encode(val)
+	if err != nil {
+		t.Fatalf("Failed to marshal %v: %v", c.typ, err)
+	}
+	if !bytes.Equal(encoded, serialized) {
+		t.Errorf("Expected %v to encode to %#x, received %#x", c.typ, serialized, encoded)
+	}
+	root, err := ssz.HashTreeRoot(val.Interface())
+	if err != nil {
+		t.Fatalf("Failed to hash %v: %v", c.typ, err)
+	}
+	if want := readSpecRoot(t, filepath.Join(dir, rootFile)); root != want {
+		t.Errorf("Expected root %#x, received %#x", want, root)
+	}
+	checkValueYAML(t, c, dir, serialized)
+}
+
+// checkValueYAML checks that the value.yaml file in dir, if any, decodes to the
+// value of the serialization.
+func checkValueYAML(t *testing.T, c specCase, dir string, serialized []byte) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "value.yaml"))
+	if os.IsNotExist(err) || c.wrapped {
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	val := reflect.New(c.typ)
+	if err := jsonx.UnmarshalYAML(data, val.Interface()); err != nil {
+		t.Fatalf("Failed to read value.yaml into %v: %v", c.typ, err)
+	}
+	encoded, err := ssz.Marshal(val.Elem().Interface())
+	if err != nil {
+		t.Fatalf("Failed to marshal %v: %v", c.typ, err)
+	}
+	if !bytes.Equal(encoded, serialized) {
+		t.Errorf("Expected value.yaml to encode to %#x, received %#x", serialized, encoded)
+	}
+}
+
+var basicSpecTypes = map[string]reflect.Type{
+	"bool":   reflect.TypeOf(false),
+	"uint8":  reflect.TypeOf(uint8(0)),
+	"uint16": reflect.TypeOf(uint16(0)),
+	"uint32": reflect.TypeOf(uint32(0)),
+	"uint64": reflect.TypeOf(uint64(0)),
+}
+
+// The containers of the ssz_generic containers handler. Bitvectors are byte arrays,
+// as go-bitfield only provides a Bitvector4 type. The json tags keep the field names
+// of the value.yaml files.
+type SingleFieldTestStruct struct {
+	A byte `json:"A"`
+}
+
+type SmallTestStruct struct {
+	A uint16 `json:"A"`
+	B uint16 `json:"B"`
+}
+
+type FixedTestStruct struct {
+	A uint8  `json:"A"`
+	B uint64 `json:"B"`
+	C uint32 `json:"C"`
+}
+
+type VarTestStruct struct {
+	A uint16   `json:"A"`
+	B []uint16 `json:"B" ssz-max:"1024"`
+	C uint8    `json:"C"`
+}
+
+type ComplexTestStruct struct {
+	A uint16             `json:"A"`
+	B []uint16           `json:"B" ssz-max:"128"`
+	C uint8              `json:"C"`
+	D []byte             `json:"D" ssz-max:"256"`
+	E VarTestStruct      `json:"E"`
+	F [4]FixedTestStruct `json:"F"`
+	G [2]VarTestStruct   `json:"G"`
+}
+
+type BitsStruct struct {
+	A bitfield.Bitlist `json:"A" ssz-max:"5"`
+	B [1]byte          `json:"B"`
+	C [1]byte          `json:"C"`
+	D bitfield.Bitlist `json:"D" ssz-max:"6"`
+	E [1]byte          `json:"E"`
+}
+
+var containerSpecTypes = map[string]reflect.Type{
+	"SingleFieldTestStruct": reflect.TypeOf(SingleFieldTestStruct{}),
+	"SmallTestStruct":       reflect.TypeOf(SmallTestStruct{}),
+	"FixedTestStruct":       reflect.TypeOf(FixedTestStruct{}),
+	"VarTestStruct":         reflect.TypeOf(VarTestStruct{}),
+	"ComplexTestStruct":     reflect.TypeOf(ComplexTestStruct{}),
+	"BitsStruct":            reflect.TypeOf(BitsStruct{}),
+}
+
+// genericSpecCase returns the Go shape of an ssz_generic case from the names of its
+// handler and case, which encode the type being tested, or the reason why the type
+// cannot be represented.
+func genericSpecCase(handler string, name string) (specCase, string) {
+	parts := strings.Split(name, "_")
+	number := func(i int) (int, bool) {
+		if i >= len(parts) {
+			return 0, false
+		}
+		n, err := strconv.Atoi(parts[i])
+		return n, err == nil
+	}
+	switch handler {
+	case "boolean":
+		return specCase{typ: basicSpecTypes["bool"]}, ""
+	case "uints":
+		typ, ok := basicSpecTypes["uint"+parts[1]]
+		if !ok {
+			return specCase{}, fmt.Sprintf("uint%s is not supported", parts[1])
+		}
+		return specCase{typ: typ}, ""
+	case "basic_vector":
+		elem, ok := basicSpecTypes[parts[1]]
+		if !ok {
+			return specCase{}, fmt.Sprintf("vectors of %s are not supported", parts[1])
+		}
+		length, ok := number(2)
+		if !ok || length == 0 {
+			return specCase{}, "vectors of no elements cannot be represented"
+		}
+		return specCase{typ: reflect.ArrayOf(length, elem)}, ""
+	case "bitvector":
+		size, ok := number(1)
+		if !ok || size == 0 {
+			return specCase{}, "bitvectors of no bits cannot be represented"
+		}
+		return specCase{typ: reflect.ArrayOf((size+7)/8, reflect.TypeOf(byte(0))), loose: size%8 != 0}, ""
+	case "bitlist":
+		limit, ok := number(1)
+		if !ok {
+			// Cases named bitlist_no_delimiter_* have no limit.
+			limit = 1
+		}
+		return specCase{
+			typ: reflect.StructOf([]reflect.StructField{{
+				Name: "Bits",
+				Type: reflect.TypeOf(bitfield.Bitlist{}),
+				Tag:  reflect.StructTag(fmt.Sprintf(`ssz-max:"%d"`, limit)),
+			}}),
+			wrapped: true,
+		}, ""
+	case "containers":
+		typ, ok := containerSpecTypes[parts[0]]
+		if !ok {
+			return specCase{}, fmt.Sprintf("container %s is not supported", parts[0])
+		}
+		return specCase{typ: typ, loose: typ == containerSpecTypes["BitsStruct"]}, ""
+	default:
+		return specCase{}, fmt.Sprintf("handler %s is not supported", handler)
+	}
+}
+
+func TestConsensusSpec_Generic(t *testing.T) {
+	root := consensusSpecTestsDir(t, "consensus_spec_tests_general", "tests/general/phase0/ssz_generic")
+	for _, handler := range subdirectories(t, root) {
+		t.Run(handler, func(t *testing.T) {
+			for _, validity := range []string{"valid", "invalid"} {
+				dir := filepath.Join(root, handler, validity)
+				if _, err := os.Stat(dir); err != nil {
+					continue
+				}
+				for _, name := range subdirectories(t, dir) {
+					caseDir := filepath.Join(dir, name)
+					t.Run(validity+"/"+name, func(t *testing.T) {
+						c, unsupported := genericSpecCase(handler, name)
+						if unsupported != "" {
+							t.Skip(unsupported)
+						}
+						if validity == "valid" {
+							checkValidCase(t, c, caseDir, "meta.yaml")
+							return
+						}
+						if c.loose {
+							t.Skip("Padding bits of bitvectors are not checked")
+						}
+						serialized := readSerialized(t, caseDir)
+						if val, err := c.decode(serialized); err == nil {
+							t.Errorf("Expected error decoding %#x into %v, received %+v", serialized, c.typ, val.Interface())
+						}
+					})
+				}
+			}
+		})
+	}
+}
+
+// staticSpecTypes maps the phase 0 containers of the ssz_static cases to their Go types.
+var staticSpecTypes = map[string]reflect.Type{
+	"Attestation":             reflect.TypeOf(benchmarks.Attestation{}),
+	"AttestationData":         reflect.TypeOf(benchmarks.AttestationData{}),
+	"AttesterSlashing":        reflect.TypeOf(benchmarks.AttesterSlashing{}),
+	"BeaconBlock":             reflect.TypeOf(benchmarks.BeaconBlock{}),
+	"BeaconBlockBody":         reflect.TypeOf(benchmarks.BeaconBlockBody{}),
+	"BeaconBlockHeader":       reflect.TypeOf(benchmarks.BeaconBlockHeader{}),
+	"BeaconState":             reflect.TypeOf(benchmarks.BeaconState{}),
+	"Checkpoint":              reflect.TypeOf(benchmarks.Checkpoint{}),
+	"Deposit":                 reflect.TypeOf(benchmarks.Deposit{}),
+	"DepositData":             reflect.TypeOf(benchmarks.DepositData{}),
+	"Eth1Data":                reflect.TypeOf(benchmarks.Eth1Data{}),
+	"Fork":                    reflect.TypeOf(benchmarks.Fork{}),
+	"IndexedAttestation":      reflect.TypeOf(benchmarks.IndexedAttestation{}),
+	"PendingAttestation":      reflect.TypeOf(benchmarks.PendingAttestation{}),
+	"ProposerSlashing":        reflect.TypeOf(benchmarks.ProposerSlashing{}),
+	"SignedBeaconBlock":       reflect.TypeOf(benchmarks.SignedBeaconBlock{}),
+	"SignedBeaconBlockHeader": reflect.TypeOf(benchmarks.SignedBeaconBlockHeader{}),
+	"SignedVoluntaryExit":     reflect.TypeOf(benchmarks.SignedVoluntaryExit{}),
+	"Validator":               reflect.TypeOf(benchmarks.Validator{}),
+	"VoluntaryExit":           reflect.TypeOf(benchmarks.VoluntaryExit{}),
+}
+
+func TestConsensusSpec_Static(t *testing.T) {
+	root := consensusSpecTestsDir(t, "consensus_spec_tests_mainnet", "tests/mainnet/phase0/ssz_static")
+	for _, name := range subdirectories(t, root) {
+		typ, ok := staticSpecTypes[name]
+		t.Run(name, func(t *testing.T) {
+			if !ok {
+				t.Skipf("Container %s is not supported", name)
+			}
+			for _, suite := range subdirectories(t, filepath.Join(root, name)) {
+				suiteDir := filepath.Join(root, name, suite)
+				for _, caseName := range subdirectories(t, suiteDir) {
+					caseDir := filepath.Join(suiteDir, caseName)
+					t.Run(suite+"/"+caseName, func(t *testing.T) {
+						checkValidCase(t, specCase{typ: typ}, caseDir, "roots.yaml")
+					})
+				}
+			}
+		})
+	}
+}
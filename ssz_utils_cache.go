@@ -9,9 +9,9 @@ import (
 // it returns the index of the last byte written and an error, if any.
 type marshaler func(reflect.Value, []byte, uint64) (uint64, error)
 
-type unmarshaler func([]byte, reflect.Value, uint64) (uint64, error)
+type unmarshaler func(*Arena, []byte, reflect.Value, uint64) (uint64, error)
 
-type hasher func(reflect.Value, uint64) ([32]byte, error)
+type hasher func(*Hasher, reflect.Value, uint64) ([32]byte, error)
 
 type sszUtils struct {
 	marshaler
@@ -53,14 +53,21 @@ func cachedSSZUtilsNoAcquireLock(typ reflect.Type) (*sszUtils, error) {
 	if utils != nil {
 		return utils, nil
 	}
+	// Values of cyclic types cannot be encoded, and would make the functions walking
+	// types, such as isVariableSizeType, recurse forever.
+	if err := checkAcyclic(typ); err != nil {
+		return nil, err
+	}
 	// Put a dummy value into the cache before generating.
 	// If the generator tries to lookup the type of itself,
 	// it will get the dummy value and won't call recursively forever.
 	sszUtilsCache[typ] = new(sszUtils)
 	utils, err := generateSSZUtilsForType(typ)
 	if err != nil {
-		// Don't forget to remove the dummy key when fail
+		// Don't forget to remove the dummy key when fail, along with the fields
+		// which may have been cached pointing to it.
 		delete(sszUtilsCache, typ)
+		structFieldsCache.Delete(typ)
 		return nil, err
 	}
 	// Overwrite the dummy value with real value
@@ -69,6 +76,9 @@ func cachedSSZUtilsNoAcquireLock(typ reflect.Type) (*sszUtils, error) {
 }
 
 func generateSSZUtilsForType(typ reflect.Type) (utils *sszUtils, err error) {
+	if codec := lookupCodec(typ); codec != nil {
+		return codec.utils(typ), nil
+	}
 	utils = new(sszUtils)
 	if utils.marshaler, err = makeMarshaler(typ); err != nil {
 		return nil, err
@@ -0,0 +1,68 @@
+package ssz
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// Strings are encoded and hashed as the byte lists of their UTF-8 encoding, so that a
+// string field shares the encoding and root of a byte slice field with the same tags:
+//
+//  type exampleStruct struct {
+//      Name string `ssz-max:"64"`
+//  }
+//
+// Strings which are not valid UTF-8 can neither be encoded nor decoded.
+
+// stringBytes returns the bytes of val, a value of a string type, as a byte slice value
+// which the walkers of values go through in its place.
+func stringBytes(val reflect.Value) reflect.Value {
+	return reflect.ValueOf([]byte(val.String()))
+}
+
+func checkUTF8(s string) error {
+	if !utf8.ValidString(s) {
+		return errorf(ErrInvalidValue, "string %q is not valid UTF-8", s)
+	}
+	return nil
+}
+
+func marshalString(val reflect.Value, buf []byte, startOffset uint64) (uint64, error) {
+	s := val.String()
+	if err := checkUTF8(s); err != nil {
+		return 0, err
+	}
+	return startOffset + uint64(copy(buf[startOffset:], s)), nil
+}
+
+func unmarshalString(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	// A string consumes the rest of its budget, as byte slices do.
+	offset := uint64(len(input))
+	b, err := segment(input, startOffset, offset)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.checkListElements(uint64(len(b))); err != nil {
+		return 0, err
+	}
+	s := string(b)
+	if err := checkUTF8(s); err != nil {
+		return 0, err
+	}
+	val.SetString(s)
+	return offset, nil
+}
+
+func makeStringHasher() (hasher, error) {
+	utils, err := cachedSSZUtilsNoAcquireLock(byteSliceType)
+	if err != nil {
+		return nil, err
+	}
+	hasher := func(h *Hasher, val reflect.Value, maxCapacity uint64) ([32]byte, error) {
+		if err := checkUTF8(val.String()); err != nil {
+			return [32]byte{}, err
+		}
+		return utils.hasher(h, stringBytes(val), maxCapacity)
+	}
+	return hasher, nil
+}
@@ -0,0 +1,140 @@
+package ssz
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type stringContainer struct {
+	Slot   uint64
+	Name   string   `ssz-max:"64"`
+	Labels []string `ssz-max:"4,16"`
+}
+
+// bytesContainer is the container SSZ sees stringContainer as.
+type bytesContainer struct {
+	Slot   uint64
+	Name   []byte   `ssz-max:"64"`
+	Labels [][]byte `ssz-max:"4,16"`
+}
+
+func TestStringFields(t *testing.T) {
+	item := stringContainer{Slot: 5, Name: "héllo", Labels: []string{"a", "", "ünïcode"}}
+	equivalent := bytesContainer{
+		Slot:   5,
+		Name:   []byte("héllo"),
+		Labels: [][]byte{[]byte("a"), {}, []byte("ünïcode")},
+	}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+	var decoded stringContainer
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, item) {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+	if err := ValidateType(reflect.TypeOf(stringContainer{})); err != nil {
+		t.Errorf("Expected string fields with ssz-max to be valid, received %v", err)
+	}
+}
+
+func TestStringFields_InvalidUTF8(t *testing.T) {
+	item := stringContainer{Name: "\xff\xfe"}
+	if _, err := Marshal(item); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected invalid UTF-8 to fail encoding, received %v", err)
+	}
+	if _, err := HashTreeRoot(item); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected invalid UTF-8 to fail hashing, received %v", err)
+	}
+	encoded, err := Marshal(bytesContainer{Name: []byte("\xff\xfe")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded stringContainer
+	if err := Unmarshal(encoded, &decoded); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected invalid UTF-8 to fail decoding, received %v", err)
+	}
+}
+
+func TestStringFields_MaxLength(t *testing.T) {
+	if _, err := Marshal(stringContainer{Name: strings.Repeat("a", 65)}); !errors.Is(err, ErrMaxLength) {
+		t.Errorf("Expected a string exceeding its ssz-max to fail, received %v", err)
+	}
+	item := stringContainer{Labels: []string{strings.Repeat("b", 17)}}
+	if _, err := Marshal(item); !errors.Is(err, ErrMaxLength) || !strings.Contains(err.Error(), "element 0") {
+		t.Errorf("Expected a nested string exceeding its limit to fail, received %v", err)
+	}
+}
+
+type unboundedStringContainer struct {
+	Name string
+}
+
+func TestStringFields_ValidateType(t *testing.T) {
+	err := ValidateType(reflect.TypeOf(unboundedStringContainer{}))
+	if !errors.Is(err, ErrInvalidTag) || !strings.Contains(err.Error(), "Name") {
+		t.Errorf("Expected a string without ssz-max to be reported, received %v", err)
+	}
+}
+
+func TestStringFields_CacheKeys(t *testing.T) {
+	// Both pairs of values print the same once their fields run into each other, and
+	// the root of the first value of each pair is cached before the second is hashed.
+	type pair struct {
+		A      string   `ssz-max:"64"`
+		B      string   `ssz-max:"64"`
+		Labels []string `ssz-max:"4,16"`
+	}
+	type bytesPair struct {
+		A      []byte   `ssz-max:"64"`
+		B      []byte   `ssz-max:"64"`
+		Labels [][]byte `ssz-max:"4,16"`
+	}
+	tests := [][2]pair{
+		{{A: "x", B: "stringB64y"}, {A: "xstringB64", B: "y"}},
+		{{Labels: []string{"a b", "c"}}, {Labels: []string{"a", "b c"}}},
+	}
+	for _, values := range tests {
+		for _, val := range values {
+			root, err := HashTreeRoot(val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bytesVal := bytesPair{A: []byte(val.A), B: []byte(val.B)}
+			for _, label := range val.Labels {
+				bytesVal.Labels = append(bytesVal.Labels, []byte(label))
+			}
+			want, err := HashTreeRoot(bytesVal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root != want {
+				t.Errorf("Expected root %#x for %+v, received %#x", want, val, root)
+			}
+		}
+	}
+}
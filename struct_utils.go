@@ -3,14 +3,15 @@ package ssz
 import (
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
+	"sync"
 )
 
 // UnboundedSSZFieldSizeMarker is the character used to specify a ssz field should have
 // unbounded size, which is useful when describing slices of arrays such as [][32]byte.
 // The ssz struct tag for such field type would be `ssz:"size=?,32"`. A question mark
 // is chosen as the default value given its simplicity to represent unbounded size.
+// The ssz-size tag `ssz-size:"?,32"` is equivalent.
 var UnboundedSSZFieldSizeMarker = "?"
 
 // field defines a custom wrapper around a struct field which
@@ -23,6 +24,29 @@ type field struct {
 	sszUtils    *sszUtils
 	capacity    uint64
 	hasCapacity bool
+	// innerLimits are the ssz-max of the lists nested within the field, from the
+	// outermost to the innermost, given by the dimensions of its ssz-max tag after
+	// the first. nestedHasher hashes the field with them, and both are nil when
+	// the tag has a single dimension.
+	innerLimits  []uint64
+	nestedHasher hasher
+	// opaque is the codec of fields tagged with `ssz:"opaque"`, nil otherwise.
+	opaque OpaqueCodec
+	// bits marks fields tagged with `ssz:"bits"`, whose booleans are packed into
+	// the bitfield of typ, see fieldValue.
+	bits bool
+	// progressive marks fields tagged with `ssz:"progressive"`, which sszUtils
+	// merkleizes progressively.
+	progressive bool
+}
+
+// fieldValue returns the value of the field f of the struct val as a value of f.typ,
+// packing the booleans of bits fields into their bitfield.
+func fieldValue(val reflect.Value, f field) reflect.Value {
+	if f.bits {
+		return packBits(val.Field(f.index), f.typ)
+	}
+	return val.Field(f.index)
 }
 
 // truncateLast removes the last value of a struct, usually the signature,
@@ -35,50 +59,191 @@ func truncateLast(typ reflect.Type) (fields []field, err error) {
 	return fields[:len(fields)-1], nil
 }
 
-// structFields iterates over the raw fields of a struct, ignoring XXX protobuf fields,
-// and determines the necessary ssz utils such as the marshaler, unmarshaler, and tree hasher
+// structFieldsCache maps struct types to their fields, so that struct tags are only
+// parsed once per type. Failures are not cached, as they may be fixed by registering
+// an opaque codec.
+var structFieldsCache sync.Map
+
+// structFields returns the field wrappers of a struct, computing them on first use.
+// The returned slice is shared and must not be modified.
+func structFields(typ reflect.Type) ([]field, error) {
+	if fields, ok := structFieldsCache.Load(typ); ok {
+		return fields.([]field), nil
+	}
+	fields, err := computeStructFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	structFieldsCache.Store(typ, fields)
+	return fields, nil
+}
+
+// isSSZField reports whether a raw struct field is a field of the SSZ container the struct
+// describes. The internal fields of protobuf-generated structs are left out: the XXX_ fields
+// of gogo/protobuf and golang/protobuf, and the unexported state, sizeCache and unknownFields
+// of google.golang.org/protobuf, along with any other unexported field and the fields,
+// exported or not, tagged `ssz:"-"`.
+func isSSZField(f reflect.StructField) bool {
+	return !strings.HasPrefix(f.Name, "XXX") && f.PkgPath == "" && f.Tag.Get("ssz") != "-"
+}
+
+// UnexportedFieldPolicy is what happens to the unexported fields of structs, see
+// SetUnexportedFieldPolicy.
+type UnexportedFieldPolicy int
+
+const (
+	// SkipUnexportedFields leaves unexported fields out of the containers their structs
+	// describe. This is the default.
+	SkipUnexportedFields UnexportedFieldPolicy = iota
+	// RejectUnexportedFields fails for structs with unexported fields, other than the
+	// internal fields of protobuf-generated structs and the fields tagged `ssz:"-"`.
+	RejectUnexportedFields
+)
+
+var unexportedFieldPolicy = SkipUnexportedFields
+
+// SetUnexportedFieldPolicy allows to programmatically select whether unexported struct
+// fields are skipped, or rejected so that a field accidentally named in lowercase does
+// not silently change the roots of its struct:
+//
+//  ssz.SetUnexportedFieldPolicy(ssz.RejectUnexportedFields)
+//
+//  type Checkpoint struct {
+//      Epoch uint64
+//      root  [32]byte // rejected
+//      cache []byte   `ssz:"-"`
+//  }
+//
+// The policy must be set before the first use of the types it applies to, as the
+// fields of a type are only computed once.
+func SetUnexportedFieldPolicy(policy UnexportedFieldPolicy) {
+	unexportedFieldPolicy = policy
+}
+
+// checkUnexportedField fails for the unexported field f of a struct of type typ if
+// unexported fields are rejected, see SetUnexportedFieldPolicy.
+func checkUnexportedField(typ reflect.Type, f reflect.StructField) error {
+	if unexportedFieldPolicy == SkipUnexportedFields || f.PkgPath == "" || f.Tag.Get("ssz") == "-" {
+		return nil
+	}
+	switch f.Name {
+	case "state", "sizeCache", "unknownFields":
+		return nil
+	}
+	return errorf(
+		ErrUnsupportedType,
+		"unexported field %s of %v would be left out of its container, export it or tag it with `ssz:\"-\"`",
+		f.Name,
+		typ,
+	)
+}
+
+// computeStructFields iterates over the raw fields of a struct, ignoring protobuf internal
+// and unexported fields, and determines the necessary ssz utils such as the marshaler, unmarshaler, and tree hasher
 // for that particular struct field. Then, it returns a slice of field wrappers containing
 // the necessary SSZ utils and field type information.
-func structFields(typ reflect.Type) (fields []field, err error) {
+func computeStructFields(typ reflect.Type) (fields []field, err error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("expected a struct kind input, received kind: %v", typ.Kind())
 	}
 	for i := 0; i < typ.NumField(); i++ {
 		f := typ.Field(i)
-		if strings.Contains(f.Name, "XXX") {
+		if !isSSZField(f) {
+			if err := checkUnexportedField(typ, f); err != nil {
+				return nil, err
+			}
 			continue
 		}
+		tags, err := parseFieldTags(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse tags of field %s: %w", f.Name, err)
+		}
 		// determineFieldType parses the struct's tags to check if there are any ssz tags
 		// which specify a field should be treated as fixed-size by the marshaler.
 		fType, err := determineFieldType(f)
 		if err != nil {
 			return nil, err
 		}
-		fCapacity, hasCapacity := determineFieldCapacity(f)
+		hasCapacity := tags.hasLimits
+		fCapacity, innerLimits := splitLimits(tags.limits)
+
+		if tags.opaque {
+			if !hasCapacity {
+				return nil, errorf(ErrInvalidTag, "opaque field %s requires an ssz-max tag", f.Name)
+			}
+			utils, codec, err := makeOpaqueUtils(f, fCapacity)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field{
+				index:       i,
+				name:        f.Name,
+				sszUtils:    utils,
+				typ:         fType,
+				capacity:    fCapacity,
+				hasCapacity: hasCapacity,
+				opaque:      codec,
+			})
+			continue
+		}
 
 		// We determine the SSZ utils for the field, including its respective
 		// marshaler, unmarshaler, and hasher.
-		utils, err := cachedSSZUtilsNoAcquireLock(fType)
+		var utils *sszUtils
+		if tags.bits {
+			if tags.hasSizes {
+				return nil, errorf(ErrInvalidTag, "bits field %s cannot have sizes", f.Name)
+			}
+			if fType.Kind() == reflect.Slice && !hasCapacity {
+				return nil, errorf(ErrInvalidTag, "bits field %s holding a list requires an ssz-max tag", f.Name)
+			}
+			utils, err = makeBitsUtils(f.Type, fType)
+		} else if tags.progressive {
+			if tags.hasSizes || hasCapacity {
+				return nil, errorf(ErrInvalidTag, "progressive field %s can neither have sizes nor an ssz-max", f.Name)
+			}
+			utils, err = makeProgressiveUtils(fType)
+		} else {
+			utils, err = cachedSSZUtilsNoAcquireLock(fType)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to get ssz utils: %v", err)
+			return nil, fmt.Errorf("failed to get ssz utils: %w", err)
+		}
+		var nested hasher
+		if innerLimits != nil {
+			if nested, err = makeNestedHasher(fType, innerLimits); err != nil {
+				return nil, fmt.Errorf("could not apply ssz-max tag of field %s: %w", f.Name, err)
+			}
 		}
 		name := f.Name
 		fields = append(fields, field{
-			index:       i,
-			name:        name,
-			sszUtils:    utils,
-			typ:         fType,
-			capacity:    fCapacity,
-			hasCapacity: hasCapacity,
+			index:        i,
+			name:         name,
+			sszUtils:     utils,
+			typ:          fType,
+			capacity:     fCapacity,
+			hasCapacity:  hasCapacity,
+			innerLimits:  innerLimits,
+			nestedHasher: nested,
+			bits:         tags.bits,
+			progressive:  tags.progressive,
 		})
 	}
 	return fields, nil
 }
 
 func determineFieldType(field reflect.StructField) (reflect.Type, error) {
+	// Opaque fields are treated as byte lists, whatever their Go type.
+	if isOpaqueField(field) {
+		return opaqueType, nil
+	}
+	// Bits fields are treated as the bitfields their booleans are packed into.
+	if isBitsField(field) {
+		return bitsFieldType(field.Type)
+	}
 	fieldSizeTags, exists, err := parseSSZFieldTags(field)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse ssz struct field tags: %v", err)
+		return nil, fmt.Errorf("could not parse ssz struct field tags: %w", err)
 	}
 	if exists {
 		// If the field does indeed specify ssz struct tags, we infer the field's type.
@@ -87,38 +252,20 @@ func determineFieldType(field reflect.StructField) (reflect.Type, error) {
 	return field.Type, nil
 }
 
-func determineFieldCapacity(field reflect.StructField) (uint64, bool) {
-	tag, exists := field.Tag.Lookup("ssz-max")
-	if !exists {
-		return 0, false
+// splitLimits returns the first of limits, 0 if there is none, and the others, nil
+// if they are all 0 as they then leave the hashing of values unchanged.
+func splitLimits(limits []uint64) (uint64, []uint64) {
+	if len(limits) == 0 {
+		return 0, nil
 	}
-	val, err := strconv.ParseUint(tag, 10, 64)
-	if err != nil {
-		return 0, false
+	inner := limits[1:]
+	for len(inner) > 0 && inner[len(inner)-1] == 0 {
+		inner = inner[:len(inner)-1]
 	}
-	return val, true
-}
-
-func parseSSZFieldTags(field reflect.StructField) ([]uint64, bool, error) {
-	tag, exists := field.Tag.Lookup("ssz-size")
-	if !exists {
-		return nil, false, nil
-	}
-	items := strings.Split(tag, ",")
-	sizes := make([]uint64, len(items))
-	var err error
-	for i := 0; i < len(items); i++ {
-		// If a field is unbounded, we mark it with a size of 0.
-		if items[i] == UnboundedSSZFieldSizeMarker {
-			sizes[i] = 0
-			continue
-		}
-		sizes[i], err = strconv.ParseUint(items[i], 10, 64)
-		if err != nil {
-			return nil, false, err
-		}
+	if len(inner) == 0 {
+		return limits[0], nil
 	}
-	return sizes, true, nil
+	return limits[0], inner
 }
 
 func inferFieldTypeFromSizeTags(field reflect.StructField, sizes []uint64) reflect.Type {
@@ -137,13 +284,18 @@ func inferFieldTypeFromSizeTags(field reflect.StructField, sizes []uint64) refle
 	return currentType
 }
 
-func growSliceFromSizeTags(val reflect.Value, sizes []uint64) reflect.Value {
+func growSliceFromSizeTags(a *Arena, val reflect.Value, sizes []uint64) reflect.Value {
 	if len(sizes) == 0 {
 		return val
 	}
-	finalValue := reflect.MakeSlice(val.Type(), int(sizes[0]), int(sizes[0]))
+	var finalValue reflect.Value
+	if val.Kind() == reflect.Slice && val.Cap() >= int(sizes[0]) {
+		finalValue = val.Slice(0, int(sizes[0]))
+	} else {
+		finalValue = a.makeSlice(val.Type(), int(sizes[0]), int(sizes[0]))
+	}
 	for i := 0; i < int(sizes[0]); i++ {
-		intermediate := growSliceFromSizeTags(finalValue.Index(i), sizes[1:])
+		intermediate := growSliceFromSizeTags(a, finalValue.Index(i), sizes[1:])
 		finalValue.Index(i).Set(intermediate)
 	}
 	return finalValue
@@ -2,6 +2,7 @@ package ssz
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -79,3 +80,252 @@ func TestParseSSZFieldTags_HandlesOverflow(t *testing.T) {
 		t.Errorf("got: %d, wanted %d", result, want)
 	}
 }
+
+func TestStructFields_Cached(t *testing.T) {
+	typ := reflect.TypeOf(treeContainer{})
+	fields, err := structFields(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		cached, err := structFields(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if &cached[0] != &fields[0] {
+			t.Fatal("Expected the fields of the struct to be cached")
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("Expected cached fields not to allocate, received %v allocations", allocs)
+	}
+}
+
+// protoCheckpoint has the internal fields of the structs generated by gogo/protobuf
+// and golang/protobuf.
+type protoCheckpoint struct {
+	Epoch                uint64   `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Root                 []byte   `protobuf:"bytes,2,opt,name=root,proto3" json:"root,omitempty" ssz-size:"32"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+// protoV2Checkpoint has the internal fields of the structs generated by
+// google.golang.org/protobuf.
+type protoV2Checkpoint struct {
+	state         struct{ p *int }
+	sizeCache     int32
+	unknownFields []byte
+
+	Epoch uint64 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Root  []byte `protobuf:"bytes,2,opt,name=root,proto3" json:"root,omitempty" ssz-size:"32"`
+}
+
+// uint64Value has the shape of the UInt64Value wrapper type of gogo/protobuf.
+type uint64Value struct {
+	Value                uint64   `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+type checkpoint struct {
+	Epoch uint64
+	Root  [32]byte
+}
+
+func TestStructFields_SkipsProtobufInternals(t *testing.T) {
+	root := [32]byte{1, 2, 3}
+	want := []checkpoint{{Epoch: 1, Root: root}, {Epoch: 2}}
+	v1 := []protoCheckpoint{
+		{Epoch: 1, Root: root[:], XXX_unrecognized: []byte{9}, XXX_sizecache: 3},
+		{Epoch: 2, Root: make([]byte, 32)},
+	}
+	v2 := []*protoV2Checkpoint{
+		{Epoch: 1, Root: root[:], sizeCache: 3, unknownFields: []byte{9}},
+		{Epoch: 2, Root: make([]byte, 32)},
+	}
+	// The internal fields must not make the containers variable-size, which would
+	// change the encoding of lists of them.
+	for _, typ := range []reflect.Type{reflect.TypeOf(protoCheckpoint{}), reflect.TypeOf(protoV2Checkpoint{})} {
+		if isVariableSizeType(typ) {
+			t.Errorf("Expected %v to be fixed-size", typ)
+		}
+	}
+	wantEnc, err := Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRootWithCapacity(want, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, val := range []interface{}{v1, v2} {
+		enc, err := Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(enc, wantEnc) {
+			t.Errorf("%T: expected encoding %#x, received %#x", val, wantEnc, enc)
+		}
+		root, err := HashTreeRootWithCapacity(val, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != wantRoot {
+			t.Errorf("%T: expected root %#x, received %#x", val, wantRoot, root)
+		}
+		decoded := reflect.New(reflect.TypeOf(val))
+		if err := Unmarshal(enc, decoded.Interface()); err != nil {
+			t.Fatalf("%T: %v", val, err)
+		}
+		if !DeepEqual(decoded.Elem().Interface(), val) {
+			t.Errorf("%T: round trip changed the value", val)
+		}
+	}
+}
+
+func TestStructFields_ProtobufWrappers(t *testing.T) {
+	// Wrapper types are containers of their single Value field, which share the
+	// encoding and root of fixed-size values.
+	wrapped := &uint64Value{Value: 42, XXX_sizecache: 8}
+	enc, err := Marshal(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEnc, err := Marshal(uint64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(enc, wantEnc) {
+		t.Errorf("Expected encoding %#x, received %#x", wantEnc, enc)
+	}
+	root, err := HashTreeRoot(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(uint64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+}
+
+func TestParseFieldLimits(t *testing.T) {
+	input := struct {
+		Single  []uint64   `ssz-max:"16"`
+		Nested  [][]byte   `ssz-max:"1048576,1073741824"`
+		Vectors [][4][]int `ssz-max:"8,?,32"`
+		Trailed [][]byte   `ssz-max:"4,?"`
+	}{}
+	tests := []struct {
+		capacity uint64
+		inner    []uint64
+	}{
+		{16, nil},
+		{1048576, []uint64{1073741824}},
+		{8, []uint64{0, 32}},
+		{4, nil},
+	}
+	typ := reflect.TypeOf(input)
+	for i, tt := range tests {
+		limits, exists, err := parseFieldLimits(typ.Field(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Fatalf("Expected ssz-max of field %d to exist", i)
+		}
+		capacity, inner := splitLimits(limits)
+		if capacity != tt.capacity || !reflect.DeepEqual(inner, tt.inner) {
+			t.Errorf("Field %d: expected limits %d, %v, received %d, %v", i, tt.capacity, tt.inner, capacity, inner)
+		}
+		if result, _ := determineFieldCapacity(typ.Field(i)); result != tt.capacity {
+			t.Errorf("Field %d: expected capacity %d, received %d", i, tt.capacity, result)
+		}
+	}
+}
+
+type lowercaseCheckpoint struct {
+	Epoch uint64
+	root  [32]byte
+}
+
+type skippedFieldCheckpoint struct {
+	Epoch         uint64
+	cache         []byte `ssz:"-"`
+	sizeCache     int32
+	unknownFields []byte
+}
+
+func TestSetUnexportedFieldPolicy(t *testing.T) {
+	SetUnexportedFieldPolicy(RejectUnexportedFields)
+	want := "unexported field root of ssz.lowercaseCheckpoint would be left out of its container"
+	if _, err := Marshal(lowercaseCheckpoint{Epoch: 1}); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+	if err := ValidateType(reflect.TypeOf(lowercaseCheckpoint{})); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, received %v", want, err)
+	}
+	// Fields tagged to be skipped and the internal fields of protobuf messages are
+	// not rejected.
+	if _, err := Marshal(skippedFieldCheckpoint{Epoch: 1, cache: []byte{1}}); err != nil {
+		t.Error(err)
+	}
+	// Rejected types are not cached, and are accepted once unexported fields are
+	// skipped again.
+	SetUnexportedFieldPolicy(SkipUnexportedFields)
+	enc, err := Marshal(lowercaseCheckpoint{Epoch: 1, root: [32]byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEnc, err := Marshal(uint64(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(enc, wantEnc) {
+		t.Errorf("Expected encoding %#x, received %#x", wantEnc, enc)
+	}
+}
+
+type skippedExportedCheckpoint struct {
+	Epoch uint64
+	Cache map[string]int `ssz:"-"`
+}
+
+func TestSkippedExportedField(t *testing.T) {
+	item := skippedExportedCheckpoint{Epoch: 1, Cache: map[string]int{"a": 1}}
+	enc, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEnc, err := Marshal(uint64(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(enc, wantEnc) {
+		t.Errorf("Expected encoding %#x, received %#x", wantEnc, enc)
+	}
+	var decoded skippedExportedCheckpoint
+	if err := Unmarshal(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Epoch != 1 || decoded.Cache != nil {
+		t.Errorf("Expected only the epoch to be decoded, received %v", decoded)
+	}
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantRoot, err := HashTreeRoot(uint64(1)); err != nil {
+		t.Fatal(err)
+	} else if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+	if err := ValidateType(reflect.TypeOf(item)); err != nil {
+		t.Error(err)
+	}
+}
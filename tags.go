@@ -0,0 +1,191 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldTags are the SSZ options of a struct field. Sizes and limits are given by the
+// ssz-size and ssz-max tags, or equivalently by the size and max options of the ssz
+// tag, which also holds the opaque flag of fields with a foreign encoding, the bits
+// flag of lists and vectors of booleans packed into bitlists and bitvectors, and the
+// progressive flag of lists and containers merkleized progressively:
+//
+//  type exampleStruct struct {
+//      Field1 [][]byte `ssz-size:"?,32" ssz-max:"16"`
+//      Field2 [][]byte `ssz:"size=?,32,max=16"`
+//      Field3 []*types.Transaction `ssz:"opaque,max=1073741824"`
+//      Field4 []bool `ssz:"bits,max=2048"`
+//      Field5 [][]byte `ssz:"progressive"`
+//  }
+//
+// Flags come first in the ssz tag, and the values of an option extend up to the next
+// option, as sizes and limits hold one value per dimension of nested slices. The
+// ssz-fork and ssz-until tags name the forks a field is added and removed at, as
+// selected with ForFork.
+type fieldTags struct {
+	// sizes are the sizes of the dimensions of the field, 0 for lists.
+	sizes    []uint64
+	hasSizes bool
+	// limits are the ssz-max of the dimensions of the field.
+	limits    []uint64
+	hasLimits bool
+	opaque    bool
+	bits      bool
+	// progressive marks the fields merkleized progressively, see makeProgressiveHasher.
+	progressive bool
+	// fork and until are the forks the field is added and removed at, if any.
+	fork  string
+	until string
+}
+
+// parseFieldTags parses the SSZ options of a struct field, which may be given by either
+// syntax but not both.
+func parseFieldTags(field reflect.StructField) (*fieldTags, error) {
+	tags := &fieldTags{}
+	var err error
+	if tag, ok := field.Tag.Lookup("ssz-size"); ok {
+		if tags.sizes, err = parseDimensions(tag); err != nil {
+			return nil, errorf(ErrInvalidTag, "invalid ssz-size tag: %v", err)
+		}
+		tags.hasSizes = true
+	}
+	if tag, ok := field.Tag.Lookup("ssz-max"); ok {
+		if tags.limits, err = parseDimensions(tag); err != nil {
+			return nil, errorf(ErrInvalidTag, "invalid ssz-max tag: %v", err)
+		}
+		tags.hasLimits = true
+	}
+	tags.fork = field.Tag.Get("ssz-fork")
+	tags.until = field.Tag.Get("ssz-until")
+	tag, ok := field.Tag.Lookup("ssz")
+	if !ok {
+		return tags, nil
+	}
+	if err := tags.parseOptions(tag); err != nil {
+		return nil, errorf(ErrInvalidTag, "invalid ssz tag %q: %v", tag, err)
+	}
+	return tags, nil
+}
+
+// parseOptions parses the options of an ssz tag into tags.
+func (tags *fieldTags) parseOptions(tag string) error {
+	items := strings.Split(tag, ",")
+	i := 0
+	for ; i < len(items) && !strings.Contains(items[i], "="); i++ {
+		switch items[i] {
+		case "opaque":
+			tags.opaque = true
+		case "bits":
+			tags.bits = true
+		case "progressive":
+			tags.progressive = true
+		default:
+			return fmt.Errorf("unknown flag %q", items[i])
+		}
+	}
+	if tags.opaque && tags.bits {
+		return errors.New("opaque fields cannot hold bits")
+	}
+	if tags.progressive && (tags.opaque || tags.bits) {
+		return errors.New("progressive fields can neither be opaque nor hold bits")
+	}
+	for i < len(items) {
+		kv := strings.SplitN(items[i], "=", 2)
+		values := []string{kv[1]}
+		for i++; i < len(items) && !strings.Contains(items[i], "="); i++ {
+			values = append(values, items[i])
+		}
+		dims, err := parseDimensions(strings.Join(values, ","))
+		if err != nil {
+			return fmt.Errorf("invalid %s option: %w", kv[0], err)
+		}
+		switch kv[0] {
+		case "size":
+			if tags.hasSizes {
+				return errors.New("sizes are given by both the ssz-size tag and the size option")
+			}
+			tags.sizes, tags.hasSizes = dims, true
+		case "max":
+			if tags.hasLimits {
+				return errors.New("limits are given by both the ssz-max tag and the max option")
+			}
+			tags.limits, tags.hasLimits = dims, true
+		default:
+			return fmt.Errorf("unknown option %q", kv[0])
+		}
+	}
+	return nil
+}
+
+// parseDimensions parses a comma-separated list of sizes or limits, one per dimension
+// from the outermost to the innermost. Each of them is a number or the name of a
+// constant registered with RegisterConstants, and UnboundedSSZFieldSizeMarker marks
+// lists in sizes and vectors, or lists left without limit, in limits, with a 0.
+func parseDimensions(tag string) ([]uint64, error) {
+	items := strings.Split(tag, ",")
+	dims := make([]uint64, len(items))
+	for i, item := range items {
+		if item == UnboundedSSZFieldSizeMarker {
+			continue
+		}
+		val, err := tagValue(item)
+		if err != nil {
+			return nil, err
+		}
+		dims[i] = val
+	}
+	return dims, nil
+}
+
+// parseSSZFieldTags returns the sizes of the dimensions of a field, if it has any.
+func parseSSZFieldTags(field reflect.StructField) ([]uint64, bool, error) {
+	tags, err := parseFieldTags(field)
+	if err != nil {
+		return nil, false, err
+	}
+	return tags.sizes, tags.hasSizes, nil
+}
+
+// parseFieldLimits returns the limits of the dimensions of a field, such as
+// `ssz-max:"1048576,1073741824"` for a list of byte lists, if it has any.
+func parseFieldLimits(field reflect.StructField) ([]uint64, bool, error) {
+	tags, err := parseFieldTags(field)
+	if err != nil {
+		return nil, false, err
+	}
+	return tags.limits, tags.hasLimits, nil
+}
+
+// parseFieldCapacity returns the limit of the outermost dimension of a field.
+func parseFieldCapacity(field reflect.StructField) (uint64, bool, error) {
+	limits, exists, err := parseFieldLimits(field)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+	return limits[0], true, nil
+}
+
+func determineFieldCapacity(field reflect.StructField) (uint64, bool) {
+	val, exists, err := parseFieldCapacity(field)
+	if err != nil {
+		return 0, false
+	}
+	return val, exists
+}
+
+// isOpaqueField reports whether a struct field is tagged as holding a foreign encoding.
+// Invalid tags are reported when the fields of its struct are computed.
+func isOpaqueField(field reflect.StructField) bool {
+	tags, err := parseFieldTags(field)
+	return err == nil && tags.opaque
+}
+
+// isBitsField reports whether a struct field is tagged as holding booleans packed into
+// a bitfield. Invalid tags are reported when the fields of its struct are computed.
+func isBitsField(field reflect.StructField) bool {
+	tags, err := parseFieldTags(field)
+	return err == nil && tags.bits
+}
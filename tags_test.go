@@ -0,0 +1,116 @@
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type dashedTags struct {
+	Roots   [][]byte   `ssz-size:"?,32" ssz-max:"16"`
+	Hash    []byte     `ssz-size:"32"`
+	Lists   [][]uint64 `ssz-max:"4,8"`
+	Balance uint64
+}
+
+type optionTags struct {
+	Roots   [][]byte   `ssz:"size=?,32,max=16"`
+	Hash    []byte     `ssz:"size=32"`
+	Lists   [][]uint64 `ssz:"max=4,8"`
+	Balance uint64
+}
+
+func TestFieldTags_SyntaxesAreSymmetric(t *testing.T) {
+	dashed := dashedTags{
+		Roots:   [][]byte{make([]byte, 32), append([]byte{1}, make([]byte, 31)...)},
+		Hash:    append([]byte{2}, make([]byte, 31)...),
+		Lists:   [][]uint64{{1, 2}, {3}},
+		Balance: 32,
+	}
+	options := optionTags(dashed)
+
+	encoded, err := Marshal(dashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	root, err := HashTreeRoot(dashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+	var decoded optionTags
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !DeepEqual(decoded, options) {
+		t.Errorf("Expected %v, received %v", options, decoded)
+	}
+	dashedDesc, err := Describe(reflect.TypeOf(dashed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	optionsDesc, err := Describe(reflect.TypeOf(options))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dashedDesc.MaxSize != optionsDesc.MaxSize || dashedDesc.Fields[1].Size != optionsDesc.Fields[1].Size {
+		t.Errorf("Expected descriptions of both syntaxes to match")
+	}
+}
+
+func TestParseFieldTags(t *testing.T) {
+	input := struct {
+		Opaque  []byte   `ssz:"opaque,max=64"`
+		Vectors [][]byte `ssz:"max=8,size=?,32"`
+		Plain   []byte
+	}{}
+	typ := reflect.TypeOf(input)
+	tests := []fieldTags{
+		{limits: []uint64{64}, hasLimits: true, opaque: true},
+		{sizes: []uint64{0, 32}, hasSizes: true, limits: []uint64{8}, hasLimits: true},
+		{},
+	}
+	for i, want := range tests {
+		tags, err := parseFieldTags(typ.Field(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*tags, want) {
+			t.Errorf("Field %d: expected tags %+v, received %+v", i, want, *tags)
+		}
+	}
+}
+
+func TestParseFieldTags_Errors(t *testing.T) {
+	input := struct {
+		UnknownFlag   []byte `ssz:"packed"`
+		UnknownOption []byte `ssz:"length=32"`
+		BothSizes     []byte `ssz-size:"32" ssz:"size=32"`
+		BothLimits    []byte `ssz-max:"32" ssz:"max=32"`
+		InvalidSize   []byte `ssz:"size=-1"`
+		InvalidMax    []byte `ssz-max:"1,x"`
+		OpaqueBits    []bool `ssz:"opaque,bits"`
+	}{}
+	typ := reflect.TypeOf(input)
+	for i := 0; i < typ.NumField(); i++ {
+		if _, err := parseFieldTags(typ.Field(i)); err == nil {
+			t.Errorf("Expected tags of field %s to be invalid", typ.Field(i).Name)
+		}
+	}
+	if _, err := Marshal(input); err == nil {
+		t.Error("Expected invalid tags to fail marshaling")
+	}
+}
@@ -0,0 +1,92 @@
+package ssz
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// TraceOperation is the operation a traced field is part of.
+type TraceOperation int
+
+const (
+	// TraceMarshal traces fields being encoded by Marshal.
+	TraceMarshal TraceOperation = iota
+	// TraceUnmarshal traces fields being decoded by Unmarshal.
+	TraceUnmarshal
+)
+
+func (op TraceOperation) String() string {
+	if op == TraceMarshal {
+		return "marshal"
+	}
+	return "unmarshal"
+}
+
+// TraceEvent describes a struct field being encoded or decoded.
+type TraceEvent struct {
+	Operation TraceOperation
+	// Struct is the type of the struct holding the field.
+	Struct reflect.Type
+	Field  string
+	Kind   reflect.Kind
+	// Variable reports whether the field is variable-size, in which case its
+	// offset is read from, or written to, the fixed part of the struct.
+	Variable bool
+	// Offset and Size locate the encoding of the field within the encoding
+	// of the struct.
+	Offset uint64
+	Size   uint64
+}
+
+// Tracer receives the events of the struct fields being encoded and decoded.
+type Tracer func(TraceEvent)
+
+var (
+	tracerLock sync.RWMutex
+	tracer     Tracer
+	// hasTracer lets the hot paths skip looking up the tracer when none is set.
+	hasTracer int32
+)
+
+// SetTracer registers the tracer called for every struct field encoded or decoded,
+// which helps debugging encodings. A nil tracer disables tracing, which is the default
+// and costs nothing.
+//
+//  ssz.SetTracer(func(e ssz.TraceEvent) {
+//      log.Printf("%v %v.%s at offset %d (%d bytes)", e.Operation, e.Struct, e.Field, e.Offset, e.Size)
+//  })
+//  defer ssz.SetTracer(nil)
+func SetTracer(t Tracer) {
+	tracerLock.Lock()
+	defer tracerLock.Unlock()
+	tracer = t
+	if t != nil {
+		atomic.StoreInt32(&hasTracer, 1)
+	} else {
+		atomic.StoreInt32(&hasTracer, 0)
+	}
+}
+
+// activeTracer returns the registered tracer, if any.
+func activeTracer() Tracer {
+	if atomic.LoadInt32(&hasTracer) == 0 {
+		return nil
+	}
+	tracerLock.RLock()
+	defer tracerLock.RUnlock()
+	return tracer
+}
+
+// traceField reports a struct field of typ to t.
+func traceField(t Tracer, op TraceOperation, typ reflect.Type, f field, variable bool, start uint64, end uint64) {
+	t(TraceEvent{
+		Operation: op,
+		Struct:    typ,
+		Field:     f.name,
+		Kind:      typ.Field(f.index).Type.Kind(),
+		Variable:  variable,
+		Offset:    start,
+		Size:      end - start,
+	})
+}
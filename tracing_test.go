@@ -0,0 +1,43 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetTracer(t *testing.T) {
+	item := iteratedItem{Slot: 5, Data: []byte{1, 2, 3}}
+	var events []TraceEvent
+	SetTracer(func(e TraceEvent) {
+		events = append(events, e)
+	})
+	defer SetTracer(nil)
+
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded iteratedItem
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(item)
+	want := []TraceEvent{
+		{Operation: TraceMarshal, Struct: typ, Field: "Slot", Kind: reflect.Uint64, Offset: 0, Size: 8},
+		{Operation: TraceMarshal, Struct: typ, Field: "Data", Kind: reflect.Slice, Variable: true, Offset: 12, Size: 3},
+		{Operation: TraceUnmarshal, Struct: typ, Field: "Slot", Kind: reflect.Uint64, Offset: 0, Size: 8},
+		{Operation: TraceUnmarshal, Struct: typ, Field: "Data", Kind: reflect.Slice, Variable: true, Offset: 12, Size: 3},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Expected events %+v, received %+v", want, events)
+	}
+
+	SetTracer(nil)
+	events = nil
+	if _, err := Marshal(item); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events once tracing is disabled, received %d", len(events))
+	}
+}
@@ -0,0 +1,460 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Node is a node of a backing Merkle tree. Leaves hold a 32 byte chunk, while
+// branches hold the root of their two children, which is only recomputed when
+// a node below them changes.
+type Node struct {
+	left  *Node
+	right *Node
+	root  [32]byte
+	// dirty marks branches whose root is outdated.
+	dirty bool
+	// owner is the id of the only tree allowed to modify the node in place.
+	// Other trees copy the node before modifying it, so that nodes can be
+	// shared between trees. Nodes which do not belong to any tree yet have
+	// owner 0, while the shared zero subtrees never belong to any tree.
+	owner uint64
+}
+
+// sharedOwner is the owner of nodes which must never be modified in place.
+const sharedOwner = ^uint64(0)
+
+// lastTreeID is the id most recently given to a tree.
+var lastTreeID uint64
+
+func nextTreeID() uint64 {
+	return atomic.AddUint64(&lastTreeID, 1)
+}
+
+// zeroNodes[i] is the shared subtree of depth i whose leaves are all zero chunks.
+var zeroNodes = make([]*Node, maxTreeDepth+1)
+
+// computeZeroNodes builds the zero subtrees from the zero hashes.
+func computeZeroNodes() {
+	zeroNodes[0] = &Node{owner: sharedOwner}
+	for i := 1; i < len(zeroNodes); i++ {
+		zeroNodes[i] = &Node{
+			left:  zeroNodes[i-1],
+			right: zeroNodes[i-1],
+			root:  toBytes32(zeroHashes[i]),
+			owner: sharedOwner,
+		}
+	}
+}
+
+// NewLeaf returns a leaf node holding the given chunk.
+func NewLeaf(chunk [32]byte) *Node {
+	return &Node{root: chunk}
+}
+
+// NewBranch returns a branch node whose root is the hash of the roots of its children.
+func NewBranch(left *Node, right *Node) *Node {
+	return &Node{left: left, right: right, dirty: true}
+}
+
+// IsLeaf reports whether the node is a leaf.
+func (n *Node) IsLeaf() bool {
+	return n.left == nil
+}
+
+// Left returns the left child of a branch, or nil for leaves.
+func (n *Node) Left() *Node {
+	return n.left
+}
+
+// Right returns the right child of a branch, or nil for leaves.
+func (n *Node) Right() *Node {
+	return n.right
+}
+
+// Root returns the chunk of a leaf, or the hash tree root of the subtree of a branch,
+// recomputing the roots of outdated branches below it.
+func (n *Node) Root() [32]byte {
+	if !n.dirty {
+		return n.root
+	}
+	h := acquireHasher()
+	defer releaseHasher(h)
+	return n.hashWith(h)
+}
+
+func (n *Node) hashWith(h *Hasher) [32]byte {
+	if !n.dirty {
+		return n.root
+	}
+	left := n.left.hashWith(h)
+	right := n.right.hashWith(h)
+	n.root = h.hashPair(left[:], right[:])
+	n.dirty = false
+	return n.root
+}
+
+// Tree is a backing Merkle tree of an SSZ value, whose nodes are addressed by
+// generalized index: the root has index 1, and the children of the node at
+// index i have indices 2*i and 2*i+1. Modifying a node only marks the branches
+// above it as outdated, so that the root is recomputed in O(log n) hashes.
+// Trees returned by Copy share their unmodified subtrees with the original.
+// A Tree is not safe for concurrent use, but copies of a tree may be used
+// concurrently with each other.
+//
+//  tree, err := ssz.NewTree(state)
+//  if err != nil {
+//      return fmt.Errorf("failed to build tree: %v", err)
+//  }
+//  if err := tree.SetLeaf(balanceChunkIndex, balanceChunk); err != nil {
+//      return fmt.Errorf("failed to update balance: %v", err)
+//  }
+//  root := tree.Root()
+type Tree struct {
+	root *Node
+	id   uint64
+}
+
+// NewTree builds the backing Merkle tree of a value, whose root is the
+// hash tree root of the value.
+func NewTree(val interface{}) (*Tree, error) {
+	if val == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	rval := reflect.ValueOf(val)
+	node, err := buildNode(rval, rval.Type(), 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not build tree of type: %v: %w", rval.Type(), err)
+	}
+	t := &Tree{root: node, id: nextTreeID()}
+	claimNodes(node, t.id)
+	return t, nil
+}
+
+// NewTreeFromNode returns a tree rooted at the given node. The nodes are left
+// untouched, the tree copies them before modifying them.
+func NewTreeFromNode(root *Node) *Tree {
+	return &Tree{root: root, id: nextTreeID()}
+}
+
+// claimNodes gives the nodes of a newly built subtree to the tree with the given id,
+// so that it can modify them in place.
+func claimNodes(n *Node, id uint64) {
+	if n == nil || n.owner != 0 {
+		return
+	}
+	n.owner = id
+	claimNodes(n.left, id)
+	claimNodes(n.right, id)
+}
+
+// Copy returns a copy of the tree, which shares all of its nodes with the original.
+// Modifying either tree copies the nodes on the path to the modified node, so that
+// unmodified subtrees remain shared.
+//
+//  variant := tree.Copy()
+//  if err := variant.SetLeaf(gindex, chunk); err != nil {
+//      return fmt.Errorf("failed to update variant: %v", err)
+//  }
+func (t *Tree) Copy() *Tree {
+	// Shared nodes must not be written to by hashing, so their roots are
+	// computed before they become shared.
+	t.Root()
+	t.id = nextTreeID()
+	return &Tree{root: t.root, id: nextTreeID()}
+}
+
+// Root returns the hash tree root of the tree, only rehashing the branches
+// above nodes which changed since the last call.
+func (t *Tree) Root() [32]byte {
+	return t.root.Root()
+}
+
+// RootNode returns the root node of the tree.
+func (t *Tree) RootNode() *Node {
+	return t.root
+}
+
+// Get returns the node at the given generalized index.
+func (t *Tree) Get(gindex uint64) (*Node, error) {
+	if gindex == 0 {
+		return nil, errors.New("generalized index 0 is not a valid index")
+	}
+	n := t.root
+	for i := int(bitLength(gindex)) - 2; i >= 0; i-- {
+		if n.IsLeaf() {
+			return nil, fmt.Errorf("generalized index %d is below a leaf", gindex)
+		}
+		if gindex&(1<<uint(i)) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n, nil
+}
+
+// Leaf returns the root of the node at the given generalized index, which is
+// its chunk if the node is a leaf.
+func (t *Tree) Leaf(gindex uint64) ([32]byte, error) {
+	n, err := t.Get(gindex)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return n.Root(), nil
+}
+
+// Set replaces the subtree at the given generalized index with node, marking
+// the branches above it as outdated.
+func (t *Tree) Set(gindex uint64, node *Node) error {
+	if gindex == 0 {
+		return errors.New("generalized index 0 is not a valid index")
+	}
+	if node == nil {
+		return errors.New("node cannot be nil")
+	}
+	slot := &t.root
+	for i := int(bitLength(gindex)) - 2; i >= 0; i-- {
+		n := *slot
+		if n.IsLeaf() {
+			return fmt.Errorf("generalized index %d is below a leaf", gindex)
+		}
+		if n.owner != t.id {
+			// Nodes the tree does not own are copied before being
+			// modified, their children are left shared.
+			n = &Node{left: n.left, right: n.right, root: n.root, dirty: n.dirty, owner: t.id}
+			*slot = n
+		}
+		n.dirty = true
+		if gindex&(1<<uint(i)) == 0 {
+			slot = &n.left
+		} else {
+			slot = &n.right
+		}
+	}
+	*slot = node
+	return nil
+}
+
+// SetLeaf replaces the node at the given generalized index with a leaf holding chunk.
+func (t *Tree) SetLeaf(gindex uint64, chunk [32]byte) error {
+	return t.Set(gindex, NewLeaf(chunk))
+}
+
+// buildNode builds the backing tree of val, following the same rules as the
+// hasher of typ so that the root of the tree is the hash tree root of val. Lists
+// are bounded by maxCapacity, and the lists nested within them by innerLimits.
+func buildNode(val reflect.Value, typ reflect.Type, maxCapacity uint64, innerLimits []uint64) (*Node, error) {
+	if typ.Kind() == reflect.String {
+		if err := checkUTF8(val.String()); err != nil {
+			return nil, err
+		}
+		val, typ = stringBytes(val), byteSliceType
+	}
+	kind := typ.Kind()
+	switch {
+	case lookupCodec(typ) != nil:
+		root, err := lookupCodec(typ).hash(val.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %v: %w", typ, err)
+		}
+		return NewLeaf(root), nil
+	case isBitvector(typ):
+		buf, err := bitvectorChunks(val)
+		if err != nil {
+			return nil, err
+		}
+		length, _ := bitvectorLength(typ)
+		return buildChunksNode(buf, (length+255)/256)
+	case isPackedArray(typ) || isBasicType(kind) || isBasicTypeArray(typ, kind):
+		utils, err := cachedSSZUtils(typ)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, paddedSize(determineSize(val)))
+		if _, err := utils.marshaler(val, buf, 0); err != nil {
+			return nil, err
+		}
+		return buildChunksNode(buf, uint64(len(buf)/BytesPerChunk))
+	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
+		utils, err := cachedSSZUtils(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		elemSize := staticFixedSize(typ.Elem())
+		limit := (maxCapacity*elemSize + 31) / 32
+		if limit == 0 {
+			limit = 1
+		}
+		buf := make([]byte, paddedSize(uint64(val.Len())*elemSize))
+		index := uint64(0)
+		for i := 0; i < val.Len(); i++ {
+			if index, err = utils.marshaler(val.Index(i), buf, index); err != nil {
+				return nil, err
+			}
+		}
+		contents, err := buildChunksNode(buf, limit)
+		if err != nil {
+			return nil, err
+		}
+		return mixInLengthNode(contents, uint64(val.Len())), nil
+	case kind == reflect.Slice && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()):
+		limit := maxCapacity
+		if limit == 0 {
+			limit = 1
+		}
+		contents, err := buildElementsNode(val, typ.Elem(), innerLimits, limit)
+		if err != nil {
+			return nil, err
+		}
+		return mixInLengthNode(contents, uint64(val.Len())), nil
+	case kind == reflect.Array && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()):
+		return buildElementsNode(val, typ.Elem(), innerLimits, uint64(val.Len()))
+	case kind == reflect.Slice:
+		limit := maxCapacity
+		if maxCapacity == 0 {
+			limit = uint64(val.Len())
+		}
+		contents, err := buildElementsNode(val, typ.Elem(), innerLimits, limit)
+		if err != nil {
+			return nil, err
+		}
+		return mixInLengthNode(contents, uint64(val.Len())), nil
+	case kind == reflect.Array:
+		return buildElementsNode(val, typ.Elem(), innerLimits, uint64(val.Len()))
+	case kind == reflect.Struct:
+		return buildStructNode(val, typ)
+	case kind == reflect.Ptr:
+		elem, err := pointee(val)
+		if err != nil {
+			return nil, err
+		}
+		return buildNode(elem, typ.Elem(), maxCapacity, innerLimits)
+	default:
+		return nil, errorf(ErrUnsupportedType, "type %v is not hashable", typ)
+	}
+}
+
+func buildBitlistNode(val reflect.Value, maxCapacity uint64) (*Node, error) {
+	limit := (maxCapacity + 255) / 256
+	if val.IsNil() {
+		contents, err := buildSubtree(nil, limit)
+		if err != nil {
+			return nil, err
+		}
+		return mixInLengthNode(contents, 0), nil
+	}
+	if err := validateBitlist(val.Bytes(), maxCapacity); err != nil {
+		return nil, err
+	}
+	bfield := val.Interface().(Bitfield)
+	chunks, err := pack([][]byte{bfield.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]*Node, len(chunks))
+	for i, chunk := range chunks {
+		leaves[i] = NewLeaf(toBytes32(chunk))
+	}
+	contents, err := buildSubtree(leaves, limit)
+	if err != nil {
+		return nil, err
+	}
+	return mixInLengthNode(contents, bfield.Len()), nil
+}
+
+// buildChunksNode builds the subtree of the chunks backed by buf, whose length
+// must be a multiple of BytesPerChunk, padded with zero chunks up to limit.
+func buildChunksNode(buf []byte, limit uint64) (*Node, error) {
+	leaves := make([]*Node, len(buf)/BytesPerChunk)
+	for i := range leaves {
+		leaves[i] = NewLeaf(toBytes32(buf[i*BytesPerChunk:]))
+	}
+	return buildSubtree(leaves, limit)
+}
+
+// buildElementsNode builds the subtree whose leaves are the trees of the
+// elements of val, padded with zero chunks up to limit. The lists nested
+// within the elements are bounded by limits.
+func buildElementsNode(val reflect.Value, elemType reflect.Type, limits []uint64, limit uint64) (*Node, error) {
+	elemCapacity, innerLimits := splitLimits(limits)
+	leaves := make([]*Node, val.Len())
+	for i := range leaves {
+		n, err := buildNode(val.Index(i), elemType, elemCapacity, innerLimits)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		leaves[i] = n
+	}
+	return buildSubtree(leaves, limit)
+}
+
+func buildStructNode(val reflect.Value, typ reflect.Type) (*Node, error) {
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]*Node, len(fields))
+	for i, f := range fields {
+		fieldVal := fieldValue(val, f)
+		var n *Node
+		switch {
+		case f.progressive:
+			return nil, errorf(ErrUnsupportedType, "backing trees do not support progressive field %s", f.name)
+		case f.opaque != nil:
+			encoded, err := f.opaque.Encode(fieldVal.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode opaque field %s: %w", f.name, err)
+			}
+			n, err = buildNode(reflect.ValueOf(encoded), opaqueType, f.capacity, nil)
+			if err != nil {
+				return nil, err
+			}
+		case isBitlist(f.typ):
+			n, err = buildBitlistNode(fieldVal, f.capacity)
+		default:
+			n, err = buildNode(fieldVal, f.typ, f.capacity, f.innerLimits)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tree of field %s of struct: %w", f.name, err)
+		}
+		leaves[i] = n
+	}
+	return buildSubtree(leaves, uint64(len(fields)))
+}
+
+// buildSubtree builds the tree whose leaves are the given nodes, padded with
+// zero subtrees up to limit, following the rules of bitwiseMerkleize.
+func buildSubtree(leaves []*Node, limit uint64) (*Node, error) {
+	count := uint64(len(leaves))
+	if count > limit {
+		return nil, errorf(ErrMaxLength, "chunk count = %d cannot be greater than padding = %d", count, limit)
+	}
+	if limit == 0 {
+		return zeroNodes[0], nil
+	}
+	depth := bitLength(limit - 1)
+	if count == 0 {
+		return zeroNodes[depth], nil
+	}
+	layer := leaves
+	for d := uint64(0); d < depth; d++ {
+		next := make([]*Node, (len(layer)+1)/2)
+		for i := range next {
+			right := zeroNodes[d]
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			next[i] = NewBranch(layer[2*i], right)
+		}
+		layer = next
+	}
+	return layer[0], nil
+}
+
+// mixInLengthNode returns the branch mixing the length of a list into the root of its contents.
+func mixInLengthNode(contents *Node, length uint64) *Node {
+	return NewBranch(contents, NewLeaf(lengthChunk(length)))
+}
@@ -0,0 +1,329 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+type treeContainer struct {
+	Slot        uint64
+	Forks       []fork     `ssz-max:"16"`
+	Balances    []uint64   `ssz-max:"1024"`
+	Roots       [][32]byte `ssz-max:"8"`
+	Custody     [4]uint64
+	Pointer     *fork
+	Nil         *fork
+	Bits        bitfield.Bitlist `ssz-max:"2048"`
+	Fixed       [2]fork
+	Vectors     [3][32]byte
+	Tagged      []byte      `ssz-size:"32"`
+	Opaque      opaqueWords `ssz:"opaque" ssz-max:"16"`
+	EmptyForks  []fork
+	EmptyBits   bitfield.Bitlist `ssz-max:"8"`
+	Flag        bool
+	ShortVector [5]uint16
+}
+
+func newTreeContainer() treeContainer {
+	bits := bitfield.NewBitlist(300)
+	bits.SetBitAt(3, true)
+	bits.SetBitAt(299, true)
+	return treeContainer{
+		Slot:        9,
+		Forks:       []fork{{Epoch: 1}, {PreviousVersion: [4]byte{1}, Epoch: 2}, {Epoch: 3}},
+		Balances:    []uint64{32, 31, 30, 29, 28, 27},
+		Roots:       [][32]byte{{1}, {2}},
+		Custody:     [4]uint64{1, 2, 3, 4},
+		Pointer:     &fork{Epoch: 5},
+		Bits:        bits,
+		Fixed:       [2]fork{{Epoch: 6}, {Epoch: 7}},
+		Vectors:     [3][32]byte{{3}, {4}, {5}},
+		Tagged:      make([]byte, 32),
+		Opaque:      opaqueWords{"foo"},
+		Flag:        true,
+		ShortVector: [5]uint16{1, 2, 3, 4, 5},
+	}
+}
+
+func TestNewTree_RootMatchesHashTreeRoot(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	values := []interface{}{
+		newTreeContainer(),
+		fork{Epoch: 3},
+		&fork{Epoch: 4},
+		[4]uint64{1, 2, 3, 4},
+		[5]uint64{1, 2, 3, 4, 5},
+		[0]uint64{},
+		[3][32]byte{{1}, {2}, {3}},
+		[2]fork{{Epoch: 1}, {Epoch: 2}},
+		uint64(7),
+		true,
+		accountBalances{Balances: []uint64{1, 2, 3}},
+		nilItem{Field1: []*fork{nil, {Epoch: 1}}, Field2: 10},
+	}
+	for _, v := range values {
+		want, err := HashTreeRoot(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := NewTree(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tree.Root(); got != want {
+			t.Errorf("Expected tree root %#x for %T, received %#x", want, v, got)
+		}
+	}
+}
+
+// balanceChunkIndex returns the generalized index of the chunk holding balance i
+// in a treeContainer: the Balances field is the third of 16 fields, and its
+// contents are 256 chunks deep.
+func balanceChunkIndex(i uint64) uint64 {
+	fieldIndex := uint64(16 + 2)
+	contentsIndex := fieldIndex * 2
+	return contentsIndex<<8 + i/4
+}
+
+func TestTree_SetLeaf(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, balance := range []uint64{100, 200, 300} {
+		item.Balances[i*2] = balance
+		chunkIndex := balanceChunkIndex(uint64(i * 2))
+		chunk, err := tree.Leaf(chunkIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.LittleEndian.PutUint64(chunk[(i*2%4)*8:], balance)
+		if err := tree.SetLeaf(chunkIndex, chunk); err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tree.Root(); got != want {
+			t.Errorf("Expected root %#x after setting balance %d, received %#x", want, i*2, got)
+		}
+	}
+}
+
+func TestTree_SetInsideZeroSubtree(t *testing.T) {
+	item := newTreeContainer()
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := tree.Root()
+	zeroRoots := make([][32]byte, len(zeroNodes))
+	for i, n := range zeroNodes {
+		zeroRoots[i] = n.Root()
+	}
+	// Chunk 100 of the balances lies within the zero padding of the list.
+	if err := tree.SetLeaf(balanceChunkIndex(400), [32]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() == original {
+		t.Error("Expected root to change")
+	}
+	if err := tree.SetLeaf(balanceChunkIndex(400), [32]byte{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Root(); got != original {
+		t.Errorf("Expected root %#x once the chunk is reset, received %#x", original, got)
+	}
+	for i, n := range zeroNodes {
+		if n.Root() != zeroRoots[i] || n.dirty || n.owner != sharedOwner {
+			t.Fatalf("Expected shared zero subtree %d to be left untouched", i)
+		}
+	}
+}
+
+func TestTree_InvalidIndices(t *testing.T) {
+	tree, err := NewTree(fork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Get(0); err == nil {
+		t.Error("Expected error for generalized index 0")
+	}
+	if err := tree.SetLeaf(0, [32]byte{}); err == nil {
+		t.Error("Expected error for generalized index 0")
+	}
+	// The fork container has 3 fields, so its leaves are at depth 2.
+	if _, err := tree.Get(4 << 1); err == nil {
+		t.Error("Expected error for generalized index below a leaf")
+	}
+	if err := tree.SetLeaf(4<<1, [32]byte{}); err == nil {
+		t.Error("Expected error for generalized index below a leaf")
+	}
+	if err := tree.Set(4, nil); err == nil {
+		t.Error("Expected error for nil node")
+	}
+}
+
+func TestTree_SetSubtree(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.Pointer = &fork{Epoch: 50}
+	pointerTree, err := NewTree(item.Pointer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set(16+5, pointerTree.RootNode()); err != nil {
+		t.Fatal(err)
+	}
+	want, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Root(); got != want {
+		t.Errorf("Expected root %#x, received %#x", want, got)
+	}
+}
+
+func BenchmarkTree_SetBalanceAndRoot(b *testing.B) {
+	item := accountBalances{Balances: make([]uint64, 100000)}
+	tree, err := NewTree(item)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// The balances container has a single field, whose contents are 2^38 chunks deep.
+	contentsIndex := uint64(2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if err := tree.SetLeaf(contentsIndex<<38+uint64(n%25000), [32]byte{byte(n)}); err != nil {
+			b.Fatal(err)
+		}
+		tree.Root()
+	}
+}
+
+func BenchmarkHashTreeRoot_Balances(b *testing.B) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := accountBalances{Balances: make([]uint64, 100000)}
+	for n := 0; n < b.N; n++ {
+		item.Balances[n%100000] = uint64(n)
+		if _, err := HashTreeRoot(item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTree_Copy(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	original, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalRoot := original.Root()
+	variant := original.Copy()
+	if variant.Root() != originalRoot {
+		t.Fatal("Expected copy to have the same root")
+	}
+
+	chunkIndex := balanceChunkIndex(0)
+	chunk, err := variant.Leaf(chunkIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint64(chunk[:8], 1000)
+	if err := variant.SetLeaf(chunkIndex, chunk); err != nil {
+		t.Fatal(err)
+	}
+	modified := item
+	modified.Balances = append([]uint64{1000}, item.Balances[1:]...)
+	want, err := HashTreeRoot(modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := variant.Root(); got != want {
+		t.Errorf("Expected copy root %#x, received %#x", want, got)
+	}
+	if got := original.Root(); got != originalRoot {
+		t.Errorf("Expected original root %#x to be unchanged, received %#x", originalRoot, got)
+	}
+
+	// Only the nodes on the path to the modified leaf are copied.
+	sameSlot, err := original.Get(16 + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variantSlot, err := variant.Get(16 + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sameSlot != variantSlot {
+		t.Error("Expected unmodified subtree to be shared")
+	}
+	originalBalances, err := original.Get(16 + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variantBalances, err := variant.Get(16 + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if originalBalances == variantBalances {
+		t.Error("Expected modified subtree to be copied")
+	}
+
+	// Modifying the original leaves the copy untouched.
+	if err := original.SetLeaf(16, [32]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := variant.Root(); got != want {
+		t.Errorf("Expected copy root %#x to be unchanged, received %#x", want, got)
+	}
+}
+
+func TestTree_CopiesUsedConcurrently(t *testing.T) {
+	tree, err := NewTree(accountBalances{Balances: make([]uint64, 1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies := make([]*Tree, 8)
+	for i := range copies {
+		copies[i] = tree.Copy()
+	}
+	var wg sync.WaitGroup
+	roots := make([][32]byte, len(copies))
+	for i := range copies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := copies[i].SetLeaf(uint64(2)<<38+uint64(j), [32]byte{byte(i)}); err != nil {
+					t.Error(err)
+					return
+				}
+				roots[i] = copies[i].Root()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 1; i < len(roots); i++ {
+		if roots[i] == roots[0] {
+			t.Errorf("Expected copies %d and 0 to have different roots", i)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package ssz
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Hints tune how the values of a specific type are hashed, without changing
+// the resulting roots.
+type Hints struct {
+	// NoCache skips the root cache for values of the type, which avoids
+	// the cost of generating cache keys for values which rarely repeat.
+	NoCache bool
+	// Parallel hashes the elements of a list or vector, or the fields of a
+	// struct, concurrently.
+	Parallel bool
+}
+
+var (
+	typeHintsLock sync.RWMutex
+	typeHints     = make(map[reflect.Type]Hints)
+	// hasTypeHints lets the hot paths skip looking up hints when none are set.
+	hasTypeHints int32
+)
+
+// SetTypeHints registers hints tuning how values of type typ are hashed, replacing
+// any hints previously registered for it. Setting the zero value of Hints restores
+// the default behavior.
+//
+//  ssz.SetTypeHints(reflect.TypeOf(BeaconState{}), ssz.Hints{Parallel: true})
+//  ssz.SetTypeHints(reflect.TypeOf(Attestation{}), ssz.Hints{NoCache: true})
+func SetTypeHints(typ reflect.Type, hints Hints) {
+	typeHintsLock.Lock()
+	defer typeHintsLock.Unlock()
+	if hints == (Hints{}) {
+		delete(typeHints, typ)
+	} else {
+		typeHints[typ] = hints
+	}
+	if len(typeHints) > 0 {
+		atomic.StoreInt32(&hasTypeHints, 1)
+	} else {
+		atomic.StoreInt32(&hasTypeHints, 0)
+	}
+}
+
+// parallelFieldsThreshold is the number of fields from which the fields of structs
+// are hashed concurrently, zero disabling it.
+var parallelFieldsThreshold int32
+
+// SetParallelFieldHashing hashes the fields of structs holding at least minFields
+// fields concurrently, as with the Parallel hint, without registering hints for every
+// type. Field roots are independent, so that large containers such as BeaconState hash
+// faster on multiple cores. Fields of structs already hashed concurrently are hashed
+// sequentially, to keep the number of goroutines bounded. Zero, the default, disables it.
+//
+//  ssz.SetParallelFieldHashing(16)
+func SetParallelFieldHashing(minFields int) {
+	if minFields < 0 {
+		minFields = 0
+	}
+	atomic.StoreInt32(&parallelFieldsThreshold, int32(minFields))
+}
+
+// parallelFields reports whether h should hash the n fields of a struct of type
+// typ concurrently.
+func parallelFields(h *Hasher, typ reflect.Type, n int) bool {
+	if hintsFor(typ).Parallel {
+		return true
+	}
+	threshold := atomic.LoadInt32(&parallelFieldsThreshold)
+	return threshold > 0 && n >= int(threshold) && !h.worker
+}
+
+// hintsFor returns the hints registered for typ, if any.
+func hintsFor(typ reflect.Type) Hints {
+	if atomic.LoadInt32(&hasTypeHints) == 0 {
+		return Hints{}
+	}
+	typeHintsLock.RLock()
+	defer typeHintsLock.RUnlock()
+	return typeHints[typ]
+}
+
+// parallelFor calls fn for every index in [0, n) using up to GOMAXPROCS goroutines,
+// each with its own hasher hashing like parent does, or with the default backend
+// when parent is nil, returning the first error encountered. A panic raised by fn
+// is raised again by parallelFor once the goroutines are done, so that it unwinds
+// the stack of the caller rather than crashing the program.
+func parallelFor(parent *Hasher, n int, fn func(h *Hasher, i int) error) error {
+	f := sha256Functions[PooledSHA256]
+	if parent != nil {
+		f = parent.fn
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	var next int64 = -1
+	var firstErr error
+	var errOnce sync.Once
+	var panicked interface{}
+	var panicOnce sync.Once
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					panicOnce.Do(func() {
+						panicked = r
+					})
+					// The other goroutines stop at their next index.
+					atomic.StoreInt64(&next, int64(n))
+				}
+			}()
+			h := acquireHasher()
+			h.worker = true
+			h.setHashFunction(f)
+			defer func() {
+				h.worker = false
+				h.setHashFunction(sha256Functions[PooledSHA256])
+				releaseHasher(h)
+			}()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				if err := fn(h, i); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+	return firstErr
+}
@@ -0,0 +1,103 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+type hintedContainer struct {
+	Forks    []fork
+	Balances []uint64 `ssz-max:"1024"`
+	Epoch    uint64
+}
+
+func TestSetTypeHints_SameRoots(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := hintedContainer{
+		Forks:    []fork{{Epoch: 1}, {Epoch: 2}, {Epoch: 3}},
+		Balances: []uint64{1, 2, 3},
+		Epoch:    4,
+	}
+	want, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTypeHints(reflect.TypeOf(item), Hints{Parallel: true})
+	SetTypeHints(reflect.TypeOf(item.Forks), Hints{Parallel: true})
+	defer SetTypeHints(reflect.TypeOf(item), Hints{})
+	defer SetTypeHints(reflect.TypeOf(item.Forks), Hints{})
+	for _, cache := range []bool{false, true} {
+		useCache = cache
+		got, err := HashTreeRoot(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Expected parallel hashing root %#x to match %#x", got, want)
+		}
+	}
+}
+
+func TestSetTypeHints_NoCache(t *testing.T) {
+	useCache = true
+	defer SetRootCache(nil)
+	SetRootCache(newHashCache(100000))
+	if _, err := HashTreeRoot(fork{Epoch: 8}); err != nil {
+		t.Fatal(err)
+	}
+	unhinted := CacheStats().Entries
+
+	SetRootCache(newHashCache(100000))
+	SetTypeHints(reflect.TypeOf(fork{}), Hints{NoCache: true})
+	defer SetTypeHints(reflect.TypeOf(fork{}), Hints{})
+	if _, err := HashTreeRoot(fork{Epoch: 8}); err != nil {
+		t.Fatal(err)
+	}
+	// Only the root of the struct itself skips the cache, its fields are still cached.
+	if entries := CacheStats().Entries; entries != unhinted-1 {
+		t.Errorf("Expected %d cache entries, got %d", unhinted-1, entries)
+	}
+}
+
+func TestSetTypeHints_ZeroValueRemovesHints(t *testing.T) {
+	typ := reflect.TypeOf(fork{})
+	SetTypeHints(typ, Hints{Parallel: true})
+	if !hintsFor(typ).Parallel {
+		t.Error("Expected hints to be registered")
+	}
+	SetTypeHints(typ, Hints{})
+	if hintsFor(typ) != (Hints{}) {
+		t.Errorf("Expected hints to be removed, got %+v", hintsFor(typ))
+	}
+}
+
+func TestSetParallelFieldHashing(t *testing.T) {
+	useCache = false
+	defer func() { useCache = true }()
+	item := newTreeContainer()
+	want, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetParallelFieldHashing(4)
+	defer SetParallelFieldHashing(0)
+	h := NewHasher()
+	if !parallelFields(h, reflect.TypeOf(item), 16) {
+		t.Error("Expected the fields of large structs to be hashed concurrently")
+	}
+	if parallelFields(h, reflect.TypeOf(fork{}), 3) {
+		t.Error("Expected the fields of small structs to be hashed sequentially")
+	}
+	h.worker = true
+	if parallelFields(h, reflect.TypeOf(item), 16) {
+		t.Error("Expected the fields of nested structs to be hashed sequentially")
+	}
+	got, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Expected parallel hashing root %#x to match %#x", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package types
+
+// Mainnet sizes and limits of the blob containers, which the tags of the containers of
+// the package repeat.
+const (
+	BytesPerFieldElement              = 32
+	FieldElementsPerBlob              = 4096
+	BytesPerBlob                      = BytesPerFieldElement * FieldElementsPerBlob
+	BytesPerCommitment                = 48
+	BytesPerProof                     = 48
+	MaxBlobCommitmentsPerBlock        = 4096
+	KZGCommitmentInclusionProofDepth  = 17
+	FieldElementsPerCell              = 64
+	BytesPerCell                      = BytesPerFieldElement * FieldElementsPerCell
+	NumberOfColumns                   = 128
+	KZGCommitmentsInclusionProofDepth = 4
+)
+
+// BeaconBlockHeader is the header of a beacon block, in which its body is replaced by
+// its root.
+type BeaconBlockHeader struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    []byte `ssz-size:"32"`
+	StateRoot     []byte `ssz-size:"32"`
+	BodyRoot      []byte `ssz-size:"32"`
+}
+
+// SignedBeaconBlockHeader is a beacon block header signed by its proposer.
+type SignedBeaconBlockHeader struct {
+	Message   *BeaconBlockHeader
+	Signature []byte `ssz-size:"96"`
+}
+
+// BlobSidecar is a blob of a beacon block along with the proof of its KZG commitment
+// against the body of the block, as of Deneb.
+type BlobSidecar struct {
+	Index                       uint64
+	Blob                        []byte `ssz-size:"131072"`
+	KZGCommitment               []byte `ssz-size:"48"`
+	KZGProof                    []byte `ssz-size:"48"`
+	SignedBlockHeader           *SignedBeaconBlockHeader
+	KZGCommitmentInclusionProof [][]byte `ssz-size:"17,32"`
+}
+
+// BlobIdentifier identifies a blob sidecar by the root of its block and its index, as
+// requested by BlobSidecarsByRoot, as of Deneb.
+type BlobIdentifier struct {
+	BlockRoot []byte `ssz-size:"32"`
+	Index     uint64
+}
+
+// DataColumnSidecar is a column of the extended blobs of a beacon block along with the
+// proof of their KZG commitments against the body of the block, as of Fulu.
+type DataColumnSidecar struct {
+	Index                        uint64
+	Column                       [][]byte `ssz-size:"?,2048" ssz-max:"4096"`
+	KZGCommitments               [][]byte `ssz-size:"?,48" ssz-max:"4096"`
+	KZGProofs                    [][]byte `ssz-size:"?,48" ssz-max:"4096"`
+	SignedBlockHeader            *SignedBeaconBlockHeader
+	KZGCommitmentsInclusionProof [][]byte `ssz-size:"4,32"`
+}
+
+// DataColumnsByRootIdentifier identifies data column sidecars by the root of their
+// block and their indices, as requested by DataColumnSidecarsByRoot, as of Fulu.
+type DataColumnsByRootIdentifier struct {
+	BlockRoot []byte   `ssz-size:"32"`
+	Columns   []uint64 `ssz-max:"128"`
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+func TestBlobTypes_Sizes(t *testing.T) {
+	tests := map[reflect.Type]uint64{
+		// The index, blob, commitment, proof, signed header and inclusion proof.
+		reflect.TypeOf(BlobSidecar{}):             8 + BytesPerBlob + 48 + 48 + 208 + KZGCommitmentInclusionProofDepth*32,
+		reflect.TypeOf(BlobIdentifier{}):          40,
+		reflect.TypeOf(SignedBeaconBlockHeader{}): 208,
+	}
+	for typ, want := range tests {
+		desc, err := ssz.Describe(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.Variable || desc.Size != want {
+			t.Errorf("Expected %v to have a fixed size of %d, received %+v", typ, want, desc)
+		}
+	}
+}
+
+func TestBlobTypes_Limits(t *testing.T) {
+	sidecar := reflect.TypeOf(BlobSidecar{})
+	column := reflect.TypeOf(DataColumnSidecar{})
+	cells := describeField(t, column, "Column")
+	tests := map[string]struct {
+		got, want uint64
+	}{
+		"blob":              {describeField(t, sidecar, "Blob").Length, BytesPerBlob},
+		"commitment":        {describeField(t, sidecar, "KZGCommitment").Length, BytesPerCommitment},
+		"proof":             {describeField(t, sidecar, "KZGProof").Length, BytesPerProof},
+		"inclusion proof":   {describeField(t, sidecar, "KZGCommitmentInclusionProof").Length, KZGCommitmentInclusionProofDepth},
+		"cells":             {cells.Limit, MaxBlobCommitmentsPerBlock},
+		"cell":              {cells.Elem.Length, BytesPerCell},
+		"commitments":       {describeField(t, column, "KZGCommitments").Limit, MaxBlobCommitmentsPerBlock},
+		"proofs":            {describeField(t, column, "KZGProofs").Limit, MaxBlobCommitmentsPerBlock},
+		"column proof":      {describeField(t, column, "KZGCommitmentsInclusionProof").Length, KZGCommitmentsInclusionProofDepth},
+		"requested columns": {describeField(t, reflect.TypeOf(DataColumnsByRootIdentifier{}), "Columns").Limit, NumberOfColumns},
+	}
+	for name, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %d, received %d", name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestBlobSidecar_RoundTrip(t *testing.T) {
+	want := &BlobSidecar{
+		Index:         3,
+		Blob:          bytes.Repeat([]byte{0x5a}, BytesPerBlob),
+		KZGCommitment: bytes.Repeat([]byte{1}, BytesPerCommitment),
+		KZGProof:      bytes.Repeat([]byte{2}, BytesPerProof),
+		SignedBlockHeader: &SignedBeaconBlockHeader{
+			Message: &BeaconBlockHeader{
+				Slot:       9,
+				ParentRoot: make([]byte, 32),
+				StateRoot:  make([]byte, 32),
+				BodyRoot:   make([]byte, 32),
+			},
+			Signature: make([]byte, 96),
+		},
+		KZGCommitmentInclusionProof: make([][]byte, KZGCommitmentInclusionProofDepth),
+	}
+	for i := range want.KZGCommitmentInclusionProof {
+		want.KZGCommitmentInclusionProof[i] = bytes.Repeat([]byte{byte(i)}, 32)
+	}
+	encoded, err := ssz.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &BlobSidecar{}
+	if err := ssz.Unmarshal(encoded, got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Expected the blob sidecar to round trip")
+	}
+}
@@ -0,0 +1,124 @@
+// Package types holds reference definitions of the execution and blob containers of
+// the consensus specs, tagged with the mainnet sizes and limits of the specs, so that
+// they can be used as is or as known-good templates of the tags of other definitions.
+// Containers which changed across forks are defined once, with the fields added by
+// later forks tagged with ssz-fork, and are encoded and hashed as of a fork with the
+// codec of the fork:
+//
+//  codec, err := ssz.ForFork("capella")
+//  if err != nil {
+//      return err
+//  }
+//  payload := &types.ExecutionPayload{}
+//  if err := codec.Unmarshal(encoded, payload); err != nil {
+//      return err
+//  }
+//  root, err := codec.HashTreeRoot(payload)
+//
+// Marshal, Unmarshal and HashTreeRoot of the ssz package treat them as of their latest
+// fork.
+package types
+
+import (
+	"math/big"
+)
+
+// Mainnet sizes and limits of the execution containers, which the tags of the
+// containers of the package repeat.
+const (
+	BytesPerLogsBloom                  = 256
+	MaxExtraDataBytes                  = 32
+	MaxBytesPerTransaction             = 1073741824
+	MaxTransactionsPerPayload          = 1048576
+	MaxWithdrawalsPerPayload           = 16
+	MaxDepositRequestsPerPayload       = 8192
+	MaxWithdrawalRequestsPerPayload    = 16
+	MaxConsolidationRequestsPerPayload = 2
+)
+
+// Withdrawal is a withdrawal of the balance of a validator to an execution address, as
+// of Capella.
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        []byte `ssz-size:"20"`
+	Amount         uint64
+}
+
+// ExecutionPayload is the execution block of a beacon block, as of Bellatrix. Its base
+// fee per gas is a uint256, and nil base fees are encoded as zero.
+type ExecutionPayload struct {
+	ParentHash    []byte `ssz-size:"32"`
+	FeeRecipient  []byte `ssz-size:"20"`
+	StateRoot     []byte `ssz-size:"32"`
+	ReceiptsRoot  []byte `ssz-size:"32"`
+	LogsBloom     []byte `ssz-size:"256"`
+	PrevRandao    []byte `ssz-size:"32"`
+	BlockNumber   uint64
+	GasLimit      uint64
+	GasUsed       uint64
+	Timestamp     uint64
+	ExtraData     []byte `ssz-max:"32"`
+	BaseFeePerGas *big.Int
+	BlockHash     []byte        `ssz-size:"32"`
+	Transactions  [][]byte      `ssz-max:"1048576,1073741824"`
+	Withdrawals   []*Withdrawal `ssz-fork:"capella" ssz-max:"16"`
+	BlobGasUsed   uint64        `ssz-fork:"deneb"`
+	ExcessBlobGas uint64        `ssz-fork:"deneb"`
+}
+
+// ExecutionPayloadHeader is the header of an execution payload held by beacon states,
+// in which transactions and withdrawals are replaced by their roots, as of Bellatrix.
+type ExecutionPayloadHeader struct {
+	ParentHash       []byte `ssz-size:"32"`
+	FeeRecipient     []byte `ssz-size:"20"`
+	StateRoot        []byte `ssz-size:"32"`
+	ReceiptsRoot     []byte `ssz-size:"32"`
+	LogsBloom        []byte `ssz-size:"256"`
+	PrevRandao       []byte `ssz-size:"32"`
+	BlockNumber      uint64
+	GasLimit         uint64
+	GasUsed          uint64
+	Timestamp        uint64
+	ExtraData        []byte `ssz-max:"32"`
+	BaseFeePerGas    *big.Int
+	BlockHash        []byte `ssz-size:"32"`
+	TransactionsRoot []byte `ssz-size:"32"`
+	WithdrawalsRoot  []byte `ssz-fork:"capella" ssz-size:"32"`
+	BlobGasUsed      uint64 `ssz-fork:"deneb"`
+	ExcessBlobGas    uint64 `ssz-fork:"deneb"`
+}
+
+// DepositRequest is a deposit of the deposit contract processed by the execution
+// layer, as of Electra.
+type DepositRequest struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+	Signature             []byte `ssz-size:"96"`
+	Index                 uint64
+}
+
+// WithdrawalRequest is a withdrawal triggered from the execution layer by the
+// withdrawal address of a validator, as of Electra.
+type WithdrawalRequest struct {
+	SourceAddress   []byte `ssz-size:"20"`
+	ValidatorPubkey []byte `ssz-size:"48"`
+	Amount          uint64
+}
+
+// ConsolidationRequest is a consolidation of the balance of a validator into another
+// one, requested from the execution layer, as of Electra.
+type ConsolidationRequest struct {
+	SourceAddress []byte `ssz-size:"20"`
+	SourcePubkey  []byte `ssz-size:"48"`
+	TargetPubkey  []byte `ssz-size:"48"`
+}
+
+// ExecutionRequests are the requests of the execution layer of a beacon block body, as
+// of Electra.
+type ExecutionRequests struct {
+	Deposits       []*DepositRequest       `ssz-max:"8192"`
+	Withdrawals    []*WithdrawalRequest    `ssz-max:"16"`
+	Consolidations []*ConsolidationRequest `ssz-max:"2"`
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-ssz"
+)
+
+// describeField returns the descriptor of the field name of the container typ.
+func describeField(t *testing.T, typ reflect.Type, name string) *ssz.TypeDescriptor {
+	t.Helper()
+	desc, err := ssz.Describe(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range desc.Fields {
+		if f.Name == name {
+			return f.Type
+		}
+	}
+	t.Fatalf("%v has no field %s", typ, name)
+	return nil
+}
+
+func TestExecutionPayload_ForkSizes(t *testing.T) {
+	tests := []struct {
+		fork                    string
+		payloadSize, headerSize uint64
+	}{
+		{"bellatrix", 508, 536},
+		{"capella", 512, 568},
+		{"deneb", 528, 584},
+		{"electra", 528, 584},
+	}
+	for _, tt := range tests {
+		codec, err := ssz.ForFork(tt.fork)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for typ, want := range map[reflect.Type]uint64{
+			reflect.TypeOf(ExecutionPayload{}):       tt.payloadSize,
+			reflect.TypeOf(ExecutionPayloadHeader{}): tt.headerSize,
+		} {
+			forkType, err := codec.Type(typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			desc, err := ssz.Describe(forkType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if desc.MinSize != want {
+				t.Errorf("%s: expected %v to have a minimum size of %d, received %d", tt.fork, typ, want, desc.MinSize)
+			}
+		}
+	}
+}
+
+func TestExecutionTypes_Limits(t *testing.T) {
+	payload := reflect.TypeOf(ExecutionPayload{})
+	requests := reflect.TypeOf(ExecutionRequests{})
+	transactions := describeField(t, payload, "Transactions")
+	tests := map[string]struct {
+		got, want uint64
+	}{
+		"logs bloom":      {describeField(t, payload, "LogsBloom").Length, BytesPerLogsBloom},
+		"extra data":      {describeField(t, payload, "ExtraData").Limit, MaxExtraDataBytes},
+		"transactions":    {transactions.Limit, MaxTransactionsPerPayload},
+		"transaction":     {transactions.Elem.Limit, MaxBytesPerTransaction},
+		"withdrawals":     {describeField(t, payload, "Withdrawals").Limit, MaxWithdrawalsPerPayload},
+		"deposits":        {describeField(t, requests, "Deposits").Limit, MaxDepositRequestsPerPayload},
+		"withdrawal reqs": {describeField(t, requests, "Withdrawals").Limit, MaxWithdrawalRequestsPerPayload},
+		"consolidations":  {describeField(t, requests, "Consolidations").Limit, MaxConsolidationRequestsPerPayload},
+	}
+	for name, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected a limit of %d, received %d", name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestExecutionPayload_RoundTrip(t *testing.T) {
+	codec, err := ssz.ForFork("capella")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ExecutionPayload{
+		ParentHash:    make([]byte, 32),
+		FeeRecipient:  make([]byte, 20),
+		StateRoot:     make([]byte, 32),
+		ReceiptsRoot:  make([]byte, 32),
+		LogsBloom:     make([]byte, BytesPerLogsBloom),
+		PrevRandao:    make([]byte, 32),
+		BlockNumber:   17034870,
+		ExtraData:     []byte("builder"),
+		BaseFeePerGas: big.NewInt(27000000000),
+		BlockHash:     make([]byte, 32),
+		Transactions:  [][]byte{{0x02, 0xf8}, {}},
+		Withdrawals:   []*Withdrawal{{Index: 1, ValidatorIndex: 2, Address: make([]byte, 20), Amount: 3}},
+		BlobGasUsed:   131072,
+	}
+	encoded, err := codec.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &ExecutionPayload{}
+	if err := codec.Unmarshal(encoded, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.BaseFeePerGas.Cmp(want.BaseFeePerGas) != 0 {
+		t.Errorf("Expected base fee %v, received %v", want.BaseFeePerGas, got.BaseFeePerGas)
+	}
+	// Blob gas is not part of Capella payloads.
+	want.BaseFeePerGas, got.BaseFeePerGas = nil, nil
+	want.BlobGasUsed = 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, received %+v", want, got)
+	}
+}
+
+func TestWithdrawal_HashTreeRoot(t *testing.T) {
+	w := &Withdrawal{Index: 1, ValidatorIndex: 2, Address: make([]byte, 20), Amount: 3}
+	for i := range w.Address {
+		w.Address[i] = 0xaa
+	}
+	root, err := ssz.HashTreeRoot(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The four fields are the leaves of a tree of depth 2.
+	chunks := make([]byte, 4*32)
+	binary.LittleEndian.PutUint64(chunks[0:], w.Index)
+	binary.LittleEndian.PutUint64(chunks[32:], w.ValidatorIndex)
+	copy(chunks[64:], w.Address)
+	binary.LittleEndian.PutUint64(chunks[96:], w.Amount)
+	left := sha256.Sum256(chunks[:64])
+	right := sha256.Sum256(chunks[64:])
+	if want := sha256.Sum256(append(left[:], right[:]...)); root != want {
+		t.Errorf("Expected withdrawal root %#x, received %#x", want, root)
+	}
+}
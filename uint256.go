@@ -0,0 +1,62 @@
+package ssz
+
+import (
+	"math/big"
+	"reflect"
+)
+
+// bigIntType is the type of the values of *big.Int fields, which hold the uint256 values
+// of execution payloads, such as their base fee per gas or their difficulty:
+//
+//  type ExecutionPayload struct {
+//      ParentHash    [32]byte
+//      ...
+//      BaseFeePerGas *big.Int
+//  }
+//
+// They are encoded as 32-byte little-endian integers, and hashed as such, provided that
+// their values range from 0 to 2^256-1. The uint256.Int of github.com/holiman/uint256
+// needs no such support: its 4 little-endian uint64 words are encoded and hashed as a
+// uint256 is.
+var bigIntType = reflect.TypeOf(big.Int{})
+
+func init() {
+	RegisterFixedSizeCodec(bigIntType, 32, marshalBigInt, unmarshalBigInt, hashBigInt)
+}
+
+// uint256Bytes returns the little-endian encoding of x, which must fit in a uint256.
+func uint256Bytes(x *big.Int) ([]byte, error) {
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		return nil, errorf(ErrInvalidValue, "%v is out of the range of uint256", x)
+	}
+	enc := x.FillBytes(make([]byte, 32))
+	for i, j := 0, len(enc)-1; i < j; i, j = i+1, j-1 {
+		enc[i], enc[j] = enc[j], enc[i]
+	}
+	return enc, nil
+}
+
+func marshalBigInt(val interface{}) ([]byte, error) {
+	x := val.(big.Int)
+	return uint256Bytes(&x)
+}
+
+func unmarshalBigInt(data []byte, val interface{}) error {
+	be := make([]byte, len(data))
+	for i := range data {
+		be[len(data)-1-i] = data[i]
+	}
+	val.(*big.Int).SetBytes(be)
+	return nil
+}
+
+func hashBigInt(val interface{}) ([32]byte, error) {
+	x := val.(big.Int)
+	enc, err := uint256Bytes(&x)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	var root [32]byte
+	copy(root[:], enc)
+	return root, nil
+}
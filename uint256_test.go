@@ -0,0 +1,92 @@
+package ssz
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// uint256Int has the layout of the uint256.Int of github.com/holiman/uint256.
+type uint256Int [4]uint64
+
+type bigIntPayload struct {
+	GasUsed       uint64
+	BaseFeePerGas *big.Int
+	Difficulty    big.Int
+	Value         uint256Int
+}
+
+// bigIntEquivalent is the container SSZ sees bigIntPayload as.
+type bigIntEquivalent struct {
+	GasUsed       uint64
+	BaseFeePerGas [32]byte
+	Difficulty    [32]byte
+	Value         [32]byte
+}
+
+func TestUint256Fields(t *testing.T) {
+	baseFee, ok := new(big.Int).SetString("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20", 16)
+	if !ok {
+		t.Fatal("Invalid base fee")
+	}
+	item := bigIntPayload{
+		GasUsed:       7,
+		BaseFeePerGas: baseFee,
+		Difficulty:    *big.NewInt(0x1234),
+		Value:         uint256Int{1, 0, 0, 1 << 63},
+	}
+	equivalent := bigIntEquivalent{GasUsed: 7, Difficulty: [32]byte{0x34, 0x12}}
+	for i := range equivalent.BaseFeePerGas {
+		equivalent.BaseFeePerGas[i] = byte(32 - i)
+	}
+	equivalent.Value[0] = 1
+	equivalent.Value[31] = 0x80
+
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected encoding %#x, received %#x", want, encoded)
+	}
+	root, err := HashTreeRoot(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, err := HashTreeRoot(equivalent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != wantRoot {
+		t.Errorf("Expected root %#x, received %#x", wantRoot, root)
+	}
+	var decoded bigIntPayload
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.BaseFeePerGas.Cmp(baseFee) != 0 || decoded.Difficulty.Cmp(&item.Difficulty) != 0 || decoded.Value != item.Value {
+		t.Errorf("Expected %v, received %v", item, decoded)
+	}
+}
+
+func TestUint256Fields_OutOfRange(t *testing.T) {
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 256)
+	for _, x := range []*big.Int{big.NewInt(-1), tooLarge} {
+		item := bigIntPayload{BaseFeePerGas: x}
+		if _, err := Marshal(item); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expected %v to be out of range, received %v", x, err)
+		}
+		if _, err := HashTreeRoot(item); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expected %v to be out of range, received %v", x, err)
+		}
+	}
+	max := new(big.Int).Sub(tooLarge, big.NewInt(1))
+	if _, err := Marshal(bigIntPayload{BaseFeePerGas: max}); err != nil {
+		t.Errorf("Expected 2^256-1 to be in range, received %v", err)
+	}
+}
@@ -7,6 +7,18 @@ import (
 	"reflect"
 )
 
+var zeroCopy = false
+
+// ToggleZeroCopy allows to programmatically enable/disable zero-copy decoding, where
+// Unmarshal sets byte slices, including those with an ssz-size tag, to sub-slices of
+// its input instead of copying their contents. Decoded values then share their memory
+// with the input: the caller owns both, and must neither modify nor recycle the input
+// while decoded values are in use, as writes to one show up in the other. Byte arrays
+// are values and are always copied. It is disabled by default.
+func ToggleZeroCopy(enableZeroCopy bool) {
+	zeroCopy = enableZeroCopy
+}
+
 // Unmarshal SSZ encoded data and output it into the object pointed by pointer val.
 // Given a struct with the following fields, and some encoded bytes of type []byte,
 // one can then unmarshal the bytes into a pointer of the struct as follows:
@@ -19,7 +31,30 @@ import (
 //  if err := Unmarshal(encodedBytes, &targetStruct); err != nil {
 //      return fmt.Errorf("failed to unmarshal: %v", err)
 //  }
+//
+// Slices and pointers already held by the target are reused when they are large
+// enough, so that decoding repeatedly into the same, or pooled, targets does not
+// reallocate them. Their previous contents are overwritten: they must not be shared
+// with values still in use.
 func Unmarshal(input []byte, val interface{}) error {
+	return UnmarshalWithArena(input, val, nil)
+}
+
+// UnmarshalWithArena unmarshals SSZ encoded data into the object pointed by val like
+// Unmarshal does, allocating the slices and pointers it sets from arena. A nil arena
+// allocates them from the heap, like Unmarshal.
+//
+//  arena := ssz.NewArena()
+//  for _, encoded := range encodedAttestations {
+//      var att Attestation
+//      if err := ssz.UnmarshalWithArena(encoded, &att, arena); err != nil {
+//          return fmt.Errorf("failed to unmarshal: %v", err)
+//      }
+//      process(&att)
+//  }
+//  arena.Reset()
+func UnmarshalWithArena(input []byte, val interface{}, arena *Arena) (err error) {
+	defer recoverPanic(&err)
 	if val == nil {
 		return errors.New("cannot unmarshal into untyped, nil value")
 	}
@@ -32,12 +67,19 @@ func Unmarshal(input []byte, val interface{}) error {
 	if rval.IsNil() {
 		return errors.New("cannot output to pointer of nil value")
 	}
+	limits := decodeLimits
+	if err := limits.checkInputSize(input); err != nil {
+		return fmt.Errorf("could not unmarshal input into type: %v, %w", rval.Elem().Type(), err)
+	}
+	if limits.MaxDepth > 0 || limits.MaxListElements > 0 {
+		arena = arena.withLimits(&limits)
+	}
 	sszUtils, err := cachedSSZUtils(rval.Elem().Type())
 	if err != nil {
-		return fmt.Errorf("could not initialize unmarshaler for type: %v, %v", rval.Elem().Type(), err)
+		return fmt.Errorf("could not initialize unmarshaler for type: %v, %w", rval.Elem().Type(), err)
 	}
-	if _, err = sszUtils.unmarshaler(input, rval.Elem(), 0); err != nil {
-		return fmt.Errorf("could not unmarshal input into type: %v, %v", rval.Elem().Type(), err)
+	if _, err = sszUtils.unmarshaler(arena, input, rval.Elem(), 0); err != nil {
+		return fmt.Errorf("could not unmarshal input into type: %v, %w", rval.Elem().Type(), err)
 	}
 	return nil
 }
@@ -57,10 +99,16 @@ func makeUnmarshaler(typ reflect.Type) (dec unmarshaler, err error) {
 		return unmarshalUint32, nil
 	case kind == reflect.Uint64:
 		return unmarshalUint64, nil
+	case isBitlist(typ):
+		return makeBitlistUnmarshaler()
+	case isBitvector(typ):
+		return makeBitvectorUnmarshaler(typ)
 	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
 		return makeByteSliceUnmarshaler()
 	case kind == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
 		return makeBasicArrayUnmarshaler(typ)
+	case isPackedArray(typ):
+		return makePackedArrayUnmarshaler(typ)
 	case kind == reflect.Slice && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()):
 		return makeBasicSliceUnmarshaler(typ)
 	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
@@ -73,60 +121,157 @@ func makeUnmarshaler(typ reflect.Type) (dec unmarshaler, err error) {
 		return makeCompositeSliceUnmarshaler(typ)
 	case kind == reflect.Array:
 		return makeCompositeArrayUnmarshaler(typ)
+	case kind == reflect.String:
+		return unmarshalString, nil
 	case kind == reflect.Struct:
 		return makeStructUnmarshaler(typ)
 	case kind == reflect.Ptr:
 		return makePtrUnmarshaler(typ)
 	default:
-		return nil, fmt.Errorf("type %v is not deserializable", typ)
+		return nil, errorf(ErrUnsupportedType, "type %v is not deserializable", typ)
 	}
 }
 
-func unmarshalBool(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+// segment returns the bytes of input between start and end, which become the byte
+// budget of the decoder consuming them. It errors instead of panicking if the bounds
+// fall outside of input, and caps the capacity of the returned slice so that nested
+// decoders cannot reach past the end of their segment even by reslicing.
+func segment(input []byte, start uint64, end uint64) ([]byte, error) {
+	if start > end || end > uint64(len(input)) {
+		return nil, errorf(ErrSize, "segment [%d:%d] exceeds byte budget of %d", start, end, len(input))
+	}
+	return input[start:end:end], nil
+}
+
+func unmarshalBool(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	if startOffset >= uint64(len(input)) {
+		return 0, errorf(ErrSize, "offset %d exceeds byte budget of %d", startOffset, len(input))
+	}
 	v := uint8(input[startOffset])
 	if v == 0 {
 		val.SetBool(false)
 	} else if v == 1 {
 		val.SetBool(true)
 	} else {
-		return 0, fmt.Errorf("expected 0 or 1 but received %d", v)
+		return 0, errorf(ErrInvalidValue, "expected 0 or 1 but received %d", v)
 	}
 	return startOffset + 1, nil
 }
 
-func unmarshalUint8(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+func unmarshalUint8(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	if startOffset >= uint64(len(input)) {
+		return 0, errorf(ErrSize, "offset %d exceeds byte budget of %d", startOffset, len(input))
+	}
 	val.SetUint(uint64(input[startOffset]))
 	return startOffset + 1, nil
 }
 
-func unmarshalUint16(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+func unmarshalUint16(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
 	offset := startOffset + 2
+	b, err := segment(input, startOffset, offset)
+	if err != nil {
+		return 0, err
+	}
 	buf := make([]byte, 2)
-	copy(buf, input[startOffset:offset])
+	copy(buf, b)
 	val.SetUint(uint64(binary.LittleEndian.Uint16(buf)))
 	return offset, nil
 }
 
-func unmarshalUint32(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+func unmarshalUint32(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
 	offset := startOffset + 4
+	b, err := segment(input, startOffset, offset)
+	if err != nil {
+		return 0, err
+	}
 	buf := make([]byte, 4)
-	copy(buf, input[startOffset:offset])
+	copy(buf, b)
 	val.SetUint(uint64(binary.LittleEndian.Uint32(buf)))
 	return offset, nil
 }
 
-func unmarshalUint64(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+func unmarshalUint64(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
 	offset := startOffset + 8
+	b, err := segment(input, startOffset, offset)
+	if err != nil {
+		return 0, err
+	}
 	buf := make([]byte, 8)
-	copy(buf, input[startOffset:offset])
+	copy(buf, b)
 	val.SetUint(binary.LittleEndian.Uint64(buf))
 	return offset, nil
 }
 
 func makeByteSliceUnmarshaler() (unmarshaler, error) {
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
-		offset := startOffset + uint64(len(input))
-		val.SetBytes(input[startOffset:offset])
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		// A byte slice consumes the rest of its budget.
+		offset := uint64(len(input))
+		b, err := segment(input, startOffset, offset)
+		if err != nil {
+			return 0, err
+		}
+		if err := a.checkListElements(uint64(len(b))); err != nil {
+			return 0, err
+		}
+		switch {
+		case zeroCopy:
+			val.SetBytes(b)
+		case val.Cap() >= len(b) && !val.IsNil():
+			val.SetLen(len(b))
+			copy(val.Bytes(), b)
+		default:
+			val.SetBytes(a.copyBytes(b))
+		}
+		return offset, nil
+	}
+	return unmarshaler, nil
+}
+
+// makeBitlistUnmarshaler returns the unmarshaler of bitlists, which decodes them as byte
+// slices once their encoding is checked to end with a delimiter bit. Their ssz-max is
+// checked by the unmarshalers of the structs holding them.
+func makeBitlistUnmarshaler() (unmarshaler, error) {
+	bytesUnmarshaler, err := makeByteSliceUnmarshaler()
+	if err != nil {
+		return nil, err
+	}
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		b, err := segment(input, startOffset, uint64(len(input)))
+		if err != nil {
+			return 0, err
+		}
+		if err := validateBitlist(b, 0 /* max capacity */); err != nil {
+			return 0, err
+		}
+		return bytesUnmarshaler(a, input, val, startOffset)
+	}
+	return unmarshaler, nil
+}
+
+// makeBitvectorUnmarshaler returns the unmarshaler of the bitvector type typ, which
+// decodes the bytes of bitvectors once they are checked to leave the bits past the
+// length of the type unset.
+func makeBitvectorUnmarshaler(typ reflect.Type) (unmarshaler, error) {
+	length, _ := bitvectorLength(typ)
+	size := bitvectorSize(typ)
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		offset := startOffset + size
+		b, err := segment(input, startOffset, offset)
+		if err != nil {
+			return 0, err
+		}
+		if err := validateBitvector(b, length); err != nil {
+			return 0, err
+		}
+		switch {
+		case zeroCopy:
+			val.SetBytes(b)
+		case val.Cap() >= len(b) && !val.IsNil():
+			val.SetLen(len(b))
+			copy(val.Bytes(), b)
+		default:
+			val.SetBytes(a.copyBytes(b))
+		}
 		return offset, nil
 	}
 	return unmarshaler, nil
@@ -137,10 +282,13 @@ func makeBasicSliceUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
 		if len(input) == 0 {
-			newVal := reflect.MakeSlice(val.Type(), 0, 0)
-			val.Set(newVal)
+			if val.IsNil() {
+				val.Set(a.makeSlice(val.Type(), 0, 0))
+			} else {
+				val.SetLen(0)
+			}
 			return 0, nil
 		}
 		// If there are struct tags that specify a different type, we handle accordingly.
@@ -159,21 +307,27 @@ func makeBasicSliceUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 				}
 			}
 			// If the item is a slice, we grow it accordingly based on the size tags.
-			result := growSliceFromSizeTags(val, sizes)
+			result := growSliceFromSizeTags(a, val, sizes)
 			reflect.Copy(result, val)
 			val.Set(result)
 		} else {
-			growConcreteSliceType(val, val.Type(), 1)
+			growConcreteSliceType(a, val, val.Type(), 1)
 		}
 
 		index := startOffset
-		index, err = elemSSZUtils.unmarshaler(input, val.Index(0), index)
+		index, err = elemSSZUtils.unmarshaler(a, input, val.Index(0), index)
 		if err != nil {
-			return 0, fmt.Errorf("failed to unmarshal element of slice: %v", err)
+			return 0, fmt.Errorf("failed to unmarshal element of slice: %w", err)
 		}
 
 		elementSize := index - startOffset
+		if elementSize == 0 || uint64(len(input))%elementSize != 0 {
+			return 0, errorf(ErrSize, "byte budget of %d is not a multiple of element size %d", len(input), elementSize)
+		}
 		endOffset := uint64(len(input)) / elementSize
+		if err := a.checkListElements(endOffset); err != nil {
+			return 0, err
+		}
 		if val.Type() != typ {
 			sizes := []uint64{endOffset}
 			innerElement := typ.Elem()
@@ -189,18 +343,18 @@ func makeBasicSliceUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 				}
 			}
 			// If the item is a slice, we grow it accordingly based on the size tags.
-			result := growSliceFromSizeTags(val, sizes)
+			result := growSliceFromSizeTags(a, val, sizes)
 			reflect.Copy(result, val)
 			val.Set(result)
 		}
 		i := uint64(1)
 		for i < endOffset {
 			if val.Type() == typ {
-				growConcreteSliceType(val, val.Type(), int(i)+1)
+				growConcreteSliceType(a, val, val.Type(), int(i)+1)
 			}
-			index, err = elemSSZUtils.unmarshaler(input, val.Index(int(i)), index)
+			index, err = elemSSZUtils.unmarshaler(a, input, val.Index(int(i)), index)
 			if err != nil {
-				return 0, fmt.Errorf("failed to unmarshal element of slice: %v", err)
+				return 0, fmt.Errorf("failed to unmarshal element of slice: %w", err)
 			}
 			i++
 		}
@@ -215,19 +369,35 @@ func makeCompositeSliceUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	minElemSize := minimumSize(elemType)
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		if err := a.enter(); err != nil {
+			return 0, err
+		}
+		defer a.leave()
 		if len(input) == 0 {
-			newVal := reflect.MakeSlice(val.Type(), 0, 0)
-			val.Set(newVal)
+			if val.IsNil() {
+				val.Set(a.makeSlice(val.Type(), 0, 0))
+			} else {
+				val.SetLen(0)
+			}
 			return 0, nil
 		}
-		growConcreteSliceType(val, typ, 1)
+		growConcreteSliceType(a, val, typ, 1)
 		endOffset := uint64(len(input))
 
 		currentIndex := startOffset
 		nextIndex := currentIndex
-		offsetVal := input[startOffset : startOffset+BytesPerLengthOffset]
-		firstOffset := startOffset + uint64(binary.LittleEndian.Uint32(offsetVal))
+		firstOffset, err := readOffset(input, startOffset, startOffset)
+		if err != nil {
+			return 0, err
+		}
+		if firstOffset == startOffset || (firstOffset-startOffset)%BytesPerLengthOffset != 0 {
+			return 0, errorf(ErrOffset, "first offset %d is not a non-zero multiple of %d", firstOffset-startOffset, BytesPerLengthOffset)
+		}
+		if err := a.checkListElements((firstOffset - startOffset) / BytesPerLengthOffset); err != nil {
+			return 0, err
+		}
 		currentOffset := firstOffset
 		nextOffset := currentOffset
 		i := 0
@@ -236,13 +406,24 @@ func makeCompositeSliceUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 			if nextIndex == firstOffset {
 				nextOffset = endOffset
 			} else {
-				nextOffsetVal := input[nextIndex : nextIndex+BytesPerLengthOffset]
-				nextOffset = startOffset + uint64(binary.LittleEndian.Uint32(nextOffsetVal))
+				nextOffset, err = readOffset(input, nextIndex, startOffset)
+				if err != nil {
+					return 0, err
+				}
+			}
+			elemInput, err := segment(input, currentOffset, nextOffset)
+			if err != nil {
+				return 0, fmt.Errorf("failed to unmarshal element of slice: %w", err)
+			}
+			// Two equal offsets give an element no bytes at all, which is only
+			// valid if its type can be serialized to nothing.
+			if currentOffset == nextOffset && minElemSize > 0 {
+				return 0, errorf(ErrOffset, "duplicate offset %d for element %d of type %v which cannot be empty", currentOffset-startOffset, i, elemType)
 			}
 			// We grow the slice's size to accommodate a new element being unmarshaled.
-			growConcreteSliceType(val, typ, i+1)
-			if _, err := elemSSZUtils.unmarshaler(input[currentOffset:nextOffset], val.Index(i), 0); err != nil {
-				return 0, fmt.Errorf("failed to unmarshal element of slice: %v", err)
+			growConcreteSliceType(a, val, typ, i+1)
+			if _, err := elemSSZUtils.unmarshaler(a, elemInput, val.Index(i), 0); err != nil {
+				return 0, fmt.Errorf("failed to unmarshal element of slice: %w", err)
 			}
 			i++
 			currentIndex = nextIndex
@@ -259,17 +440,27 @@ func makeBasicArrayUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		// Byte slices with an ssz-size tag alias their input, like byte lists.
+		if zeroCopy && val.Kind() == reflect.Slice && elemType.Kind() == reflect.Uint8 {
+			end := startOffset + uint64(typ.Len())
+			b, err := segment(input, startOffset, end)
+			if err != nil {
+				return 0, err
+			}
+			val.SetBytes(b)
+			return end, nil
+		}
 		i := 0
 		index := startOffset
 		size := val.Len()
 		for i < size {
 			if val.Index(i).Kind() == reflect.Ptr {
-				instantiateConcreteTypeForElement(val.Index(i), typ.Elem().Elem())
+				instantiateConcreteTypeForElement(a, val.Index(i), typ.Elem().Elem())
 			}
-			index, err = elemSSZUtils.unmarshaler(input, val.Index(i), index)
+			index, err = elemSSZUtils.unmarshaler(a, input, val.Index(i), index)
 			if err != nil {
-				return 0, fmt.Errorf("failed to unmarshal element of array: %v", err)
+				return 0, fmt.Errorf("failed to unmarshal element of array: %w", err)
 			}
 			i++
 		}
@@ -278,17 +469,57 @@ func makeBasicArrayUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	return unmarshaler, nil
 }
 
+func makePackedArrayUnmarshaler(typ reflect.Type) (unmarshaler, error) {
+	elemSize := staticFixedSize(typ.Elem())
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		end := startOffset + uint64(val.Len())*elemSize
+		b, err := segment(input, startOffset, end)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal array: %w", err)
+		}
+		switch elemSize {
+		case 2:
+			for i := 0; i < val.Len(); i++ {
+				val.Index(i).SetUint(uint64(binary.LittleEndian.Uint16(b[i*2:])))
+			}
+		case 4:
+			for i := 0; i < val.Len(); i++ {
+				val.Index(i).SetUint(uint64(binary.LittleEndian.Uint32(b[i*4:])))
+			}
+		case 8:
+			for i := 0; i < val.Len(); i++ {
+				val.Index(i).SetUint(binary.LittleEndian.Uint64(b[i*8:]))
+			}
+		}
+		return end, nil
+	}
+	return unmarshaler, nil
+}
+
 func makeCompositeArrayUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	elemType := typ.Elem()
 	elemSSZUtils, err := cachedSSZUtilsNoAcquireLock(elemType)
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	minElemSize := minimumSize(elemType)
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		if err := a.enter(); err != nil {
+			return 0, err
+		}
+		defer a.leave()
 		currentIndex := startOffset
 		nextIndex := currentIndex
-		offsetVal := input[startOffset : startOffset+BytesPerLengthOffset]
-		firstOffset := startOffset + uint64(binary.LittleEndian.Uint32(offsetVal))
+		firstOffset, err := readOffset(input, startOffset, startOffset)
+		if err != nil {
+			return 0, err
+		}
+		if firstOffset == startOffset || (firstOffset-startOffset)%BytesPerLengthOffset != 0 {
+			return 0, errorf(ErrOffset, "first offset %d is not a non-zero multiple of %d", firstOffset-startOffset, BytesPerLengthOffset)
+		}
+		if (firstOffset-startOffset)/BytesPerLengthOffset != uint64(val.Len()) {
+			return 0, errorf(ErrOffset, "expected %d offsets, received %d", val.Len(), (firstOffset-startOffset)/BytesPerLengthOffset)
+		}
 		currentOffset := firstOffset
 		nextOffset := currentOffset
 		endOffset := uint64(len(input))
@@ -299,14 +530,25 @@ func makeCompositeArrayUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 			if nextIndex == firstOffset {
 				nextOffset = endOffset
 			} else {
-				nextOffsetVal := input[nextIndex : nextIndex+BytesPerLengthOffset]
-				nextOffset = startOffset + uint64(binary.LittleEndian.Uint32(nextOffsetVal))
+				nextOffset, err = readOffset(input, nextIndex, startOffset)
+				if err != nil {
+					return 0, err
+				}
+			}
+			elemInput, err := segment(input, currentOffset, nextOffset)
+			if err != nil {
+				return 0, fmt.Errorf("failed to unmarshal element of array: %w", err)
+			}
+			// Two equal offsets give an element no bytes at all, which is only
+			// valid if its type can be serialized to nothing.
+			if currentOffset == nextOffset && minElemSize > 0 {
+				return 0, errorf(ErrOffset, "duplicate offset %d for element %d of type %v which cannot be empty", currentOffset-startOffset, i, elemType)
 			}
 			if val.Index(i).Kind() == reflect.Ptr {
-				instantiateConcreteTypeForElement(val.Index(i), typ.Elem().Elem())
+				instantiateConcreteTypeForElement(a, val.Index(i), typ.Elem().Elem())
 			}
-			if _, err := elemSSZUtils.unmarshaler(input[currentOffset:nextOffset], val.Index(i), 0); err != nil {
-				return 0, fmt.Errorf("failed to unmarshal element of slice: %v", err)
+			if _, err := elemSSZUtils.unmarshaler(a, elemInput, val.Index(i), 0); err != nil {
+				return 0, fmt.Errorf("failed to unmarshal element of slice: %w", err)
 			}
 			i++
 			currentIndex = nextIndex
@@ -322,72 +564,119 @@ func makeStructUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+	// The sizes of fixed-size fields do not depend on their values, and neither do
+	// the size tags of fields, so both are only determined once.
+	fixedSizes := make([]uint64, len(fields))
+	fixed := make([]bool, len(fields))
+	sizeTags := make([][]uint64, len(fields))
+	for i := range fields {
+		if isVariableSizeType(fields[i].typ) {
+			continue
+		}
+		fixed[i] = true
+		fixedSizes[i] = staticFixedSize(fields[i].typ)
+		tags, hasTags, err := parseSSZFieldTags(typ.Field(fields[i].index))
+		if err != nil {
+			return nil, err
+		}
+		if hasTags {
+			sizeTags[i] = tags
+		}
+	}
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		if err := a.enter(); err != nil {
+			return 0, err
+		}
+		defer a.leave()
+		var name string
+		defer annotatePanic(&name)
 		endOffset := uint64(len(input))
 		currentIndex := startOffset
 		nextIndex := currentIndex
-		fixedSizes := make([]uint64, len(fields))
 
 		for i := 0; i < len(fixedSizes); i++ {
-			if !isVariableSizeType(fields[i].typ) {
-				if val.Field(i).Kind() == reflect.Ptr {
-					instantiateConcreteTypeForElement(val.Field(i), fields[i].typ.Elem())
-				}
-				concreteVal := val.Field(i)
-				sszSizeTags, hasTags, err := parseSSZFieldTags(typ.Field(i))
-				if err != nil {
-					return 0, err
-				}
-				if hasTags {
-					concreteType := inferFieldTypeFromSizeTags(typ.Field(i), sszSizeTags)
-					concreteVal = reflect.New(concreteType).Elem()
-					// If the item is a slice, we grow it accordingly based on the size tags.
-					if val.Field(i).Kind() == reflect.Slice {
-						result := growSliceFromSizeTags(val.Field(i), sszSizeTags)
-						val.Field(i).Set(result)
-					}
-				}
-				fixedSz := determineFixedSize(concreteVal, fields[i].typ)
-				if fixedSz > 0 {
-					fixedSizes[i] = fixedSz
+			if !fixed[i] {
+				continue
+			}
+			fieldVal := val.Field(fields[i].index)
+			if fieldVal.Kind() == reflect.Ptr {
+				instantiateConcreteTypeForElement(a, fieldVal, fields[i].typ.Elem())
+			}
+			if sizeTags[i] != nil {
+				concreteType := fields[i].typ
+				// If the item is a slice, we grow it accordingly based on the size tags,
+				// unless it is a byte slice about to alias the input.
+				aliased := zeroCopy && concreteType.Kind() == reflect.Array && concreteType.Elem().Kind() == reflect.Uint8
+				if fieldVal.Kind() == reflect.Slice && !aliased {
+					result := growSliceFromSizeTags(a, fieldVal, sizeTags[i])
+					fieldVal.Set(result)
 				}
-			} else {
-				fixedSizes[i] = 0
 			}
 		}
 
-		offsets := make([]uint64, 0)
+		var offsetsBuf [16]uint64
+		offsets := offsetsBuf[:0]
 		offsetIndexCounter := startOffset
 		for _, item := range fixedSizes {
 			if item > 0 {
 				offsetIndexCounter += item
 			} else {
-				offsetVal := input[offsetIndexCounter : offsetIndexCounter+BytesPerLengthOffset]
-				offsets = append(offsets, startOffset+uint64(binary.LittleEndian.Uint32(offsetVal)))
+				offset, err := readOffset(input, offsetIndexCounter, startOffset)
+				if err != nil {
+					return 0, err
+				}
+				offsets = append(offsets, offset)
 				offsetIndexCounter += BytesPerLengthOffset
 			}
 		}
+		// The first variable-size field starts right after the fixed part.
+		if len(offsets) > 0 && offsets[0] != offsetIndexCounter {
+			return 0, errorf(ErrOffset, "first offset %d does not match fixed part size of %d", offsets[0]-startOffset, offsetIndexCounter-startOffset)
+		}
 		offsets = append(offsets, endOffset)
 		offsetIndex := uint64(0)
+		trace := activeTracer()
 		for i := 0; i < len(fields); i++ {
 			f := fields[i]
+			name = f.name
 			fieldSize := fixedSizes[i]
-			if val.Field(i).Kind() == reflect.Ptr {
-				instantiateConcreteTypeForElement(val.Field(i), fields[i].typ.Elem())
+			fieldVal := val.Field(f.index)
+			if fieldVal.Kind() == reflect.Ptr && f.opaque == nil {
+				instantiateConcreteTypeForElement(a, fieldVal, f.typ.Elem())
 			}
 			if fieldSize > 0 {
 				nextIndex = currentIndex + fieldSize
-				if _, err := f.sszUtils.unmarshaler(input[currentIndex:nextIndex], val.Field(i), 0); err != nil {
+				fieldInput, err := segment(input, currentIndex, nextIndex)
+				if err != nil {
+					return 0, fmt.Errorf("failed to unmarshal field %s: %w", f.name, err)
+				}
+				if _, err := f.sszUtils.unmarshaler(a, fieldInput, fieldVal, 0); err != nil {
 					return 0, err
 				}
+				if trace != nil {
+					traceField(trace, TraceUnmarshal, typ, f, false, currentIndex-startOffset, nextIndex-startOffset)
+				}
 				currentIndex = nextIndex
 
 			} else {
 				firstOff := offsets[offsetIndex]
 				nextOff := offsets[offsetIndex+1]
-				if _, err := f.sszUtils.unmarshaler(input[firstOff:nextOff], val.Field(i), 0); err != nil {
+				fieldInput, err := segment(input, firstOff, nextOff)
+				if err != nil {
+					return 0, fmt.Errorf("failed to unmarshal field %s: %w", f.name, err)
+				}
+				if _, err := f.sszUtils.unmarshaler(a, fieldInput, fieldVal, 0); err != nil {
 					return 0, err
 				}
+				// Decoded values must encode again, within the limits of their tags.
+				if f.hasCapacity && f.opaque == nil {
+					if err := checkFieldLength(fieldVal, f); err != nil {
+						return 0, err
+					}
+				}
+				if trace != nil {
+					traceField(trace, TraceUnmarshal, typ, f, true, firstOff-startOffset, nextOff-startOffset)
+				}
 				offsetIndex++
 				currentIndex += BytesPerLengthOffset
 			}
@@ -397,16 +686,30 @@ func makeStructUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	return unmarshaler, nil
 }
 
+// readOffset reads the length offset stored at index, relative to startOffset,
+// making sure both the offset and the bytes it is stored in fit the byte budget.
+func readOffset(input []byte, index uint64, startOffset uint64) (uint64, error) {
+	offsetVal, err := segment(input, index, index+BytesPerLengthOffset)
+	if err != nil {
+		return 0, fmt.Errorf("could not read offset: %w", err)
+	}
+	offset := startOffset + uint64(binary.LittleEndian.Uint32(offsetVal))
+	if offset > uint64(len(input)) {
+		return 0, errorf(ErrOffset, "offset %d exceeds byte budget of %d", offset, len(input))
+	}
+	return offset, nil
+}
+
 func makePtrUnmarshaler(typ reflect.Type) (unmarshaler, error) {
 	elemType := typ.Elem()
 	elemSSZUtils, err := cachedSSZUtilsNoAcquireLock(elemType)
 	if err != nil {
 		return nil, err
 	}
-	unmarshaler := func(input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
-		elemSize, err := elemSSZUtils.unmarshaler(input, val.Elem(), startOffset)
+	unmarshaler := func(a *Arena, input []byte, val reflect.Value, startOffset uint64) (uint64, error) {
+		elemSize, err := elemSSZUtils.unmarshaler(a, input, val.Elem(), startOffset)
 		if err != nil {
-			return 0, fmt.Errorf("failed to unmarshal to object pointed by pointer: %v", err)
+			return 0, fmt.Errorf("failed to unmarshal to object pointed by pointer: %w", err)
 		}
 		return elemSize, nil
 	}
@@ -0,0 +1,294 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// UnmarshalPath decodes the value designated by path within data, the encoding of a
+// value of type typ, into the object pointed by out. Only the fixed parts and offsets
+// leading to the value are read, so that a single field can be decoded out of a large
+// encoding. Paths follow GeneralizedIndex: the length of a list is decoded into a
+// uint64 with LengthPathElement, and the bits of bitlists into bools.
+//
+//  var slot uint64
+//  if err := UnmarshalPath(encodedState, reflect.TypeOf(BeaconState{}), &slot, "Slot"); err != nil {
+//      return fmt.Errorf("failed to decode slot: %v", err)
+//  }
+func UnmarshalPath(data []byte, typ reflect.Type, out interface{}, path ...interface{}) (err error) {
+	defer recoverPanic(&err)
+	if typ == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	if out == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	rval := reflect.ValueOf(out)
+	if rval.Kind() != reflect.Ptr || rval.IsNil() {
+		return errors.New("can only unmarshal into a non-nil pointer target")
+	}
+	c, err := locatePath(data, typ, typ, path)
+	if err != nil {
+		return err
+	}
+	return c.decode(rval.Elem())
+}
+
+// locatePath returns the cursor designating the encoding of the value at path
+// within data, the encoding of a value of SSZ type typ and Go type goType.
+func locatePath(data []byte, typ reflect.Type, goType reflect.Type, path []interface{}) (*pathCursor, error) {
+	if err := checkAcyclic(typ); err != nil {
+		return nil, err
+	}
+	c := &pathCursor{input: data, typ: typ, goType: goType}
+	for i, p := range path {
+		if err := c.descend(p); err != nil {
+			return nil, fmt.Errorf("could not resolve path element %d (%v): %w", i, p, err)
+		}
+	}
+	return c, nil
+}
+
+// valueType returns the Go type of the value designated by the cursor.
+func (c *pathCursor) valueType() reflect.Type {
+	if c.scalar != nil {
+		return c.scalar.Type()
+	}
+	goType := c.goType
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	return goType
+}
+
+// decode decodes the value designated by the cursor into val.
+func (c *pathCursor) decode(val reflect.Value) error {
+	if val.Type() != c.valueType() {
+		return fmt.Errorf("cannot decode %v into %v", c.valueType(), val.Type())
+	}
+	if c.scalar != nil {
+		val.Set(*c.scalar)
+		return nil
+	}
+	utils := c.opaque
+	if utils == nil {
+		utils = c.bits
+	}
+	if utils == nil {
+		var err error
+		if utils, err = cachedSSZUtils(val.Type()); err != nil {
+			return fmt.Errorf("could not initialize unmarshaler for type: %v, %w", val.Type(), err)
+		}
+	}
+	if _, err := utils.unmarshaler(nil, c.input, val, 0); err != nil {
+		return fmt.Errorf("could not unmarshal input into type: %v, %w", val.Type(), err)
+	}
+	return nil
+}
+
+// pathCursor is the encoding of the value designated by a path, resolved one
+// path element at a time.
+type pathCursor struct {
+	input []byte
+	// typ is the SSZ type of the value, which differs from its Go type for
+	// fields with size tags.
+	typ       reflect.Type
+	goType    reflect.Type
+	isBitlist bool
+	// opaque holds the utils of opaque fields, which decode their Go type
+	// from a byte list.
+	opaque *sszUtils
+	// bits holds the utils of fields tagged with `ssz:"bits"`, which decode
+	// their booleans from a bitfield, and bitvector the number of bits of
+	// those holding vectors of booleans.
+	bits      *sszUtils
+	bitvector uint64
+	// scalar holds values which are not encoded by themselves: lengths of
+	// lists and bits of bitlists.
+	scalar *reflect.Value
+}
+
+func (c *pathCursor) descend(p interface{}) error {
+	if c.opaque != nil {
+		return errors.New("opaque fields have no children")
+	}
+	if c.scalar != nil {
+		return fmt.Errorf("type %v has no children", c.scalar.Type())
+	}
+	for c.typ.Kind() == reflect.Ptr {
+		c.typ = c.typ.Elem()
+	}
+	kind := c.typ.Kind()
+	if name, ok := p.(string); ok && name == LengthPathElement {
+		if !c.isBitlist && (kind != reflect.Slice || isBitvector(c.typ)) {
+			return fmt.Errorf("type %v is not a list", c.typ)
+		}
+		length, err := c.length()
+		if err != nil {
+			return err
+		}
+		v := reflect.ValueOf(length)
+		c.scalar = &v
+		return nil
+	}
+	if kind == reflect.Struct {
+		name, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("expected a field name for struct %v", c.typ)
+		}
+		return c.field(name)
+	}
+	if kind != reflect.Array && kind != reflect.Slice {
+		return fmt.Errorf("type %v has no children", c.typ)
+	}
+
+	index, err := pathIndex(p)
+	if err != nil {
+		return err
+	}
+	if c.isBitlist {
+		length, err := c.length()
+		if err != nil {
+			return err
+		}
+		if index >= length {
+			return fmt.Errorf("bit %d exceeds bitlist length of %d", index, length)
+		}
+		v := reflect.ValueOf(bitfield.Bitlist(c.input).BitAt(index))
+		c.scalar = &v
+		return nil
+	}
+	length, ok := c.bitvector, c.bitvector != 0
+	if !ok {
+		length, ok = bitvectorLength(c.typ)
+	}
+	if ok {
+		if index >= length {
+			return fmt.Errorf("bit %d exceeds bitvector length of %d", index, length)
+		}
+		if index/8 >= uint64(len(c.input)) {
+			return errorf(ErrSize, "bitvector of length %d is encoded in %d bytes", length, len(c.input))
+		}
+		v := reflect.ValueOf(c.input[index/8]&(1<<(index%8)) != 0)
+		c.scalar = &v
+		return nil
+	}
+	elemType := c.typ.Elem()
+	var start, end uint64
+	if !isVariableSizeType(elemType) {
+		elemSize := staticFixedSize(elemType)
+		start, end = index*elemSize, (index+1)*elemSize
+		if end > uint64(len(c.input)) {
+			return fmt.Errorf("index %d exceeds length of %d", index, uint64(len(c.input))/elemSize)
+		}
+	} else {
+		count, err := c.length()
+		if err != nil {
+			return err
+		}
+		if index >= count {
+			return fmt.Errorf("index %d exceeds length of %d", index, count)
+		}
+		if start, err = readOffset(c.input, index*BytesPerLengthOffset, 0); err != nil {
+			return err
+		}
+		end = uint64(len(c.input))
+		if index+1 < count {
+			if end, err = readOffset(c.input, (index+1)*BytesPerLengthOffset, 0); err != nil {
+				return err
+			}
+		}
+	}
+	if c.input, err = segment(c.input, start, end); err != nil {
+		return err
+	}
+	c.goType = c.goType.Elem()
+	for c.goType.Kind() == reflect.Ptr {
+		c.goType = c.goType.Elem()
+	}
+	c.typ = elemType
+	return nil
+}
+
+func (c *pathCursor) field(name string) error {
+	fields, err := structFields(c.typ)
+	if err != nil {
+		return err
+	}
+	for c.goType.Kind() == reflect.Ptr {
+		c.goType = c.goType.Elem()
+	}
+	offset := uint64(0)
+	for i, f := range fields {
+		variable := f.opaque != nil || isVariableSizeType(f.typ)
+		size := uint64(BytesPerLengthOffset)
+		if !variable {
+			size = staticFixedSize(f.typ)
+		}
+		if f.name != name {
+			offset += size
+			continue
+		}
+		start, end := offset, offset+size
+		if variable {
+			if start, err = readOffset(c.input, offset, 0); err != nil {
+				return err
+			}
+			// The value ends where the next variable-size field starts.
+			end = uint64(len(c.input))
+			next := offset + size
+			for _, g := range fields[i+1:] {
+				if g.opaque != nil || isVariableSizeType(g.typ) {
+					if end, err = readOffset(c.input, next, 0); err != nil {
+						return err
+					}
+					break
+				}
+				next += staticFixedSize(g.typ)
+			}
+		}
+		if c.input, err = segment(c.input, start, end); err != nil {
+			return err
+		}
+		c.goType = c.goType.Field(f.index).Type
+		c.typ = f.typ
+		c.isBitlist = isBitlist(c.typ)
+		if f.opaque != nil {
+			c.opaque = f.sszUtils
+		}
+		if f.bits {
+			c.bits = f.sszUtils
+			if c.goType.Kind() == reflect.Array {
+				c.bitvector = uint64(c.goType.Len())
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("struct %v has no field %s", c.typ, name)
+}
+
+// length returns the number of elements of the list, or bits of the bitlist,
+// encoded in the input of the cursor.
+func (c *pathCursor) length() (uint64, error) {
+	if c.isBitlist {
+		if len(c.input) == 0 || c.input[len(c.input)-1] == 0 {
+			return 0, errorf(ErrInvalidValue, "bitlist is missing its length bit")
+		}
+		return bitfield.Bitlist(c.input).Len(), nil
+	}
+	elemType := c.typ.Elem()
+	if !isVariableSizeType(elemType) {
+		return uint64(len(c.input)) / staticFixedSize(elemType), nil
+	}
+	if len(c.input) == 0 {
+		return 0, nil
+	}
+	if uint64(len(c.input)) < BytesPerLengthOffset {
+		return 0, errorf(ErrSize, "input of %d bytes cannot hold an offset", len(c.input))
+	}
+	return uint64(binary.LittleEndian.Uint32(c.input)) / BytesPerLengthOffset, nil
+}
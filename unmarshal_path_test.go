@@ -0,0 +1,102 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalPath(t *testing.T) {
+	item := newTreeContainer()
+	item.Nil = &fork{Epoch: 10}
+	item.EmptyForks = []fork{{Epoch: 11}, {Epoch: 12}}
+	typ := reflect.TypeOf(item)
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path []interface{}
+		out  interface{}
+		want interface{}
+	}{
+		{path: []interface{}{"Slot"}, out: new(uint64), want: item.Slot},
+		{path: []interface{}{"Forks"}, out: new([]fork), want: item.Forks},
+		{path: []interface{}{"Forks", 1}, out: new(fork), want: item.Forks[1]},
+		{path: []interface{}{"Forks", 1, "PreviousVersion"}, out: new([4]byte), want: item.Forks[1].PreviousVersion},
+		{path: []interface{}{"Forks", LengthPathElement}, out: new(uint64), want: uint64(len(item.Forks))},
+		{path: []interface{}{"Balances", 5}, out: new(uint64), want: item.Balances[5]},
+		{path: []interface{}{"Balances", LengthPathElement}, out: new(uint64), want: uint64(len(item.Balances))},
+		{path: []interface{}{"Roots", 1}, out: new([32]byte), want: item.Roots[1]},
+		{path: []interface{}{"Custody", 2}, out: new(uint64), want: item.Custody[2]},
+		{path: []interface{}{"Pointer"}, out: new(fork), want: *item.Pointer},
+		{path: []interface{}{"Nil", "Epoch"}, out: new(uint64), want: item.Nil.Epoch},
+		{path: []interface{}{"Bits", 299}, out: new(bool), want: true},
+		{path: []interface{}{"Bits", 298}, out: new(bool), want: false},
+		{path: []interface{}{"Bits", LengthPathElement}, out: new(uint64), want: item.Bits.Len()},
+		{path: []interface{}{"Tagged"}, out: new([]byte), want: item.Tagged},
+		{path: []interface{}{"Opaque"}, out: new(opaqueWords), want: item.Opaque},
+		{path: []interface{}{"EmptyForks", 1}, out: new(fork), want: item.EmptyForks[1]},
+		{path: []interface{}{"Flag"}, out: new(bool), want: item.Flag},
+		{path: []interface{}{"ShortVector", 4}, out: new(uint16), want: item.ShortVector[4]},
+	}
+	for _, tt := range tests {
+		if err := UnmarshalPath(encoded, typ, tt.out, tt.path...); err != nil {
+			t.Errorf("Could not decode %v: %v", tt.path, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.out).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expected %v to be %v, received %v", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestUnmarshalPath_Errors(t *testing.T) {
+	item := newTreeContainer()
+	item.Nil = &fork{}
+	typ := reflect.TypeOf(item)
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		out  interface{}
+		path []interface{}
+	}{
+		{name: "missing field", data: encoded, out: new(uint64), path: []interface{}{"Missing"}},
+		{name: "element past length", data: encoded, out: new(uint64), path: []interface{}{"Balances", 6}},
+		{name: "composite element past length", data: encoded, out: new(fork), path: []interface{}{"Forks", 3}},
+		{name: "bit past length", data: encoded, out: new(bool), path: []interface{}{"Bits", 300}},
+		{name: "mismatched output", data: encoded, out: new(uint32), path: []interface{}{"Slot"}},
+		{name: "non-pointer output", data: encoded, out: uint64(0), path: []interface{}{"Slot"}},
+		{name: "opaque children", data: encoded, out: new(byte), path: []interface{}{"Opaque", 0}},
+		{name: "children of basic value", data: encoded, out: new(uint64), path: []interface{}{"Slot", 0}},
+		{name: "children of length", data: encoded, out: new(uint64), path: []interface{}{"Forks", LengthPathElement, 0}},
+		{name: "length of vector", data: encoded, out: new(uint64), path: []interface{}{"Custody", LengthPathElement}},
+		{name: "index into struct", data: encoded, out: new(uint64), path: []interface{}{0}},
+		{name: "truncated input", data: encoded[:10], out: new(fork), path: []interface{}{"Forks", 0}},
+	}
+	for _, tt := range tests {
+		if err := UnmarshalPath(tt.data, typ, tt.out, tt.path...); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func BenchmarkUnmarshalPath(b *testing.B) {
+	item := accountBalances{Balances: make([]uint64, 100000)}
+	encoded, err := Marshal(item)
+	if err != nil {
+		b.Fatal(err)
+	}
+	typ := reflect.TypeOf(item)
+	var balance uint64
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := UnmarshalPath(encoded, typ, &balance, "Balances", 1234); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
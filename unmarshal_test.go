@@ -0,0 +1,395 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+type compositeElement struct {
+	Field1 uint16
+	Field2 []uint16
+}
+
+func TestCompositeArrayUnmarshaler_DuplicateOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		typ     reflect.Type
+		wantErr bool
+	}{
+		{
+			name: "equal offsets of elements which cannot be empty",
+			// Both elements point to the same 6 bytes: the second element
+			// gets no bytes of its own.
+			input:   []byte{8, 0, 0, 0, 8, 0, 0, 0, 1, 0, 6, 0, 0, 0},
+			typ:     reflect.TypeOf([2]compositeElement{}),
+			wantErr: true,
+		},
+		{
+			name:    "equal offsets of list elements which cannot be empty",
+			input:   []byte{8, 0, 0, 0, 8, 0, 0, 0, 1, 0, 6, 0, 0, 0},
+			typ:     reflect.TypeOf([]compositeElement{}),
+			wantErr: true,
+		},
+		{
+			name:  "equal offsets of elements which can be empty",
+			input: []byte{8, 0, 0, 0, 8, 0, 0, 0, 1, 0},
+			typ:   reflect.TypeOf([2][]uint16{}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utils, err := cachedSSZUtils(tt.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			val := reflect.New(tt.typ).Elem()
+			_, err = utils.unmarshaler(nil, tt.input, val, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unmarshaler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func FuzzCompositeArrayUnmarshaler(f *testing.F) {
+	for _, seed := range []interface{}{
+		[2]compositeElement{{Field1: 1, Field2: []uint16{2, 3}}, {Field1: 4}},
+		[3][]uint16{{1}, {}, {2, 3}},
+	} {
+		encoded, err := Marshal(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(encoded)
+	}
+	f.Add([]byte{8, 0, 0, 0, 8, 0, 0, 0, 1, 0, 6, 0, 0, 0})
+	types := []reflect.Type{
+		reflect.TypeOf([2]compositeElement{}),
+		reflect.TypeOf([3][]uint16{}),
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		for _, typ := range types {
+			unmarshaler, err := makeCompositeArrayUnmarshaler(typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			val := reflect.New(typ).Elem()
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("unmarshaling %#x into %v panicked: %v", input, typ, r)
+					}
+				}()
+				// Arbitrary inputs may fail to decode, but must never panic, and their
+				// errors must be of one of the kinds of the package.
+				var kindErr *kindError
+				if _, err := unmarshaler(nil, input, val, 0); err != nil && !errors.As(err, &kindErr) {
+					t.Errorf("unmarshaling %#x into %v failed with an untyped error: %v", input, typ, err)
+				}
+			}()
+		}
+	})
+}
+
+type zeroCopyContainer struct {
+	Root [4]byte
+	Tag  []byte `ssz-size:"4"`
+	Data []byte `ssz-max:"16"`
+}
+
+func TestUnmarshal_ZeroCopy(t *testing.T) {
+	item := zeroCopyContainer{Root: [4]byte{1}, Tag: []byte{2, 2, 2, 2}, Data: []byte{3, 3}}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var copied zeroCopyContainer
+	if err := Unmarshal(encoded, &copied); err != nil {
+		t.Fatal(err)
+	}
+	ToggleZeroCopy(true)
+	defer ToggleZeroCopy(false)
+	var aliased zeroCopyContainer
+	if err := Unmarshal(encoded, &aliased); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(copied, item) || !reflect.DeepEqual(aliased, item) {
+		t.Fatalf("Expected %v, received %v and %v", item, copied, aliased)
+	}
+
+	for i := range encoded {
+		encoded[i] = 0xff
+	}
+	if !reflect.DeepEqual(copied, item) {
+		t.Errorf("Expected copied value to be unaffected by its input, received %v", copied)
+	}
+	if aliased.Root != item.Root {
+		t.Errorf("Expected byte arrays to be copied, received %v", aliased.Root)
+	}
+	if aliased.Tag[0] != 0xff || aliased.Data[0] != 0xff {
+		t.Errorf("Expected byte slices to alias their input, received %v and %v", aliased.Tag, aliased.Data)
+	}
+	// Appending to an aliased slice must not write past its end in the input.
+	_ = append(aliased.Tag, 4)
+	if aliased.Data[0] != 0xff {
+		t.Error("Expected append to an aliased slice to reallocate")
+	}
+}
+
+func BenchmarkUnmarshal_ZeroCopy(b *testing.B) {
+	item := zeroCopyContainer{Tag: make([]byte, 4), Data: make([]byte, 16)}
+	encoded, err := Marshal(item)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, enabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("zeroCopy=%v", enabled), func(b *testing.B) {
+			ToggleZeroCopy(enabled)
+			defer ToggleZeroCopy(false)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var decoded zeroCopyContainer
+				if err := Unmarshal(encoded, &decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type reusedContainer struct {
+	Slot     uint64
+	Forks    []fork `ssz-max:"16"`
+	Pointer  *fork
+	Data     []byte     `ssz-max:"64"`
+	Tag      []byte     `ssz-size:"4"`
+	Balances []uint64   `ssz-max:"64"`
+	Roots    [][32]byte `ssz-max:"8"`
+}
+
+func TestUnmarshal_ReusesCapacity(t *testing.T) {
+	large := reusedContainer{
+		Slot:     1,
+		Forks:    []fork{{Epoch: 1}, {Epoch: 2}, {Epoch: 3}},
+		Pointer:  &fork{Epoch: 4},
+		Data:     []byte{1, 2, 3, 4},
+		Tag:      []byte{5, 6, 7, 8},
+		Balances: []uint64{1, 2, 3},
+		Roots:    [][32]byte{{1}, {2}},
+	}
+	small := reusedContainer{
+		Slot:     2,
+		Forks:    []fork{{Epoch: 5}},
+		Pointer:  &fork{Epoch: 6},
+		Data:     []byte{9},
+		Tag:      []byte{1, 1, 1, 1},
+		Balances: []uint64{},
+		Roots:    [][32]byte{{3}},
+	}
+	largeEncoded, err := Marshal(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallEncoded, err := Marshal(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var target reusedContainer
+	if err := Unmarshal(largeEncoded, &target); err != nil {
+		t.Fatal(err)
+	}
+	forks, pointer, data := &target.Forks[0], target.Pointer, &target.Data[0]
+	if err := Unmarshal(smallEncoded, &target); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(target, small) {
+		t.Errorf("Expected %v, received %v", small, target)
+	}
+	if &target.Forks[0] != forks || target.Pointer != pointer || &target.Data[0] != data {
+		t.Error("Expected slices and pointers of the target to be reused")
+	}
+	if err := Unmarshal(largeEncoded, &target); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(target, large) {
+		t.Errorf("Expected %v, received %v", large, target)
+	}
+
+	fresh := testing.AllocsPerRun(100, func() {
+		var fresh reusedContainer
+		if err := Unmarshal(largeEncoded, &fresh); err != nil {
+			t.Fatal(err)
+		}
+	})
+	reused := testing.AllocsPerRun(100, func() {
+		if err := Unmarshal(largeEncoded, &target); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if reused >= fresh {
+		t.Errorf("Expected decoding into a used target to allocate less than %v times, received %v allocations", fresh, reused)
+	}
+}
+
+func TestGrowConcreteSliceType_Amortized(t *testing.T) {
+	val := reflect.New(reflect.TypeOf([]uint64{})).Elem()
+	reallocations := 0
+	for i := 1; i <= 1000; i++ {
+		before := val.Cap()
+		growConcreteSliceType(nil, val, val.Type(), i)
+		if val.Cap() != before {
+			reallocations++
+		}
+	}
+	if reallocations > 11 {
+		t.Errorf("Expected growing a slice to reallocate it logarithmically, received %d reallocations", reallocations)
+	}
+}
+
+func BenchmarkUnmarshal_ReusedTarget(b *testing.B) {
+	val := reusedContainer{
+		Forks:    make([]fork, 16),
+		Pointer:  &fork{},
+		Data:     make([]byte, 64),
+		Tag:      make([]byte, 4),
+		Balances: make([]uint64, 64),
+		Roots:    make([][32]byte, 8),
+	}
+	encoded, err := Marshal(val)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var target reusedContainer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Unmarshal(encoded, &target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnmarshal_RejectsNonCanonicalOffsets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		val   interface{}
+	}{
+		{
+			name:  "list with a zero first offset",
+			input: []byte{0, 0, 0, 0},
+			val:   &[]iteratedItem{},
+		},
+		{
+			name:  "struct with a gap after its fixed part",
+			input: []byte{1, 0, 0, 0, 0, 0, 0, 0, 16, 0, 0, 0, 0, 0, 0, 0, 5},
+			val:   &iteratedItem{},
+		},
+		{
+			name:  "struct with a first offset within its fixed part",
+			input: []byte{1, 0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 5},
+			val:   &iteratedItem{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal(tt.input, tt.val); err == nil {
+				t.Errorf("Expected error decoding %#x, received %+v", tt.input, tt.val)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_ChecksListLimits(t *testing.T) {
+	type limited struct {
+		Balances []uint64 `ssz-max:"2"`
+		Data     [][]byte `ssz-max:"4,2"`
+		Name     string   `ssz-max:"2"`
+	}
+	tests := []struct {
+		name string
+		val  limited
+	}{
+		{"list", limited{Balances: []uint64{1, 2, 3}}},
+		{"nested list", limited{Data: [][]byte{{1}, {1, 2, 3}}}},
+		{"string", limited{Name: "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ToggleMaxLengthCheck(false)
+			encoded, err := Marshal(tt.val)
+			ToggleMaxLengthCheck(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded limited
+			if err := Unmarshal(encoded, &decoded); !errors.Is(err, ErrMaxLength) {
+				t.Errorf("Expected decoding %+v to exceed its ssz-max, received %v", tt.val, err)
+			}
+		})
+	}
+}
+
+type bitlistItem struct {
+	Slot uint64
+	Bits bitfield.Bitlist `ssz-max:"8"`
+}
+
+func TestUnmarshal_ValidatesBitlists(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		val   interface{}
+	}{
+		{
+			name:  "empty bitlist",
+			input: []byte{},
+			val:   &bitfield.Bitlist{},
+		},
+		{
+			name:  "bitlist missing its delimiter",
+			input: []byte{0x05, 0x00},
+			val:   &bitfield.Bitlist{},
+		},
+		{
+			name:  "empty bitlist field",
+			input: []byte{1, 0, 0, 0, 0, 0, 0, 0, 12, 0, 0, 0},
+			val:   &bitlistItem{},
+		},
+		{
+			name:  "bitlist field exceeding its ssz-max",
+			input: []byte{1, 0, 0, 0, 0, 0, 0, 0, 12, 0, 0, 0, 0xff, 0x02},
+			val:   &bitlistItem{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal(tt.input, tt.val); err == nil {
+				t.Errorf("Expected error decoding %#x, received %+v", tt.input, tt.val)
+			}
+		})
+	}
+
+	encoded, err := Marshal(bitlistItem{Slot: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{1, 0, 0, 0, 0, 0, 0, 0, 12, 0, 0, 0, 0x01}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("Expected nil bitlist to encode as the empty bitlist %#x, received %#x", want, encoded)
+	}
+	var decoded bitlistItem
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Bits.Len() != 0 {
+		t.Errorf("Expected empty bitlist, received %#x", decoded.Bits)
+	}
+}
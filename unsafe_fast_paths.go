@@ -0,0 +1,74 @@
+//go:build ssz_unsafe
+// +build ssz_unsafe
+
+package ssz
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// unsafeFastPaths reports whether the library is built with the ssz_unsafe tag,
+// which encodes slices of elements laid out in memory as SSZ lays them out by
+// copying their memory at once.
+const unsafeFastPaths = true
+
+// littleEndian reports whether the host stores integers as SSZ encodes them.
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// rawLayouts caches whether element types are laid out in memory as SSZ encodes them.
+var rawLayouts sync.Map
+
+// rawBytes returns the memory of val, a slice, when it is the SSZ encoding of its
+// elements. The returned bytes alias val and must only be read.
+func rawBytes(val reflect.Value) ([]byte, bool) {
+	if !littleEndian || val.Kind() != reflect.Slice || val.Len() == 0 {
+		return nil, false
+	}
+	elem := val.Type().Elem()
+	raw, ok := rawLayouts.Load(elem)
+	if !ok {
+		raw = hasRawLayout(elem)
+		rawLayouts.Store(elem, raw)
+	}
+	if !raw.(bool) {
+		return nil, false
+	}
+	size := val.Len() * int(elem.Size())
+	return (*[1 << 40]byte)(unsafe.Pointer(val.Pointer()))[:size:size], true
+}
+
+// hasRawLayout reports whether values of typ are laid out in memory as SSZ encodes
+// them: unsigned integers, and arrays and structs of them without any padding.
+// Booleans are left out, as Go does not guarantee their memory holds 0 or 1.
+func hasRawLayout(typ reflect.Type) bool {
+	if lookupCodec(typ) != nil {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Array:
+		return hasRawLayout(typ.Elem())
+	case reflect.Struct:
+		fields, err := structFields(typ)
+		if err != nil || len(fields) != typ.NumField() {
+			return false
+		}
+		offset := uintptr(0)
+		for _, f := range fields {
+			goField := typ.Field(f.index)
+			if goField.Type != f.typ || goField.Offset != offset || !hasRawLayout(f.typ) {
+				return false
+			}
+			offset += goField.Type.Size()
+		}
+		return offset == typ.Size()
+	default:
+		return false
+	}
+}
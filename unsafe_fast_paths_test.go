@@ -0,0 +1,98 @@
+package ssz
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+type rawLayoutItem struct {
+	Epoch   uint64
+	Version [4]byte
+	Index   uint32
+	Roots   [2][32]byte
+}
+
+type paddedItem struct {
+	Flag  uint8
+	Epoch uint64
+}
+
+// Slices are encoded the same with and without the ssz_unsafe build tag: run with
+// -tags ssz_unsafe to compare the unsafe fast paths against element-wise encoding.
+func TestFastPaths_RandomEquivalence(t *testing.T) {
+	config := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+	elementWise := func(val reflect.Value) ([]byte, error) {
+		var encoded []byte
+		for i := 0; i < val.Len(); i++ {
+			elem, err := Marshal(val.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, elem...)
+		}
+		return encoded, nil
+	}
+	tests := []interface{}{
+		[]uint64{},
+		[]uint32{},
+		[]uint16{},
+		[]byte{},
+		[][32]byte{},
+		[]fork{},
+		[]rawLayoutItem{},
+		[]paddedItem{},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt)
+		t.Run(typ.String(), func(t *testing.T) {
+			check := func(val reflect.Value) bool {
+				encoded, err := Marshal(val.Interface())
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := elementWise(val)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return bytes.Equal(encoded, want)
+			}
+			values := func(args []reflect.Value, r *rand.Rand) {
+				val, ok := quick.Value(typ, r)
+				if !ok {
+					t.Fatalf("Cannot generate values of type %v", typ)
+				}
+				args[0] = val
+			}
+			fn := reflect.MakeFunc(reflect.FuncOf([]reflect.Type{typ}, []reflect.Type{reflect.TypeOf(true)}, false), func(args []reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.ValueOf(check(args[0]))}
+			})
+			if err := quick.Check(fn.Interface(), &quick.Config{MaxCount: config.MaxCount, Rand: config.Rand, Values: values}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFastPaths_RandomHashEquivalence(t *testing.T) {
+	check := func(values []uint64) bool {
+		converted := make([]gwei, len(values))
+		for i, v := range values {
+			converted[i] = gwei(v)
+		}
+		root, err := HashTreeRootWithCapacity(values, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := HashTreeRootWithCapacity(converted, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return root == want
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build ssz_unsafe
+// +build ssz_unsafe
+
+package ssz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHasRawLayout(t *testing.T) {
+	tests := []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{typ: reflect.TypeOf(uint64(0)), want: true},
+		{typ: reflect.TypeOf([32]byte{}), want: true},
+		{typ: reflect.TypeOf(fork{}), want: true},
+		{typ: reflect.TypeOf(rawLayoutItem{}), want: true},
+		{typ: reflect.TypeOf(paddedItem{}), want: false},
+		{typ: reflect.TypeOf(true), want: false},
+		{typ: reflect.TypeOf(testDepositData{}), want: false},
+	}
+	for _, tt := range tests {
+		if got := hasRawLayout(tt.typ); got != tt.want {
+			t.Errorf("Expected hasRawLayout(%v) to be %v, received %v", tt.typ, tt.want, got)
+		}
+	}
+	if !Features().UnsafeFastPaths {
+		t.Error("Expected unsafe fast paths to be reported")
+	}
+}
@@ -0,0 +1,279 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// Validate checks whether data is a well-formed, canonical SSZ encoding of a value of
+// type typ, walking its offsets and sizes without constructing any value of that type.
+// Data is rejected wherever Unmarshal would reject it, along with encodings Unmarshal
+// would decode but which no value encodes to, such as offsets leaving gaps between the
+// values they point to, or bits set past the end of bitvectors. This allows gatekeeping
+// network messages before paying the cost of decoding them:
+//
+//  if err := Validate(encodedBytes, reflect.TypeOf(exampleStruct{})); err != nil {
+//      return fmt.Errorf("invalid encoding: %v", err)
+//  }
+//
+// Only the values of types registered with RegisterCodec are decoded, as their
+// encodings can only be checked by their codec.
+func Validate(data []byte, typ reflect.Type) (err error) {
+	defer recoverPanic(&err)
+	if typ == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	// We make sure the type is supported, which also caches the ssz utils
+	// of every nested type before we walk them.
+	if _, err := cachedSSZUtils(typ); err != nil {
+		return fmt.Errorf("could not get ssz utils for type: %v: %w", typ, err)
+	}
+	if err := validateEncoding(data, typ, 0 /* max capacity */, nil); err != nil {
+		return fmt.Errorf("invalid encoding for type: %v: %w", typ, err)
+	}
+	return nil
+}
+
+// validateEncoding validates data against typ. A non-zero maxCapacity
+// bounds the number of elements of lists, or the number of bits of bitlists,
+// and innerLimits bound the lists nested within them.
+func validateEncoding(data []byte, typ reflect.Type, maxCapacity uint64, innerLimits []uint64) error {
+	kind := typ.Kind()
+	switch {
+	case lookupCodec(typ) != nil:
+		// The encodings of registered types can only be checked by decoding them.
+		codec := lookupCodec(typ)
+		if codec.size != 0 && uint64(len(data)) != codec.size {
+			return errorf(ErrSize, "expected %d bytes, received %d", codec.size, len(data))
+		}
+		return codec.unmarshal(data, reflect.New(typ).Interface())
+	case kind == reflect.Bool:
+		if len(data) != 1 {
+			return errorf(ErrSize, "expected 1 byte, received %d", len(data))
+		}
+		if data[0] > 1 {
+			return errorf(ErrInvalidValue, "expected 0 or 1 but received %d", data[0])
+		}
+		return nil
+	case isBasicType(kind):
+		if size := staticFixedSize(typ); uint64(len(data)) != size {
+			return errorf(ErrSize, "expected %d bytes, received %d", size, len(data))
+		}
+		return nil
+	case isBitlist(typ):
+		return validateBitlist(data, maxCapacity)
+	case isBitvector(typ):
+		length, _ := bitvectorLength(typ)
+		return validateBitvector(data, length)
+	case kind == reflect.Slice && !isVariableSizeType(typ.Elem()):
+		return validateFixedSizeList(data, typ.Elem(), maxCapacity)
+	case kind == reflect.Array && !isVariableSizeType(typ.Elem()):
+		if size := staticFixedSize(typ); uint64(len(data)) != size {
+			return errorf(ErrSize, "expected %d bytes, received %d", size, len(data))
+		}
+		return validateFixedSizeList(data, typ.Elem(), 0)
+	case kind == reflect.Slice:
+		return validateVariableSizeList(data, typ.Elem(), maxCapacity, innerLimits, -1)
+	case kind == reflect.Array:
+		return validateVariableSizeList(data, typ.Elem(), 0, innerLimits, typ.Len())
+	case kind == reflect.String:
+		if err := validateFixedSizeList(data, byteType, maxCapacity); err != nil {
+			return err
+		}
+		// Valid strings are checked in place, without copying them.
+		if !utf8.Valid(data) {
+			return checkUTF8(string(data))
+		}
+		return nil
+	case kind == reflect.Struct:
+		return validateStruct(data, typ)
+	case kind == reflect.Ptr:
+		return validateEncoding(data, typ.Elem(), maxCapacity, innerLimits)
+	default:
+		return errorf(ErrUnsupportedType, "type %v is not deserializable", typ)
+	}
+}
+
+func validateBitlist(data []byte, maxCapacity uint64) error {
+	if len(data) == 0 {
+		return errorf(ErrInvalidValue, "bitlist is empty and is missing its delimiter bit")
+	}
+	if data[len(data)-1] == 0 {
+		return errorf(ErrInvalidValue, "last byte of bitlist does not contain a delimiter bit")
+	}
+	if length := bitfield.Bitlist(data).Len(); maxCapacity > 0 && length > maxCapacity {
+		return errorf(ErrMaxLength, "bitlist length %d exceeds max capacity %d", length, maxCapacity)
+	}
+	return nil
+}
+
+// validateBitvector checks that data is the encoding of a bitvector of length bits,
+// whose bits past its length are unset.
+func validateBitvector(data []byte, length uint64) error {
+	if size := (length + 7) / 8; uint64(len(data)) != size {
+		return errorf(ErrSize, "expected %d bytes, received %d", size, len(data))
+	}
+	if length%8 != 0 && data[len(data)-1]>>(length%8) != 0 {
+		return errorf(ErrInvalidValue, "bitvector of length %d has bits set past its end", length)
+	}
+	return nil
+}
+
+func validateFixedSizeList(data []byte, elemType reflect.Type, maxCapacity uint64) error {
+	elemSize := staticFixedSize(elemType)
+	if elemSize == 0 {
+		if len(data) != 0 {
+			return errorf(ErrSize, "expected no bytes for list of empty elements, received %d", len(data))
+		}
+		return nil
+	}
+	if uint64(len(data))%elemSize != 0 {
+		return errorf(ErrSize, "list of %d bytes is not a multiple of its element size %d", len(data), elemSize)
+	}
+	count := uint64(len(data)) / elemSize
+	if maxCapacity > 0 && count > maxCapacity {
+		return errorf(ErrMaxLength, "list length %d exceeds max capacity %d", count, maxCapacity)
+	}
+	// Unsigned integers of the right size are always valid, so we can skip
+	// walking their elements.
+	if kind := elemType.Kind(); isBasicType(kind) && kind != reflect.Bool {
+		return nil
+	}
+	for i := uint64(0); i < count; i++ {
+		if err := validateEncoding(data[i*elemSize:(i+1)*elemSize], elemType, 0, nil); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateVariableSizeList validates a list, or vector when length is not negative,
+// of variable-size elements, whose encoding starts with a table of offsets. The lists
+// nested within the elements are bounded by limits.
+func validateVariableSizeList(data []byte, elemType reflect.Type, maxCapacity uint64, limits []uint64, length int) error {
+	if len(data) == 0 {
+		if length > 0 {
+			return errorf(ErrSize, "expected %d elements, received none", length)
+		}
+		return nil
+	}
+	if uint64(len(data)) < BytesPerLengthOffset {
+		return errorf(ErrSize, "list of %d bytes is too short to contain an offset", len(data))
+	}
+	firstOffset := uint64(binary.LittleEndian.Uint32(data[:BytesPerLengthOffset]))
+	if firstOffset == 0 || firstOffset%BytesPerLengthOffset != 0 {
+		return errorf(ErrOffset, "first offset %d is not a non-zero multiple of %d", firstOffset, BytesPerLengthOffset)
+	}
+	if firstOffset > uint64(len(data)) {
+		return errorf(ErrOffset, "first offset %d is out of bounds of %d bytes", firstOffset, len(data))
+	}
+	count := firstOffset / BytesPerLengthOffset
+	if length >= 0 && count != uint64(length) {
+		return fmt.Errorf("expected %d elements, received %d", length, count)
+	}
+	if maxCapacity > 0 && count > maxCapacity {
+		return errorf(ErrMaxLength, "list length %d exceeds max capacity %d", count, maxCapacity)
+	}
+	elemCapacity, innerLimits := splitLimits(limits)
+	currentOffset := firstOffset
+	for i := uint64(0); i < count; i++ {
+		nextOffset := uint64(len(data))
+		if i+1 < count {
+			nextIndex := (i + 1) * BytesPerLengthOffset
+			nextOffset = uint64(binary.LittleEndian.Uint32(data[nextIndex : nextIndex+BytesPerLengthOffset]))
+		}
+		if nextOffset < currentOffset || nextOffset > uint64(len(data)) {
+			return errorf(ErrOffset, "offset %d of element %d is out of bounds", nextOffset, i+1)
+		}
+		if err := validateEncoding(data[currentOffset:nextOffset], elemType, elemCapacity, innerLimits); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		currentOffset = nextOffset
+	}
+	return nil
+}
+
+func validateStruct(data []byte, typ reflect.Type) error {
+	fields, err := structFields(typ)
+	if err != nil {
+		return err
+	}
+	fixedLength := uint64(0)
+	for _, f := range fields {
+		if isVariableSizeType(f.typ) {
+			fixedLength += BytesPerLengthOffset
+		} else {
+			fixedLength += staticFixedSize(f.typ)
+		}
+	}
+	if uint64(len(data)) < fixedLength {
+		return errorf(ErrSize, "expected at least %d bytes, received %d", fixedLength, len(data))
+	}
+
+	// We first walk the fixed part of the struct, validating fixed-size fields
+	// and making sure the offsets of variable-size fields are consistent.
+	index := uint64(0)
+	previousOffset := fixedLength
+	hasVariableFields := false
+	for _, f := range fields {
+		if !isVariableSizeType(f.typ) {
+			size := staticFixedSize(f.typ)
+			err := validateEncoding(data[index:index+size], f.typ, f.capacity, f.innerLimits)
+			if err == nil && f.bits {
+				// Vectors of booleans tagged bits are packed into bitvectors.
+				err = validateBitvector(data[index:index+size], uint64(typ.Field(f.index).Type.Len()))
+			}
+			if err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+			index += size
+			continue
+		}
+		offset := uint64(binary.LittleEndian.Uint32(data[index : index+BytesPerLengthOffset]))
+		if !hasVariableFields && offset != fixedLength {
+			return errorf(ErrOffset, "field %s: first offset %d does not match fixed length %d", f.name, offset, fixedLength)
+		}
+		if offset < previousOffset || offset > uint64(len(data)) {
+			return errorf(ErrOffset, "field %s: offset %d is out of bounds", f.name, offset)
+		}
+		hasVariableFields = true
+		previousOffset = offset
+		index += BytesPerLengthOffset
+	}
+	if !hasVariableFields && uint64(len(data)) != fixedLength {
+		return errorf(ErrSize, "expected %d bytes, received %d", fixedLength, len(data))
+	}
+
+	// We then validate the variable-size fields within the segments delimited
+	// by consecutive offsets.
+	index = 0
+	var current *field
+	currentOffset := uint64(0)
+	for i := range fields {
+		f := &fields[i]
+		if !isVariableSizeType(f.typ) {
+			index += staticFixedSize(f.typ)
+			continue
+		}
+		offset := uint64(binary.LittleEndian.Uint32(data[index : index+BytesPerLengthOffset]))
+		if current != nil {
+			if err := validateEncoding(data[currentOffset:offset], current.typ, current.capacity, current.innerLimits); err != nil {
+				return fmt.Errorf("field %s: %w", current.name, err)
+			}
+		}
+		current = f
+		currentOffset = offset
+		index += BytesPerLengthOffset
+	}
+	if current != nil {
+		if err := validateEncoding(data[currentOffset:], current.typ, current.capacity, current.innerLimits); err != nil {
+			return fmt.Errorf("field %s: %w", current.name, err)
+		}
+	}
+	return nil
+}
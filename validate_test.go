@@ -0,0 +1,163 @@
+package ssz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+type validateVarItem struct {
+	Field1 uint16
+	Field2 []uint16         `ssz-max:"4"`
+	Field3 bitfield.Bitlist `ssz-max:"16"`
+}
+
+type validateNestedItem struct {
+	Items []validateVarItem
+	Flag  bool
+	Fork  fork
+}
+
+func TestValidate_ValidEncodings(t *testing.T) {
+	bits := bitfield.NewBitlist(10)
+	bits.SetBitAt(3, true)
+	tests := []interface{}{
+		uint64(5),
+		true,
+		[4]uint16{1, 2, 3, 4},
+		[]uint64{1, 2, 3},
+		[][]byte{{1}, {}, {2, 3}},
+		fork{PreviousVersion: [4]byte{1}, Epoch: 10},
+		validateVarItem{Field1: 1, Field2: []uint16{1, 2}, Field3: bits},
+		validateNestedItem{
+			Items: []validateVarItem{
+				{Field2: []uint16{}, Field3: bits},
+				{Field1: 3, Field2: []uint16{3}, Field3: bits},
+			},
+			Flag: true,
+		},
+	}
+	for _, tt := range tests {
+		encoded, err := Marshal(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Validate(encoded, reflect.TypeOf(tt)); err != nil {
+			t.Errorf("Validate(%T) = %v", tt, err)
+		}
+	}
+}
+
+func TestValidate_InvalidEncodings(t *testing.T) {
+	bits := bitfield.NewBitlist(10)
+	valid, err := Marshal(validateVarItem{Field1: 1, Field2: []uint16{1, 2}, Field3: bits})
+	if err != nil {
+		t.Fatal(err)
+	}
+	varItemType := reflect.TypeOf(validateVarItem{})
+	// Marshal rejects lists exceeding their ssz-max unless told not to.
+	ToggleMaxLengthCheck(false)
+	nested, err := Marshal(nestedLists{Lists: [][]uint64{make([]uint64, 9)}})
+	ToggleMaxLengthCheck(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		typ  reflect.Type
+	}{
+		{
+			name: "wrong size for basic type",
+			data: []byte{1, 2, 3},
+			typ:  reflect.TypeOf(uint64(0)),
+		},
+		{
+			name: "bool out of range",
+			data: []byte{2},
+			typ:  reflect.TypeOf(false),
+		},
+		{
+			name: "list not a multiple of its element size",
+			data: []byte{1, 2, 3},
+			typ:  reflect.TypeOf([]uint16{}),
+		},
+		{
+			name: "truncated fixed part",
+			data: valid[:5],
+			typ:  varItemType,
+		},
+		{
+			name: "first offset not matching fixed part",
+			data: append([]byte{1, 0, 11, 0, 0, 0}, valid[6:]...),
+			typ:  varItemType,
+		},
+		{
+			name: "list exceeding ssz-max",
+			data: []byte{1, 0, 10, 0, 0, 0, 20, 0, 0, 0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 1},
+			typ:  varItemType,
+		},
+		{
+			name: "nested list exceeding its dimension of ssz-max",
+			data: nested,
+			typ:  reflect.TypeOf(nestedLists{}),
+		},
+		{
+			name: "bitlist missing its delimiter",
+			data: append(append([]byte{}, valid[:len(valid)-1]...), 0),
+			typ:  varItemType,
+		},
+		{
+			name: "offsets of list elements out of bounds",
+			data: []byte{8, 0, 0, 0, 100, 0, 0, 0},
+			typ:  reflect.TypeOf([][]byte{}),
+		},
+		{
+			name: "first offset of list not a multiple of the offset size",
+			data: []byte{5, 0, 0, 0, 0},
+			typ:  reflect.TypeOf([][]byte{}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.data, tt.typ); err == nil {
+				t.Errorf("Expected encoding %#x to be invalid for %v", tt.data, tt.typ)
+			}
+		})
+	}
+}
+
+func TestValidate_NoAllocations(t *testing.T) {
+	bits := bitfield.NewBitlist(10)
+	bits.SetBitAt(3, true)
+	tests := []interface{}{
+		validateNestedItem{
+			Items: []validateVarItem{
+				{Field2: []uint16{}, Field3: bits},
+				{Field1: 3, Field2: []uint16{3}, Field3: bits},
+			},
+			Flag: true,
+		},
+		nestedLists{Lists: [][]uint64{{1, 2}, {}}, Transactions: [][]byte{{1}}},
+		stringContainer{Slot: 5, Name: "héllo", Labels: []string{"a", ""}},
+	}
+	for _, tt := range tests {
+		encoded, err := Marshal(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		typ := reflect.TypeOf(tt)
+		if err := Validate(encoded, typ); err != nil {
+			t.Fatal(err)
+		}
+		allocs := testing.AllocsPerRun(10, func() {
+			if err := Validate(encoded, typ); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("Expected validating %T not to allocate, received %v allocations", tt, allocs)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TypeErrors are the problems found by ValidateType in a type, each naming the path
+// of the type it was found at.
+type TypeErrors []error
+
+// Error lists the problems, one per line.
+func (e TypeErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the problems, for errors.Is and errors.As to match any of them.
+func (e TypeErrors) Unwrap() []error {
+	return e
+}
+
+// ValidateType checks that values of type typ can be encoded, decoded and hashed,
+// so that services can check the types they use once at startup rather than fail
+// at their first encoding:
+//
+//  if err := ssz.ValidateType(reflect.TypeOf(BeaconState{})); err != nil {
+//      log.Fatalf("BeaconState is not SSZ-serializable:\n%v", err)
+//  }
+//
+// It reports kinds SSZ has no encoding for, such as int, string or map, lists held
+// by containers without an ssz-max tag bounding each of their dimensions, invalid
+// tags, and types holding themselves other than within lists. Rather than stopping
+// at the first of them, it returns all the problems found as TypeErrors.
+func ValidateType(typ reflect.Type) error {
+	if typ == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	if err := checkAcyclic(typ); err != nil {
+		// The types of the cycle cannot be walked.
+		return TypeErrors{err}
+	}
+	v := &typeValidator{seen: make(map[reflect.Type]bool)}
+	v.walk(typ, fmt.Sprint(typ))
+	if len(v.errs) == 0 {
+		// The walk only covers what SSZ requires of types, which the utils of typ
+		// may still fail on.
+		if _, err := cachedSSZUtils(typ); err != nil {
+			return TypeErrors{err}
+		}
+		return nil
+	}
+	return v.errs
+}
+
+// typeValidator walks types for ValidateType, collecting their problems.
+type typeValidator struct {
+	// seen holds the types walked, whose problems are reported at the first path
+	// they are found at.
+	seen map[reflect.Type]bool
+	errs TypeErrors
+}
+
+func (v *typeValidator) fail(path string, err error) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %w", path, err))
+}
+
+// walk checks typ, found at path, and the types it holds.
+func (v *typeValidator) walk(typ reflect.Type, path string) {
+	if v.seen[typ] || lookupCodec(typ) != nil {
+		return
+	}
+	v.seen[typ] = true
+	switch kind := typ.Kind(); {
+	case kind == reflect.Bool, kind == reflect.Uint8, kind == reflect.Uint16,
+		kind == reflect.Uint32, kind == reflect.Uint64, kind == reflect.String:
+	case isBitlist(typ), isBitvector(typ):
+	case kind == reflect.Array, kind == reflect.Slice:
+		v.walk(typ.Elem(), path+"[i]")
+	case kind == reflect.Ptr:
+		v.walk(typ.Elem(), path)
+	case kind == reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if isSSZField(f) {
+				v.walkField(f, path+"."+f.Name)
+			} else if err := checkUnexportedField(typ, f); err != nil {
+				v.fail(path+"."+f.Name, err)
+			}
+		}
+	default:
+		v.fail(path, errorf(ErrUnsupportedType, "type %v is not serializable, as SSZ has no encoding for kind %v", typ, kind))
+	}
+}
+
+// walkField checks the tags of the struct field f, found at path, against its type,
+// then walks the type.
+func (v *typeValidator) walkField(f reflect.StructField, path string) {
+	tags, err := parseFieldTags(f)
+	if err != nil {
+		v.fail(path, fmt.Errorf("could not parse tags: %w", err))
+		return
+	}
+	fType, err := determineFieldType(f)
+	if err != nil {
+		v.fail(path, err)
+		return
+	}
+	switch {
+	case tags.opaque:
+		if !tags.hasLimits {
+			v.fail(path, errorf(ErrInvalidTag, "opaque field requires an ssz-max tag"))
+		}
+		opaqueCodecsLock.RLock()
+		_, ok := opaqueCodecs[f.Type]
+		opaqueCodecsLock.RUnlock()
+		if !ok {
+			v.fail(path, errorf(ErrUnsupportedType, "no opaque codec registered for type %v", f.Type))
+		}
+		return
+	case tags.bits && tags.hasSizes:
+		v.fail(path, errorf(ErrInvalidTag, "bits field cannot have sizes"))
+		return
+	case tags.progressive:
+		// Progressive lists have no limit.
+		if tags.hasSizes || tags.hasLimits {
+			v.fail(path, errorf(ErrInvalidTag, "progressive field can neither have sizes nor an ssz-max"))
+		}
+		sszUtilsCacheMutex.Lock()
+		_, err := makeProgressiveHasher(fType)
+		sszUtilsCacheMutex.Unlock()
+		if err != nil {
+			v.fail(path, err)
+		}
+		v.walk(fType, path)
+		return
+	}
+	// Each list of the field, from the outermost, needs a limit, while the lists
+	// held by the containers of the field are bounded by their own tags.
+	dim := fType
+	for d := 0; dim.Kind() == reflect.Slice || dim.Kind() == reflect.Array || dim.Kind() == reflect.String; d++ {
+		if lookupCodec(dim) != nil || isBitvector(dim) {
+			break
+		}
+		if dim.Kind() != reflect.Array && (d >= len(tags.limits) || tags.limits[d] == 0) {
+			if d == 0 {
+				v.fail(path, errorf(ErrInvalidTag, "list requires an ssz-max tag"))
+			} else {
+				v.fail(path, errorf(ErrInvalidTag, "list nested at dimension %d requires a limit in the ssz-max tag", d))
+			}
+		}
+		if isBitlist(dim) || dim.Kind() == reflect.String {
+			break
+		}
+		dim = dim.Elem()
+	}
+	if _, innerLimits := splitLimits(tags.limits); innerLimits != nil {
+		sszUtilsCacheMutex.Lock()
+		_, err := makeNestedHasher(fType, innerLimits)
+		sszUtilsCacheMutex.Unlock()
+		if err != nil {
+			v.fail(path, fmt.Errorf("could not apply ssz-max tag: %w", err))
+		}
+	}
+	v.walk(fType, path)
+}
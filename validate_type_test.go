@@ -0,0 +1,71 @@
+package ssz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+type validTypeInner struct {
+	Roots [][32]byte `ssz-max:"8"`
+	Bits  []bool     `ssz:"bits,max=16"`
+}
+
+type validType struct {
+	Slot         uint64
+	Votes        [4]bitfield.Bitvector4
+	Participants bitfield.Bitlist `ssz-max:"64"`
+	Data         [][]byte         `ssz-max:"4,32"`
+	Inner        *validTypeInner
+	Inners       []validTypeInner `ssz-max:"2"`
+}
+
+type invalidTypeInner struct {
+	Count   int
+	Weights map[string]uint64
+}
+
+type invalidType struct {
+	Slot      uint64
+	Roots     [][32]byte
+	Data      [][]byte `ssz-max:"4"`
+	Inner     invalidTypeInner
+	Inners    []invalidTypeInner `ssz-max:"2"`
+	Size      []byte             `ssz-size:"x"`
+	Signature float64
+}
+
+func TestValidateType(t *testing.T) {
+	if err := ValidateType(reflect.TypeOf(validType{})); err != nil {
+		t.Errorf("Expected a valid type, received %v", err)
+	}
+	if err := ValidateType(reflect.TypeOf([]uint64{})); err != nil {
+		t.Errorf("Expected lists outside of containers to be valid, received %v", err)
+	}
+	err := ValidateType(reflect.TypeOf(&invalidType{}))
+	errs, ok := err.(TypeErrors)
+	if !ok {
+		t.Fatalf("Expected TypeErrors, received %v", err)
+	}
+	want := []string{
+		"ssz.invalidType.Roots: list requires an ssz-max tag",
+		"ssz.invalidType.Data: list nested at dimension 1 requires a limit in the ssz-max tag",
+		"ssz.invalidType.Inner.Count: type int is not serializable",
+		"ssz.invalidType.Inner.Weights: type map[string]uint64 is not serializable",
+		"ssz.invalidType.Size: could not parse tags: invalid ssz-size tag",
+		"ssz.invalidType.Signature: type float64 is not serializable",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d problems, received %d:\n%v", len(want), len(errs), errs)
+	}
+	for i := range want {
+		if !strings.Contains(errs[i].Error(), want[i]) {
+			t.Errorf("Expected problem %d to contain %q, received %v", i, want[i], errs[i])
+		}
+	}
+	if err := ValidateType(reflect.TypeOf(linkedNode{})); err == nil || !strings.Contains(err.Error(), "holds itself") {
+		t.Errorf("Expected the cycle of the type to be reported, received %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// View is a lazily decoded value: it wraps the encoding of a value along with the
+// descriptor of its type, and only decodes the fields accessed through it, following
+// UnmarshalPath. Decoded values are memoized, so that each of them is only decoded
+// once. Views are safe for concurrent use, and values returned by views are shared
+// between callers, which must not modify them.
+//
+//  view, err := NewView(encodedBlock, reflect.TypeOf(BeaconBlock{}))
+//  if err != nil {
+//      return fmt.Errorf("failed to wrap block: %v", err)
+//  }
+//  var slot uint64
+//  if err := view.Load(&slot, "Slot"); err != nil {
+//      return fmt.Errorf("failed to decode slot: %v", err)
+//  }
+type View struct {
+	data   []byte
+	typ    reflect.Type
+	goType reflect.Type
+	desc   *TypeDescriptor
+
+	lock    sync.Mutex
+	decoded map[string]reflect.Value
+}
+
+// NewView returns the view of data, the encoding of a value of type typ. The size
+// of data is checked against the bounds of typ, while its contents are only checked
+// as they are decoded.
+func NewView(data []byte, typ reflect.Type) (*View, error) {
+	if typ == nil {
+		return nil, errors.New("untyped nil is not supported")
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return newView(data, typ, typ)
+}
+
+func newView(data []byte, typ reflect.Type, goType reflect.Type) (*View, error) {
+	desc, err := Describe(typ)
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(len(data))
+	if size < desc.MinSize || (desc.MaxSize != 0 && size > desc.MaxSize) {
+		return nil, errorf(ErrSize, "%d bytes cannot encode a value of type %v", size, goType)
+	}
+	return &View{
+		data:    data,
+		typ:     typ,
+		goType:  goType,
+		desc:    desc,
+		decoded: make(map[string]reflect.Value),
+	}, nil
+}
+
+// Bytes returns the encoding wrapped by the view.
+func (v *View) Bytes() []byte {
+	return v.data
+}
+
+// Type returns the type of the value wrapped by the view.
+func (v *View) Type() reflect.Type {
+	return v.goType
+}
+
+// Descriptor returns the layout of the type of the value wrapped by the view.
+func (v *View) Descriptor() *TypeDescriptor {
+	return v.desc
+}
+
+// Get returns the value designated by path, decoding it on first access.
+func (v *View) Get(path ...interface{}) (interface{}, error) {
+	val, err := v.get(path)
+	if err != nil {
+		return nil, err
+	}
+	return val.Interface(), nil
+}
+
+// Load sets the object pointed by out to the value designated by path, decoding it
+// on first access.
+func (v *View) Load(out interface{}, path ...interface{}) error {
+	if out == nil {
+		return errors.New("cannot unmarshal into untyped, nil value")
+	}
+	rval := reflect.ValueOf(out)
+	if rval.Kind() != reflect.Ptr || rval.IsNil() {
+		return errors.New("can only unmarshal into a non-nil pointer target")
+	}
+	val, err := v.get(path)
+	if err != nil {
+		return err
+	}
+	if val.Type() != rval.Elem().Type() {
+		return fmt.Errorf("cannot decode %v into %T", val.Type(), out)
+	}
+	rval.Elem().Set(val)
+	return nil
+}
+
+// View returns the view of the value designated by path, which is not decoded.
+func (v *View) View(path ...interface{}) (*View, error) {
+	c, err := locatePath(v.data, v.typ, v.goType, path)
+	if err != nil {
+		return nil, err
+	}
+	if c.scalar != nil || c.opaque != nil || c.bits != nil {
+		return nil, errors.New("lengths, bits and opaque fields cannot be viewed")
+	}
+	typ := c.typ
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return newView(c.input, typ, c.valueType())
+}
+
+func (v *View) get(path []interface{}) (reflect.Value, error) {
+	key := fmt.Sprintf("%#v", path)
+	v.lock.Lock()
+	val, ok := v.decoded[key]
+	v.lock.Unlock()
+	if ok {
+		return val, nil
+	}
+	c, err := locatePath(v.data, v.typ, v.goType, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	val = reflect.New(c.valueType()).Elem()
+	if err := c.decode(val); err != nil {
+		return reflect.Value{}, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	// Another goroutine may have decoded the value in the meantime: keep
+	// the first one, so that all callers share it.
+	if first, ok := v.decoded[key]; ok {
+		return first, nil
+	}
+	v.decoded[key] = val
+	return val, nil
+}
@@ -0,0 +1,118 @@
+package ssz
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	item := newTreeContainer()
+	item.Nil = &fork{Epoch: 10}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := NewView(encoded, reflect.TypeOf(&item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Type() != reflect.TypeOf(item) || view.Descriptor().Kind != KindContainer {
+		t.Errorf("Unexpected view of type %v and kind %s", view.Type(), view.Descriptor().Kind)
+	}
+
+	var slot uint64
+	if err := view.Load(&slot, "Slot"); err != nil {
+		t.Fatal(err)
+	}
+	if slot != item.Slot {
+		t.Errorf("Expected slot %d, received %d", item.Slot, slot)
+	}
+	forks, err := view.Get("Forks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(forks, item.Forks) {
+		t.Errorf("Expected forks %v, received %v", item.Forks, forks)
+	}
+	// Memoized values are shared between accesses.
+	again, err := view.Get("Forks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &again.([]fork)[0] != &forks.([]fork)[0] {
+		t.Error("Expected forks to be decoded once")
+	}
+
+	all, err := view.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reencoded, err := Marshal(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reencoded, encoded) {
+		t.Errorf("Expected %v, received %v", item, all)
+	}
+
+	sub, err := view.View("Forks", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var epoch uint64
+	if err := sub.Load(&epoch, "Epoch"); err != nil {
+		t.Fatal(err)
+	}
+	if epoch != item.Forks[1].Epoch {
+		t.Errorf("Expected epoch %d, received %d", item.Forks[1].Epoch, epoch)
+	}
+
+	if err := view.Load(&epoch, "Flag"); err == nil {
+		t.Error("Expected error loading a bool into a uint64")
+	}
+	if _, err := view.View("Forks", LengthPathElement); err == nil {
+		t.Error("Expected error viewing the length of a list")
+	}
+}
+
+func TestView_Concurrent(t *testing.T) {
+	item := newTreeContainer()
+	item.Nil = &fork{}
+	encoded, err := Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := NewView(encoded, reflect.TypeOf(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var balance uint64
+			if err := view.Load(&balance, "Balances", 2); err != nil {
+				t.Error(err)
+			}
+			if balance != item.Balances[2] {
+				t.Errorf("Expected balance %d, received %d", item.Balances[2], balance)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewView_Size(t *testing.T) {
+	if _, err := NewView(make([]byte, 3), reflect.TypeOf(fork{})); err == nil {
+		t.Error("Expected error for input shorter than its type")
+	}
+	if _, err := NewView(make([]byte, 3), reflect.TypeOf(treeContainer{})); err == nil {
+		t.Error("Expected error for input shorter than the fixed part of its type")
+	}
+	if _, err := NewView(nil, nil); err == nil {
+		t.Error("Expected error for untyped nil")
+	}
+}
@@ -0,0 +1,243 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Walk traverses val in merkleization order, calling fn with each chunk of its backing
+// tree along with its path and generalized index, as resolved by GeneralizedIndex. The
+// zero chunks padding lists up to their limit are not reported. The path of a chunk of
+// packed basic values or bits designates its first element, and the path of the length
+// of a list ends with LengthPathElement. fn must not retain path nor leaf, and the walk
+// stops at the first error it returns.
+//
+//  err := Walk(state, func(path []string, gindex uint64, leaf []byte) error {
+//      fmt.Printf("%s (%d): %#x\n", strings.Join(path, "."), gindex, leaf)
+//      return nil
+//  })
+func Walk(val interface{}, fn func(path []string, gindex uint64, leaf []byte) error) error {
+	if val == nil {
+		return errors.New("untyped nil is not supported")
+	}
+	rval := reflect.ValueOf(val)
+	w := &walker{fn: fn}
+	return w.walk(rval, rval.Type(), 0, nil, 1)
+}
+
+type walker struct {
+	fn   func(path []string, gindex uint64, leaf []byte) error
+	path []string
+}
+
+// walk reports the leaves of the tree of val rooted at gindex. Lists are bounded by
+// maxCapacity, and the lists nested within them by innerLimits.
+func (w *walker) walk(val reflect.Value, typ reflect.Type, maxCapacity uint64, innerLimits []uint64, gindex uint64) error {
+	if typ.Kind() == reflect.String {
+		if err := checkUTF8(val.String()); err != nil {
+			return err
+		}
+		val, typ = stringBytes(val), byteSliceType
+	}
+	kind := typ.Kind()
+	switch {
+	case lookupCodec(typ) != nil:
+		root, err := lookupCodec(typ).hash(val.Interface())
+		if err != nil {
+			return fmt.Errorf("failed to hash %v: %w", typ, err)
+		}
+		return w.fn(w.path, gindex, root[:])
+	case isBitvector(typ):
+		buf, err := bitvectorChunks(val)
+		if err != nil {
+			return err
+		}
+		length, _ := bitvectorLength(typ)
+		return w.chunks(buf, gindex, (length+255)/256, 256)
+	case isPackedArray(typ) || isBasicType(kind) || isBasicTypeArray(typ, kind):
+		utils, err := cachedSSZUtils(typ)
+		if err != nil {
+			return err
+		}
+		buf := make([]byte, paddedSize(determineSize(val)))
+		if _, err := utils.marshaler(val, buf, 0); err != nil {
+			return err
+		}
+		count := uint64(len(buf) / BytesPerChunk)
+		if count == 1 {
+			return w.fn(w.path, gindex, buf)
+		}
+		return w.chunks(buf, gindex, count, 32/staticFixedSize(typ.Elem()))
+	case kind == reflect.Slice && isBasicType(typ.Elem().Kind()):
+		utils, err := cachedSSZUtils(typ.Elem())
+		if err != nil {
+			return err
+		}
+		elemSize := staticFixedSize(typ.Elem())
+		limit := (maxCapacity*elemSize + 31) / 32
+		if limit == 0 {
+			limit = 1
+		}
+		buf := make([]byte, paddedSize(uint64(val.Len())*elemSize))
+		index := uint64(0)
+		for i := 0; i < val.Len(); i++ {
+			if index, err = utils.marshaler(val.Index(i), buf, index); err != nil {
+				return err
+			}
+		}
+		contents, err := childIndex(gindex, 2, 0)
+		if err != nil {
+			return err
+		}
+		if err := w.chunks(buf, contents, limit, 32/elemSize); err != nil {
+			return err
+		}
+		return w.length(gindex, uint64(val.Len()))
+	case kind == reflect.Slice:
+		limit := maxCapacity
+		if limit == 0 && isBasicTypeArray(typ.Elem(), typ.Elem().Kind()) {
+			limit = 1
+		} else if limit == 0 {
+			limit = uint64(val.Len())
+		}
+		contents, err := childIndex(gindex, 2, 0)
+		if err != nil {
+			return err
+		}
+		if err := w.elements(val, typ.Elem(), innerLimits, contents, limit); err != nil {
+			return err
+		}
+		return w.length(gindex, uint64(val.Len()))
+	case kind == reflect.Array:
+		return w.elements(val, typ.Elem(), innerLimits, gindex, uint64(val.Len()))
+	case kind == reflect.Struct:
+		return w.fields(val, typ, gindex)
+	case kind == reflect.Ptr:
+		elem, err := pointee(val)
+		if err != nil {
+			return err
+		}
+		return w.walk(elem, typ.Elem(), maxCapacity, innerLimits, gindex)
+	default:
+		return errorf(ErrUnsupportedType, "type %v is not hashable", typ)
+	}
+}
+
+// chunks reports the chunks backed by buf, the leaves of a subtree of limit
+// chunks rooted at gindex, each packing perChunk elements.
+func (w *walker) chunks(buf []byte, gindex uint64, limit uint64, perChunk uint64) error {
+	for i := 0; i < len(buf)/BytesPerChunk; i++ {
+		leaf, err := childIndex(gindex, limit, uint64(i))
+		if err != nil {
+			return err
+		}
+		w.push(strconv.FormatUint(uint64(i)*perChunk, 10))
+		err = w.fn(w.path, leaf, buf[i*BytesPerChunk:(i+1)*BytesPerChunk])
+		w.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elements walks the elements of val, the leaves of a subtree of limit nodes
+// rooted at gindex, whose nested lists are bounded by limits.
+func (w *walker) elements(val reflect.Value, elemType reflect.Type, limits []uint64, gindex uint64, limit uint64) error {
+	elemCapacity, innerLimits := splitLimits(limits)
+	if uint64(val.Len()) > limit {
+		return errorf(ErrMaxLength, "chunk count = %d cannot be greater than padding = %d", val.Len(), limit)
+	}
+	for i := 0; i < val.Len(); i++ {
+		elem, err := childIndex(gindex, limit, uint64(i))
+		if err != nil {
+			return err
+		}
+		w.push(strconv.Itoa(i))
+		err = w.walk(val.Index(i), elemType, elemCapacity, innerLimits, elem)
+		w.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) fields(val reflect.Value, typ reflect.Type, gindex uint64) error {
+	fields, err := structFields(typ)
+	if err != nil {
+		return err
+	}
+	for i, f := range fields {
+		fieldIndex, err := childIndex(gindex, uint64(len(fields)), uint64(i))
+		if err != nil {
+			return err
+		}
+		fieldVal := fieldValue(val, f)
+		w.push(f.name)
+		switch {
+		case f.progressive:
+			err = errorf(ErrUnsupportedType, "progressive field %s cannot be walked", f.name)
+		case f.opaque != nil:
+			var encoded []byte
+			if encoded, err = f.opaque.Encode(fieldVal.Interface()); err != nil {
+				err = fmt.Errorf("failed to encode opaque field %s: %w", f.name, err)
+				break
+			}
+			err = w.walk(reflect.ValueOf(encoded), opaqueType, f.capacity, nil, fieldIndex)
+		case isBitlist(f.typ):
+			err = w.bitlist(fieldVal, f.capacity, fieldIndex)
+		default:
+			err = w.walk(fieldVal, f.typ, f.capacity, f.innerLimits, fieldIndex)
+		}
+		w.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) bitlist(val reflect.Value, maxCapacity uint64, gindex uint64) error {
+	contents, err := childIndex(gindex, 2, 0)
+	if err != nil {
+		return err
+	}
+	var length uint64
+	if !val.IsNil() {
+		if err := validateBitlist(val.Bytes(), maxCapacity); err != nil {
+			return err
+		}
+		bfield := val.Interface().(Bitfield)
+		length = bfield.Len()
+		buf := make([]byte, paddedSize(uint64(len(bfield.Bytes()))))
+		copy(buf, bfield.Bytes())
+		if err := w.chunks(buf, contents, (maxCapacity+255)/256, 256); err != nil {
+			return err
+		}
+	}
+	return w.length(gindex, length)
+}
+
+// length reports the chunk mixing length into the root of the list at gindex.
+func (w *walker) length(gindex uint64, length uint64) error {
+	leaf, err := childIndex(gindex, 2, 1)
+	if err != nil {
+		return err
+	}
+	chunk := lengthChunk(length)
+	w.push(LengthPathElement)
+	err = w.fn(w.path, leaf, chunk[:])
+	w.pop()
+	return err
+}
+
+func (w *walker) push(element string) {
+	w.path = append(w.path, element)
+}
+
+func (w *walker) pop() {
+	w.path = w.path[:len(w.path)-1]
+}
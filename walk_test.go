@@ -0,0 +1,112 @@
+package ssz
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWalk_MatchesTree(t *testing.T) {
+	item := newTreeContainer()
+	tree, err := NewTree(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var previous uint64
+	count := 0
+	err = Walk(item, func(path []string, gindex uint64, leaf []byte) error {
+		count++
+		want, err := tree.Leaf(gindex)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(leaf, want[:]) {
+			t.Errorf("Expected leaf %v at %d to be %#x, received %#x", path, gindex, want, leaf)
+		}
+		elements := make([]interface{}, len(path))
+		for i, p := range path {
+			if index, err := strconv.Atoi(p); err == nil {
+				elements[i] = index
+			} else {
+				elements[i] = p
+			}
+		}
+		resolved, err := GeneralizedIndex(reflect.TypeOf(item), elements...)
+		if err != nil {
+			return err
+		}
+		if resolved != gindex {
+			t.Errorf("Expected path %v to resolve to %d, received %d", path, gindex, resolved)
+		}
+		// Leaves come from left to right: compare them at the same depth.
+		if previous != 0 {
+			a, b := previous, gindex
+			for bitLength(a) < bitLength(b) {
+				a <<= 1
+			}
+			for bitLength(b) < bitLength(a) {
+				b <<= 1
+			}
+			if a >= b {
+				t.Errorf("Expected leaf %d to come after leaf %d", gindex, previous)
+			}
+		}
+		previous = gindex
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("Expected leaves to be walked")
+	}
+}
+
+func TestWalk_Paths(t *testing.T) {
+	item := newTreeContainer()
+	seen := make(map[string]bool)
+	err := Walk(item, func(path []string, gindex uint64, leaf []byte) error {
+		key := ""
+		for _, p := range path {
+			key += "/" + p
+		}
+		seen[key] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{
+		"/Slot",
+		"/Forks/1/Epoch",
+		"/Forks/__len__",
+		"/Balances/4",
+		"/Bits/256",
+		"/Bits/__len__",
+		"/Custody",
+		"/Vectors/2",
+	} {
+		if !seen[key] {
+			t.Errorf("Expected leaf at %s", key)
+		}
+	}
+	if seen["/Balances/1"] {
+		t.Error("Expected packed balances to share a chunk")
+	}
+}
+
+func TestWalk_StopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := Walk(newTreeContainer(), func(path []string, gindex uint64, leaf []byte) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected walk to return %v, received %v", stop, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected walk to stop after 1 call, received %d", calls)
+	}
+}